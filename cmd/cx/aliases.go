@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// aliasPrefix starts the config keys that define aliases, e.g.
+// alias.pp: "paste --persist"
+const aliasPrefix = "alias."
+
+// aliases returns the configured aliases by name
+func (c *Config) aliases() map[string]string {
+	aliases := make(map[string]string)
+	for key, value := range c.values {
+		if name, ok := strings.CutPrefix(key, aliasPrefix); ok {
+			aliases[name] = value
+		}
+	}
+	return aliases
+}
+
+// aliasKeys returns the alias keys set in c, sorted by name
+func (c *Config) aliasKeys() []string {
+	var keys []string
+	for key := range c.values {
+		if strings.HasPrefix(key, aliasPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateAlias checks that an alias can be typed as a command and that
+// its expansion can be split into arguments
+func validateAlias(name, expansion string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t\"'") {
+		return fmt.Errorf("invalid alias name %q", name)
+	}
+	_, err := splitAlias(expansion)
+	return err
+}
+
+// shadowedCommand returns the command cx already has under name, which an
+// alias of that name would never be used over, or nil
+func shadowedCommand(name string) *cobra.Command {
+	if cmd, _, err := rootCmd.Find([]string{name}); err == nil && cmd != rootCmd {
+		return cmd
+	}
+	return nil
+}
+
+// splitAlias splits an alias expansion into arguments at spaces, keeping
+// single- or double-quoted text together
+func splitAlias(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("alias expands to nothing")
+	}
+	return args, nil
+}
+
+// expandAliases replaces the command in args with its alias, if it is one,
+// so "cx pp 2" runs "cx paste --persist 2" with alias.pp set to
+// "paste --persist". Commands cx already has always win over aliases.
+func expandAliases(args []string, aliases map[string]string) ([]string, error) {
+	i := commandIndex(args)
+	if i < 0 {
+		return args, nil
+	}
+	expansion, ok := aliases[args[i]]
+	if !ok {
+		return args, nil
+	}
+	if shadowedCommand(args[i]) != nil {
+		return args, nil
+	}
+
+	words, err := splitAlias(expansion)
+	if err != nil {
+		return nil, fmt.Errorf("alias %s: %w", args[i], err)
+	}
+
+	expanded := make([]string, 0, len(args)+len(words)-1)
+	expanded = append(expanded, args[:i]...)
+	expanded = append(expanded, words...)
+	return append(expanded, args[i+1:]...), nil
+}
+
+// commandIndex returns the index of the first argument that isn't a flag of
+// the root command or a flag's value, or -1 if there is none
+func commandIndex(args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return -1
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i
+		}
+		if strings.Contains(arg, "=") {
+			continue
+		}
+
+		var flag *pflag.Flag
+		if name, ok := strings.CutPrefix(arg, "--"); ok {
+			flag = lookupRootFlag(name, "")
+		} else if len(arg) == 2 {
+			flag = lookupRootFlag("", arg[1:])
+		}
+		// flags that need a value take the next argument with them
+		if flag != nil && flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return -1
+}
+
+// lookupRootFlag finds a flag of the root command by name or shorthand
+func lookupRootFlag(name, shorthand string) *pflag.Flag {
+	for _, flags := range []*pflag.FlagSet{rootCmd.PersistentFlags(), rootCmd.Flags()} {
+		if name != "" {
+			if flag := flags.Lookup(name); flag != nil {
+				return flag
+			}
+		} else if flag := flags.ShorthandLookup(shorthand); flag != nil {
+			return flag
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestSplitAlias(t *testing.T) {
+	args, err := splitAlias(`paste --rename 's/ /_/g'  --to "my dir"`)
+	if err != nil {
+		t.Fatalf("splitAlias failed: %v", err)
+	}
+	expected := []string{"paste", "--rename", "s/ /_/g", "--to", "my dir"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %q, got %q", expected, args)
+	}
+
+	for _, bad := range []string{`paste "unterminated`, "   "} {
+		if _, err := splitAlias(bad); err == nil {
+			t.Errorf("Expected error splitting %q", bad)
+		}
+	}
+}
+
+func TestExpandAliases(t *testing.T) {
+	aliases := map[string]string{"pp": "paste --persist", "list": "clear"}
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"pp", "2"}, []string{"paste", "--persist", "2"}},
+		{[]string{"--store", "daemon", "-q", "pp"}, []string{"--store", "daemon", "-q", "paste", "--persist"}},
+		{[]string{"--store=sqlite", "pp"}, []string{"--store=sqlite", "paste", "--persist"}},
+		// built-in commands win, and only the command is expanded
+		{[]string{"list"}, []string{"list"}},
+		{[]string{"copy", "pp"}, []string{"copy", "pp"}},
+		{[]string{"--", "pp"}, []string{"--", "pp"}},
+	}
+	for _, test := range tests {
+		args, err := expandAliases(test.args, aliases)
+		if err != nil {
+			t.Fatalf("expandAliases(%q) failed: %v", test.args, err)
+		}
+		if !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("expandAliases(%q): expected %q, got %q", test.args, test.expected, args)
+		}
+	}
+}
+
+func TestConfigSetAlias(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := handleConfigSet(io.Discard, "alias.pp", "paste --persist --to 'my dir'", Options{}); err != nil {
+		t.Fatalf("handleConfigSet failed: %v", err)
+	}
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatalf("readConfig failed: %v", err)
+	}
+	if aliases := cfg.aliases(); aliases["pp"] != "paste --persist --to 'my dir'" {
+		t.Errorf("Unexpected aliases: %v", aliases)
+	}
+
+	for _, name := range []string{"alias.paste", "alias.v", "alias.-x"} {
+		if err := handleConfigSet(io.Discard, name, "list", Options{}); err == nil {
+			t.Errorf("Expected error setting %s", name)
+		}
+	}
+}
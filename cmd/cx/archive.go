@@ -0,0 +1,318 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// archivePath returns where --archive should write, and in which format.
+// A relative name is taken inside the paste destination, and --zip adds a
+// .zip extension if the name doesn't have one.
+func archivePath(destDir string, opts Options) (string, fsops.ArchiveFormat, error) {
+	name := opts.archive
+	if opts.zip && !strings.HasSuffix(strings.ToLower(name), ".zip") {
+		name += ".zip"
+	}
+
+	format, err := fsops.ArchiveFormatFor(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(destDir, name)
+	}
+	return name, format, nil
+}
+
+// pickEntries returns the positions of the clipboard entries at the given
+// indices, in clipboard order, or of every entry if indices is nil
+func pickEntries(board clipboard.Clipboard, indices []int) ([]int, error) {
+	if len(board.Entries) == 0 {
+		return nil, clipboard.ErrEmptyClipboard
+	}
+
+	selected := make(map[int]bool, len(indices))
+	for _, index := range indices {
+		if index < 0 || index >= len(board.Entries) {
+			return nil, fmt.Errorf("invalid clipboard index: %d", index)
+		}
+		selected[index] = true
+	}
+
+	var picked []int
+	for i := range board.Entries {
+		if indices == nil || selected[i] {
+			picked = append(picked, i)
+		}
+	}
+	return picked, nil
+}
+
+// handlePasteArchive packs the clipboard entries at the given indices, or
+// every entry if indices is nil, into a single archive instead of pasting
+// them one by one. Cut entries are removed, along with their sources, once
+// the archive has been written; copies stay in the clipboard.
+func handlePasteArchive(w io.Writer, indices []int, opts Options) error {
+	destDir, err := resolveDestDir(opts)
+	if err != nil {
+		return err
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	picked, err := pickEntries(board, indices)
+	if err != nil {
+		return err
+	}
+
+	var archiving []clipboard.Entry
+	var sources []string
+	for _, i := range picked {
+		entry := board.Entries[i]
+		if isRemoteEntry(entry) {
+			return fmt.Errorf("%s is on another machine; paste it locally before archiving it", entry.CurrentPath)
+		}
+		if _, err := os.Lstat(entry.CurrentPath); err != nil {
+			return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+		}
+		archiving = append(archiving, entry)
+		sources = append(sources, entry.CurrentPath)
+	}
+
+	destPath, format, err := archivePath(destDir, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if opts.dryRun {
+		var total int64
+		for _, source := range sources {
+			size, _ := fsops.TreeSize(source)
+			total += size
+		}
+		details := FormatSize(total)
+		if _, err := os.Lstat(destPath); err == nil {
+			if opts.onConflict == clipboard.ConflictFail {
+				fmt.Fprintf(w, "Would fail: %s already exists (use --on-conflict to resolve)\n", destPath)
+				return nil
+			}
+			details += ", destination exists, would " + string(opts.onConflict)
+		}
+		fmt.Fprintf(w, "Would archive %d entries (%s) -> %s\n", len(sources), details, destPath)
+		return nil
+	}
+
+	destPath, overwrite, err := engine().ResolveConflict("", destPath, opts.onConflict)
+	if errors.Is(err, clipboard.ErrSkipped) {
+		fmt.Fprintf(w, "Skipped: %s (destination already exists)\n", opts.archive)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range archiving {
+		if err := runHook("pre_paste", hookVars{source: entry.CurrentPath, dest: destPath, op: pasteOp(opts.persist || entry.IsCopy())}); err != nil {
+			return overwrite.Finish(err)
+		}
+	}
+
+	// everything is read into the archive, so progress covers moves too
+	progressOpts := opts
+	progressOpts.persist = true
+	opts.progress = startProgress(w, archiving, progressOpts)
+	err = overwrite.Finish(fsops.WriteArchive(destPath, format, sources, opts.pasteOptions().FS))
+	opts.progress.finish()
+
+	records := make([]HistoryRecord, 0, len(archiving))
+	for _, entry := range archiving {
+		records = append(records, newHistoryRecord("paste", entry.CurrentPath, destPath, err))
+	}
+	if histErr := appendHistory(records...); histErr != nil || err != nil {
+		return errors.Join(err, histErr)
+	}
+
+	var errs []error
+	var archived []clipboard.Entry
+	for _, i := range picked {
+		entry := board.Entries[i]
+		persist := opts.persist || entry.IsCopy()
+		if !persist {
+			if err := os.RemoveAll(entry.CurrentPath); err != nil {
+				errs = append(errs, fmt.Errorf("archived, but failed to remove %s: %w", entry.CurrentPath, err))
+				continue
+			}
+		}
+		if !persist || opts.pop {
+			archived = append(archived, entry)
+		}
+		if err := runHook("post_paste", hookVars{source: entry.CurrentPath, dest: destPath, op: pasteOp(persist)}); err != nil {
+			errs = append(errs, err)
+		}
+		fmt.Fprintf(w, "Archived: %s\n", entry.CurrentPath)
+	}
+
+	if len(archived) > 0 {
+		if err := engine().RemoveEntries(archived...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	fmt.Fprintf(w, "Wrote %s (%d entries)\n", destPath, len(archiving))
+	return errors.Join(errs...)
+}
+
+// handlePasteExtract unpacks the archive entries at the given indices, or
+// every entry if indices is nil, into the destination instead of pasting the
+// archive files. Each archive is unpacked into a staging directory first, so
+// its top-level files and directories go through the usual conflict
+// handling. Cut archives are removed once unpacked; copies stay.
+func handlePasteExtract(w io.Writer, indices []int, opts Options) error {
+	destDir, err := resolveDestDir(opts)
+	if err != nil {
+		return err
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	picked, err := pickEntries(board, indices)
+	if err != nil {
+		return err
+	}
+
+	formats := make(map[int]fsops.ArchiveFormat, len(picked))
+	for _, i := range picked {
+		entry := board.Entries[i]
+		if isRemoteEntry(entry) {
+			return fmt.Errorf("%s is on another machine; paste it locally before extracting it", entry.CurrentPath)
+		}
+		if info, err := os.Stat(entry.CurrentPath); err != nil {
+			return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+		} else if info.IsDir() {
+			return fmt.Errorf("%s is a directory, not an archive", entry.CurrentPath)
+		}
+		if formats[i], err = fsops.ArchiveFormatFor(entry.CurrentPath); err != nil {
+			return err
+		}
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if opts.dryRun {
+		for _, i := range picked {
+			fmt.Fprintf(w, "Would extract %s -> %s\n", board.Entries[i].CurrentPath, destDir)
+		}
+		return nil
+	}
+
+	var errs []error
+	var extracted []clipboard.Entry
+	for _, i := range picked {
+		entry := board.Entries[i]
+		persist := opts.persist || entry.IsCopy()
+		vars := hookVars{source: entry.CurrentPath, dest: destDir, op: pasteOp(persist)}
+		if err := runHook("pre_paste", vars); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		names, err := extractInto(entry.CurrentPath, destDir, formats[i], opts.onConflict)
+		if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, destDir, err)); histErr != nil {
+			errs = append(errs, histErr)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if len(names) == 0 {
+			fmt.Fprintf(w, "Skipped: %s (destination already exists)\n", entry.CurrentPath)
+			continue
+		}
+
+		if !persist {
+			if err := os.Remove(entry.CurrentPath); err != nil {
+				errs = append(errs, fmt.Errorf("extracted, but failed to remove %s: %w", entry.CurrentPath, err))
+				continue
+			}
+		}
+		if !persist || opts.pop {
+			extracted = append(extracted, entry)
+		}
+		if err := runHook("post_paste", vars); err != nil {
+			errs = append(errs, err)
+		}
+		fmt.Fprintf(w, "Extracted: %s -> %s (%s)\n", entry.CurrentPath, destDir, strings.Join(names, ", "))
+	}
+
+	if len(extracted) > 0 {
+		if err := engine().RemoveEntries(extracted...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// extractInto unpacks the archive at src into destDir, moving its top-level
+// files and directories into place through the conflict strategy, and
+// returns the names they ended up with. With no strategy, nothing is moved
+// unless none of them exist yet.
+func extractInto(src, destDir string, format fsops.ArchiveFormat, strategy clipboard.ConflictStrategy) ([]string, error) {
+	staging, err := os.MkdirTemp(destDir, ".cx-extract-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := fsops.ExtractArchive(src, staging, format); err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(staging)
+	if err != nil {
+		return nil, err
+	}
+	if strategy == clipboard.ConflictFail {
+		for _, dirEntry := range dirEntries {
+			target := filepath.Join(destDir, dirEntry.Name())
+			if _, err := os.Lstat(target); err == nil {
+				return nil, fmt.Errorf("%w: %s (use --on-conflict to resolve)", clipboard.ErrConflict, target)
+			}
+		}
+	}
+
+	var names []string
+	for _, dirEntry := range dirEntries {
+		staged := filepath.Join(staging, dirEntry.Name())
+		target, overwrite, err := engine().ResolveConflict(staged, filepath.Join(destDir, dirEntry.Name()), strategy)
+		if errors.Is(err, clipboard.ErrSkipped) {
+			continue
+		}
+		if err != nil {
+			return names, err
+		}
+		if err := overwrite.Finish(os.Rename(staged, target)); err != nil {
+			return names, err
+		}
+		names = append(names, filepath.Base(target))
+	}
+	return names, nil
+}
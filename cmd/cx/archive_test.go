@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+func TestHandlePasteArchive(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	cutSource := filepath.Join(tempDir, "file1.txt")
+	copySource := filepath.Join(tempDir, "nested")
+	if err := cutFile(io.Discard, cutSource, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := copyFileToClipboard(io.Discard, copySource, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handlePasteArchive(&out, nil, Options{dest: destDir, archive: "bundle", zip: true, dryRun: true}); err != nil {
+		t.Fatalf("handlePasteArchive dry run failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Would archive 2 entries") {
+		t.Errorf("Unexpected dry run output: %q", out.String())
+	}
+
+	if err := handlePasteArchive(io.Discard, nil, Options{dest: destDir, archive: "bundle", zip: true}); err != nil {
+		t.Fatalf("handlePasteArchive failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "bundle.zip")); err != nil {
+		t.Errorf("Expected bundle.zip to be written: %v", err)
+	}
+	if _, err := os.Stat(cutSource); !os.IsNotExist(err) {
+		t.Error("Expected cut source to be removed once archived")
+	}
+	if _, err := os.Stat(copySource); err != nil {
+		t.Errorf("Expected copied source to remain: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != copySource {
+		t.Errorf("Expected only the copy to remain in the clipboard, got %+v", board.Entries)
+	}
+
+	if err := handlePasteArchive(io.Discard, nil, Options{dest: destDir, archive: "bundle.zip"}); err == nil {
+		t.Error("Expected error for an existing archive, got nil")
+	}
+	if err := handlePasteArchive(io.Discard, nil, Options{dest: destDir, archive: "bundle.zip", onConflict: clipboard.ConflictOverwrite}); err != nil {
+		t.Errorf("Expected overwrite to succeed, got %v", err)
+	}
+	if err := handlePasteArchive(io.Discard, nil, Options{dest: destDir, archive: "bundle.rar"}); err == nil {
+		t.Error("Expected error for an unknown archive type, got nil")
+	}
+}
+
+func TestHandlePasteExtract(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	bundle := filepath.Join(tempDir, "bundle.tar.gz")
+	if err := fsops.WriteArchive(bundle, fsops.ArchiveTarGz, []string{filepath.Join(tempDir, "nested")}, fsops.Options{}); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if err := cutFile(io.Discard, bundle, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handlePasteExtract(&out, nil, Options{dest: destDir}); err != nil {
+		t.Fatalf("handlePasteExtract failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Extracted: "+bundle) {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "nested", "file3.txt")); err != nil {
+		t.Errorf("Expected the archive to be unpacked: %v", err)
+	}
+	if _, err := os.Stat(bundle); !os.IsNotExist(err) {
+		t.Error("Expected cut archive to be removed once extracted")
+	}
+
+	// A copied archive stays, and conflicts are resolved per top-level name
+	if err := fsops.WriteArchive(bundle, fsops.ArchiveTarGz, []string{filepath.Join(tempDir, "nested")}, fsops.Options{}); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if err := copyFileToClipboard(io.Discard, bundle, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := handlePasteExtract(io.Discard, nil, Options{dest: destDir}); err == nil {
+		t.Error("Expected error for an existing destination, got nil")
+	}
+	if err := handlePasteExtract(io.Discard, nil, Options{dest: destDir, onConflict: clipboard.ConflictRename}); err != nil {
+		t.Fatalf("handlePasteExtract with rename failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "nested (1)", "file3.txt")); err != nil {
+		t.Errorf("Expected the archive to be unpacked under a new name: %v", err)
+	}
+	if _, err := os.Stat(bundle); err != nil {
+		t.Errorf("Expected copied archive to remain: %v", err)
+	}
+	// .gitkeep and the two unpacked copies, with no staging directory left
+	entries, _ := os.ReadDir(destDir)
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 entries in the destination, got %d", len(entries))
+	}
+
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := handlePasteExtract(io.Discard, []int{0}, Options{dest: destDir}); err == nil {
+		t.Error("Expected error for an entry that isn't an archive, got nil")
+	}
+}
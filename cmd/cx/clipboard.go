@@ -1,314 +1,854 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
-	"golang.org/x/sys/unix"
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
 )
 
-// Entry represents a clipboard entry containing file/directory information
-type Entry struct {
-	OriginalPath string    `json:"original_path"`
-	CurrentPath  string    `json:"current_path"`
-	CutAt        time.Time `json:"timestamp"`
+// storeKinds lists the clipboard storage backends accepted by --store
+var storeKinds = []string{"file", "sqlite", "daemon"}
+
+// sqlitePath returns the path of the database the sqlite store keeps the
+// clipboard in, next to the clipboard file
+func sqlitePath() string {
+	return siblingPath(".db")
 }
 
-// Clipboard represents the collection of clipboard entries
-type Clipboard struct {
-	Entries []Entry `json:"entries"`
+// clipboardStore returns the store selected with --store, by default the
+// clipboard file at --clipboard
+func clipboardStore() clipboard.Store {
+	switch storeKind {
+	case "daemon":
+		return newSocketStore()
+	case "sqlite":
+		store := clipboard.NewSQLiteStore(sqlitePath())
+		store.Waiting = waitingForLock
+		return store
+	}
+	store := clipboard.NewFileStore(clipboardPath)
+	store.Waiting = waitingForLock
+	store.Recovered = warnRecovered
+	return store
 }
 
-// getClipboardPath returns the path to the clipboard file, creating it if it doesn't exist
-func getClipboardPath() (string, error) {
-	_, err := os.Stat(clipboardPath)
-	if err != nil {
-		clipboardJSON, err := json.Marshal(Clipboard{Entries: []Entry{}})
-		if err != nil {
-			return "", err
-		}
+// waitingForLock tells the user the clipboard is locked by another process
+func waitingForLock() {
+	fmt.Fprintln(os.Stderr, "Waiting for another cx process to finish...")
+}
 
-		err = os.WriteFile(clipboardPath, clipboardJSON, 0o644)
-		if err != nil {
-			return "", err
-		}
+// backupSuffix is set by --backup to keep overwritten destinations under
+// their name with it added, instead of deleting them
+var backupSuffix string
+
+// engine returns a clipboard engine on the selected store, prompting on
+// stderr for conflicts
+func engine() *clipboard.Engine {
+	e := clipboard.NewEngine(clipboardStore())
+	e.Prompt = promptConflict
+	e.Backup = backupSuffix
+	e.Limits = limits
+	if confirming() {
+		e.Confirm = confirmAction
 	}
-
-	return clipboardPath, nil
+	return e
 }
 
-// readClipboard reads and parses the clipboard file
-func readClipboard() (Clipboard, error) {
-	var clipboard Clipboard
+// readClipboard reads the clipboard from the selected store
+func readClipboard() (clipboard.Clipboard, error) {
+	return clipboardStore().Read()
+}
 
-	clipboardPath, err := getClipboardPath()
+// entryIndices returns where entries are on the clipboard now, which may
+// have changed since they were read
+func entryIndices(entries []clipboard.Entry) ([]int, error) {
+	board, err := readClipboard()
 	if err != nil {
-		return clipboard, err
+		return nil, err
 	}
 
-	clipboardFile, err := os.Open(clipboardPath)
-	if err != nil {
-		return clipboard, err
+	indices := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		i := slices.IndexFunc(board.Entries, entry.Is)
+		if i < 0 {
+			return nil, fmt.Errorf("%s is no longer on the clipboard", entry.CurrentPath)
+		}
+		indices = append(indices, i)
 	}
-	defer clipboardFile.Close()
+	return indices, nil
+}
 
-	clipboardJSON, err := io.ReadAll(clipboardFile)
-	if err != nil {
-		return clipboard, err
-	}
+// updateClipboard changes the clipboard with fn under the store's lock; see
+// clipboard.Engine.Update
+func updateClipboard(fn func(*clipboard.Clipboard) error) error {
+	return engine().Update(fn)
+}
 
-	err = json.Unmarshal(clipboardJSON, &clipboard)
-	return clipboard, err
+type Options struct {
+	persist      bool
+	quiet        bool
+	detailed     bool
+	format       listFormat
+	all          bool
+	onConflict   clipboard.ConflictStrategy
+	preserve     fsops.Attrs
+	exclude      fsops.Rules
+	gitignore    bool
+	verify       bool
+	noReflink    bool
+	showProgress bool
+	dest         string
+	mkdir        bool
+	as           string
+	sys          bool
+	force        bool
+	dryRun       bool
+	tree         bool
+	lines        int
+	depth        int
+	sizes        bool
+	pop          bool
+	archive      string
+	zip          bool
+	extract      bool
+	symlinks     fsops.SymlinkPolicy
+	hardlink     bool
+	vars         map[string]string
+	tags         []string
+	tag          string
+	clear        bool
+	regex        bool
+	pasteFirst   bool
+	pathMode     pathMode
+	columns      []string
+	preserveRoot string
+	rename       *renamer
+	sudo         bool
+	devices      bool
+	resume       bool
+	bwlimit      int64
+	update       bool
+	checksum     bool
+	deleteExtras bool
+	sortBy       string
+	reverse      bool
+
+	// progress is set while pasting when progress should be rendered
+	progress *progress
+	// ctx stops a paste when it's canceled, e.g. by Ctrl-C
+	ctx context.Context
 }
 
-// writeClipboard writes the clipboard data to the clipboard file
-func writeClipboard(clipboard Clipboard) error {
-	clipboardPath, err := getClipboardPath()
-	if err != nil {
-		return err
-	}
+// warnSkipped reports a file left out of a copy, like a socket
+func warnSkipped(message string) {
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+}
 
-	clipboardJSON, err := json.MarshalIndent(clipboard, "", "  ")
-	if err != nil {
-		return err
+// pasteOptions returns the options the clipboard engine needs to paste
+func (opts Options) pasteOptions() clipboard.PasteOptions {
+	p := clipboard.PasteOptions{
+		Persist:    opts.persist,
+		OnConflict: opts.onConflict,
+		As:         opts.as,
+		Verify:     opts.verify,
+		FS: fsops.Options{
+			Preserve:  opts.preserve,
+			Exclude:   opts.exclude,
+			Gitignore: opts.gitignore,
+			NoReflink: opts.noReflink,
+			Symlinks:  opts.symlinks,
+			Hardlink:  opts.hardlink,
+			Devices:   opts.devices,
+			Warn:      warnSkipped,
+			Context:   opts.ctx,
+			Update:    opts.update,
+			Checksum:  opts.checksum,
+		},
+	}
+	if opts.update {
+		// the next update compares modification times with the source's
+		p.FS.Preserve.Timestamps = true
+	}
+	if opts.progress != nil {
+		p.FS.Progress = opts.progress
+	}
+	if opts.bwlimit > 0 {
+		p.FS.Limit = fsops.NewLimiter(opts.bwlimit)
 	}
+	return p
+}
 
-	err = os.WriteFile(clipboardPath, clipboardJSON, 0o644)
-	return err
+// cutFile adds a file or directory to the clipboard to be moved on paste
+func cutFile(w io.Writer, path string, opts Options) error {
+	return addEntry(w, path, clipboard.OpCut, opts)
 }
 
-type Options struct {
-	persist  bool
-	quiet    bool
-	detailed bool
-	json     bool
+// copyFileToClipboard adds a file or directory to the clipboard to be
+// duplicated on paste
+func copyFileToClipboard(w io.Writer, path string, opts Options) error {
+	return addEntry(w, path, clipboard.OpCopy, opts)
 }
 
-// cutFile adds a file or directory to the clipboard
-func cutFile(w io.Writer, path string, opts Options) error {
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return err
-	}
+// addEntry records a file or directory in the clipboard with the given operation
+func addEntry(w io.Writer, path string, op clipboard.Operation, opts Options) error {
+	return addEntries(w, []string{path}, op, opts)
+}
 
-	fileInfo, err := os.Lstat(absPath)
+// addEntries records several files or directories in the clipboard with the
+// given operation, as if each had been added in turn. Nothing is added
+// unless every path is valid.
+func addEntries(w io.Writer, paths []string, op clipboard.Operation, opts Options) error {
+	remotes, err := splitRemotePaths(paths)
 	if err != nil {
 		return err
 	}
+	if len(remotes) > 0 {
+		return addRemoteEntries(w, remotes, op, opts)
+	}
 
-	if !(fileInfo.Mode()&os.ModeSymlink != 0) {
-		err = unix.Access(absPath, unix.R_OK)
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
 		if err != nil {
-			return fmt.Errorf("no read permission for %s: %w", absPath, err)
+			return err
+		}
+		if err := runHook("pre_cut", hookVars{source: absPath, op: op}); err != nil {
+			return err
 		}
 	}
 
-	clipboard, err := readClipboard()
+	e := engine()
+	var evicted []clipboard.Entry
+	e.Evicted = func(entry clipboard.Entry) { evicted = append(evicted, entry) }
+	absPaths, err := e.Add(paths, op, clipboard.AddOptions{Replace: opts.force, Tags: opts.tags})
+	var dup *clipboard.DuplicateError
+	if errors.As(err, &dup) {
+		err = fmt.Errorf("%w (use --force to replace it)", err)
+	}
+	err = explainLimit(err)
 	if err != nil {
+		var records []HistoryRecord
+		for _, path := range paths {
+			if absPath, absErr := filepath.Abs(path); absErr == nil {
+				path = absPath
+			}
+			records = append(records, newHistoryRecord(string(op), path, "", err))
+		}
+		return errors.Join(err, appendHistory(records...))
+	}
+
+	records := make([]HistoryRecord, 0, len(absPaths))
+	for _, absPath := range absPaths {
+		records = append(records, newHistoryRecord(string(op), absPath, "", nil))
+	}
+	if err := appendHistory(records...); err != nil {
 		return err
 	}
 
-	// prepend entry since clipboard is a stack
-	clipboard.Entries = append([]Entry{Entry{
-		OriginalPath: absPath,
-		CurrentPath:  absPath,
-		CutAt:        time.Now(),
-	}}, clipboard.Entries...)
+	for _, absPath := range absPaths {
+		if err := runHook("post_cut", hookVars{source: absPath, op: op}); err != nil {
+			return err
+		}
+	}
 
-	err = writeClipboard(clipboard)
-	if err != nil {
-		return err
+	if opts.sys {
+		if err := writeSysClipboard(absPaths); err != nil {
+			return err
+		}
 	}
 
 	if opts.quiet {
 		w = io.Discard
 	}
 
-	fmt.Fprintf(w, "Cut: %s\n", absPath)
+	for _, absPath := range absPaths {
+		if op == clipboard.OpCopy {
+			fmt.Fprintf(w, "Copy: %s\n", absPath)
+		} else {
+			fmt.Fprintf(w, "Cut: %s\n", absPath)
+		}
+	}
+	printEvicted(w, evicted)
 	return nil
 }
 
-// handlePasteAt pastes a specific clipboard entry by index
-func handlePasteAt(w io.Writer, index int, opts Options) error {
-	pwd, err := os.Getwd()
+// readPathList reads paths from r, one per line, or separated by NUL bytes
+// if the input contains any (as produced by find -print0 or fd -0)
+func readPathList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	clipboard, err := readClipboard()
-	if err != nil {
-		return err
+	sep := "\n"
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = "\x00"
 	}
 
-	if index < 0 || index >= len(clipboard.Entries) {
-		return fmt.Errorf("invalid clipboard index: %d", index)
+	var paths []string
+	for _, path := range strings.Split(string(data), sep) {
+		if sep == "\n" {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
 	}
 
-	entry := clipboard.Entries[index]
-	if _, err := os.Lstat(entry.CurrentPath); err != nil {
-		return fmt.Errorf("source path no longer exists: %s", entry.CurrentPath)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths given on stdin")
 	}
+	return paths, nil
+}
 
-	result, err := pasteEntry(entry, pwd, opts)
-	if err != nil {
-		return err
+// resolveDestDir returns the absolute directory to paste into: the --to
+// directory if one was given, otherwise the current directory
+func resolveDestDir(opts Options) (string, error) {
+	if opts.dest == "" {
+		return os.Getwd()
 	}
 
-	if opts.quiet {
-		w = io.Discard
+	destDir, err := filepath.Abs(opts.dest)
+	if err != nil {
+		return "", err
 	}
 
-	if opts.persist {
-		if err := updateEntryPath(index, result); err != nil {
-			return err
+	info, err := os.Stat(destDir)
+	if errors.Is(err, os.ErrNotExist) {
+		if !opts.mkdir {
+			return "", fmt.Errorf("destination does not exist: %s (use --mkdir to create it)", destDir)
 		}
-		fmt.Fprintf(w, "Copied: %s -> %s\n", entry.CurrentPath, result)
-	} else {
-		if err := removeFromClipboard(index); err != nil {
-			return err
+		if opts.dryRun {
+			return destDir, nil
 		}
-		fmt.Fprintf(w, "Moved: %s -> %s\n", entry.CurrentPath, result)
+		return destDir, os.MkdirAll(destDir, 0o755)
+	}
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	if !info.IsDir() {
+		return "", fmt.Errorf("destination is not a directory: %s", destDir)
+	}
+
+	return destDir, nil
 }
 
-// pasteEntry performs the actual paste operation (copy or move)
-func pasteEntry(entry Entry, destDir string, opts Options) (string, error) {
-	srcInfo, err := os.Lstat(entry.CurrentPath)
-	if err != nil {
-		return "", err
+// rootedDestDir returns the directory to paste entry into. With
+// --preserve-root that's destDir plus the entry's parent directory relative
+// to the root, so root/src/a/x.go lands in destDir/src/a; otherwise it's
+// destDir itself.
+func rootedDestDir(entry clipboard.Entry, destDir string, opts Options) (string, error) {
+	if opts.preserveRoot == "" {
+		return destDir, nil
+	}
+	if isRemoteEntry(entry) {
+		return "", fmt.Errorf("--preserve-root is not supported for remote entries")
 	}
 
-	destPath := filepath.Join(destDir, filepath.Base(entry.CurrentPath))
+	rel, err := filepath.Rel(opts.preserveRoot, filepath.Dir(entry.CurrentPath))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not under %s", entry.CurrentPath, opts.preserveRoot)
+	}
+	return filepath.Join(destDir, rel), nil
+}
 
-	if opts.persist {
-		if srcInfo.IsDir() {
-			err = copyDir(entry.CurrentPath, destPath)
-		} else if srcInfo.Mode()&os.ModeSymlink != 0 {
-			err = copySymlink(entry.CurrentPath, destPath)
-		} else {
-			err = copyFile(entry.CurrentPath, destPath)
-		}
-	} else {
-		err = os.Rename(entry.CurrentPath, destPath)
+// prepareRootedDestDir is rootedDestDir, creating the directory if needed
+func prepareRootedDestDir(entry clipboard.Entry, destDir string, opts Options) (string, error) {
+	entryDir, err := rootedDestDir(entry, destDir, opts)
+	if err != nil || entryDir == destDir {
+		return entryDir, err
 	}
+	return entryDir, os.MkdirAll(entryDir, 0o755)
+}
 
-	if err != nil {
-		return "", err
+// locateMissing looks for a missing entry's file nearby, in case something
+// else moved it, and returns its new path once the user agrees to update the
+// entry. Otherwise it fails with "source path no longer exists", setting
+// lost if nothing that could be the file was found. --yes doesn't answer for
+// the user here: pasting a file they never picked is worse than stopping a
+// script.
+func locateMissing(entry clipboard.Entry) (found string, lost bool, err error) {
+	found, ok := clipboard.Locate(entry)
+	if !ok {
+		return "", true, fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+	}
+	if assumeYes {
+		return "", false, fmt.Errorf("%w: %s (it may have moved to %s; paste without --yes to update the entry)", clipboard.ErrSourceMissing, entry.CurrentPath, found)
+	}
+	question := fmt.Sprintf("%s is gone, but looks like it was moved to %s. Update the entry?", entry.CurrentPath, found)
+	if ok, err := confirmAction(question); err != nil || !ok {
+		return "", false, fmt.Errorf("%w: %s (it may have moved to %s)", clipboard.ErrSourceMissing, entry.CurrentPath, found)
 	}
+	return found, false, nil
+}
 
-	return destPath, nil
+// printPruned reports an entry dropped by prune_missing once paste couldn't
+// find its file anywhere
+func printPruned(w io.Writer, entry clipboard.Entry) {
+	fmt.Fprintf(w, "Pruned: %s (no longer exists)\n", entry.CurrentPath)
 }
 
-// copyDir recursively copies a directory
-func copyDir(src, dst string) error {
-	srcInfo, err := os.Stat(src)
+// pasteEntry pastes entry into destDir, downloading it first if it is on
+// another machine
+func pasteEntry(entry clipboard.Entry, destDir string, opts Options) (clipboard.Pasted, error) {
+	if remote, ok := parseRemotePath(entry.CurrentPath); ok {
+		dest, err := download(remote, destDir, opts)
+		return clipboard.Pasted{Path: dest}, err
+	}
+	pasteOpts := opts.pasteOptions()
+	manifest, resume, err := openTransferManifest(entry, destDir, opts)
 	if err != nil {
-		return err
+		return clipboard.Pasted{}, err
 	}
+	var interrupted string
+	if manifest != nil {
+		pasteOpts.FS.Manifest, pasteOpts.Resume = manifest, resume
+		interrupted = manifest.Dest()
+	}
+
+	if !opts.persist && !opts.quiet {
+		warnNetworkMove(os.Stderr, entry.CurrentPath, destDir)
+	}
+	target, _ := pastePath(entry, destDir, opts)
+	pasted, err := engine().Paste(entry, destDir, pasteOpts)
+	if manifest != nil {
+		// kept after a failure that left something to resume
+		if err == nil || manifest.Dest() == "" {
+			manifest.Remove()
+		} else {
+			manifest.Close()
+			if resume == "" && interrupted == target {
+				err = fmt.Errorf("%w; an earlier paste into it was interrupted, use --resume to carry on with it", err)
+			}
+		}
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		if opts.sudo {
+			// a copy denied part way through leaves what it got done behind
+			// wherever the conflict sent it, which the retry has to clear
+			// first. What an update went into was there before, and what
+			// an overwrite replaced has been put back, so neither is.
+			partial := ""
+			if !pasted.Into && !pasted.Overwrote {
+				partial = pasted.Path
+			}
+			dest, err := pasteWithSudo(entry, destDir, partial, opts)
+			return clipboard.Pasted{Path: dest}, err
+		}
+		return clipboard.Pasted{}, permissionHint(err, entry, destDir, opts)
+	}
+	if err != nil {
+		return clipboard.Pasted{}, err
+	}
+	return pasted, nil
+}
 
-	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+// handlePasteAt pastes a specific clipboard entry by index. With opts.pop
+// the entry is removed afterwards even if it was a copy.
+func handlePasteAt(w io.Writer, index int, opts Options) error {
+	destDir, err := resolveDestDir(opts)
+	if err != nil {
 		return err
 	}
 
-	dirEntries, err := os.ReadDir(src)
+	board, err := readClipboard()
 	if err != nil {
 		return err
 	}
 
-	for _, entry := range dirEntries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
-		if entry.IsDir() {
-			if err := copyDir(srcPath, dstPath); err != nil {
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	if index < 0 || index >= len(board.Entries) {
+		return fmt.Errorf("invalid clipboard index: %d", index)
+	}
+
+	entry := board.Entries[index]
+	// a dry run only reports where a missing entry seems to have gone,
+	// without asking to update it
+	if opts.dryRun {
+		return printPastePlans(w, []clipboard.Entry{entry}, destDir, opts)
+	}
+
+	if _, err := os.Lstat(entry.CurrentPath); err != nil && !isRemoteEntry(entry) {
+		found, lost, err := locateMissing(entry)
+		if lost && pruneMissing {
+			if err := engine().RemoveEntries(entry); err != nil {
 				return err
 			}
-		} else {
-			if err := copyFile(srcPath, dstPath); err != nil {
-				return err
+			if !opts.quiet {
+				printPruned(w, entry)
 			}
 		}
+		if err != nil {
+			return err
+		}
+		entry.CurrentPath = found
+		entry.Identify()
+		if err := engine().ReplaceEntries(entry); err != nil {
+			return err
+		}
 	}
 
-	return nil
-}
-
-// copyFile copies a single file
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
+	opts, err = renamedOpts(filepath.Base(entry.CurrentPath), 1, opts)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
 
-	srcInfo, err := srcFile.Stat()
+	// copied entries are always duplicated, regardless of --persist
+	if entry.IsCopy() {
+		opts.persist = true
+	}
+
+	if err := checkRoom([]clipboard.Entry{entry}, destDir, opts); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	destDir, err = prepareRootedDestDir(entry, destDir, opts)
 	if err != nil {
 		return err
 	}
 
-	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
+	if err := runPrePasteHook(entry, destDir, opts); err != nil {
+		return err
+	}
+
+	printPasteSummary(w, []clipboard.Entry{entry})
+	timing := startTiming([]clipboard.Entry{entry})
+	opts.progress = startProgress(w, []clipboard.Entry{entry}, opts)
+	pasted, err := pasteEntry(entry, destDir, opts)
+	result := pasted.Path
+	opts.progress.finish()
+	if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, result, err)); histErr != nil {
+		return errors.Join(err, histErr)
+	}
+	if errors.Is(err, clipboard.ErrSkipped) {
+		fmt.Fprintf(w, "Skipped: %s (destination already exists)\n", entry.CurrentPath)
+		return nil
+	}
+	if errors.Is(err, clipboard.ErrAlreadyThere) {
+		// the move is already done
+		if err := engine().RemoveEntries(entry); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Already there: %s\n", entry.CurrentPath)
+		return nil
+	}
 	if err != nil {
+		return interruptedError(err, entry)
+	}
+
+	if err := recordPaste(entry, pasted, opts.persist); err != nil {
 		return err
 	}
-	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+	if err := runHook("post_paste", hookVars{source: entry.CurrentPath, dest: result, op: pasteOp(opts.persist)}); err != nil {
+		return err
+	}
+
+	if opts.pop && opts.persist {
+		if err := engine().RemoveEntries(entry); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Copied: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+	} else if entry.IsCopy() {
+		// the source stays put, so the entry can be pasted again as-is
+		fmt.Fprintf(w, "Copied: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+	} else if opts.persist {
+		pasted := entry
+		pasted.CurrentPath = result
+		pasted.Identify()
+		if err := engine().ReplaceEntries(pasted); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Copied: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+	} else {
+		if err := engine().RemoveEntries(entry); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Moved: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+	}
+
+	return nil
 }
 
-func copySymlink(src, dst string) error {
-	target, err := os.Readlink(src)
+// handlePasteAll pastes every clipboard entry into the current directory
+func handlePasteAll(w io.Writer, opts Options) error {
+	return handlePasteEntries(w, nil, opts)
+}
+
+// handlePasteEntries pastes the clipboard entries at the given indices into
+// the current directory, or every entry if indices is nil. A failing entry
+// does not stop the others; it is left in the clipboard and its error is
+// reported once all entries have been attempted. With opts.pop every pasted
+// entry is removed, copies included.
+func handlePasteEntries(w io.Writer, indices []int, opts Options) error {
+	destDir, err := resolveDestDir(opts)
 	if err != nil {
 		return err
 	}
-	return os.Symlink(target, dst)
-}
 
-// updateEntryPath updates the current path of a clipboard entry
-func updateEntryPath(index int, newPath string) error {
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		return err
 	}
 
-	if index < 0 || index >= len(clipboard.Entries) {
-		return fmt.Errorf("invalid clipboard index: %d", index)
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	selected := make(map[int]bool, len(indices))
+	for _, index := range indices {
+		if index < 0 || index >= len(board.Entries) {
+			return fmt.Errorf("invalid clipboard index: %d", index)
+		}
+		selected[index] = true
 	}
 
-	entry := clipboard.Entries[index]
-	entry.CurrentPath = newPath
+	var pasting []clipboard.Entry
+	for i, entry := range board.Entries {
+		if indices == nil || selected[i] {
+			pasting = append(pasting, entry)
+		}
+	}
 
-	clipboard.Entries[index] = entry
+	if opts.dryRun {
+		return printPastePlans(w, pasting, destDir, opts)
+	}
 
-	return writeClipboard(clipboard)
-}
+	if err := checkRoom(pasting, destDir, opts); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	printPasteSummary(w, pasting)
+	timing := startTiming(pasting)
+	opts.progress = startProgress(w, pasting, opts)
+
+	// done counts the entries dealt with, which aren't left to paste
+	total, done := 0, 0
+	var errs []error
+	// the clipboard may change while we paste, so what happened to each
+	// entry is applied to it afterwards rather than writing board back
+	var removed, updated []clipboard.Entry
+
+	for i, entry := range board.Entries {
+		if indices != nil && !selected[i] || opts.interrupted() {
+			continue
+		}
+		total++
+
+		if _, err := os.Lstat(entry.CurrentPath); err != nil && !isRemoteEntry(entry) {
+			found, lost, err := locateMissing(entry)
+			if lost && pruneMissing {
+				printPruned(w, entry)
+				removed = append(removed, entry)
+				done++
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, "", err)); histErr != nil {
+					errs = append(errs, histErr)
+				}
+				continue
+			}
+			entry.CurrentPath = found
+			entry.Identify()
+			updated = append(updated, entry)
+		}
+
+		entryOpts, err := renamedOpts(filepath.Base(entry.CurrentPath), total, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+		if entry.IsCopy() {
+			entryOpts.persist = true
+		}
+
+		entryDir, err := prepareRootedDestDir(entry, destDir, entryOpts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+
+		if err := runPrePasteHook(entry, entryDir, entryOpts); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+
+		timing.begin()
+		pasted, err := pasteEntry(entry, entryDir, entryOpts)
+		result := pasted.Path
+		opts.progress.clear()
+		if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, result, err)); histErr != nil {
+			errs = append(errs, histErr)
+		}
+		if errors.Is(err, clipboard.ErrSkipped) {
+			fmt.Fprintf(w, "Skipped: %s (destination already exists)\n", entry.CurrentPath)
+			continue
+		}
+		if errors.Is(err, clipboard.ErrAlreadyThere) {
+			fmt.Fprintf(w, "Already there: %s\n", entry.CurrentPath)
+			removed = append(removed, entry)
+			done++
+			continue
+		}
+		if errors.Is(err, context.Canceled) {
+			errs = append(errs, interruptedError(err, entry))
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+
+		done++
+		if err := recordPaste(entry, pasted, entryOpts.persist); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+		}
+		if err := runHook("post_paste", hookVars{source: entry.CurrentPath, dest: result, op: pasteOp(entryOpts.persist)}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+		}
+
+		switch {
+		case opts.pop && entryOpts.persist:
+			fmt.Fprintf(w, "Copied: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+			removed = append(removed, entry)
+		case entry.IsCopy():
+			fmt.Fprintf(w, "Copied: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+		case entryOpts.persist:
+			fmt.Fprintf(w, "Copied: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+			entry.CurrentPath = result
+			entry.Identify()
+			updated = append(updated, entry)
+		default:
+			fmt.Fprintf(w, "Moved: %s -> %s%s\n", entry.CurrentPath, result, timing.describe(entry.CurrentPath))
+			removed = append(removed, entry)
+		}
+	}
 
-// removeFromClipboard removes an entry from the clipboard by index
-func removeFromClipboard(index int) error {
-	clipboard, err := readClipboard()
+	opts.progress.finish()
+
+	err = updateClipboard(func(board *clipboard.Clipboard) error {
+		board.Drop(removed...)
+		board.Replace(updated...)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	if index < 0 || index >= len(clipboard.Entries) {
-		return fmt.Errorf("invalid clipboard index: %d", index)
+	if opts.interrupted() {
+		left := len(pasting) - done
+		message := fmt.Sprintf("paste interrupted, %d of %d entries left on the clipboard", left, len(pasting))
+		return errors.Join(interruptError{message}, errors.Join(errs...))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to paste %d of %d entries:\n%w", len(errs), total, errors.Join(errs...))
 	}
+	return nil
+}
+
+// listFormat selects how handleList renders the clipboard
+type listFormat string
 
-	clipboard.Entries = append(clipboard.Entries[:index], clipboard.Entries[index+1:]...)
+const (
+	formatPlain listFormat = "plain"
+	formatJSON  listFormat = "json"
+	formatTSV   listFormat = "tsv"
+)
+
+var listFormats = []string{string(formatPlain), string(formatJSON), string(formatTSV)}
 
-	return writeClipboard(clipboard)
+// parseListFormat validates a format name given on the command line
+func parseListFormat(s string) (listFormat, error) {
+	switch format := listFormat(s); format {
+	case formatPlain, formatJSON, formatTSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid format %q (expected one of: %s)", s, strings.Join(listFormats, ", "))
+}
+
+// quotePath returns path as it's safe to show on a terminal: as it is or,
+// if it has control characters such as newlines or isn't valid UTF-8,
+// quoted with those escaped, so a file name can't break a listing apart or
+// send escape sequences to the terminal
+func quotePath(path string) string {
+	if utf8.ValidString(path) && strings.IndexFunc(path, func(r rune) bool { return !strconv.IsPrint(r) }) < 0 {
+		return path
+	}
+	return strconv.Quote(path)
+}
+
+// pathMode selects how cx list shows paths
+type pathMode string
+
+const (
+	pathAbsolute pathMode = ""
+	pathRelative pathMode = "relative"
+	pathBasename pathMode = "basename"
+)
+
+// displayPath returns path as cx list shows it: relative to cwd or just its
+// base name in those modes, otherwise with the home directory abbreviated to
+// ~, unless the output is plain. Entries keep their absolute paths, and
+// remote paths are shown as they are.
+func displayPath(path string, mode pathMode, cwd, home string) string {
+	if _, ok := parseRemotePath(path); ok {
+		return path
+	}
+
+	switch mode {
+	case pathBasename:
+		return filepath.Base(path)
+	case pathRelative:
+		if rel, err := filepath.Rel(cwd, path); cwd != "" && err == nil {
+			return rel
+		}
+		return path
+	}
+
+	if home != "" && !plainOutput {
+		if path == home {
+			return "~"
+		}
+		if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+			return filepath.Join("~", rest)
+		}
+	}
+	return path
 }
 
 type listEntry struct {
 	index         int
 	basePath      string
+	displayPath   string
+	currentPath   string
 	symlinkTarget string
 	size          int64
 	sizeDisplay   string
@@ -318,6 +858,15 @@ type listEntry struct {
 	isDir         bool
 	isLink        bool
 	isMissing     bool
+	// recorded is set for a missing entry described by what was recorded
+	// when it was added
+	recorded  bool
+	isCopy    bool
+	isExpired bool
+	stats     *treeStats
+	tags      []string
+	note      string
+	identity  string
 }
 
 var (
@@ -345,6 +894,9 @@ var (
 
 	detailsStyle = lipgloss.NewStyle().
 			Foreground(colorMuted)
+
+	tagStyle = lipgloss.NewStyle().
+			Foreground(colorCyan)
 )
 
 func indexStyle(width int) lipgloss.Style {
@@ -354,22 +906,35 @@ func indexStyle(width int) lipgloss.Style {
 		Align(lipgloss.Right)
 }
 
-func renderPath(entry listEntry, width int) string {
-	padded := fmt.Sprintf("%-*s", width, entry.basePath)
+// pathStyle returns the style of the entry's path, which depends on its kind
+func pathStyle(entry listEntry) lipgloss.Style {
 	switch {
 	case entry.isMissing:
-		return missingPathStyle.Render(padded)
+		return missingPathStyle
 	case entry.isDir:
-		return dirStyle.Render(padded)
+		return dirStyle
 	case entry.isLink:
-		path := fmt.Sprintf("%s -> %s", entry.basePath, entry.symlinkTarget)
-		padded := fmt.Sprintf("%-*s", width, path)
-		return symlinkStyle.Render(padded)
+		return symlinkStyle
 	default:
-		return fileStyle.Render(padded)
+		return fileStyle
 	}
 }
 
+// pathText returns the entry's path as shown, with a symlink's target
+func pathText(entry listEntry) string {
+	if entry.isLink {
+		return fmt.Sprintf("%s -> %s", entry.displayPath, entry.symlinkTarget)
+	}
+	return entry.displayPath
+}
+
+// renderPath pads the entry's path to width terminal cells, in which wide
+// characters, like CJK ones, take two, and styles it
+func renderPath(entry listEntry, width int) string {
+	text := pathText(entry)
+	return pathStyle(entry).Render(text + strings.Repeat(" ", max(width-lipgloss.Width(text), 0)))
+}
+
 func renderTable(w io.Writer, entries []listEntry, opts Options, maxPathWidth, maxSizeWidth, maxIndexWidth int) {
 
 	idxStyle := indexStyle(maxIndexWidth)
@@ -378,45 +943,158 @@ func renderTable(w io.Writer, entries []listEntry, opts Options, maxPathWidth, m
 		indexStr := idxStyle.Render(fmt.Sprintf("%d:", entry.index))
 		pathStr := renderPath(entry, maxPathWidth)
 
+		tagStr := ""
+		if len(entry.tags) > 0 {
+			tagStr = " " + tagStyle.Render(formatTags(entry.tags))
+		}
+
+		// notes go on their own line under the path, past the index column
+		noteStr := ""
+		if entry.note != "" {
+			noteStr = strings.Repeat(" ", maxIndexWidth+1) + detailsStyle.Render("note: "+entry.note) + "\n"
+		}
+		// with --verbose, so does what identifies the file if it moves
+		if verbose && entry.identity != "" {
+			noteStr += strings.Repeat(" ", maxIndexWidth+1) + detailsStyle.Render("id: "+entry.identity) + "\n"
+		}
+
 		if entry.isMissing {
-			fmt.Fprintf(w, "%s %s %s\n", indexStr, pathStr, detailsStyle.Render("(file not found)"))
+			fmt.Fprintf(w, "%s %s %s%s\n%s", indexStr, pathStr, detailsStyle.Render(missingText(entry)), tagStr, noteStr)
 			continue
 		}
 
+		if entry.isExpired {
+			pathStr += " " + detailsStyle.Render("(expired)")
+		}
+
+		if entry.stats != nil {
+			pathStr += " " + detailsStyle.Render(formatDirSummary(entry.stats.Files, entry.stats.Size))
+		} else if opts.tree && entry.isDir {
+			files, size := dirSummary(entry.currentPath)
+			pathStr += " " + detailsStyle.Render(formatDirSummary(files, size))
+		}
+
 		if opts.detailed {
 
-			fmt.Fprintf(w, "%s %s %s %s %s %s\n", indexStr, pathStr,
+			fmt.Fprintf(w, "%s %s %s %s %s %s%s\n", indexStr, pathStr,
 				detailsStyle.Render(fmt.Sprintf("%*s", maxSizeWidth, entry.sizeDisplay)),
 				detailsStyle.Render(entry.perms),
 				detailsStyle.Render(entry.modTime.Format("2006-01-02 15:04:05")),
 				detailsStyle.Render(FormatCutAtTime(entry.cutTime)),
+				tagStr,
 			)
-			continue
+		} else {
+			fmt.Fprintf(w, "%s %s%s\n", indexStr, pathStr, tagStr)
 		}
+		fmt.Fprint(w, noteStr)
+
+		if opts.tree && entry.isDir {
+			// line the tree up under the path, past the index column
+			indent := strings.Repeat(" ", maxIndexWidth+1)
+			if err := printTree(w, entry.currentPath, indent, opts.depth); err != nil {
+				fmt.Fprintf(w, "%s└── %s\n", indent, missingPathStyle.Render(err.Error()))
+			}
+		}
+	}
+}
+
+// missingText says that a missing entry's file wasn't found and, if it was
+// recorded, what it was, e.g. "(file not found, was a 2.1 kB file)"
+func missingText(entry listEntry) string {
+	switch {
+	case !entry.recorded:
+		return "(file not found)"
+	case entry.kind() == "file":
+		return fmt.Sprintf("(file not found, was a %s file)", entry.sizeDisplay)
+	default:
+		return fmt.Sprintf("(file not found, was a %s)", entry.kind())
+	}
+}
 
-		fmt.Fprintf(w, "%s %s\n", indexStr, pathStr)
+// formatIdentity describes what was recorded to find the entry's file again
+// if it moves, e.g. "device 2049, inode 131, 14 B, fnv1a:1f0c…, sha256:9e3a…"
+func formatIdentity(entry clipboard.Entry) string {
+	var parts []string
+	if entry.Inode != 0 {
+		parts = append(parts, fmt.Sprintf("device %d, inode %d", entry.Device, entry.Inode))
+	}
+	if entry.Fingerprint != "" || entry.Hash != "" {
+		parts = append(parts, FormatSize(entry.Size))
+	}
+	for _, sum := range []string{entry.Fingerprint, entry.Hash} {
+		if sum == "" {
+			continue
+		}
+		// enough to tell them apart at a glance
+		if algo, digest, ok := strings.Cut(sum, ":"); ok && len(digest) > 12 {
+			sum = algo + ":" + digest[:12] + "…"
+		}
+		parts = append(parts, sum)
+	}
+	return strings.Join(parts, ", ")
+}
 
+// kind returns the type of file the entry refers to
+func (e listEntry) kind() string {
+	switch {
+	case e.isMissing && !e.recorded:
+		return ""
+	case e.isDir:
+		return "directory"
+	case e.isLink:
+		return "symlink"
+	default:
+		return "file"
 	}
 }
 
+// jsonEntry is a list entry as emitted by --format json. Path is the
+// original path of the entry.
 type jsonEntry struct {
-	Path         string    `json:"path"`
-	Symlink      string    `json:"symlink,omitempty"`
-	Size         int64     `json:"size,omitempty"`
-	Permissions  string    `json:"permissions,omitempty"`
-	LastModified time.Time `json:"last_modified,omitzero"`
-	CutAt        time.Time `json:"cut_at,omitzero"`
-	Error        string    `json:"error,omitempty"`
+	Index        int                 `json:"index"`
+	Path         string              `json:"path"`
+	CurrentPath  string              `json:"current_path"`
+	Type         string              `json:"type,omitempty"`
+	Exists       bool                `json:"exists"`
+	Symlink      string              `json:"symlink,omitempty"`
+	Size         int64               `json:"size,omitempty"`
+	Files        int                 `json:"files,omitempty"`
+	TotalSize    int64               `json:"total_size,omitempty"`
+	Permissions  string              `json:"permissions,omitempty"`
+	LastModified time.Time           `json:"last_modified,omitzero"`
+	CutAt        time.Time           `json:"cut_at,omitzero"`
+	Operation    clipboard.Operation `json:"operation,omitempty"`
+	Expired      bool                `json:"expired,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	Note         string              `json:"note,omitempty"`
+	Error        string              `json:"error,omitempty"`
 }
 
 func renderJSON(w io.Writer, entries []listEntry, opts Options) error {
 	jsonEntries := make([]jsonEntry, 0, len(entries))
 
 	for _, entry := range entries {
-		e := jsonEntry{Path: entry.basePath}
+		e := jsonEntry{
+			Index:       entry.index,
+			Path:        entry.basePath,
+			CurrentPath: entry.currentPath,
+			Type:        entry.kind(),
+			Exists:      !entry.isMissing,
+			Expired:     entry.isExpired,
+			Tags:        entry.tags,
+			Note:        entry.note,
+		}
+		if entry.isCopy {
+			e.Operation = clipboard.OpCopy
+		}
 
 		if entry.isMissing {
 			e.Error = "file not found"
+			e.Size = entry.size
+			if opts.detailed && entry.recorded {
+				e.Permissions = entry.perms
+				e.CutAt = entry.cutTime
+			}
 			jsonEntries = append(jsonEntries, e)
 			continue
 		}
@@ -425,10 +1103,15 @@ func renderJSON(w io.Writer, entries []listEntry, opts Options) error {
 			e.Symlink = entry.symlinkTarget
 		}
 
+		e.Size = entry.size
+		e.LastModified = entry.modTime
+		if entry.stats != nil {
+			e.Files = entry.stats.Files
+			e.TotalSize = entry.stats.Size
+		}
+
 		if opts.detailed {
-			e.Size = entry.size
 			e.Permissions = entry.perms
-			e.LastModified = entry.modTime
 			e.CutAt = entry.cutTime
 		}
 		jsonEntries = append(jsonEntries, e)
@@ -444,17 +1127,46 @@ func renderJSON(w io.Writer, entries []listEntry, opts Options) error {
 
 }
 
+// renderTSV writes one tab-separated line per entry with the columns index,
+// original path, current path, type, size, mtime (RFC 3339) and exists, then
+// file count and total size with --sizes. Paths with tabs, newlines or other
+// control characters are quoted, see quotePath.
+func renderTSV(w io.Writer, entries []listEntry, opts Options) {
+	for _, entry := range entries {
+		modTime := ""
+		if !entry.isMissing {
+			modTime = entry.modTime.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%t", entry.index, quotePath(entry.basePath), quotePath(entry.currentPath),
+			entry.kind(), entry.size, modTime, !entry.isMissing)
+		if opts.sizes {
+			var stats treeStats
+			if entry.stats != nil {
+				stats = *entry.stats
+			}
+			fmt.Fprintf(w, "\t%d\t%d", stats.Files, stats.Size)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
 // handleList displays all clipboard entries with proper column alignment
 func handleList(w io.Writer, opts Options) error {
 	// todo: use relative paths
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		return err
 	}
 
-	numEntries := len(clipboard.Entries)
+	numEntries := len(board.Entries)
 	if numEntries == 0 {
-		fmt.Fprintln(w, "Clipboard is empty")
+		switch opts.format {
+		case formatJSON:
+			fmt.Fprintln(w, "[]")
+		case formatTSV:
+		default:
+			fmt.Fprintln(w, "Clipboard is empty")
+		}
 		return nil
 	}
 
@@ -465,19 +1177,44 @@ func handleList(w io.Writer, opts Options) error {
 	maxPathWidth := 0
 	maxSizeWidth := 0
 
-	for i, entry := range clipboard.Entries {
+	// without them, paths are shown in full
+	cwd, _ := os.Getwd()
+	home, _ := os.UserHomeDir()
+
+	now := time.Now()
+	for i, entry := range board.Entries {
+		if opts.tag != "" && !entry.HasTag(opts.tag) {
+			continue
+		}
+
 		var e listEntry
 		e.index = i
+		e.tags = entry.Tags
+		e.note = entry.Note
+		e.isExpired = entry.Expired(expireAfter, now)
 		e.basePath = entry.OriginalPath
+		e.displayPath = quotePath(displayPath(entry.OriginalPath, opts.pathMode, cwd, home))
+		e.currentPath = entry.CurrentPath
 		e.cutTime = entry.CutAt
+		e.isCopy = entry.IsCopy()
+		e.identity = formatIdentity(entry)
 
-		fileInfo, err := os.Lstat(entry.OriginalPath)
+		fileInfo, err := os.Lstat(entry.CurrentPath)
 		if err != nil {
-			e.isMissing = true
-			entries = append(entries, e)
-			if len(e.basePath) > maxPathWidth {
-				maxPathWidth = len(e.basePath)
+			// remote entries aren't checked, to keep listing quick
+			e.isMissing = !isRemoteEntry(entry)
+			// what was recorded at cut time still says what it is, say
+			// while a network share is unmounted
+			if e.isMissing && entry.Kind != "" {
+				e.recorded = true
+				e.isDir = entry.Kind == clipboard.KindDir
+				e.isLink = entry.Kind == clipboard.KindSymlink
+				e.size = entry.Size
+				e.sizeDisplay = FormatSize(entry.Size)
+				e.perms = entry.Mode.String()
 			}
+			entries = append(entries, e)
+			maxPathWidth = max(maxPathWidth, lipgloss.Width(e.displayPath))
 			continue
 		}
 
@@ -487,56 +1224,296 @@ func handleList(w io.Writer, opts Options) error {
 		e.modTime = fileInfo.ModTime()
 		e.isDir = fileInfo.IsDir()
 		e.isLink = fileInfo.Mode()&os.ModeSymlink != 0
-		displayPathWidth := len(e.basePath)
 
 		if e.isLink {
-			if target, err := os.Readlink(entry.OriginalPath); err == nil {
-				displayPathWidth = len(fmt.Sprintf("%s -> %s", e.basePath, target))
-				e.symlinkTarget = target
+			if target, err := os.Readlink(entry.CurrentPath); err == nil {
+				e.symlinkTarget = quotePath(target)
 			} else {
-				displayPathWidth = len(fmt.Sprintf("%s -> (broken)", e.basePath))
 				e.symlinkTarget = "(broken)"
 			}
-
 		}
 
 		entries = append(entries, e)
-
-		if displayPathWidth > maxPathWidth {
-			maxPathWidth = displayPathWidth
-		}
+		maxPathWidth = max(maxPathWidth, lipgloss.Width(pathText(e)))
 
 		if len(e.sizeDisplay) > maxSizeWidth {
 			maxSizeWidth = len(e.sizeDisplay)
 		}
 	}
 
-	if opts.json {
+	if len(entries) == 0 {
+		switch opts.format {
+		case formatJSON:
+			fmt.Fprintln(w, "[]")
+		case formatTSV:
+		default:
+			fmt.Fprintf(w, "No entries tagged %s\n", opts.tag)
+		}
+		return nil
+	}
+
+	var total treeStats
+	if opts.sizes {
+		stats := measureEntries(board.Entries)
+		for i := range entries {
+			if s, ok := stats[entries[i].currentPath]; ok {
+				entries[i].stats = &s
+				total.Files += s.Files
+				total.Size += s.Size
+			}
+		}
+	}
+
+	if opts.sortBy != "" || opts.reverse {
+		sortEntries(entries, opts.sortBy, opts.reverse)
+	}
+
+	switch opts.format {
+	case formatJSON:
 		return renderJSON(w, entries, opts)
+	case formatTSV:
+		renderTSV(w, entries, opts)
+		return nil
 	}
 
-	renderTable(w, entries, opts, maxPathWidth, maxSizeWidth, maxIndexWidth)
+	if plainOutput {
+		maxPathWidth, maxSizeWidth, maxIndexWidth = 0, 0, 0
+	}
+	if opts.columns != nil {
+		renderColumns(w, entries, opts.columns, terminalWidth())
+	} else {
+		renderTable(w, entries, opts, maxPathWidth, maxSizeWidth, maxIndexWidth)
+	}
+	if opts.sizes {
+		fmt.Fprintln(w, detailsStyle.Render(formatTotals(len(entries), total)))
+	}
 	return nil
 }
 
 // handleClear clears all clipboard entries
 func handleClear(w io.Writer, opts Options) error {
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if confirming() && len(board.Entries) > confirmClearOver {
+		ok, err := confirmAction(fmt.Sprintf("Clear %d clipboard entries?", len(board.Entries)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(w, "Clipboard left as it was")
+			return nil
+		}
+	}
+
+	// only what was read is cleared, so an entry added meanwhile survives
+	cleared := board.Entries
+	if err := engine().RemoveEntries(cleared...); err != nil {
+		return err
+	}
+
+	records := make([]HistoryRecord, 0, len(cleared))
+	for _, entry := range cleared {
+		records = append(records, newHistoryRecord("clear", entry.CurrentPath, "", nil))
+	}
+	if err := appendHistory(records...); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "Clipboard cleared")
+	return nil
+}
+
+// parseEntrySelectors resolves drop arguments to clipboard indices. Each
+// argument is an index, an inclusive range such as 1-3, or the path of an
+// entry.
+func parseEntrySelectors(args []string, entries []clipboard.Entry) ([]int, error) {
+	var indices []int
+	seen := make(map[int]bool)
+	add := func(index int) error {
+		if index < 0 || index >= len(entries) {
+			return fmt.Errorf("invalid clipboard index: %d", index)
+		}
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+		return nil
+	}
+
+	for _, arg := range args {
+		if index, err := strconv.Atoi(arg); err == nil {
+			if err := add(index); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if from, to, ok := strings.Cut(arg, "-"); ok {
+			start, startErr := strconv.Atoi(from)
+			end, endErr := strconv.Atoi(to)
+			if startErr == nil && endErr == nil {
+				if start > end {
+					return nil, fmt.Errorf("invalid range: %s", arg)
+				}
+				for index := start; index <= end; index++ {
+					if err := add(index); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+		}
+
+		absPath, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for i, entry := range entries {
+			if entry.OriginalPath == absPath || entry.CurrentPath == absPath {
+				found = true
+				add(i)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no clipboard entry for %s", absPath)
+		}
+	}
+
+	return indices, nil
+}
+
+// handleDrop removes the selected entries from the clipboard without
+// touching the files they refer to
+func handleDrop(w io.Writer, args []string, opts Options) error {
+	board, err := readClipboard()
 	if err != nil {
 		return err
 	}
 
-	clipboard.Entries = []Entry{}
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
 
-	err = writeClipboard(clipboard)
+	indices, err := parseEntrySelectors(args, board.Entries)
 	if err != nil {
 		return err
 	}
 
+	dropped := make([]clipboard.Entry, 0, len(indices))
+	for _, index := range indices {
+		dropped = append(dropped, board.Entries[index])
+	}
+	if err := engine().RemoveEntries(dropped...); err != nil {
+		return err
+	}
+
 	if opts.quiet {
 		w = io.Discard
 	}
 
-	fmt.Fprintln(w, "Clipboard cleared")
+	for _, entry := range dropped {
+		fmt.Fprintf(w, "Dropped: %s\n", entry.CurrentPath)
+	}
+	return nil
+}
+
+// handleReorder moves the entry at index from to index to, so that moving
+// it to 0 makes it the one a bare paste picks
+func handleReorder(w io.Writer, from, to int, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	if err := engine().Reorder(from, to); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	fmt.Fprintf(w, "Moved entry %d to %d: %s\n", from, to, board.Entries[from].CurrentPath)
+	return nil
+}
+
+// handleNote sets the note on the entry at index, clears it with opts.clear,
+// or with neither shows it
+func handleNote(w io.Writer, index int, note string, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	if index < 0 || index >= len(board.Entries) {
+		return fmt.Errorf("invalid clipboard index: %d", index)
+	}
+	entry := board.Entries[index]
+
+	if note == "" && !opts.clear {
+		if entry.Note == "" {
+			fmt.Fprintf(w, "No note on %d: %s\n", index, entry.CurrentPath)
+		} else {
+			fmt.Fprintln(w, entry.Note)
+		}
+		return nil
+	}
+
+	if err := engine().SetNote(index, note); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if opts.clear {
+		fmt.Fprintf(w, "Cleared note on %d: %s\n", index, entry.CurrentPath)
+	} else {
+		fmt.Fprintf(w, "Noted %d: %s\n", index, entry.CurrentPath)
+	}
+	return nil
+}
+
+// handleRotate cycles the clipboard n places, so the entry at index n
+// becomes the most recent
+func handleRotate(w io.Writer, n int, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	if err := engine().Rotate(n); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	count := len(board.Entries)
+	top := board.Entries[((n%count)+count)%count]
+	fmt.Fprintf(w, "Rotated clipboard, now on top: %s\n", top.CurrentPath)
 	return nil
 }
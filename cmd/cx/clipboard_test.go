@@ -1,12 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pkitazos/cx/pkg/clipboard"
 )
 
 // setupTestEnvironment creates a temporary test directory with test files and sets up clipboard path
@@ -69,16 +75,16 @@ func TestCutFile(t *testing.T) {
 	}
 
 	// Verify clipboard contains the file
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		t.Fatalf("Failed to read clipboard: %v", err)
 	}
 
-	if len(clipboard.Entries) != 1 {
-		t.Fatalf("Expected 1 clipboard entry, got %d", len(clipboard.Entries))
+	if len(board.Entries) != 1 {
+		t.Fatalf("Expected 1 clipboard entry, got %d", len(board.Entries))
 	}
 
-	entry := clipboard.Entries[0]
+	entry := board.Entries[0]
 	if entry.OriginalPath != testFile {
 		t.Errorf("Expected OriginalPath %s, got %s", testFile, entry.OriginalPath)
 	}
@@ -87,6 +93,38 @@ func TestCutFile(t *testing.T) {
 	}
 }
 
+func TestSQLiteStoreKind(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	storeKind = "sqlite"
+	defer func() { storeKind = "file" }()
+
+	testFile := filepath.Join(tempDir, "file1.txt")
+	err := cutFile(io.Discard, testFile, Options{})
+	if errors.Is(err, clipboard.ErrNoSQLite) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != testFile {
+		t.Fatalf("Expected %s in the clipboard, got %+v", testFile, board.Entries)
+	}
+
+	if _, err := os.Stat(sqlitePath()); err != nil {
+		t.Errorf("Expected the clipboard database at %s: %v", sqlitePath(), err)
+	}
+	if _, err := os.Stat(clipboardPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no clipboard file with the sqlite store, got: %v", err)
+	}
+}
+
 func TestCutNonexistentFile(t *testing.T) {
 	tempDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -99,6 +137,33 @@ func TestCutNonexistentFile(t *testing.T) {
 	}
 }
 
+func TestCutDuplicate(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	testFile := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, testFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	err := cutFile(io.Discard, testFile, Options{})
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Expected cutting the same path twice to fail, got: %v", err)
+	}
+
+	if err := cutFile(io.Discard, testFile, Options{force: true}); err != nil {
+		t.Fatalf("cutFile with force failed: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 {
+		t.Errorf("Expected 1 clipboard entry, got %d", len(board.Entries))
+	}
+}
+
 func TestCutDirectory(t *testing.T) {
 	tempDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -111,16 +176,16 @@ func TestCutDirectory(t *testing.T) {
 	}
 
 	// Verify clipboard contains the directory
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		t.Fatalf("Failed to read clipboard: %v", err)
 	}
 
-	if len(clipboard.Entries) != 1 {
-		t.Fatalf("Expected 1 clipboard entry, got %d", len(clipboard.Entries))
+	if len(board.Entries) != 1 {
+		t.Fatalf("Expected 1 clipboard entry, got %d", len(board.Entries))
 	}
 
-	entry := clipboard.Entries[0]
+	entry := board.Entries[0]
 	if entry.OriginalPath != testDir {
 		t.Errorf("Expected OriginalPath %s, got %s", testDir, entry.OriginalPath)
 	}
@@ -144,21 +209,21 @@ func TestMultipleCuts(t *testing.T) {
 	}
 
 	// Verify clipboard contains both files
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		t.Fatalf("Failed to read clipboard: %v", err)
 	}
 
-	if len(clipboard.Entries) != 2 {
-		t.Fatalf("Expected 2 clipboard entries, got %d", len(clipboard.Entries))
+	if len(board.Entries) != 2 {
+		t.Fatalf("Expected 2 clipboard entries, got %d", len(board.Entries))
 	}
 
-	// Verify files are in clipboard in correct order (most recent last)
-	if clipboard.Entries[0].OriginalPath != files[0] {
-		t.Errorf("Expected first entry to be %s, got %s", files[0], clipboard.Entries[0].OriginalPath)
+	// Verify files are in clipboard in correct order (most recent first)
+	if board.Entries[0].OriginalPath != files[1] {
+		t.Errorf("Expected first entry to be %s, got %s", files[1], board.Entries[0].OriginalPath)
 	}
-	if clipboard.Entries[1].OriginalPath != files[1] {
-		t.Errorf("Expected second entry to be %s, got %s", files[1], clipboard.Entries[1].OriginalPath)
+	if board.Entries[1].OriginalPath != files[0] {
+		t.Errorf("Expected second entry to be %s, got %s", files[0], board.Entries[1].OriginalPath)
 	}
 }
 
@@ -207,13 +272,13 @@ func TestPasteMove(t *testing.T) {
 	}
 
 	// Verify clipboard is empty after non-persistent paste
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		t.Fatalf("Failed to read clipboard: %v", err)
 	}
 
-	if len(clipboard.Entries) != 0 {
-		t.Errorf("Expected empty clipboard after move, got %d entries", len(clipboard.Entries))
+	if len(board.Entries) != 0 {
+		t.Errorf("Expected empty clipboard after move, got %d entries", len(board.Entries))
 	}
 }
 
@@ -279,13 +344,13 @@ func TestPasteCopy(t *testing.T) {
 	}
 
 	// Verify clipboard still has entry after persistent paste
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		t.Fatalf("Failed to read clipboard: %v", err)
 	}
 
-	if len(clipboard.Entries) != 1 {
-		t.Errorf("Expected 1 clipboard entry after copy, got %d", len(clipboard.Entries))
+	if len(board.Entries) != 1 {
+		t.Errorf("Expected 1 clipboard entry after copy, got %d", len(board.Entries))
 	}
 }
 
@@ -380,6 +445,72 @@ func TestPasteNonexistentFile(t *testing.T) {
 	}
 }
 
+func TestPasteMovedFile(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	// something else moves the file after it was cut
+	movedFile := filepath.Join(tempDir, "nested", "renamed.txt")
+	if err := os.Rename(sourceFile, movedFile); err != nil {
+		t.Fatalf("Failed to move source file: %v", err)
+	}
+
+	originalStdin := stdin
+	defer func() { stdin = originalStdin }()
+
+	// a dry run says where it went, without asking to update the entry
+	for _, paste := range []func(io.Writer, Options) error{
+		func(w io.Writer, opts Options) error { return handlePasteAt(w, 0, opts) },
+		handlePasteAll,
+	} {
+		stdin = strings.NewReader("y\n")
+		var out bytes.Buffer
+		if err := paste(&out, Options{dest: filepath.Join(tempDir, "empty_dir"), dryRun: true}); err != nil {
+			t.Fatalf("Dry run failed: %v", err)
+		}
+		if !strings.Contains(out.String(), "may have moved to "+movedFile) {
+			t.Errorf("Expected the dry run to report the new location, got:\n%s", out.String())
+		}
+		if rest, _ := io.ReadAll(stdin); string(rest) != "y\n" {
+			t.Error("Expected the dry run not to prompt")
+		}
+		board, err := readClipboard()
+		if err != nil {
+			t.Fatalf("Failed to read clipboard: %v", err)
+		}
+		if len(board.Entries) != 1 || board.Entries[0].CurrentPath != sourceFile {
+			t.Errorf("Expected the dry run to leave the entry alone, got %+v", board.Entries)
+		}
+	}
+
+	stdin = strings.NewReader("n\n")
+	err := handlePasteAt(io.Discard, 0, Options{dest: filepath.Join(tempDir, "empty_dir")})
+	if err == nil || !strings.Contains(err.Error(), "may have moved to "+movedFile) {
+		t.Errorf("Expected the new location to be suggested, got %v", err)
+	}
+
+	// --yes never accepts a new location on the user's behalf
+	assumeYes = true
+	err = handlePasteAt(io.Discard, 0, Options{dest: filepath.Join(tempDir, "empty_dir")})
+	assumeYes = false
+	if err == nil || !strings.Contains(err.Error(), "without --yes") {
+		t.Errorf("Expected --yes to refuse the new location, got %v", err)
+	}
+
+	stdin = strings.NewReader("y\n")
+	if err := handlePasteAt(io.Discard, 0, Options{dest: filepath.Join(tempDir, "empty_dir")}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "empty_dir", "renamed.txt")); err != nil {
+		t.Errorf("Expected the moved file to be pasted: %v", err)
+	}
+}
+
 func TestHandleList(t *testing.T) {
 	tempDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -410,6 +541,143 @@ func TestHandleList(t *testing.T) {
 	}
 }
 
+func TestHandleListTree(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "config"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handleList(&buf, Options{tree: true, depth: 2}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 2 entries and 2 tree lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[1], "config") || !strings.Contains(lines[1], "(2 files, 29 B)") {
+		t.Errorf("Expected the directory with its file count and size, got %q", lines[1])
+	}
+	if lines[2] != "   ├── config.ini 9 B" || lines[3] != "   └── settings.json 20 B" {
+		t.Errorf("Expected the directory's contents indented under it, got %q", lines[2:])
+	}
+}
+
+func TestHandleReorder(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "file2.txt"),
+		filepath.Join(tempDir, "nested"),
+	}
+	for _, file := range files {
+		if err := cutFile(io.Discard, file, Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := handleReorder(&buf, 2, 0, Options{}); err != nil {
+		t.Fatalf("handleReorder failed: %v", err)
+	}
+	if buf.String() != "Moved entry 2 to 0: "+files[0]+"\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if board.Entries[0].CurrentPath != files[0] || board.Entries[1].CurrentPath != files[2] {
+		t.Errorf("Expected file1.txt on top followed by nested, got %+v", board.Entries)
+	}
+
+	if err := handleReorder(io.Discard, 0, 3, Options{}); err == nil {
+		t.Error("Expected error for an invalid index, got nil")
+	}
+}
+
+func TestHandleRotate(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "file2.txt"),
+		filepath.Join(tempDir, "nested"),
+	}
+	for _, file := range files {
+		if err := cutFile(io.Discard, file, Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := handleRotate(&buf, 1, Options{}); err != nil {
+		t.Fatalf("handleRotate failed: %v", err)
+	}
+	if buf.String() != "Rotated clipboard, now on top: "+files[1]+"\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if board.Entries[0].CurrentPath != files[1] || board.Entries[2].CurrentPath != files[2] {
+		t.Errorf("Expected file2.txt on top and nested at the bottom, got %+v", board.Entries)
+	}
+}
+
+func TestHandlePop(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	if err := copyFileToClipboard(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file2.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	// A popped cut is moved and removed, like a normal paste
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir, pop: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file2.txt")); err != nil {
+		t.Errorf("Expected file2.txt to be pasted: %v", err)
+	}
+
+	// A popped copy is removed too, but its source stays put
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir, pop: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	if _, err := os.Stat(sourceFile); err != nil {
+		t.Errorf("Expected copied source to remain: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); err != nil {
+		t.Errorf("Expected file1.txt to be pasted: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 0 {
+		t.Errorf("Expected empty clipboard after popping every entry, got %d entries", len(board.Entries))
+	}
+}
+
 func TestHandleClear(t *testing.T) {
 	tempDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -422,12 +690,12 @@ func TestHandleClear(t *testing.T) {
 	}
 
 	// Verify clipboard has entry
-	clipboard, err := readClipboard()
+	board, err := readClipboard()
 	if err != nil {
 		t.Fatalf("Failed to read clipboard: %v", err)
 	}
-	if len(clipboard.Entries) != 1 {
-		t.Fatalf("Expected 1 clipboard entry, got %d", len(clipboard.Entries))
+	if len(board.Entries) != 1 {
+		t.Fatalf("Expected 1 clipboard entry, got %d", len(board.Entries))
 	}
 
 	// Clear clipboard
@@ -437,12 +705,12 @@ func TestHandleClear(t *testing.T) {
 	}
 
 	// Verify clipboard is empty
-	clipboard, err = readClipboard()
+	board, err = readClipboard()
 	if err != nil {
 		t.Fatalf("Failed to read clipboard: %v", err)
 	}
-	if len(clipboard.Entries) != 0 {
-		t.Errorf("Expected empty clipboard after clear, got %d entries", len(clipboard.Entries))
+	if len(board.Entries) != 0 {
+		t.Errorf("Expected empty clipboard after clear, got %d entries", len(board.Entries))
 	}
 }
 
@@ -464,17 +732,746 @@ func TestClipboardPersistence(t *testing.T) {
 		t.Fatalf("Failed to read clipboard file: %v", err)
 	}
 
-	var clipboard Clipboard
-	err = json.Unmarshal(clipboardData, &clipboard)
+	var board clipboard.Clipboard
+	err = json.Unmarshal(clipboardData, &board)
 	if err != nil {
 		t.Fatalf("Failed to unmarshal clipboard JSON: %v", err)
 	}
 
-	if len(clipboard.Entries) != 1 {
-		t.Errorf("Expected 1 entry in persisted clipboard, got %d", len(clipboard.Entries))
+	if len(board.Entries) != 1 {
+		t.Errorf("Expected 1 entry in persisted clipboard, got %d", len(board.Entries))
+	}
+
+	if board.Entries[0].OriginalPath != sourceFile {
+		t.Errorf("Expected persisted entry path %s, got %s", sourceFile, board.Entries[0].OriginalPath)
+	}
+}
+
+func TestCopyEntryPaste(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	destDir := filepath.Join(tempDir, "destination")
+
+	err := os.MkdirAll(destDir, 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	// Copy the file to the clipboard
+	err = copyFileToClipboard(io.Discard, sourceFile, Options{})
+	if err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(destDir)
+	if err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	// Paste without --persist; a copied entry should still be duplicated
+	err = handlePasteAt(io.Discard, 0, Options{})
+	if err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); os.IsNotExist(err) {
+		t.Errorf("File was not copied to destination")
+	}
+
+	if _, err := os.Stat(sourceFile); os.IsNotExist(err) {
+		t.Errorf("Source file was removed after pasting a copied entry: %s", sourceFile)
+	}
+
+	// Verify entry is kept and still points at the original source
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+
+	if len(board.Entries) != 1 {
+		t.Fatalf("Expected 1 clipboard entry after paste, got %d", len(board.Entries))
+	}
+
+	entry := board.Entries[0]
+	if entry.Op != clipboard.OpCopy {
+		t.Errorf("Expected operation %q, got %q", clipboard.OpCopy, entry.Op)
+	}
+	if entry.CurrentPath != sourceFile {
+		t.Errorf("Expected CurrentPath %s, got %s", sourceFile, entry.CurrentPath)
+	}
+}
+
+func TestPasteAtIndex(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "file2.txt"),
+	}
+
+	for _, file := range files {
+		if err := cutFile(io.Discard, file, Options{}); err != nil {
+			t.Fatalf("cutFile failed for %s: %v", file, err)
+		}
+	}
+
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(destDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	// Index 1 is the older entry, file1.txt
+	if err := handlePasteAt(io.Discard, 1, Options{}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); os.IsNotExist(err) {
+		t.Errorf("Entry at index 1 was not pasted")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file2.txt")); !os.IsNotExist(err) {
+		t.Errorf("Entry at index 0 was pasted unexpectedly")
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+
+	if len(board.Entries) != 1 || board.Entries[0].OriginalPath != files[1] {
+		t.Errorf("Expected only %s to remain in clipboard, got %+v", files[1], board.Entries)
 	}
 
-	if clipboard.Entries[0].OriginalPath != sourceFile {
-		t.Errorf("Expected persisted entry path %s, got %s", sourceFile, clipboard.Entries[0].OriginalPath)
+	// Out of range index
+	err = handlePasteAt(io.Discard, 5, Options{})
+	if err == nil || !strings.Contains(err.Error(), "invalid clipboard index") {
+		t.Errorf("Expected 'invalid clipboard index' error, got: %v", err)
+	}
+}
+
+func TestPasteAll(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "file2.txt"),
+		filepath.Join(tempDir, "nested", "file3.txt"),
+	}
+
+	for _, file := range files {
+		if err := cutFile(io.Discard, file, Options{}); err != nil {
+			t.Fatalf("cutFile failed for %s: %v", file, err)
+		}
+	}
+
+	// Remove one source so that its paste fails
+	if err := os.Remove(files[2]); err != nil {
+		t.Fatalf("Failed to remove source file: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(destDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	err := handlePasteAll(io.Discard, Options{})
+	if err == nil {
+		t.Fatal("Expected error for missing source, got nil")
+	}
+	if !strings.Contains(err.Error(), "failed to paste 1 of 3 entries") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); os.IsNotExist(err) {
+			t.Errorf("%s was not pasted", name)
+		}
+	}
+
+	// Only the failed entry should remain
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+
+	if len(board.Entries) != 1 || board.Entries[0].OriginalPath != files[2] {
+		t.Errorf("Expected only %s to remain in clipboard, got %+v", files[2], board.Entries)
+	}
+}
+
+func TestPasteToDestination(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "new", "destination")
+
+	// Missing destination is refused without --mkdir
+	err := handlePasteAt(io.Discard, 0, Options{dest: destDir})
+	if err == nil || !strings.Contains(err.Error(), "destination does not exist") {
+		t.Fatalf("Expected 'destination does not exist' error, got: %v", err)
+	}
+
+	// A file is not a valid destination
+	err = handlePasteAt(io.Discard, 0, Options{dest: filepath.Join(tempDir, "file2.txt")})
+	if err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Fatalf("Expected 'not a directory' error, got: %v", err)
+	}
+
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir, mkdir: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); os.IsNotExist(err) {
+		t.Errorf("File was not moved to %s", destDir)
+	}
+}
+
+func TestPasteAs(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "config", "settings.json")
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	if err := handlePasteAt(io.Discard, 0, Options{dest: tempDir, as: "settings.bak.*"}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "settings.bak.json")); os.IsNotExist(err) {
+		t.Errorf("File was not pasted under its new name")
+	}
+}
+
+func TestPastePreserveRoot(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, path := range []string{filepath.Join("nested", "file3.txt"), filepath.Join("config", "settings.json")} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, path), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := handlePasteAll(io.Discard, Options{dest: destDir, preserveRoot: tempDir}); err != nil {
+		t.Fatalf("handlePasteAll with preserveRoot failed: %v", err)
+	}
+
+	for _, path := range []string{filepath.Join("nested", "file3.txt"), filepath.Join("config", "settings.json")} {
+		if _, err := os.Stat(filepath.Join(destDir, path)); err != nil {
+			t.Errorf("Expected %s to be pasted under its directory: %v", path, err)
+		}
+	}
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	err := handlePasteAt(io.Discard, 0, Options{dest: destDir, preserveRoot: filepath.Join(tempDir, "nested")})
+	if err == nil || !strings.Contains(err.Error(), "is not under") {
+		t.Errorf("Expected an error for an entry outside the root, got %v", err)
+	}
+}
+
+func TestPasteIntoItself(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	source := filepath.Join(tempDir, "nested")
+	destDir := filepath.Join(source, "sub")
+	if err := os.Mkdir(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := cutFile(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	for _, opts := range []Options{{dest: destDir}, {dest: destDir, persist: true}, {dest: source}} {
+		err := handlePasteAt(io.Discard, 0, opts)
+		if err == nil || !strings.Contains(err.Error(), "into itself") {
+			t.Errorf("Expected pasting into %s to be refused, got %v", opts.dest, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "nested")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be pasted inside the source")
+	}
+	if board, err := readClipboard(); err != nil || len(board.Entries) != 1 {
+		t.Errorf("Expected the entry to stay on the clipboard, got %v (%v)", board.Entries, err)
+	}
+}
+
+func TestPasteWhereItIs(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	source := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handlePasteAt(&out, 0, Options{dest: tempDir, onConflict: clipboard.ConflictOverwrite}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Already there") {
+		t.Errorf("Expected the move to be reported as a no-op, got %q", out.String())
+	}
+	if _, err := os.Stat(source); err != nil {
+		t.Errorf("Expected the file to be left alone: %v", err)
+	}
+	if board, err := readClipboard(); err != nil || len(board.Entries) != 0 {
+		t.Errorf("Expected the entry to be done with, got %v (%v)", board.Entries, err)
+	}
+
+	// a copy goes beside the original
+	if err := copyFileToClipboard(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := handlePasteAll(io.Discard, Options{dest: tempDir}); err != nil {
+		t.Fatalf("handlePasteAll failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "file1 (1).txt")); err != nil {
+		t.Errorf("Expected the copy to be renamed: %v", err)
+	}
+}
+
+func TestHandleDrop(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "file2.txt"),
+		filepath.Join(tempDir, "nested", "file3.txt"),
+		filepath.Join(tempDir, "config"),
+		filepath.Join(tempDir, "empty_dir"),
+	}
+
+	for _, file := range files {
+		if err := cutFile(io.Discard, file, Options{}); err != nil {
+			t.Fatalf("cutFile failed for %s: %v", file, err)
+		}
+	}
+
+	// Entries are most recent first: empty_dir, config, file3, file2, file1
+	if err := handleDrop(io.Discard, []string{"1-2", files[0]}, Options{}); err != nil {
+		t.Fatalf("handleDrop failed: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+
+	if len(board.Entries) != 2 {
+		t.Fatalf("Expected 2 entries after drop, got %d", len(board.Entries))
+	}
+	if board.Entries[0].OriginalPath != files[4] || board.Entries[1].OriginalPath != files[1] {
+		t.Errorf("Unexpected entries after drop: %+v", board.Entries)
+	}
+
+	// Files themselves are untouched
+	if _, err := os.Stat(files[0]); os.IsNotExist(err) {
+		t.Errorf("Dropping an entry removed its file")
+	}
+
+	for _, args := range [][]string{{"7"}, {"3-1"}, {filepath.Join(tempDir, "nope")}} {
+		if err := handleDrop(io.Discard, args, Options{}); err == nil {
+			t.Errorf("Expected error dropping %v, got nil", args)
+		}
+	}
+}
+
+func TestListVerboseIdentity(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handleList(&out, Options{}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if strings.Contains(out.String(), "id: ") {
+		t.Errorf("Expected no identity without --verbose, got %q", out.String())
+	}
+
+	verbose = true
+	defer func() { verbose = false }()
+	out.Reset()
+	if err := handleList(&out, Options{}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	for _, expected := range []string{"id: device ", "inode ", "14 B", "xxh64:", "sha256:"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in %q", expected, out.String())
+		}
+	}
+}
+
+func TestListRecordedKind(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, name := range []string{"file1.txt", "nested"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+	// as if they were on a share that's since been unmounted
+	if err := os.RemoveAll(filepath.Join(tempDir, "nested")); err != nil {
+		t.Fatalf("Failed to remove directory: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tempDir, "file1.txt")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handleList(&out, Options{}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	for _, expected := range []string{"(file not found, was a directory)", "(file not found, was a 14 B file)"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in %q", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := handleList(&out, Options{columns: []string{"index", "type", "size"}}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	for _, expected := range []string{"missing directory", "missing file", "14 B"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in %q", expected, out.String())
+		}
+	}
+}
+
+func TestListQuotesControlCharacters(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	weird := filepath.Join(tempDir, "two\nlines\x1b[31m\xff")
+	// a long path, a few hundred characters deep
+	deep := filepath.Join(tempDir, strings.Repeat("very-long-directory-name/", 40))
+	if err := os.MkdirAll(deep, 0o755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	for _, path := range []string{weird, filepath.Join(deep, "file.txt")} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := cutFile(io.Discard, path, Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	for _, format := range []listFormat{formatPlain, formatTSV} {
+		var out bytes.Buffer
+		if err := handleList(&out, Options{format: format}); err != nil {
+			t.Fatalf("handleList failed: %v", err)
+		}
+		if lines := strings.Count(out.String(), "\n"); lines != 2 {
+			t.Errorf("Expected one line per entry in %s output, got %d: %q", format, lines, out.String())
+		}
+		if !strings.Contains(out.String(), strconv.Quote(weird)) || strings.ContainsAny(out.String(), "\x1b\xff") {
+			t.Errorf("Expected the name quoted with its control characters escaped in %s output, got %q", format, out.String())
+		}
+	}
+}
+
+func TestRenderPathWidth(t *testing.T) {
+	for _, path := range []string{"/photos/b.jpg", "/写真/a.jpg", "/café/c.txt"} {
+		got := renderPath(listEntry{displayPath: path}, 16)
+		if width := lipgloss.Width(got); width != 16 {
+			t.Errorf("Expected %q padded to 16 cells, got %d: %q", path, width, got)
+		}
+	}
+}
+
+func TestListFormats(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "config"),
+		filepath.Join(tempDir, "file2.txt"),
+	}
+	for _, file := range files {
+		if err := cutFile(io.Discard, file, Options{}); err != nil {
+			t.Fatalf("cutFile failed for %s: %v", file, err)
+		}
+	}
+	if err := os.Remove(files[2]); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handleList(&out, Options{format: formatJSON}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+
+	var entries []jsonEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\n%s", err, out.String())
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 JSON entries, got %d", len(entries))
+	}
+
+	want := []struct {
+		typ    string
+		exists bool
+		size   int64
+	}{
+		{"file", false, 14}, // as recorded when it was cut
+		{"directory", true, 0},
+		{"file", true, 14},
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Index != i || e.CurrentPath != files[2-i] || e.Type != w.typ || e.Exists != w.exists {
+			t.Errorf("Unexpected JSON entry %d: %+v", i, e)
+		}
+		if w.typ == "file" && e.Size != w.size {
+			t.Errorf("Expected size %d, got %d", w.size, e.Size)
+		}
+	}
+
+	out.Reset()
+	if err := handleList(&out, Options{format: formatTSV}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 TSV lines, got %d:\n%s", len(lines), out.String())
+	}
+	fields := strings.Split(lines[2], "\t")
+	if len(fields) != 7 || fields[0] != "2" || fields[2] != files[0] || fields[3] != "file" || fields[4] != "14" || fields[6] != "true" {
+		t.Errorf("Unexpected TSV line: %q", lines[2])
+	}
+	if !strings.HasSuffix(lines[0], "\tfalse") {
+		t.Errorf("Expected missing entry to be reported, got %q", lines[0])
+	}
+}
+
+func TestReadPathList(t *testing.T) {
+	tests := map[string][]string{
+		"a.txt\nb dir/c.txt\n":        {"a.txt", "b dir/c.txt"},
+		"a.txt\r\nb.txt":              {"a.txt", "b.txt"},
+		"a\nnewline.txt\x00b.txt\x00": {"a\nnewline.txt", "b.txt"},
+	}
+
+	for input, want := range tests {
+		got, err := readPathList(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("readPathList(%q) failed: %v", input, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("readPathList(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if _, err := readPathList(strings.NewReader("\n\n")); err == nil {
+		t.Error("Expected error for empty input, got nil")
+	}
+}
+
+func TestAddEntries(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "file2.txt"),
+	}
+
+	// An invalid path means nothing is added
+	err := addEntries(io.Discard, append(files, filepath.Join(tempDir, "nope")), clipboard.OpCut, Options{})
+	if err == nil {
+		t.Fatal("Expected error for missing path, got nil")
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 0 {
+		t.Fatalf("Expected no entries after failed batch, got %d", len(board.Entries))
+	}
+
+	if err := addEntries(io.Discard, files, clipboard.OpCut, Options{}); err != nil {
+		t.Fatalf("addEntries failed: %v", err)
+	}
+
+	board, err = readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+
+	// Same order as cutting each file in turn: the last one is on top
+	if len(board.Entries) != 2 || board.Entries[0].OriginalPath != files[1] || board.Entries[1].OriginalPath != files[0] {
+		t.Errorf("Unexpected entries: %+v", board.Entries)
+	}
+}
+
+func TestHandlePasteHardlink(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	source := filepath.Join(tempDir, "file1.txt")
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := copyFileToClipboard(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir, hardlink: true}); err != nil {
+		t.Fatalf("handlePasteAt with hardlink failed: %v", err)
+	}
+
+	srcInfo, _ := os.Stat(source)
+	dstInfo, err := os.Stat(filepath.Join(destDir, "file1.txt"))
+	if err != nil {
+		t.Fatalf("Expected the file to be pasted: %v", err)
+	}
+	if !os.SameFile(srcInfo, dstInfo) {
+		t.Error("Expected the paste to be a hard link to the source")
+	}
+}
+
+func TestHandleNote(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handleNote(&buf, 0, "reviewed, move to archive", Options{}); err != nil {
+		t.Fatalf("handleNote failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Noted 0: ") {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := handleNote(&buf, 0, "", Options{}); err != nil {
+		t.Fatalf("handleNote failed: %v", err)
+	}
+	if buf.String() != "reviewed, move to archive\n" {
+		t.Errorf("Expected the note to be shown, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := handleList(&buf, Options{}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "note: reviewed, move to archive") {
+		t.Errorf("Expected list to show the note, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := handleList(&buf, Options{format: formatJSON}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"note": "reviewed, move to archive"`) {
+		t.Errorf("Expected JSON to include the note, got %q", buf.String())
+	}
+
+	if err := handleNote(io.Discard, 0, "", Options{clear: true}); err != nil {
+		t.Fatalf("handleNote failed: %v", err)
+	}
+	board, _ := readClipboard()
+	if board.Entries[0].Note != "" {
+		t.Errorf("Expected the note to be cleared, got %q", board.Entries[0].Note)
+	}
+
+	if err := handleNote(io.Discard, 3, "x", Options{}); err == nil {
+		t.Error("Expected error for an invalid index, got nil")
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	home, cwd := "/home/me", "/home/me/projects/cx"
+
+	tests := []struct {
+		path string
+		mode pathMode
+		want string
+	}{
+		{"/home/me/docs/report.pdf", pathAbsolute, "~/docs/report.pdf"},
+		{"/home/me", pathAbsolute, "~"},
+		{"/home/meg/notes.md", pathAbsolute, "/home/meg/notes.md"},
+		{"/home/me/projects/cx/main.go", pathRelative, "main.go"},
+		{"/home/me/docs/report.pdf", pathRelative, "../../docs/report.pdf"},
+		{"/home/me/docs/report.pdf", pathBasename, "report.pdf"},
+		{"server:/srv/data", pathBasename, "server:/srv/data"},
+	}
+	for _, tt := range tests {
+		if got := displayPath(tt.path, tt.mode, cwd, home); got != tt.want {
+			t.Errorf("displayPath(%q, %q) = %q, expected %q", tt.path, tt.mode, got, tt.want)
+		}
+	}
+
+	plainOutput = true
+	defer func() { plainOutput = false }()
+	if got := displayPath("/home/me/docs/report.pdf", pathAbsolute, cwd, home); got != "/home/me/docs/report.pdf" {
+		t.Errorf("Expected plain output to keep the full path, got %q", got)
+	}
+}
+
+func TestHandleListRelative(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "nested", "file3.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handleList(&buf, Options{pathMode: pathRelative}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), " "+filepath.Join("nested", "file3.txt")) || strings.Contains(buf.String(), tempDir) {
+		t.Errorf("Expected a relative path, got %q", buf.String())
 	}
 }
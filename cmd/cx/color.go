@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+)
+
+// colorModes lists the values accepted by --color
+var colorModes = []string{"auto", "always", "never"}
+
+// colorMode is set by --color
+var colorMode string
+
+// plainOutput is set when colors are off, so that listings also drop the
+// padding that lines their columns up, which only gets in the way of grep
+// and awk
+var plainOutput bool
+
+// applyColorMode turns colors on or off for mode. In auto mode they are off
+// when $NO_COLOR is set or stdout isn't a terminal.
+func applyColorMode(mode string, stdoutTerminal bool) error {
+	switch mode {
+	case "always":
+		if lipgloss.ColorProfile() == termenv.Ascii {
+			lipgloss.SetColorProfile(termenv.ANSI256)
+		}
+		plainOutput = false
+	case "never":
+		lipgloss.SetColorProfile(termenv.Ascii)
+		plainOutput = true
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || !stdoutTerminal {
+			lipgloss.SetColorProfile(termenv.Ascii)
+			plainOutput = true
+		}
+	default:
+		return fmt.Errorf("invalid color mode %q (expected one of: %s)", mode, strings.Join(colorModes, ", "))
+	}
+	return nil
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(os.Stdout.Fd())
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestApplyColorMode(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	defer func() {
+		lipgloss.SetColorProfile(profile)
+		plainOutput = false
+	}()
+
+	if err := applyColorMode("always", false); err != nil {
+		t.Fatalf("applyColorMode failed: %v", err)
+	}
+	if lipgloss.ColorProfile() == termenv.Ascii || plainOutput {
+		t.Error("Expected colors with --color=always, even when piped")
+	}
+
+	if err := applyColorMode("auto", true); err != nil {
+		t.Fatalf("applyColorMode failed: %v", err)
+	}
+	if plainOutput {
+		t.Error("Expected styled output on a terminal")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if err := applyColorMode("auto", true); err != nil {
+		t.Fatalf("applyColorMode failed: %v", err)
+	}
+	if lipgloss.ColorProfile() != termenv.Ascii || !plainOutput {
+		t.Error("Expected plain output with $NO_COLOR set")
+	}
+
+	if err := applyColorMode("sometimes", true); err == nil {
+		t.Error("Expected error for an invalid color mode, got nil")
+	}
+}
+
+func TestPlainList(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	plainOutput = true
+	defer func() { plainOutput = false }()
+
+	for _, path := range []string{"file1.txt", "config"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, path), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := handleList(&buf, Options{}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	want := "0: " + filepath.Join(tempDir, "config") + "\n1: " + filepath.Join(tempDir, "file1.txt") + "\n"
+	if buf.String() != want {
+		t.Errorf("Expected unpadded lines %q, got %q", want, buf.String())
+	}
+}
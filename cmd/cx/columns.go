@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/dustin/go-humanize"
+)
+
+// listColumns lists the columns cx list --columns can show
+var listColumns = []string{"index", "path", "size", "age", "type", "tag"}
+
+// minPathWidth is the narrowest a path is truncated to, however little room
+// the terminal leaves
+const minPathWidth = 16
+
+// parseColumns parses a comma-separated --columns value
+func parseColumns(s string) ([]string, error) {
+	var columns []string
+	for _, column := range strings.Split(s, ",") {
+		column = strings.TrimSpace(column)
+		if !containsString(listColumns, column) {
+			return nil, fmt.Errorf("invalid column %q (expected some of: %s)", column, strings.Join(listColumns, ", "))
+		}
+		if containsString(columns, column) {
+			return nil, fmt.Errorf("column %s given twice", column)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// terminalWidth returns the width of the terminal on stdout, or 0 if stdout
+// isn't a terminal
+func terminalWidth() int {
+	width, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// truncatePath shortens path to width terminal cells by dropping its start,
+// which is usually less telling than its end, and marking the cut with "…".
+// Wide characters, like CJK ones, take two cells and aren't cut in half.
+// When no part of the path fits beside the mark, the mark is all there is.
+func truncatePath(path string, width int) string {
+	pathWidth := lipgloss.Width(path)
+	if pathWidth <= width || width < 1 {
+		return path
+	}
+	// cutting into a wide character leaves it whole, so cut one cell more
+	for cut := pathWidth - width + 1; cut < pathWidth; cut++ {
+		if truncated := ansi.TruncateLeft(path, cut, "…"); lipgloss.Width(truncated) <= width {
+			return truncated
+		}
+	}
+	return "…"
+}
+
+// columnText returns the unstyled text of column for entry
+func columnText(entry listEntry, column string) string {
+	switch column {
+	case "index":
+		return fmt.Sprintf("%d:", entry.index)
+	case "path":
+		return pathText(entry)
+	case "size":
+		switch {
+		case entry.isMissing && !entry.recorded:
+			return "-"
+		case entry.stats != nil:
+			return FormatSize(entry.stats.Size)
+		}
+		return entry.sizeDisplay
+	case "age":
+		return humanize.Time(entry.cutTime)
+	case "type":
+		switch {
+		case entry.recorded:
+			return "missing " + entry.kind()
+		case entry.isMissing:
+			return "missing"
+		}
+		return entry.kind()
+	case "tag":
+		if len(entry.tags) == 0 {
+			return ""
+		}
+		return formatTags(entry.tags)
+	}
+	return ""
+}
+
+// columnStyle returns the style of column for entry
+func columnStyle(entry listEntry, column string) lipgloss.Style {
+	switch column {
+	case "path":
+		return pathStyle(entry)
+	case "tag":
+		return tagStyle
+	}
+	return detailsStyle
+}
+
+// renderColumns writes a line for each entry with the chosen columns, in
+// order. Columns are padded to line up unless the output is plain, and with
+// a width, paths are truncated so that lines fit in it.
+func renderColumns(w io.Writer, entries []listEntry, columns []string, width int) {
+	sep := "  "
+	if plainOutput {
+		sep = " "
+	}
+
+	rows := make([][]string, len(entries))
+	widths := make([]int, len(columns))
+	for i, entry := range entries {
+		rows[i] = make([]string, len(columns))
+		for j, column := range columns {
+			rows[i][j] = columnText(entry, column)
+			widths[j] = max(widths[j], lipgloss.Width(rows[i][j]))
+		}
+	}
+
+	if width > 0 {
+		for j, column := range columns {
+			if column != "path" {
+				continue
+			}
+			others := len(sep) * (len(columns) - 1)
+			for k := range columns {
+				if k != j {
+					others += widths[k]
+				}
+			}
+			if room := max(width-others, minPathWidth); widths[j] > room {
+				widths[j] = room
+				for i := range rows {
+					rows[i][j] = truncatePath(rows[i][j], room)
+				}
+			}
+		}
+	}
+
+	for i, entry := range entries {
+		cells := make([]string, len(columns))
+		for j, column := range columns {
+			text := rows[i][j]
+			if !plainOutput && j < len(columns)-1 {
+				padding := strings.Repeat(" ", widths[j]-lipgloss.Width(text))
+				if column == "index" || column == "size" {
+					text = padding + text
+				} else {
+					text += padding
+				}
+			}
+			cells[j] = columnStyle(entry, column).Render(text)
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, sep), " "))
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	columns, err := parseColumns("path, size,index")
+	if err != nil {
+		t.Fatalf("parseColumns failed: %v", err)
+	}
+	if got := strings.Join(columns, ","); got != "path,size,index" {
+		t.Errorf("Expected path,size,index, got %s", got)
+	}
+	for _, bad := range []string{"", "path,colour", "path,path"} {
+		if _, err := parseColumns(bad); err == nil {
+			t.Errorf("Expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestTruncatePath(t *testing.T) {
+	if got := truncatePath("/home/me/projects/report.pdf", 12); got != "…/report.pdf" {
+		t.Errorf("Expected …/report.pdf, got %q", got)
+	}
+	if got := truncatePath("/tmp/a", 12); got != "/tmp/a" {
+		t.Errorf("Expected a short path to be kept, got %q", got)
+	}
+	// each of these characters takes two cells
+	if got := truncatePath("/写真/旅行の記録.jpg", 12); got != "…の記録.jpg" {
+		t.Errorf("Expected …の記録.jpg, got %q", got)
+	}
+	// a wide character the cut would split is dropped whole
+	if got := truncatePath("/写真/旅行の記録.jpg", 14); got != "…行の記録.jpg" {
+		t.Errorf("Expected …行の記録.jpg, got %q", got)
+	}
+	// too narrow for anything but the mark
+	if got := truncatePath("/写真/旅行", 2); got != "…" {
+		t.Errorf("Expected just …, got %q", got)
+	}
+	if got := truncatePath("/tmp/a", 1); got != "…" {
+		t.Errorf("Expected just …, got %q", got)
+	}
+}
+
+func TestRenderColumns(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{tags: []string{"work"}}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "config"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handleList(&buf, Options{columns: []string{"type", "index", "tag"}}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "directory  0:" || lines[1] != "file       1:  [work]" {
+		t.Errorf("Unexpected columns: %q", lines)
+	}
+
+	buf.Reset()
+	wide := []listEntry{{index: 0, displayPath: "/写真/a.jpg"}, {index: 1, displayPath: "/photos/b.jpg"}}
+	renderColumns(&buf, wide, []string{"path", "index"}, 0)
+	if got := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"); got[0] != "/写真/a.jpg    0:" || got[1] != "/photos/b.jpg  1:" {
+		t.Errorf("Expected wide characters lined up by their width, got %q", got)
+	}
+
+	buf.Reset()
+	entries := []listEntry{{index: 0, displayPath: "/a/very/long/path/to/some/file.txt"}}
+	renderColumns(&buf, entries, []string{"index", "path"}, 24)
+	if got := strings.TrimRight(buf.String(), "\n"); got != "0:  …th/to/some/file.txt" {
+		t.Errorf("Expected the path truncated to fit, got %q", got)
+	}
+}
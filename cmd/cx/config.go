@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/muesli/termenv"
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+	"github.com/spf13/cobra"
+)
+
+// configKeys lists the supported config keys in the order they're written
+var configKeys = []string{"clipboard", "store", "on_conflict", "theme", "color", "persist", "expire_after", "confirm", "confirm_clear_over", "stack", "max_entries", "max_size", "on_limit", "prune_missing", "sync_remote", "sync_token", "exclude", "pre_cut", "post_cut", "pre_paste", "post_paste"}
+
+// configFlags maps config keys to the command flag they provide a default for
+var configFlags = map[string]string{
+	"clipboard":          "clipboard",
+	"store":              "store",
+	"on_conflict":        "on-conflict",
+	"color":              "color",
+	"persist":            "persist",
+	"expire_after":       "expire-after",
+	"confirm":            "confirm",
+	"confirm_clear_over": "confirm-clear-over",
+	"stack":              "stack",
+	"sync_remote":        "remote",
+	"sync_token":         "token",
+	"exclude":            "exclude",
+}
+
+// limitPolicies lists what on_limit can do when the clipboard is full
+var limitPolicies = []string{"evict", "refuse"}
+
+// themes lists the supported color themes
+var themes = []string{"default", "none"}
+
+// Config holds user defaults read from the config file. Values are kept as
+// strings, validated when set, and applied as flag defaults.
+type Config struct {
+	values map[string]string
+}
+
+// configPath returns the location of the config file in configDir. A config
+// already kept in ~/.config/cx, where it used to be on every platform, is
+// still used until one exists in configDir.
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "config.yaml")
+
+	if os.Getenv("XDG_CONFIG_HOME") == "" {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			legacy := filepath.Join(homeDir, ".config", "cx", "config.yaml")
+			if _, err := os.Stat(path); os.IsNotExist(err) && legacy != path {
+				if _, err := os.Stat(legacy); err == nil {
+					return legacy, nil
+				}
+			}
+		}
+	}
+	return path, nil
+}
+
+// readConfig loads the config file, returning an empty config if it doesn't
+// exist
+func readConfig() (*Config, error) {
+	cfg := &Config{values: make(map[string]string)}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.parse(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parse reads the simple YAML subset cx writes: "key: value" lines, with
+// exclude also accepting a "[a, b]" flow list or "- item" block list
+func (c *Config) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	listKey := ""
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if item, ok := strings.CutPrefix(line, "- "); ok && listKey != "" {
+			c.values[listKey] = joinList(c.values[listKey], parseScalar(item))
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		key = strings.TrimSpace(key)
+
+		listKey = ""
+		if key == "exclude" {
+			value = strings.TrimSpace(stripComment(value))
+			if value == "" {
+				listKey = key
+			}
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = parseScalar(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			value = strings.Join(items, ",")
+		} else {
+			value = parseScalar(value)
+		}
+
+		if err := c.Set(key, value); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// stripComment drops a trailing " # comment" from a config value
+func stripComment(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// parseScalar returns the value of a YAML scalar: the contents of a single-
+// or double-quoted string, which may contain " #", or otherwise the text up
+// to any comment
+func parseScalar(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '"' {
+				if value, err := strconv.Unquote(s[:i+1]); err == nil {
+					return value
+				}
+				break
+			}
+		}
+	case '\'':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+			} else if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+			} else {
+				return b.String()
+			}
+		}
+	}
+
+	return strings.TrimSpace(stripComment(s))
+}
+
+// joinList appends item to a comma-separated list
+func joinList(list, item string) string {
+	if list == "" {
+		return item
+	}
+	return list + "," + item
+}
+
+// Get returns the value of key and whether it is set
+func (c *Config) Get(key string) (string, bool) {
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// Set validates and stores value under key. An empty value unsets the key.
+func (c *Config) Set(key, value string) error {
+	if value == "" {
+		delete(c.values, key)
+		return nil
+	}
+
+	if name, ok := strings.CutPrefix(key, aliasPrefix); ok {
+		if err := validateAlias(name, value); err != nil {
+			return err
+		}
+		c.values[key] = value
+		return nil
+	}
+
+	var err error
+	switch key {
+	case "clipboard", "sync_token", "exclude", "pre_cut", "post_cut", "pre_paste", "post_paste":
+	case "sync_remote":
+		if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			err = fmt.Errorf("expected an http:// or https:// URL, got %q", value)
+		}
+	case "store":
+		if !containsString(storeKinds, value) {
+			err = fmt.Errorf("invalid store %q (expected one of: %s)", value, strings.Join(storeKinds, ", "))
+		}
+	case "on_conflict":
+		_, err = clipboard.ParseConflictStrategy(value)
+	case "color":
+		if !containsString(colorModes, value) {
+			err = fmt.Errorf("invalid color mode %q (expected one of: %s)", value, strings.Join(colorModes, ", "))
+		}
+	case "theme":
+		if !containsString(themes, value) {
+			err = fmt.Errorf("invalid theme %q (expected one of: %s)", value, strings.Join(themes, ", "))
+		}
+	case "persist", "confirm", "stack", "prune_missing":
+		_, err = strconv.ParseBool(value)
+	case "confirm_clear_over", "max_entries":
+		var n int
+		if n, err = strconv.Atoi(value); err == nil && n < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+	case "expire_after":
+		_, err = time.ParseDuration(value)
+	case "max_size":
+		_, err = humanize.ParseBytes(value)
+	case "on_limit":
+		if !containsString(limitPolicies, value) {
+			err = fmt.Errorf("invalid policy %q (expected one of: %s)", value, strings.Join(limitPolicies, ", "))
+		}
+	default:
+		return fmt.Errorf("unknown config key %q (expected one of: %s)", key, strings.Join(configKeys, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	c.values[key] = value
+	return nil
+}
+
+// excludes returns the configured exclude patterns
+func (c *Config) excludes() []string {
+	if value, ok := c.values["exclude"]; ok {
+		return strings.Split(value, ",")
+	}
+	return nil
+}
+
+// limits returns the configured clipboard limits. Once full, the oldest
+// entries are evicted unless on_limit is refuse.
+func (c *Config) limits() clipboard.Limits {
+	limits := clipboard.Limits{Size: entrySize}
+	if value, ok := c.values["max_entries"]; ok {
+		limits.MaxEntries, _ = strconv.Atoi(value)
+	}
+	if value, ok := c.values["max_size"]; ok {
+		size, _ := humanize.ParseBytes(value)
+		limits.MaxSize = int64(size)
+	}
+	limits.Evict = c.values["on_limit"] != "refuse"
+	return limits
+}
+
+// hooks returns the configured hook commands by name
+func (c *Config) hooks() map[string]string {
+	hooks := make(map[string]string)
+	for _, name := range hookNames {
+		if command, ok := c.values[name]; ok {
+			hooks[name] = command
+		}
+	}
+	return hooks
+}
+
+// writeConfig saves the config file, creating its directory if needed
+func writeConfig(c *Config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, key := range configKeys {
+		value, ok := c.values[key]
+		if !ok {
+			continue
+		}
+		if key == "exclude" {
+			fmt.Fprintf(&buf, "%s:\n", key)
+			for _, pattern := range c.excludes() {
+				fmt.Fprintf(&buf, "  - %q\n", pattern)
+			}
+			continue
+		}
+		if containsString(hookNames, key) {
+			// commands are quoted so a " #" in them isn't read as a comment
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&buf, "%s: %s\n", key, value)
+	}
+	for _, key := range c.aliasKeys() {
+		fmt.Fprintf(&buf, "%s: %s\n", key, strconv.Quote(c.values[key]))
+	}
+
+	return fsops.WriteFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// applyConfig uses the config values as defaults for any flags of cmd the
+// user didn't set explicitly, and applies the color theme
+func applyConfig(cmd *cobra.Command, c *Config) error {
+	for key, name := range configFlags {
+		value, ok := c.values[key]
+		if !ok {
+			continue
+		}
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if key == "clipboard" {
+			value = expandHome(value)
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("config %s: %w", key, err)
+		}
+	}
+
+	if c.values["theme"] == "none" {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	return nil
+}
+
+// expandHome replaces a leading "~/" in path with the home directory
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, rest)
+		}
+	}
+	return path
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// handleConfigGet prints the value of key, or every set key when key is empty
+func handleConfigGet(w io.Writer, key string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if key != "" {
+		if !containsString(configKeys, key) && !strings.HasPrefix(key, aliasPrefix) {
+			return fmt.Errorf("unknown config key %q (expected one of: %s)", key, strings.Join(configKeys, ", "))
+		}
+		value, _ := cfg.Get(key)
+		fmt.Fprintln(w, value)
+		return nil
+	}
+
+	for _, key := range configKeys {
+		if value, ok := cfg.Get(key); ok {
+			fmt.Fprintf(w, "%s: %s\n", key, value)
+		}
+	}
+	for _, key := range cfg.aliasKeys() {
+		value, _ := cfg.Get(key)
+		fmt.Fprintf(w, "%s: %s\n", key, value)
+	}
+	return nil
+}
+
+// handleConfigSet stores value under key in the config file
+func handleConfigSet(w io.Writer, key, value string, opts Options) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if name, ok := strings.CutPrefix(key, aliasPrefix); ok {
+		if cmd := shadowedCommand(name); cmd != nil {
+			return fmt.Errorf("alias %q would be hidden by the %s command", name, cmd.Name())
+		}
+	}
+	if err := cfg.Set(key, value); err != nil {
+		return err
+	}
+
+	if err := writeConfig(cfg); err != nil {
+		return err
+	}
+
+	if !opts.quiet {
+		if value == "" {
+			fmt.Fprintf(w, "Unset: %s\n", key)
+		} else {
+			fmt.Fprintf(w, "Set: %s = %s\n", key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestConfigParse(t *testing.T) {
+	cfg := &Config{values: make(map[string]string)}
+	input := `# cx defaults
+on_conflict: rename
+persist: "true"
+expire_after: 24h # a day
+exclude:
+  - node_modules
+  - '*.o'
+`
+	if err := cfg.parse(strings.NewReader(input)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if value, _ := cfg.Get("on_conflict"); value != "rename" {
+		t.Errorf("Expected on_conflict rename, got %q", value)
+	}
+	if value, _ := cfg.Get("persist"); value != "true" {
+		t.Errorf("Expected persist true, got %q", value)
+	}
+	if value, _ := cfg.Get("expire_after"); value != "24h" {
+		t.Errorf("Expected expire_after 24h, got %q", value)
+	}
+	if excludes := cfg.excludes(); len(excludes) != 2 || excludes[0] != "node_modules" || excludes[1] != "*.o" {
+		t.Errorf("Unexpected excludes: %v", excludes)
+	}
+
+	flow := &Config{values: make(map[string]string)}
+	if err := flow.parse(strings.NewReader("exclude: [vendor, \"*.tmp\"]\n")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if excludes := flow.excludes(); len(excludes) != 2 || excludes[1] != "*.tmp" {
+		t.Errorf("Unexpected flow list excludes: %v", excludes)
+	}
+
+	hook := &Config{values: make(map[string]string)}
+	input = `post_paste: "notify-send \"Pasted\" {dest} # done" # trailing comment
+pre_cut: 'echo ''{source}'''
+`
+	if err := hook.parse(strings.NewReader(input)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if value, _ := hook.Get("post_paste"); value != `notify-send "Pasted" {dest} # done` {
+		t.Errorf("Unexpected post_paste hook: %q", value)
+	}
+	if value, _ := hook.Get("pre_cut"); value != `echo '{source}'` {
+		t.Errorf("Unexpected pre_cut hook: %q", value)
+	}
+
+	for _, bad := range []string{"on_conflict: clobber\n", "colour: red\n", "no separator\n"} {
+		if err := (&Config{values: make(map[string]string)}).parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestConfigSetGet(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := handleConfigSet(io.Discard, "on_conflict", "skip", Options{}); err != nil {
+		t.Fatalf("handleConfigSet failed: %v", err)
+	}
+	if err := handleConfigSet(io.Discard, "exclude", "node_modules,*.o", Options{}); err != nil {
+		t.Fatalf("handleConfigSet failed: %v", err)
+	}
+	if err := handleConfigSet(io.Discard, "persist", "maybe", Options{}); err == nil {
+		t.Error("Expected error for invalid persist value")
+	}
+
+	var out bytes.Buffer
+	if err := handleConfigGet(&out, "on_conflict"); err != nil {
+		t.Fatalf("handleConfigGet failed: %v", err)
+	}
+	if out.String() != "skip\n" {
+		t.Errorf("Expected skip, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := handleConfigGet(&out, ""); err != nil {
+		t.Fatalf("handleConfigGet failed: %v", err)
+	}
+	if out.String() != "on_conflict: skip\nexclude: node_modules,*.o\n" {
+		t.Errorf("Unexpected config listing: %q", out.String())
+	}
+
+	// An empty value unsets the key
+	if err := handleConfigSet(io.Discard, "on_conflict", "", Options{}); err != nil {
+		t.Fatalf("handleConfigSet failed: %v", err)
+	}
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatalf("readConfig failed: %v", err)
+	}
+	if _, ok := cfg.Get("on_conflict"); ok {
+		t.Error("Expected on_conflict to be unset")
+	}
+
+	// Hook commands survive being written and read back
+	command := `notify-send "Pasted" {dest} # done`
+	if err := handleConfigSet(io.Discard, "post_paste", command, Options{}); err != nil {
+		t.Fatalf("handleConfigSet failed: %v", err)
+	}
+	cfg, err = readConfig()
+	if err != nil {
+		t.Fatalf("readConfig failed: %v", err)
+	}
+	if value, _ := cfg.Get("post_paste"); value != command {
+		t.Errorf("Expected post_paste %q, got %q", command, value)
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	cfg := &Config{values: map[string]string{"on_conflict": "rename", "persist": "true"}}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("on-conflict", "", "")
+	cmd.Flags().Bool("persist", false, "")
+	if err := cmd.Flags().Parse([]string{"--on-conflict", "skip"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if err := applyConfig(cmd, cfg); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+
+	// Explicit flags win over the config
+	if onConflict, _ := cmd.Flags().GetString("on-conflict"); onConflict != "skip" {
+		t.Errorf("Expected explicit on-conflict skip, got %q", onConflict)
+	}
+	if persist, _ := cmd.Flags().GetBool("persist"); !persist {
+		t.Error("Expected persist to default to true from config")
+	}
+}
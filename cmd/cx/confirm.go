@@ -0,0 +1,25 @@
+package main
+
+import "fmt"
+
+// confirmation settings, set by the --confirm, --yes and
+// --confirm-clear-over flags
+var (
+	confirm          bool
+	assumeYes        bool
+	confirmClearOver int
+)
+
+// confirming reports whether destructive operations should be confirmed
+func confirming() bool {
+	return confirm && !assumeYes
+}
+
+// confirmAction asks a yes/no question, defaulting to no
+func confirmAction(question string) (bool, error) {
+	answer, err := readAnswer(question + " [y/N] ")
+	if err != nil {
+		return false, fmt.Errorf("no answer to %q: %w", question, err)
+	}
+	return answer == "y" || answer == "yes", nil
+}
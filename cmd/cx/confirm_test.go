@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// withConfirm turns on --confirm with the given answers on stdin until the
+// returned function is called
+func withConfirm(answers string) func() {
+	originalStdin := stdin
+	stdin = strings.NewReader(answers)
+	confirm = true
+	return func() {
+		stdin = originalStdin
+		confirm, assumeYes, confirmClearOver = false, false, 0
+	}
+}
+
+func TestConfirmOverwriteOnPaste(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	destDir, restore := setupConflict(t, tempDir)
+	defer restore()
+	defer withConfirm("n\n")()
+
+	if err := handlePasteAt(io.Discard, 0, Options{onConflict: clipboard.ConflictOverwrite}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "file1.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read destination: %v", err)
+	}
+	if string(content) == "This is file 1" {
+		t.Error("Destination was overwritten despite answering no")
+	}
+
+	// --yes answers for the user
+	assumeYes = true
+	if err := handlePasteAt(io.Discard, 0, Options{onConflict: clipboard.ConflictOverwrite}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(destDir, "file1.txt"))
+	if string(content) != "This is file 1" {
+		t.Errorf("Expected the destination to be overwritten with --yes, got %q", content)
+	}
+}
+
+func TestConfirmClear(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	defer withConfirm("n\ny\n")()
+
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := handleClear(&buf, Options{}); err != nil {
+		t.Fatalf("handleClear failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "left as it was") {
+		t.Errorf("Expected clear to be cancelled, got %q", buf.String())
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 2 {
+		t.Fatalf("Expected 2 entries after cancelling, got %d", len(board.Entries))
+	}
+
+	// Small clipboards are cleared without asking
+	confirmClearOver = 2
+	if err := handleClear(io.Discard, Options{}); err != nil {
+		t.Fatalf("handleClear failed: %v", err)
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 0 {
+		t.Errorf("Expected the clipboard to be cleared, got %d entries", len(board.Entries))
+	}
+
+	// The second answer was never read
+	if rest, _ := io.ReadAll(stdin); string(rest) != "y\n" {
+		t.Errorf("Expected one prompt, remaining input %q", rest)
+	}
+}
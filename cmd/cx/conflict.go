@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// stdin is where interactive prompts read answers from
+var stdin io.Reader = os.Stdin
+
+// readAnswer prints question to stderr and reads a line from stdin. It
+// reads a byte at a time so that later prompts see the following lines.
+func readAnswer(question string) (string, error) {
+	fmt.Fprint(os.Stderr, question)
+
+	var answer strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := stdin.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			answer.WriteByte(buf[0])
+		}
+		if err != nil {
+			if answer.Len() == 0 {
+				return "", err
+			}
+			break
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(answer.String())), nil
+}
+
+// promptConflict asks the user how to resolve a single conflict
+func promptConflict(destPath string) (clipboard.ConflictStrategy, error) {
+	for {
+		answer, err := readAnswer(fmt.Sprintf("%s already exists. [o]verwrite, [s]kip, [r]ename? ", destPath))
+		if err != nil {
+			return "", fmt.Errorf("no answer for conflict at %s: %w", destPath, err)
+		}
+
+		switch answer {
+		case "o", "overwrite":
+			return clipboard.ConflictOverwrite, nil
+		case "s", "skip":
+			return clipboard.ConflictSkip, nil
+		case "r", "rename":
+			return clipboard.ConflictRename, nil
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// setupConflict cuts file1.txt and creates a conflicting file1.txt in a
+// destination directory, which becomes the working directory
+func setupConflict(t *testing.T, tempDir string) (destDir string, restore func()) {
+	t.Helper()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	destDir = filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "file1.txt"), []byte("existing"), 0o644); err != nil {
+		t.Fatalf("Failed to write conflicting file: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(destDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	return destDir, func() { os.Chdir(originalWd) }
+}
+
+func TestPasteConflictStrategies(t *testing.T) {
+	tests := []struct {
+		strategy clipboard.ConflictStrategy
+		want     map[string]string
+		remains  int
+		errMsg   string
+	}{
+		{
+			strategy: clipboard.ConflictFail,
+			want:     map[string]string{"file1.txt": "existing"},
+			remains:  1,
+			errMsg:   "destination already exists",
+		},
+		{
+			strategy: clipboard.ConflictOverwrite,
+			want:     map[string]string{"file1.txt": "This is file 1"},
+		},
+		{
+			strategy: clipboard.ConflictSkip,
+			want:     map[string]string{"file1.txt": "existing"},
+			remains:  1,
+		},
+		{
+			strategy: clipboard.ConflictRename,
+			want:     map[string]string{"file1.txt": "existing", "file1 (1).txt": "This is file 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			tempDir, cleanup := setupTestEnvironment(t)
+			defer cleanup()
+
+			destDir, restore := setupConflict(t, tempDir)
+			defer restore()
+
+			err := handlePasteAt(io.Discard, 0, Options{onConflict: tt.strategy})
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("Expected %q error, got: %v", tt.errMsg, err)
+				}
+			} else if err != nil {
+				t.Fatalf("handlePasteAt failed: %v", err)
+			}
+
+			for name, content := range tt.want {
+				got, err := os.ReadFile(filepath.Join(destDir, name))
+				if err != nil {
+					t.Errorf("Failed to read %s: %v", name, err)
+					continue
+				}
+				if string(got) != content {
+					t.Errorf("Expected %s to contain %q, got %q", name, content, got)
+				}
+			}
+
+			board, err := readClipboard()
+			if err != nil {
+				t.Fatalf("Failed to read clipboard: %v", err)
+			}
+			if len(board.Entries) != tt.remains {
+				t.Errorf("Expected %d clipboard entries, got %d", tt.remains, len(board.Entries))
+			}
+		})
+	}
+}
+
+func TestPasteConflictPrompt(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	destDir, restore := setupConflict(t, tempDir)
+	defer restore()
+
+	originalStdin := stdin
+	defer func() { stdin = originalStdin }()
+	stdin = strings.NewReader("what\nr\n")
+
+	if err := handlePasteAt(io.Discard, 0, Options{onConflict: clipboard.ConflictPrompt}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "file1 (1).txt")); os.IsNotExist(err) {
+		t.Error("Expected prompt answer 'r' to rename the pasted file")
+	}
+}
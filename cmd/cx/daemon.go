@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// pathEvent is a change seen in a watched directory: a rename from one path
+// to another, or just to if a file was written or moved in from elsewhere
+type pathEvent struct {
+	from string
+	to   string
+}
+
+// socketPath returns the path of the unix socket cx daemon listens on
+func socketPath() string {
+	return siblingPath(".sock")
+}
+
+// daemonStore is the clipboard held by cx daemon: kept in memory, written
+// through to the clipboard file so it outlives the daemon
+type daemonStore struct {
+	*clipboard.MemoryStore
+	file *clipboard.FileStore
+}
+
+// Write replaces the clipboard in memory and in the clipboard file
+func (s *daemonStore) Write(board clipboard.Clipboard) error {
+	if err := s.MemoryStore.Write(board); err != nil {
+		return err
+	}
+	return s.file.Write(board)
+}
+
+// Append adds entries on top of the clipboard
+func (s *daemonStore) Append(entries ...clipboard.Entry) error {
+	board, err := s.Read()
+	if err != nil {
+		return err
+	}
+	board.Entries = append(append([]clipboard.Entry{}, entries...), board.Entries...)
+	return s.Write(board)
+}
+
+// reload replaces the clipboard in memory with the clipboard file, after
+// something other than the daemon has written it
+func (s *daemonStore) reload() error {
+	unlock, err := s.file.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	board, err := s.file.Read()
+	if err != nil {
+		return err
+	}
+	return s.MemoryStore.Write(board)
+}
+
+// followRename points entries at from, or inside it, to where it was
+// renamed. Renames are pieced together from separate events, so an entry is
+// only moved if the file it would now be at is still its file, see
+// clipboard.Entry.MovedTo. It returns how many entries changed.
+func (s *daemonStore) followRename(from, to string) (int, error) {
+	unlock, err := s.file.Lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	memUnlock, _ := s.MemoryStore.Lock()
+	defer memUnlock()
+
+	board, err := s.Read()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for i, entry := range board.Entries {
+		var moved string
+		if entry.CurrentPath == from {
+			moved = to
+		} else if rest, ok := strings.CutPrefix(entry.CurrentPath, from+string(filepath.Separator)); ok {
+			moved = filepath.Join(to, rest)
+		} else {
+			continue
+		}
+		if !entry.MovedTo(moved) {
+			continue
+		}
+		board.Entries[i].CurrentPath = moved
+		changed++
+	}
+
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, s.Write(board)
+}
+
+// watchedDirs returns the directories to watch: those holding clipboard
+// entries, and the one holding the clipboard file
+func (s *daemonStore) watchedDirs() []string {
+	dirs := []string{filepath.Dir(s.file.Path)}
+	board, err := s.Read()
+	if err != nil {
+		return dirs
+	}
+	for _, entry := range board.Entries {
+		if !isRemoteEntry(entry) {
+			dirs = append(dirs, filepath.Dir(entry.CurrentPath))
+		}
+	}
+	return dirs
+}
+
+// listenSocket listens on the daemon socket, replacing a stale socket left
+// by a daemon that didn't shut down cleanly
+func listenSocket(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("cx daemon is already running (%s)", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// handleDaemon holds the clipboard in memory and serves it on a unix socket
+// for --store daemon, following cut and copied paths that other programs
+// rename until interrupted
+func handleDaemon(w io.Writer) error {
+	path, err := filepath.Abs(clipboardPath)
+	if err != nil {
+		return err
+	}
+	store := &daemonStore{MemoryStore: clipboard.NewMemoryStore(), file: clipboard.NewFileStore(path)}
+	store.file.Recovered = warnRecovered
+	if err := store.reload(); err != nil {
+		return err
+	}
+
+	listener, err := listenSocket(socketPath())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socketPath())
+
+	server := &http.Server{Handler: syncHandler(store, "")}
+	go server.Serve(listener)
+	defer server.Close()
+
+	events := make(chan pathEvent)
+	watcher, err := newPathWatcher()
+	if err != nil {
+		fmt.Fprintf(w, "Warning: %v\n", err)
+	} else {
+		defer watcher.close()
+		watcher.watch(store.watchedDirs())
+		go watcher.run(events)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(w, "cx daemon listening on %s\n", socketPath())
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			if event.to == path {
+				if err := store.reload(); err != nil {
+					fmt.Fprintf(w, "Warning: failed to reload the clipboard: %v\n", err)
+				}
+			} else if event.from != "" {
+				changed, err := store.followRename(event.from, event.to)
+				if err != nil {
+					fmt.Fprintf(w, "Warning: failed to follow %s: %v\n", event.from, err)
+				}
+				if changed > 0 {
+					fmt.Fprintf(w, "Followed: %s -> %s\n", event.from, event.to)
+				}
+			}
+			if watcher != nil {
+				watcher.watch(store.watchedDirs())
+			}
+		}
+	}
+}
+
+// socketStore is the clipboard of a running cx daemon, reached over its
+// unix socket. It shares the clipboard file's lock, which the daemon also
+// takes before changing entries itself.
+type socketStore struct {
+	path string
+	file *clipboard.FileStore
+}
+
+// newSocketStore returns the store for --store daemon
+func newSocketStore() *socketStore {
+	file := clipboard.NewFileStore(clipboardPath)
+	file.Waiting = waitingForLock
+	return &socketStore{path: socketPath(), file: file}
+}
+
+// request sends a request for the clipboard to the daemon
+func (s *socketStore) request(method string, body io.Reader) (clipboard.Clipboard, error) {
+	var board clipboard.Clipboard
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", s.path)
+		},
+	}}
+	req, err := http.NewRequest(method, "http://cx/clipboard", body)
+	if err != nil {
+		return board, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return board, fmt.Errorf("cx daemon is not running (start it with cx daemon): %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return board, fmt.Errorf("cx daemon: %s", strings.TrimSpace(string(message)))
+	}
+	boardJSON, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return board, err
+	}
+	return clipboard.Decode(boardJSON)
+}
+
+// Read returns the daemon's clipboard
+func (s *socketStore) Read() (clipboard.Clipboard, error) {
+	return s.request(http.MethodGet, nil)
+}
+
+// Write replaces the daemon's clipboard
+func (s *socketStore) Write(board clipboard.Clipboard) error {
+	boardJSON, err := json.Marshal(board)
+	if err != nil {
+		return err
+	}
+	_, err = s.request(http.MethodPut, bytes.NewReader(boardJSON))
+	return err
+}
+
+// Append adds entries on top of the daemon's clipboard
+func (s *socketStore) Append(entries ...clipboard.Entry) error {
+	board, err := s.Read()
+	if err != nil {
+		return err
+	}
+	board.Entries = append(append([]clipboard.Entry{}, entries...), board.Entries...)
+	return s.Write(board)
+}
+
+// Lock takes the clipboard file's lock
+func (s *socketStore) Lock() (func(), error) {
+	return s.file.Lock()
+}
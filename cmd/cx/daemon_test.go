@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestDaemonFollowRename(t *testing.T) {
+	tempDir := t.TempDir()
+	store := &daemonStore{MemoryStore: clipboard.NewMemoryStore(), file: clipboard.NewFileStore(filepath.Join(tempDir, "clipboard.json"))}
+
+	oldDir := filepath.Join(tempDir, "reports")
+	store.Write(clipboard.Clipboard{Entries: []clipboard.Entry{
+		{CurrentPath: filepath.Join(oldDir, "q1.pdf")},
+		{CurrentPath: oldDir},
+		{CurrentPath: filepath.Join(tempDir, "reports-old")},
+	}})
+
+	newDir := filepath.Join(tempDir, "archive")
+	changed, err := store.followRename(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("followRename failed: %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("Expected 2 entries to follow the rename, got %d", changed)
+	}
+
+	// The change is written through to the clipboard file
+	board, err := store.file.Read()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard file: %v", err)
+	}
+	expected := []string{filepath.Join(newDir, "q1.pdf"), newDir, filepath.Join(tempDir, "reports-old")}
+	for i, path := range expected {
+		if board.Entries[i].CurrentPath != path {
+			t.Errorf("Expected entry %d at %s, got %s", i, path, board.Entries[i].CurrentPath)
+		}
+	}
+}
+
+func TestDaemonFollowRenameOtherFile(t *testing.T) {
+	tempDir := t.TempDir()
+	store := &daemonStore{MemoryStore: clipboard.NewMemoryStore(), file: clipboard.NewFileStore(filepath.Join(tempDir, "clipboard.json"))}
+
+	path := filepath.Join(tempDir, "draft.txt")
+	if err := os.WriteFile(path, []byte("draft"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	entry := clipboard.Entry{CurrentPath: path}
+	entry.Identify()
+	store.Write(clipboard.Clipboard{Entries: []clipboard.Entry{entry}})
+
+	// the file went elsewhere, and an unrelated one was created just after
+	os.Remove(path)
+	other := filepath.Join(tempDir, "other.txt")
+	if err := os.WriteFile(other, []byte("other"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	changed, err := store.followRename(path, other)
+	if err != nil {
+		t.Fatalf("followRename failed: %v", err)
+	}
+	if changed != 0 {
+		t.Errorf("Expected the entry not to follow to a different file, got %d changed", changed)
+	}
+}
+
+func TestSocketStore(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "cx.sock")
+
+	listener, err := listenSocket(path)
+	if err != nil {
+		t.Fatalf("listenSocket failed: %v", err)
+	}
+	server := &http.Server{Handler: syncHandler(clipboard.NewMemoryStore(), "")}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected socket only accessible to its owner, got %v (%v)", info.Mode(), err)
+	}
+	if _, err := listenSocket(path); err == nil {
+		t.Error("Expected error starting a second daemon, got nil")
+	}
+
+	store := &socketStore{path: path, file: clipboard.NewFileStore(filepath.Join(tempDir, "clipboard.json"))}
+	if err := store.Append(clipboard.Entry{CurrentPath: "/tmp/a"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	board, err := store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != "/tmp/a" {
+		t.Errorf("Expected the appended entry back, got %+v", board.Entries)
+	}
+}
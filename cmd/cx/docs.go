@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// docsCmd generates reference documentation for packagers and the website.
+// It's hidden from help since users get the same from --help.
+var docsCmd = &cobra.Command{
+	Use:    "docs",
+	Short:  "Generate man pages and markdown help",
+	Hidden: true,
+}
+
+var docsManCmd = &cobra.Command{
+	Use:   "man [dir]",
+	Short: "Write a man page for every command into dir",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeDocs(cmd, args, genManTree)
+	},
+}
+
+var docsMarkdownCmd = &cobra.Command{
+	Use:   "markdown [dir]",
+	Short: "Write a markdown page for every command into dir",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeDocs(cmd, args, doc.GenMarkdownTree)
+	},
+}
+
+// writeDocs writes a page for every command into the directory in args, or
+// the current one, with gen, one of cobra/doc's tree generators. Pages are
+// named after the command's path, e.g. cx-paste.1 or cx_paste.md.
+func writeDocs(cmd *cobra.Command, args []string, gen func(*cobra.Command, string) error) error {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	// leave out cobra's dated footer, so markdown pages only change with
+	// the commands they describe
+	count := 0
+	visitDocumented(cmd.Root(), func(c *cobra.Command) {
+		c.DisableAutoGenTag = true
+		count++
+	})
+	if err := gen(cmd.Root(), dir); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d pages to %s\n", count, dir)
+	return nil
+}
+
+// genManTree writes man pages in section 1 of the cx manual
+func genManTree(root *cobra.Command, dir string) error {
+	return doc.GenManTree(root, &doc.GenManHeader{Section: "1", Source: "cx", Manual: "cx Manual"}, dir)
+}
+
+// visitDocumented calls fn for cmd and every command below it that cobra/doc
+// writes a page for: those that show up in help
+func visitDocumented(cmd *cobra.Command, fn func(*cobra.Command)) {
+	fn(cmd)
+	for _, child := range cmd.Commands() {
+		if child.IsAvailableCommand() && !child.IsAdditionalHelpTopicCommand() {
+			visitDocumented(child, fn)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra/doc"
+)
+
+func TestWriteDocsMan(t *testing.T) {
+	dir := t.TempDir()
+	docsManCmd.SetOut(io.Discard)
+	if err := writeDocs(docsManCmd, []string{dir}, genManTree); err != nil {
+		t.Fatalf("writeDocs failed: %v", err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "cx-paste.1"))
+	if err != nil {
+		t.Fatalf("Expected a man page for cx paste: %v", err)
+	}
+	for _, want := range []string{`.TH "CX-PASTE" "1"`, ".SH EXAMPLE", `\fB-a\fP, \fB--all\fP`, `\fBcx(1)\fP`} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("Expected the man page to contain %q, got:\n%s", want, page)
+		}
+	}
+}
+
+func TestWriteDocs(t *testing.T) {
+	dir := t.TempDir()
+	docsMarkdownCmd.SetOut(io.Discard)
+	if err := writeDocs(docsMarkdownCmd, []string{dir}, doc.GenMarkdownTree); err != nil {
+		t.Fatalf("writeDocs failed: %v", err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "cx_paste.md"))
+	if err != nil {
+		t.Fatalf("Expected a page for cx paste: %v", err)
+	}
+	if !strings.Contains(string(page), "### Examples") || !strings.Contains(string(page), "[cx](cx.md)") {
+		t.Errorf("Unexpected markdown page:\n%s", page)
+	}
+	if strings.Contains(string(page), "Auto generated") {
+		t.Errorf("Expected no generated-by footer:\n%s", page)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cx_docs.md")); !os.IsNotExist(err) {
+		t.Error("Expected the hidden docs command to be left out")
+	}
+}
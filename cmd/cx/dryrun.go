@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// pastePlan describes what pasting one entry would do
+type pastePlan struct {
+	source      string
+	dest        string
+	copy        bool
+	size        int64
+	conflict    string
+	crossDevice bool
+	remote      bool
+	err         error
+}
+
+// planPaste works out what pasting entry into destDir would do without
+// touching the filesystem
+func planPaste(entry clipboard.Entry, destDir string, opts Options) pastePlan {
+	plan := pastePlan{source: entry.CurrentPath, copy: opts.persist || entry.IsCopy(), remote: isRemoteEntry(entry)}
+
+	info, err := os.Lstat(entry.CurrentPath)
+	if err != nil && !plan.remote {
+		plan.err = fmt.Errorf("source path no longer exists")
+		if found, ok := clipboard.Locate(entry); ok {
+			plan.err = fmt.Errorf("source path no longer exists, it may have moved to %s", found)
+		}
+		return plan
+	}
+
+	destDir, err = rootedDestDir(entry, destDir, opts)
+	if err != nil {
+		plan.err = err
+		return plan
+	}
+	if info != nil && info.IsDir() {
+		if plan.err = clipboard.CheckNotInside(entry.CurrentPath, destDir); plan.err != nil {
+			return plan
+		}
+	}
+
+	name, err := clipboard.PasteName(filepath.Base(entry.CurrentPath), opts.as)
+	if err != nil {
+		plan.err = err
+		return plan
+	}
+	plan.dest = filepath.Join(destDir, name)
+	plan.size, _ = fsops.TreeSize(entry.CurrentPath)
+
+	if clipboard.SamePath(entry.CurrentPath, plan.dest) {
+		if !plan.copy {
+			plan.conflict = "already there, nothing to do"
+			return plan
+		}
+		renamed, err := clipboard.NextFreeName(plan.dest)
+		if err != nil {
+			plan.err = err
+			return plan
+		}
+		plan.dest = renamed
+		plan.conflict = "same as the source, would rename"
+	} else if destInfo, err := os.Lstat(plan.dest); err == nil && opts.update && plan.copy && info != nil && destInfo.IsDir() == info.IsDir() {
+		plan.conflict = "would update existing"
+	} else if err == nil {
+		switch opts.onConflict {
+		case clipboard.ConflictOverwrite:
+			plan.conflict = "would overwrite existing"
+			if backupSuffix != "" {
+				plan.conflict = "would back up and overwrite existing"
+			}
+		case clipboard.ConflictSkip:
+			plan.conflict = "would skip, destination exists"
+		case clipboard.ConflictRename:
+			renamed, err := clipboard.NextFreeName(plan.dest)
+			if err != nil {
+				plan.err = err
+				return plan
+			}
+			plan.dest = renamed
+			plan.conflict = "destination exists, would rename"
+		case clipboard.ConflictPrompt:
+			plan.conflict = "destination exists, would prompt"
+		default:
+			plan.err = fmt.Errorf("destination already exists: %s", plan.dest)
+			return plan
+		}
+	}
+
+	if !plan.copy && !plan.remote {
+		plan.crossDevice = !fsops.SameDevice(entry.CurrentPath, destDir)
+	}
+
+	return plan
+}
+
+// printPastePlans reports what pasting entries into destDir would do
+func printPastePlans(w io.Writer, entries []clipboard.Entry, destDir string, opts Options) error {
+	var total int64
+	count := 0
+
+	for i, entry := range entries {
+		planOpts, err := renamedOpts(filepath.Base(entry.CurrentPath), i+1, opts)
+		if err != nil {
+			fmt.Fprintf(w, "Would fail: %s (%v)\n", entry.CurrentPath, err)
+			continue
+		}
+
+		plan := planPaste(entry, destDir, planOpts)
+		if plan.err != nil {
+			fmt.Fprintf(w, "Would fail: %s (%v)\n", plan.source, plan.err)
+			continue
+		}
+
+		verb := "move"
+		if plan.copy {
+			verb = "copy"
+		}
+
+		details := FormatSize(plan.size)
+		if plan.remote {
+			details = "download over ssh"
+		}
+		if plan.conflict != "" {
+			details += ", " + plan.conflict
+		}
+		if plan.crossDevice {
+			details += ", cross-device: copy then delete"
+		}
+
+		fmt.Fprintf(w, "Would %s: %s -> %s (%s)\n", verb, plan.source, plan.dest, details)
+
+		if plan.conflict != "would skip, destination exists" && plan.conflict != "already there, nothing to do" {
+			total += plan.size
+			count++
+		}
+	}
+
+	fmt.Fprintf(w, "Total: %d entries, %s\n", count, FormatSize(total))
+	return nil
+}
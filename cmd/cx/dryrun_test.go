@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestPasteDryRun(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "config"),
+	}
+	for _, file := range files {
+		if err := cutFile(io.Discard, file, Options{}); err != nil {
+			t.Fatalf("cutFile failed for %s: %v", file, err)
+		}
+	}
+
+	destDir := filepath.Join(tempDir, "nested")
+	if err := os.WriteFile(filepath.Join(destDir, "file1.txt"), []byte("existing"), 0o644); err != nil {
+		t.Fatalf("Failed to write conflicting file: %v", err)
+	}
+
+	var out bytes.Buffer
+	err := handlePasteAll(&out, Options{dest: destDir, dryRun: true, onConflict: clipboard.ConflictRename})
+	if err != nil {
+		t.Fatalf("handlePasteAll failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"Would move: " + files[1] + " -> " + filepath.Join(destDir, "config") + " (29 B)",
+		"Would move: " + files[0] + " -> " + filepath.Join(destDir, "file1 (1).txt") + " (14 B, destination exists, would rename)",
+		"Total: 2 entries, 43 B",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected dry run output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	// Nothing was touched
+	for _, file := range files {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			t.Errorf("Dry run moved %s", file)
+		}
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 2 {
+		t.Errorf("Expected clipboard to be untouched, got %d entries", len(board.Entries))
+	}
+}
+
+func TestPasteDryRunConflictFails(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := os.WriteFile(filepath.Join(destDir, "file1.txt"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handlePasteAt(&out, 0, Options{dest: destDir, dryRun: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Would fail") || !strings.Contains(out.String(), "destination already exists") {
+		t.Errorf("Expected conflict to be reported, got:\n%s", out.String())
+	}
+}
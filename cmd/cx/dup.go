@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// dupName finds the first free name for a copy of path next to it, the way
+// file managers name them: "report copy.pdf", then "report copy 2.pdf". A
+// directory's name is kept whole, since a dot in it isn't an extension.
+func dupName(path string, isDir bool) (string, error) {
+	dir := filepath.Dir(path)
+	base := filepath.Base(path)
+	stem, ext := base, ""
+	if !isDir {
+		// a dotfile like .bashrc is all stem
+		if e := filepath.Ext(base); e != base {
+			stem, ext = strings.TrimSuffix(base, e), e
+		}
+	}
+
+	for n := 1; n < 10000; n++ {
+		name := fsops.FitName(stem, " copy"+ext)
+		if n > 1 {
+			name = fsops.FitName(stem, fmt.Sprintf(" copy %d%s", n, ext))
+		}
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free name found for a copy of %s", path)
+}
+
+// handleDup copies each path next to itself under the next free copy name,
+// without going through the clipboard
+func handleDup(w io.Writer, paths []string, opts Options) error {
+	if opts.quiet {
+		w = io.Discard
+	}
+	fsOpts := opts.pasteOptions().FS
+
+	for _, path := range paths {
+		src, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Lstat(src)
+		if err != nil {
+			return err
+		}
+
+		dst, err := dupName(src, info.IsDir())
+		if err != nil {
+			return err
+		}
+		if err := fsops.Copy(src, dst, info, fsOpts); err != nil {
+			// don't leave half a copy behind
+			os.RemoveAll(dst)
+			return fmt.Errorf("failed to duplicate %s: %w", src, err)
+		}
+		fmt.Fprintf(w, "Duplicated: %s -> %s\n", src, dst)
+	}
+	return nil
+}
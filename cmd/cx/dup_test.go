@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDupName(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		isDir    bool
+		expected string
+	}{
+		{"report.pdf", false, "report copy.pdf"},
+		{".bashrc", false, ".bashrc copy"},
+		{"v1.2", true, "v1.2 copy"},
+	}
+	for _, test := range tests {
+		name, err := dupName(filepath.Join(dir, test.name), test.isDir)
+		if err != nil {
+			t.Fatalf("dupName(%q) failed: %v", test.name, err)
+		}
+		if filepath.Base(name) != test.expected {
+			t.Errorf("dupName(%q): expected %q, got %q", test.name, test.expected, filepath.Base(name))
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "report copy.pdf"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	name, err := dupName(filepath.Join(dir, "report.pdf"), false)
+	if err != nil {
+		t.Fatalf("dupName failed: %v", err)
+	}
+	if filepath.Base(name) != "report copy 2.pdf" {
+		t.Errorf("Expected %q, got %q", "report copy 2.pdf", filepath.Base(name))
+	}
+}
+
+func TestDupLongName(t *testing.T) {
+	dir := t.TempDir()
+	// as long as a name can be, so the copy's has to be shortened
+	src := filepath.Join(dir, strings.Repeat("n", 251)+".txt")
+	if err := os.WriteFile(src, []byte("long"), 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := handleDup(io.Discard, []string{src}, Options{}); err != nil {
+		t.Fatalf("handleDup failed: %v", err)
+	}
+	dst := filepath.Join(dir, strings.Repeat("n", 246)+" copy.txt")
+	if content, err := os.ReadFile(dst); err != nil || string(content) != "long" {
+		t.Errorf("Expected the copy at a shortened name, got %q (%v)", content, err)
+	}
+}
+
+func TestDup(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	file := filepath.Join(tempDir, "file1.txt")
+	if err := handleDup(io.Discard, []string{file, file}, Options{}); err != nil {
+		t.Fatalf("handleDup failed: %v", err)
+	}
+
+	original, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read the original: %v", err)
+	}
+	for _, name := range []string{"file1 copy.txt", "file1 copy 2.txt"} {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Expected %s: %v", name, err)
+		}
+		if string(data) != string(original) {
+			t.Errorf("%s: expected %q, got %q", name, original, data)
+		}
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if len(board.Entries) != 0 {
+		t.Errorf("Expected the clipboard to be left alone, got %d entries", len(board.Entries))
+	}
+
+	if err := handleDup(io.Discard, []string{filepath.Join(tempDir, "nope")}, Options{}); err == nil {
+		t.Error("Expected error duplicating a missing path")
+	}
+}
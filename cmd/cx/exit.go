@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// Exit codes for the failures scripts may want to tell apart; anything else
+// exits with exitFailure
+const (
+	exitFailure     = 1
+	exitEmpty       = 3
+	exitMissing     = 4
+	exitConflict    = 5
+	exitCrossDevice = 6
+	exitInterrupted = 130
+)
+
+// exitCode returns the exit code for err. When several entries failed in
+// different ways, the first kind listed here wins.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, clipboard.ErrEmptyClipboard):
+		return exitEmpty
+	case errors.Is(err, clipboard.ErrSourceMissing):
+		return exitMissing
+	case errors.Is(err, clipboard.ErrConflict):
+		return exitConflict
+	case errors.Is(err, fsops.ErrCrossDevice):
+		return exitCrossDevice
+	}
+	return exitFailure
+}
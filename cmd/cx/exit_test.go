@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("something else"), exitFailure},
+		{clipboard.ErrEmptyClipboard, exitEmpty},
+		{fmt.Errorf("%w: /tmp/x", clipboard.ErrSourceMissing), exitMissing},
+		{fmt.Errorf("failed to paste 1 of 2 entries:\n%w", errors.Join(fmt.Errorf("a: %w", clipboard.ErrConflict))), exitConflict},
+		{fmt.Errorf("%w, moving /tmp/x failed: %w", fsops.ErrCrossDevice, errors.New("no space")), exitCrossDevice},
+		{interruptError{"interrupted"}, exitInterrupted},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("Expected exit code %d for %q, got %d", tt.want, tt.err, got)
+		}
+	}
+}
+
+func TestPasteErrorKinds(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := handlePasteAt(io.Discard, 0, Options{}); !errors.Is(err, clipboard.ErrEmptyClipboard) {
+		t.Errorf("Expected ErrEmptyClipboard, got %v", err)
+	}
+
+	source := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := os.WriteFile(filepath.Join(destDir, "file1.txt"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := handlePasteAll(io.Discard, Options{dest: destDir}); !errors.Is(err, clipboard.ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+
+	if err := os.Remove(source); err != nil {
+		t.Fatalf("Failed to remove source: %v", err)
+	}
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir}); !errors.Is(err, clipboard.ErrSourceMissing) {
+		t.Errorf("Expected ErrSourceMissing, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// pathMapping rewrites paths under one directory to be under another, for
+// moving a queue between machines, e.g. --map /home/a=/home/b
+type pathMapping struct {
+	from string
+	to   string
+}
+
+// parsePathMappings parses --map values of the form from=to
+func parsePathMappings(values []string) ([]pathMapping, error) {
+	mappings := make([]pathMapping, 0, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid --map %q (expected from=to, e.g. /home/a=/home/b)", value)
+		}
+		mappings = append(mappings, pathMapping{from: filepath.Clean(from), to: filepath.Clean(to)})
+	}
+	return mappings, nil
+}
+
+// mapPath rewrites path with the mapping whose directory is the longest
+// that holds it. Remote paths are left as they are.
+func mapPath(path string, mappings []pathMapping) string {
+	if _, ok := parseRemotePath(path); ok {
+		return path
+	}
+
+	best := -1
+	for i, m := range mappings {
+		if path != m.from && !strings.HasPrefix(path, m.from+string(filepath.Separator)) {
+			continue
+		}
+		if best < 0 || len(m.from) > len(mappings[best].from) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return path
+	}
+	return mappings[best].to + strings.TrimPrefix(path, mappings[best].from)
+}
+
+// mapEntries rewrites the paths of entries. The device and inode that
+// identify an entry's file only mean something on the machine it was cut on,
+// so they're dropped, leaving the size, hash and fingerprint to find a moved
+// file by.
+func mapEntries(entries []clipboard.Entry, mappings []pathMapping) []clipboard.Entry {
+	mapped := make([]clipboard.Entry, 0, len(entries))
+	for _, entry := range entries {
+		entry.OriginalPath = mapPath(entry.OriginalPath, mappings)
+		entry.CurrentPath = mapPath(entry.CurrentPath, mappings)
+		entry.Device, entry.Inode = 0, 0
+		mapped = append(mapped, entry)
+	}
+	return mapped
+}
+
+// handleExport writes the clipboard as portable JSON, with its paths
+// rewritten by mappings
+func handleExport(w io.Writer, mappings []pathMapping) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	board.Version = clipboard.CurrentVersion
+	board.Entries = mapEntries(board.Entries, mappings)
+	data, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s\n", data)
+	return nil
+}
+
+// handleImport adds the entries exported to path, or read from stdin if
+// it's "-", to the clipboard with their paths rewritten by mappings. The
+// imported entries go on top in their exported order; with opts.force they
+// replace the clipboard instead. Entries for paths already on the clipboard are
+// skipped, and ones whose files don't exist here are kept with a warning.
+func handleImport(w io.Writer, stdin io.Reader, path string, mappings []pathMapping, opts Options) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	imported, err := clipboard.Decode(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	if opts.force {
+		board.Entries = []clipboard.Entry{}
+	}
+
+	have := make(map[string]bool, len(board.Entries))
+	for _, entry := range board.Entries {
+		have[entry.CurrentPath] = true
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	var added []clipboard.Entry
+	for _, entry := range mapEntries(imported.Entries, mappings) {
+		if have[entry.CurrentPath] {
+			fmt.Fprintf(w, "Skipped: %s (already on the clipboard)\n", entry.CurrentPath)
+			continue
+		}
+		have[entry.CurrentPath] = true
+
+		if _, err := os.Lstat(entry.CurrentPath); err == nil {
+			entry.Identify()
+		} else if !isRemoteEntry(entry) {
+			fmt.Fprintf(os.Stderr, "Warning: %s doesn't exist here\n", entry.CurrentPath)
+		}
+		added = append(added, entry)
+		fmt.Fprintf(w, "Imported: %s\n", entry.CurrentPath)
+	}
+
+	return updateClipboard(func(board *clipboard.Clipboard) error {
+		board.Entries = append(added, board.Entries...)
+		return nil
+	})
+}
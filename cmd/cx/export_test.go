@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestMapPath(t *testing.T) {
+	mappings, err := parsePathMappings([]string{"/home/a=/home/b", "/home/a/work=/srv/work"})
+	if err != nil {
+		t.Fatalf("parsePathMappings failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"/home/a":             "/home/b",
+		"/home/a/notes.txt":   "/home/b/notes.txt",
+		"/home/a/work/x.go":   "/srv/work/x.go",
+		"/home/ab/notes.txt":  "/home/ab/notes.txt",
+		"host:/home/a/remote": "host:/home/a/remote",
+	}
+	for path, expected := range tests {
+		if mapped := mapPath(path, mappings); mapped != expected {
+			t.Errorf("mapPath(%q): expected %q, got %q", path, expected, mapped)
+		}
+	}
+
+	for _, bad := range []string{"/home/a", "=/home/b", "/home/a="} {
+		if _, err := parsePathMappings([]string{bad}); err == nil {
+			t.Errorf("Expected error parsing --map %q", bad)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{tags: []string{"work"}}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "nested"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	// export from a machine where the files were under /elsewhere
+	var exported bytes.Buffer
+	if err := handleExport(&exported, []pathMapping{{from: tempDir, to: "/elsewhere"}}); err != nil {
+		t.Fatalf("handleExport failed: %v", err)
+	}
+
+	if err := handleClear(io.Discard, Options{}); err != nil {
+		t.Fatalf("handleClear failed: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "nested"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	mappings := []pathMapping{{from: "/elsewhere", to: tempDir}}
+	if err := handleImport(io.Discard, &exported, "-", mappings, Options{}); err != nil {
+		t.Fatalf("handleImport failed: %v", err)
+	}
+
+	board, _ := readClipboard()
+	if len(board.Entries) != 2 {
+		t.Fatalf("Expected the entry already there to be skipped, got %+v", board.Entries)
+	}
+	imported := board.Entries[0]
+	if imported.CurrentPath != filepath.Join(tempDir, "file1.txt") || !imported.IsCopy() || !imported.HasTag("work") {
+		t.Errorf("Unexpected imported entry: %+v", imported)
+	}
+	if imported.Inode == 0 {
+		t.Error("Expected the imported entry to identify its file here")
+	}
+}
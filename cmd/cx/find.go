@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// findEntries returns the indices of the entries whose path matches pattern,
+// best match first. Patterns are fuzzy matched like the picker's search, or
+// with regex set used as a regular expression, in which case earlier and
+// shorter matches rank higher.
+func findEntries(entries []clipboard.Entry, pattern string, regex bool) ([]int, error) {
+	var re *regexp.Regexp
+	if regex {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	type match struct{ index, score int }
+
+	var matches []match
+	for i, entry := range entries {
+		if re == nil {
+			if score, ok := fuzzyMatch(pattern, entry.CurrentPath); ok {
+				matches = append(matches, match{i, score})
+			}
+			continue
+		}
+		if loc := re.FindStringIndex(entry.CurrentPath); loc != nil {
+			matches = append(matches, match{i, -loc[0] - (loc[1] - loc[0])})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].score > matches[b].score })
+
+	indices := make([]int, len(matches))
+	for i, m := range matches {
+		indices[i] = m.index
+	}
+	return indices, nil
+}
+
+// handleFind prints the entries matching pattern, best match first, or with
+// opts.pasteFirst pastes the best match
+func handleFind(w io.Writer, pattern string, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	indices, err := findEntries(board.Entries, pattern, opts.regex)
+	if err != nil {
+		return err
+	}
+	if len(indices) == 0 {
+		return fmt.Errorf("no clipboard entries match %q", pattern)
+	}
+
+	if opts.pasteFirst {
+		return handlePasteAt(w, indices[0], opts)
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	maxIndexWidth := len(fmt.Sprintf("%d", len(board.Entries)-1))
+	for _, i := range indices {
+		fmt.Fprintf(w, "%*d %s\n", maxIndexWidth, i, board.Entries[i].CurrentPath)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestFindEntries(t *testing.T) {
+	entries := []clipboard.Entry{
+		{CurrentPath: "/home/me/notes/report-draft.txt"},
+		{CurrentPath: "/home/me/report.pdf"},
+		{CurrentPath: "/home/me/photos/cat.jpg"},
+	}
+
+	indices, err := findEntries(entries, "report", false)
+	if err != nil {
+		t.Fatalf("findEntries failed: %v", err)
+	}
+	if len(indices) != 2 {
+		t.Fatalf("Expected 2 fuzzy matches, got %v", indices)
+	}
+
+	indices, err = findEntries(entries, `\.(pdf|jpg)$`, true)
+	if err != nil {
+		t.Fatalf("findEntries failed: %v", err)
+	}
+	if len(indices) != 2 || indices[0] == 0 || indices[1] == 0 {
+		t.Errorf("Expected entries 1 and 2 to match, got %v", indices)
+	}
+
+	if _, err := findEntries(entries, "(", true); err == nil {
+		t.Error("Expected error for an invalid regular expression, got nil")
+	}
+}
+
+func TestHandleFind(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, path := range []string{"file1.txt", "config/settings.json", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, path), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := handleFind(&buf, "settings", Options{}); err != nil {
+		t.Fatalf("handleFind failed: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); !strings.HasPrefix(got, "1 ") || strings.Contains(got, "\n") {
+		t.Errorf("Expected only entry 1 to match, got %q", got)
+	}
+
+	if err := handleFind(io.Discard, "nothing-like-this", Options{}); err == nil {
+		t.Error("Expected error when nothing matches, got nil")
+	}
+
+	dest := filepath.Join(tempDir, "dest")
+	if err := os.Mkdir(dest, 0o755); err != nil {
+		t.Fatalf("Failed to create destination: %v", err)
+	}
+	if err := handleFind(io.Discard, "settings", Options{pasteFirst: true, dest: dest}); err != nil {
+		t.Fatalf("handleFind --paste-first failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "settings.json")); err != nil {
+		t.Errorf("Expected settings.json to be pasted: %v", err)
+	}
+}
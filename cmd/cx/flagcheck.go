@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Things besides flags that paste flags can clash with, named as errors
+// read them
+const (
+	givenRemoteDest = "a remote destination"
+	givenDest       = "a destination"
+	givenIndex      = "an index"
+	givenJSON       = "--output json"
+)
+
+// flagRule is a flag and the flags, or other things given, that it can't be
+// combined with, or needs one of
+type flagRule struct {
+	flag   string
+	others []string
+}
+
+// pasteConflicts lists what each paste flag can't be combined with, checked
+// in order so the first clash found is the one reported
+var pasteConflicts = []flagRule{
+	{"mirror", []string{"all", "select", "archive", "extract", "flatten", "template", "stdout", "at", "after", "resume", "preserve-root", "rename", "backup", givenRemoteDest, givenJSON}},
+	{"update", []string{"archive", "extract", "flatten", "template", "resume", givenRemoteDest}},
+	{"preserve-owner", []string{"archive", "extract", givenRemoteDest}},
+	{"verify", []string{"exclude", "gitignore"}},
+	{"archive", []string{"as", "verify", "select", givenRemoteDest}},
+	{"extract", []string{"archive", "as", "verify", "select", "exclude", "gitignore", givenRemoteDest}},
+	{"flatten", []string{"archive", "extract", "as", "verify", "select", givenRemoteDest}},
+	{"follow-symlinks", []string{"rewrite-relative", givenRemoteDest}},
+	{"rewrite-relative", []string{givenRemoteDest}},
+	{"hardlink", []string{"archive", "extract", givenRemoteDest}},
+	{"template", []string{"all", "select", "archive", "extract", "flatten", "hardlink", "verify", givenRemoteDest}},
+	{"preserve-root", []string{"archive", "extract", "flatten", "template", givenRemoteDest}},
+	{"rename", []string{"as", "archive", "extract", "flatten", "template", givenRemoteDest}},
+	{"sudo", []string{"archive", "extract", "flatten", "template", "verify", "exclude", "gitignore", "hardlink", "rewrite-relative", givenRemoteDest}},
+	{"resume", []string{"archive", "extract", "flatten", "template", givenRemoteDest}},
+	{"bwlimit", []string{"archive", "extract", "sudo"}},
+	{"backup", []string{givenRemoteDest}},
+	{"stdout", []string{"all", "select", "archive", "extract", "flatten", "template", "dry-run", "at", "after", "as", givenDest, givenJSON}},
+	{"at", []string{"select", "dry-run"}},
+	{"after", []string{"select", "dry-run"}},
+	{"tag", []string{"select", givenIndex}},
+	{"select", []string{"all", givenIndex, givenRemoteDest}},
+	{"all", []string{"as", givenIndex}},
+}
+
+// pasteRequires lists the paste flags that only mean something alongside
+// one of some others
+var pasteRequires = []flagRule{
+	{"checksum", []string{"update", "mirror"}},
+	{"delete-extras", []string{"mirror"}},
+	{"zip", []string{"archive"}},
+	{"set", []string{"template"}},
+}
+
+// givenFlags returns the flags set on the command line, leaving out
+// booleans explicitly set to false
+func givenFlags(flags *pflag.FlagSet) map[string]bool {
+	given := make(map[string]bool)
+	flags.Visit(func(flag *pflag.Flag) {
+		given[flag.Name] = flag.Value.Type() != "bool" || flag.Value.String() == "true"
+	})
+	return given
+}
+
+// checkFlagRules fails on the first flag in given that clashes with
+// something else given, by conflicts, or lacks what it needs, by requires
+func checkFlagRules(given map[string]bool, conflicts, requires []flagRule) error {
+	for _, rule := range conflicts {
+		if !given[rule.flag] {
+			continue
+		}
+		for _, other := range rule.others {
+			if given[other] {
+				return fmt.Errorf("--%s cannot be combined with %s", rule.flag, flagName(other))
+			}
+		}
+	}
+
+	for _, rule := range requires {
+		if !given[rule.flag] {
+			continue
+		}
+		names := make([]string, len(rule.others))
+		found := false
+		for i, other := range rule.others {
+			names[i] = flagName(other)
+			found = found || given[other]
+		}
+		if !found {
+			return fmt.Errorf("--%s needs %s", rule.flag, strings.Join(names, " or "))
+		}
+	}
+	return nil
+}
+
+// flagName is how a flag, or something else given, is named in errors
+func flagName(name string) string {
+	if strings.Contains(name, " ") {
+		return name
+	}
+	return "--" + name
+}
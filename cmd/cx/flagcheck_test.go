@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestGivenFlags(t *testing.T) {
+	flags := pflag.NewFlagSet("paste", pflag.ContinueOnError)
+	flags.Bool("all", false, "")
+	flags.Bool("select", false, "")
+	flags.String("to", "", "")
+	flags.String("as", "", "")
+	if err := flags.Parse([]string{"--all", "--select=false", "--to", "/tmp"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	given := givenFlags(flags)
+	if !given["all"] || !given["to"] {
+		t.Errorf("Expected --all and --to to be given, got %v", given)
+	}
+	if given["select"] || given["as"] {
+		t.Errorf("Expected --select=false and unset flags not to count, got %v", given)
+	}
+}
+
+func TestCheckFlagRules(t *testing.T) {
+	tests := []struct {
+		given map[string]bool
+		want  string
+	}{
+		{map[string]bool{"all": true, "persist": true}, ""},
+		{map[string]bool{"mirror": true, "all": true}, "--mirror cannot be combined with --all"},
+		{map[string]bool{"archive": true, givenRemoteDest: true}, "--archive cannot be combined with a remote destination"},
+		{map[string]bool{"stdout": true, givenJSON: true}, "--stdout cannot be combined with --output json"},
+		{map[string]bool{"select": true, givenIndex: true}, "--select cannot be combined with an index"},
+		// reported by the rule that lists the clash
+		{map[string]bool{"archive": true, "extract": true}, "--extract cannot be combined with --archive"},
+		{map[string]bool{"checksum": true}, "--checksum needs --update or --mirror"},
+		{map[string]bool{"checksum": true, "mirror": true}, ""},
+		{map[string]bool{"set": true, "template": true}, ""},
+		{map[string]bool{"zip": true}, "--zip needs --archive"},
+	}
+
+	for _, tt := range tests {
+		err := checkFlagRules(tt.given, pasteConflicts, pasteRequires)
+		if got := errString(err); got != tt.want {
+			t.Errorf("checkFlagRules(%v) = %q, want %q", tt.given, got, tt.want)
+		}
+	}
+}
+
+func TestPasteRulesNameFlags(t *testing.T) {
+	// every rule is about a real paste flag, so a typo can't quietly
+	// disable one
+	for _, rules := range [][]flagRule{pasteConflicts, pasteRequires} {
+		for _, rule := range rules {
+			for _, name := range append([]string{rule.flag}, rule.others...) {
+				if flagName(name) != name && pasteCmd.Flags().Lookup(name) == nil {
+					t.Errorf("Rule for --%s names --%s, which paste doesn't have", rule.flag, name)
+				}
+			}
+		}
+	}
+}
+
+// errString is err's message, or "" for no error
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
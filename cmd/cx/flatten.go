@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// handlePasteFlatten pastes the files inside the directory entries at the
+// given indices, or every entry if indices is nil, straight into the
+// destination without recreating the directories around them. Files with
+// the same name go through the conflict strategy one by one. A cut entry is
+// removed once all of its files have moved, along with the directories they
+// leave empty; file entries are pasted as they are. Flattened pastes aren't
+// recorded for undo.
+func handlePasteFlatten(w io.Writer, indices []int, opts Options) error {
+	destDir, err := resolveDestDir(opts)
+	if err != nil {
+		return err
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	picked, err := pickEntries(board, indices)
+	if err != nil {
+		return err
+	}
+
+	fsOpts := opts.pasteOptions().FS
+	files := make(map[int][]string, len(picked))
+	dirs := make(map[int]bool, len(picked))
+	var pasting []clipboard.Entry
+	for _, i := range picked {
+		entry := board.Entries[i]
+		if isRemoteEntry(entry) {
+			return fmt.Errorf("%s is on another machine; paste it locally before flattening it", entry.CurrentPath)
+		}
+		info, err := os.Lstat(entry.CurrentPath)
+		if err != nil {
+			return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+		}
+		if dirs[i] = info.IsDir(); !dirs[i] {
+			files[i] = []string{entry.CurrentPath}
+		} else if err := clipboard.CheckNotInside(entry.CurrentPath, destDir); err != nil {
+			return err
+		} else if files[i], err = fsops.ListFiles(entry.CurrentPath, fsOpts); err != nil {
+			return err
+		}
+		pasting = append(pasting, entry)
+	}
+
+	// without a strategy, clashes are reported before anything is pasted
+	if opts.onConflict == clipboard.ConflictFail {
+		if err := checkFlatNames(picked, files, destDir); err != nil {
+			return err
+		}
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if opts.dryRun {
+		for _, i := range picked {
+			fmt.Fprintf(w, "Would flatten %s -> %s (%d files)\n", board.Entries[i].CurrentPath, destDir, len(files[i]))
+		}
+		return nil
+	}
+
+	if err := checkRoom(pasting, destDir, opts); err != nil {
+		return err
+	}
+
+	printPasteSummary(w, pasting)
+	opts.progress = startProgress(w, pasting, opts)
+	fsOpts = opts.pasteOptions().FS
+
+	var errs []error
+	var flattened []clipboard.Entry
+	for _, i := range picked {
+		entry := board.Entries[i]
+		persist := opts.persist || entry.IsCopy()
+		vars := hookVars{source: entry.CurrentPath, dest: destDir, op: pasteOp(persist)}
+		if err := runHook("pre_paste", vars); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+
+		pasted, skipped, err := flattenFiles(files[i], destDir, persist, opts.onConflict, fsOpts)
+		opts.progress.clear()
+		if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, destDir, err)); histErr != nil {
+			errs = append(errs, histErr)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+
+		if !persist && dirs[i] {
+			if err := fsops.RemoveEmptyDirs(entry.CurrentPath); err != nil {
+				errs = append(errs, fmt.Errorf("flattened, but failed to clean up %s: %w", entry.CurrentPath, err))
+			}
+		}
+		if _, err := os.Lstat(entry.CurrentPath); errors.Is(err, os.ErrNotExist) || opts.pop {
+			flattened = append(flattened, entry)
+		}
+		if err := runHook("post_paste", vars); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+		}
+
+		message := fmt.Sprintf("Flattened: %s -> %s (%d files", entry.CurrentPath, destDir, pasted)
+		if skipped > 0 {
+			message += fmt.Sprintf(", %d skipped", skipped)
+		}
+		fmt.Fprintln(w, message+")")
+	}
+	opts.progress.finish()
+
+	if len(flattened) > 0 {
+		if err := engine().RemoveEntries(flattened...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkFlatNames fails if two of the files would be pasted under the same
+// name, or one would land on a path that already exists
+func checkFlatNames(picked []int, files map[int][]string, destDir string) error {
+	seen := make(map[string]string)
+	for _, i := range picked {
+		for _, file := range files[i] {
+			name := filepath.Base(file)
+			if other, ok := seen[name]; ok {
+				return fmt.Errorf("%s and %s would both be pasted as %s (use --on-conflict to resolve)", other, file, name)
+			}
+			seen[name] = file
+
+			target := filepath.Join(destDir, name)
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("%w: %s (use --on-conflict to resolve)", clipboard.ErrConflict, target)
+			}
+		}
+	}
+	return nil
+}
+
+// flattenFiles copies, or with persist unset moves, each of files into
+// destDir under its base name, resolving clashes with strategy. It returns
+// how many files were pasted and how many skipped.
+func flattenFiles(files []string, destDir string, persist bool, strategy clipboard.ConflictStrategy, fsOpts fsops.Options) (pasted, skipped int, err error) {
+	for _, file := range files {
+		info, err := os.Lstat(file)
+		if err != nil {
+			return pasted, skipped, err
+		}
+
+		target, overwrite, err := engine().ResolveConflict(file, filepath.Join(destDir, filepath.Base(file)), strategy)
+		if errors.Is(err, clipboard.ErrSkipped) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return pasted, skipped, err
+		}
+
+		if persist {
+			err = fsops.Copy(file, target, info, fsOpts)
+		} else {
+			err = fsops.Move(file, target, fsOpts)
+		}
+		if err := overwrite.Finish(err); err != nil {
+			return pasted, skipped, err
+		}
+		pasted++
+	}
+	return pasted, skipped, nil
+}
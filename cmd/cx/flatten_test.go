@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestHandlePasteFlatten(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	source := filepath.Join(tempDir, "config")
+	if err := os.MkdirAll(filepath.Join(source, "deeper"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "deeper", "settings.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := cutFile(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	// two files called settings.json clash without a strategy
+	if err := handlePasteFlatten(io.Discard, nil, Options{dest: destDir}); err == nil {
+		t.Fatal("Expected error for clashing names, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "config.ini")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be pasted when names clash")
+	}
+
+	var out bytes.Buffer
+	if err := handlePasteFlatten(&out, nil, Options{dest: destDir, onConflict: clipboard.ConflictRename}); err != nil {
+		t.Fatalf("handlePasteFlatten failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "(3 files)") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	for _, name := range []string{"config.ini", "settings.json", "settings (1).json"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); err != nil {
+			t.Errorf("Expected %s in the destination: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(source); !os.IsNotExist(err) {
+		t.Error("Expected the emptied source directory to be removed")
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 0 {
+		t.Errorf("Expected the cut entry to be removed, got %+v", board.Entries)
+	}
+}
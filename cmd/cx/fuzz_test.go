@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func FuzzQuotePath(f *testing.F) {
+	for _, seed := range []string{"/tmp/report.pdf", "/tmp/a\nb", "/tmp/\x1b[31mred", "/tmp/\xff\xfe", "/tmp/tab\there", `/tmp/"quoted"`, "/tmp/日本語"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		got := quotePath(path)
+		if !utf8.ValidString(got) || strings.ContainsFunc(got, func(r rune) bool { return !strconv.IsPrint(r) }) {
+			t.Fatalf("quotePath(%q) = %q, which isn't safe to print", path, got)
+		}
+		if got == path {
+			return
+		}
+		// anything quoted can be read back exactly
+		if unquoted, err := strconv.Unquote(got); err != nil || unquoted != path {
+			t.Errorf("quotePath(%q) = %q, which unquotes to %q (%v)", path, got, unquoted, err)
+		}
+	})
+}
+
+func FuzzTruncatePath(f *testing.F) {
+	f.Add("/home/me/projects/cx/cmd/cx/main.go", 16)
+	f.Add("", 0)
+	// wide characters, which an odd width has to cut around
+	f.Add("/tmp/日本語/ファイル.txt", 5)
+	f.Add("/tmp/日本語/ファイル.txt", 2)
+	f.Add("/srv/한국어/문서.hwp", 7)
+	// zero-width combining marks, which go with the character before them
+	f.Add("/tmp/cafe\u0301/e\u0301\u0301\u0308.txt", 6)
+	f.Add("/tmp/\u0301\u0301\u0301", 1)
+	// emoji, joined and not
+	f.Add("/tmp/👩\u200d👩\u200d👧/😀.png", 4)
+
+	f.Fuzz(func(t *testing.T, path string, width int) {
+		// list quotes paths before laying them out, so there are no control
+		// characters left to take up no cells
+		path = quotePath(path)
+		got := truncatePath(path, width)
+		if width < 1 || lipgloss.Width(path) <= width {
+			if got != path {
+				t.Errorf("truncatePath(%q, %d) = %q, but it fits already", path, width, got)
+			}
+			return
+		}
+		// measured as the renderer measures it, in terminal cells
+		if lipgloss.Width(got) > width {
+			t.Errorf("truncatePath(%q, %d) = %q, %d cells wide", path, width, got, lipgloss.Width(got))
+		}
+		if !strings.HasPrefix(got, "…") {
+			t.Errorf("truncatePath(%q, %d) = %q, without an ellipsis", path, width, got)
+		}
+	})
+}
+
+func FuzzDisplayPath(f *testing.F) {
+	f.Add("/home/me/notes.txt", "/home/me/docs", "/home/me", uint8(0))
+	f.Add("host:path/to/file", "/", "/root", uint8(1))
+	f.Add("/tmp/a\nb", "/tmp", "", uint8(2))
+
+	modes := []pathMode{pathAbsolute, pathRelative, pathBasename}
+	f.Fuzz(func(t *testing.T, path, cwd, home string, mode uint8) {
+		// every kind of path is shown somehow, without panicking
+		displayPath(path, modes[int(mode)%len(modes)], cwd, home)
+	})
+}
+
+func FuzzParseRemotePath(f *testing.F) {
+	for _, seed := range []string{"host:path", "user@host:~/file", "./name:with:colons", "host:", "@host:x", ":path"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		remote, ok := parseRemotePath(s)
+		if !ok {
+			return
+		}
+		// its scp form names the same remote path
+		again, ok := parseRemotePath(remote.String())
+		if !ok || again != remote {
+			t.Errorf("parseRemotePath(%q) = %+v, whose String %q parses as %+v", s, remote, remote.String(), again)
+		}
+	})
+}
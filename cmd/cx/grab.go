@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// runPathCommand runs command with sh and returns the paths it prints, one
+// per line or NUL-separated, as --stdin reads them. What it writes to stderr
+// is passed through.
+func runPathCommand(command string) ([]string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+
+	paths, err := readPathList(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("%s printed no paths", command)
+	}
+	return paths, nil
+}
+
+// handleGrab adds every path command prints to the clipboard with op, e.g.
+// cx grab --exec 'fd -e png'. Nothing is added unless every path exists.
+func handleGrab(w io.Writer, command string, op clipboard.Operation, opts Options) error {
+	if command == "" {
+		return errors.New("grab needs a command to run, e.g. --exec 'fd -e png'")
+	}
+
+	paths, err := runPathCommand(command)
+	if err != nil {
+		return err
+	}
+	return addEntries(w, paths, op, opts)
+}
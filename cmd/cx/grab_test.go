@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestHandleGrab(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	command := "printf '%s\\0%s\\0' " + shellQuote(filepath.Join(tempDir, "file1.txt")) + " " + shellQuote(filepath.Join(tempDir, "nested"))
+	if err := handleGrab(io.Discard, command, clipboard.OpCopy, Options{tags: []string{"found"}}); err != nil {
+		t.Fatalf("handleGrab failed: %v", err)
+	}
+
+	board, _ := readClipboard()
+	if len(board.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(board.Entries))
+	}
+	for _, entry := range board.Entries {
+		if !entry.IsCopy() || !entry.HasTag("found") {
+			t.Errorf("Expected a tagged copy, got %+v", entry)
+		}
+	}
+
+	// a missing path, a failing command or no output adds nothing
+	for _, command := range []string{
+		"echo " + shellQuote(filepath.Join(tempDir, "file2.txt")) + "; echo " + shellQuote(filepath.Join(tempDir, "missing")),
+		"exit 1",
+		"true",
+	} {
+		if err := handleGrab(io.Discard, command, clipboard.OpCut, Options{}); err == nil {
+			t.Errorf("Expected error grabbing from %q", command)
+		}
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 2 {
+		t.Errorf("Expected the clipboard to be unchanged, got %d entries", len(board.Entries))
+	}
+}
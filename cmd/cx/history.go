@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// historyActions lists the operations recorded in the history
+var historyActions = []string{"cut", "copy", "paste", "clear", "delete", "restore"}
+
+// history outcomes
+const (
+	outcomeOK      = "ok"
+	outcomeSkipped = "skipped"
+	outcomeFailed  = "failed"
+)
+
+// HistoryRecord is one line of the history log
+type HistoryRecord struct {
+	At          time.Time `json:"timestamp"`
+	Action      string    `json:"action"`
+	Path        string    `json:"path"`
+	Destination string    `json:"destination,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// historyPath returns the path of the history log
+func historyPath() string {
+	return siblingPath(".history.jsonl")
+}
+
+// newHistoryRecord returns a record of action on path, failed if err is set
+func newHistoryRecord(action, path, destination string, err error) HistoryRecord {
+	record := HistoryRecord{
+		At:          time.Now(),
+		Action:      action,
+		Path:        path,
+		Destination: destination,
+		Outcome:     outcomeOK,
+	}
+	if errors.Is(err, clipboard.ErrSkipped) || errors.Is(err, clipboard.ErrAlreadyThere) {
+		record.Outcome = outcomeSkipped
+	} else if err != nil {
+		record.Outcome = outcomeFailed
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// appendHistory adds records to the end of the history log, one JSON object
+// per line. The log is only ever appended to. With --output json the records
+// are reported as results too.
+func appendHistory(records ...HistoryRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	results.write(records)
+
+	f, err := os.OpenFile(historyPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if _, err := f.WriteString(buf.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readHistory reads the history log, oldest record first
+func readHistory() ([]HistoryRecord, error) {
+	f, err := os.Open(historyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", historyPath(), lineNo, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// historyFilter selects which history records handleHistory shows
+type historyFilter struct {
+	since  time.Time
+	until  time.Time
+	path   string
+	action string
+}
+
+// parseHistoryTime parses a --since or --until value: either a duration
+// before now such as 2h, or a date such as 2024-05-01 or an RFC 3339 time
+func parseHistoryTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected a duration such as 2h, or a date such as 2006-01-02)", s)
+}
+
+// matches reports whether record passes the filter. A path matches records
+// for that path or anything under it, as source or destination.
+func (f historyFilter) matches(record HistoryRecord) bool {
+	if !f.since.IsZero() && record.At.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !record.At.Before(f.until) {
+		return false
+	}
+	if f.action != "" && record.Action != f.action {
+		return false
+	}
+	if f.path != "" && !isWithin(record.Path, f.path) && !isWithin(record.Destination, f.path) {
+		return false
+	}
+	return true
+}
+
+// isWithin reports whether path is dir or lies under it
+func isWithin(path, dir string) bool {
+	if path == "" {
+		return false
+	}
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// handleHistory prints the history records matching filter, most recent first
+func handleHistory(w io.Writer, filter historyFilter) error {
+	records, err := readHistory()
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if !filter.matches(record) {
+			continue
+		}
+		shown++
+
+		line := fmt.Sprintf("%-7s %s", record.Action, record.Path)
+		if record.Destination != "" {
+			line += " -> " + record.Destination
+		}
+		if record.Outcome != outcomeOK {
+			line += " [" + record.Outcome + "]"
+		}
+		if record.Error != "" {
+			line += ": " + record.Error
+		}
+		fmt.Fprintf(w, "%s %s\n", detailsStyle.Render(record.At.Format("2006-01-02 15:04:05")), line)
+	}
+
+	if shown == 0 {
+		fmt.Fprintln(w, "No history")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestHistoryRecordsOperations(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	copiedFile := filepath.Join(tempDir, "file2.txt")
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "missing.txt"), Options{}); err == nil {
+		t.Fatal("Expected error cutting a missing file, got nil")
+	}
+	if err := addEntry(io.Discard, copiedFile, clipboard.OpCopy, Options{}); err != nil {
+		t.Fatalf("addEntry failed: %v", err)
+	}
+	if err := handlePasteAt(io.Discard, 1, Options{dest: destDir}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	if err := handleClear(io.Discard, Options{}); err != nil {
+		t.Fatalf("handleClear failed: %v", err)
+	}
+
+	records, err := readHistory()
+	if err != nil {
+		t.Fatalf("readHistory failed: %v", err)
+	}
+
+	expected := []struct{ action, path, outcome string }{
+		{"cut", sourceFile, outcomeOK},
+		{"cut", filepath.Join(tempDir, "missing.txt"), outcomeFailed},
+		{"copy", copiedFile, outcomeOK},
+		{"paste", sourceFile, outcomeOK},
+		{"clear", copiedFile, outcomeOK},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d history records, got %+v", len(expected), records)
+	}
+	for i, want := range expected {
+		got := records[i]
+		if got.Action != want.action || got.Path != want.path || got.Outcome != want.outcome {
+			t.Errorf("Expected record %d to be %s %s (%s), got %s %s (%s)",
+				i, want.action, want.path, want.outcome, got.Action, got.Path, got.Outcome)
+		}
+	}
+	if records[3].Destination != filepath.Join(destDir, "file1.txt") {
+		t.Errorf("Expected paste destination to be recorded, got %q", records[3].Destination)
+	}
+	if records[1].Error == "" {
+		t.Error("Expected the failed cut to record its error")
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	now := time.Now()
+	records := []HistoryRecord{
+		{At: now.Add(-48 * time.Hour), Action: "cut", Path: "/home/me/old.txt", Outcome: outcomeOK},
+		{At: now.Add(-time.Hour), Action: "paste", Path: "/tmp/a.txt", Destination: "/home/me/docs/a.txt", Outcome: outcomeOK},
+		{At: now, Action: "clear", Path: "/tmp/b.txt", Outcome: outcomeOK},
+	}
+
+	tests := []struct {
+		name     string
+		filter   historyFilter
+		expected []int
+	}{
+		{"none", historyFilter{}, []int{0, 1, 2}},
+		{"since", historyFilter{since: now.Add(-2 * time.Hour)}, []int{1, 2}},
+		{"until", historyFilter{until: now.Add(-2 * time.Hour)}, []int{0}},
+		{"action", historyFilter{action: "clear"}, []int{2}},
+		{"path matches destination", historyFilter{path: "/home/me/docs"}, []int{1}},
+		{"path matches prefix only at separators", historyFilter{path: "/home/m"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for i, record := range records {
+				if tt.filter.matches(record) {
+					got = append(got, i)
+				}
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected records %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("Expected records %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.Local)
+
+	if got, err := parseHistoryTime("2h", now); err != nil || !got.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("Expected 2h before now, got %v (%v)", got, err)
+	}
+	if got, err := parseHistoryTime("2024-05-01", now); err != nil || !got.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected midnight on 2024-05-01, got %v (%v)", got, err)
+	}
+	if _, err := parseHistoryTime("yesterday", now); err == nil {
+		t.Error("Expected error for an invalid time, got nil")
+	}
+}
+
+func TestHandleHistory(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	if err := handleHistory(&buf, historyFilter{}); err != nil {
+		t.Fatalf("handleHistory failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No history") {
+		t.Errorf("Expected empty history message, got %q", buf.String())
+	}
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	buf.Reset()
+	if err := handleHistory(&buf, historyFilter{}); err != nil {
+		t.Fatalf("handleHistory failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "cut     "+sourceFile) {
+		t.Errorf("Expected the cut in the history, got %q", buf.String())
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// hookNames lists the config keys that hold hook commands. The cut hooks
+// also run for copies, with {op} telling them apart.
+var hookNames = []string{"pre_cut", "post_cut", "pre_paste", "post_paste"}
+
+// hooks holds the hook commands from the config file, by name
+var hooks = map[string]string{}
+
+// hookVars are the values substituted into a hook command
+type hookVars struct {
+	source string
+	dest   string
+	op     clipboard.Operation
+}
+
+// expandHook replaces {source}, {dest}, {name} and {op} in command with
+// shell-quoted values
+func expandHook(command string, vars hookVars) string {
+	name := ""
+	if vars.source != "" {
+		name = filepath.Base(vars.source)
+	}
+	return strings.NewReplacer(
+		"{source}", shellQuote(vars.source),
+		"{dest}", shellQuote(vars.dest),
+		"{name}", shellQuote(name),
+		"{op}", shellQuote(string(vars.op)),
+	).Replace(command)
+}
+
+// shellQuote quotes s for use as a single word in a POSIX shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runHook runs the named hook, if one is configured, with sh. Its output
+// goes to stderr so as not to mix with cx's own output, and the values are
+// also passed as CX_SOURCE, CX_DEST and CX_OP in the environment.
+func runHook(name string, vars hookVars) error {
+	command := hooks[name]
+	if command == "" {
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", expandHook(command, vars))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"CX_SOURCE="+vars.source,
+		"CX_DEST="+vars.dest,
+		"CX_OP="+string(vars.op),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
+
+// runPrePasteHook runs the pre_paste hook for pasting entry into destDir,
+// with {dest} the path it would be pasted to before any conflict is resolved
+func runPrePasteHook(entry clipboard.Entry, destDir string, opts Options) error {
+	if hooks["pre_paste"] == "" {
+		return nil
+	}
+
+	name, err := clipboard.PasteName(filepath.Base(entry.CurrentPath), opts.as)
+	if err != nil {
+		return err
+	}
+	return runHook("pre_paste", hookVars{source: entry.CurrentPath, dest: filepath.Join(destDir, name), op: pasteOp(opts.persist)})
+}
+
+// pasteOp returns what a paste does with its source: a copy if it persists,
+// otherwise a move, which hooks see as a cut
+func pasteOp(persist bool) clipboard.Operation {
+	if persist {
+		return clipboard.OpCopy
+	}
+	return clipboard.OpCut
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestExpandHook(t *testing.T) {
+	got := expandHook("echo {op} {name} {source} {dest}", hookVars{
+		source: "/tmp/it's here.txt",
+		dest:   "/home/me/it's here.txt",
+		op:     clipboard.OpCopy,
+	})
+	expected := `echo 'copy' 'it'\''s here.txt' '/tmp/it'\''s here.txt' '/home/me/it'\''s here.txt'`
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logFile := filepath.Join(tempDir, "hooks.log")
+	hooks = map[string]string{
+		"post_cut":   "echo post_cut {op} {source} >> " + shellQuote(logFile),
+		"pre_paste":  "echo pre_paste {dest} >> " + shellQuote(logFile),
+		"post_paste": "echo post_paste $CX_OP {dest} >> " + shellQuote(logFile),
+	}
+	defer func() { hooks = map[string]string{} }()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read hook log: %v", err)
+	}
+	destFile := filepath.Join(destDir, "file1.txt")
+	expected := "post_cut cut " + sourceFile + "\n" +
+		"pre_paste " + destFile + "\n" +
+		"post_paste cut " + destFile + "\n"
+	if string(content) != expected {
+		t.Errorf("Expected hook log %q, got %q", expected, content)
+	}
+}
+
+func TestPreHookFailureAborts(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	hooks = map[string]string{"pre_cut": "exit 1", "pre_paste": "test {name} != file2.txt"}
+	defer func() { hooks = map[string]string{} }()
+
+	err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{})
+	if err == nil || !strings.Contains(err.Error(), "pre_cut hook failed") {
+		t.Fatalf("Expected the pre_cut hook to fail the cut, got: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 0 {
+		t.Fatalf("Expected nothing added to the clipboard, got %+v", board.Entries)
+	}
+
+	delete(hooks, "pre_cut")
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	// the hook refuses file2.txt, which stays in the clipboard
+	if err := handlePasteAll(io.Discard, Options{dest: destDir}); err == nil {
+		t.Fatal("Expected error from the pre_paste hook, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); err != nil {
+		t.Errorf("Expected file1.txt to be pasted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file2.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected file2.txt not to be pasted, got: %v", err)
+	}
+
+	board, err = readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || filepath.Base(board.Entries[0].CurrentPath) != "file2.txt" {
+		t.Errorf("Expected file2.txt left in the clipboard, got %+v", board.Entries)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// interruptible returns a context that's canceled by Ctrl-C or SIGTERM, so a
+// paste stops cleanly instead of dying mid-copy. Once it's canceled signals
+// are delivered as usual again, so a second Ctrl-C kills cx outright.
+func interruptible(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// interrupted reports whether the paste has been interrupted
+func (opts Options) interrupted() bool {
+	return opts.ctx != nil && opts.ctx.Err() != nil
+}
+
+// interruptError is an error saying what an interruption left behind, which
+// still counts as context.Canceled
+type interruptError struct {
+	message string
+}
+
+func (e interruptError) Error() string { return e.message }
+func (e interruptError) Unwrap() error { return context.Canceled }
+
+// interruptedError explains that pasting entry was interrupted and what's
+// been kept: the entry itself and, for a directory, what was copied so far
+func interruptedError(err error, entry clipboard.Entry) error {
+	if !errors.Is(err, context.Canceled) {
+		return err
+	}
+	if info, statErr := os.Stat(entry.CurrentPath); statErr == nil && info.IsDir() {
+		return interruptError{fmt.Sprintf("interrupted, %s is still on the clipboard (paste it again with --resume to carry on)", entry.CurrentPath)}
+	}
+	return interruptError{fmt.Sprintf("interrupted, %s is still on the clipboard", entry.CurrentPath)}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestPasteInterrupted(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("copyFileToClipboard failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := Options{dest: destDir, noReflink: true, ctx: ctx}
+
+	err := handlePasteAt(io.Discard, 0, opts)
+	if err == nil || !strings.Contains(err.Error(), "interrupted") {
+		t.Errorf("Expected the paste to be interrupted, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(destDir, "file2.txt")); !os.IsNotExist(err) {
+		t.Error("Expected no partial copy to be left behind")
+	}
+
+	err = handlePasteAll(io.Discard, opts)
+	if err == nil || !strings.Contains(err.Error(), "2 of 2 entries left") {
+		t.Errorf("Expected both entries to be left on the clipboard, got %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if len(board.Entries) != 2 {
+		t.Errorf("Expected 2 entries still on the clipboard, got %d", len(board.Entries))
+	}
+}
+
+// cancelOnRead cancels a paste when it's asked a question
+type cancelOnRead struct {
+	cancel context.CancelFunc
+}
+
+func (r cancelOnRead) Read([]byte) (int, error) {
+	r.cancel()
+	return 0, io.EOF
+}
+
+func TestPasteInterruptedCountsLeft(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file2.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	destDir, restore := setupConflict(t, tempDir)
+	defer restore()
+
+	// file1.txt is pasted, though its post_paste hook fails, and the paste is
+	// interrupted before file2.txt
+	hooks = map[string]string{"post_paste": "exit 1"}
+	defer func() { hooks = map[string]string{} }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer withConfirm("")()
+	stdin = io.MultiReader(cancelOnRead{cancel}, strings.NewReader("y\n"))
+
+	err := handlePasteAll(io.Discard, Options{dest: destDir, onConflict: clipboard.ConflictOverwrite, ctx: ctx})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 entries left") {
+		t.Errorf("Expected only file2.txt to be left on the clipboard, got %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if len(board.Entries) != 1 || filepath.Base(board.Entries[0].CurrentPath) != "file2.txt" {
+		t.Errorf("Expected file2.txt still on the clipboard, got %+v", board.Entries)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// maxJournalRecords is how many completed pastes are kept for undo
+const maxJournalRecords = 50
+
+// JournalRecord describes a completed paste so that it can be undone
+type JournalRecord struct {
+	Op          clipboard.Operation `json:"operation"`
+	Source      string              `json:"source"`
+	Destination string              `json:"destination"`
+	Entry       clipboard.Entry     `json:"entry"`
+	At          time.Time           `json:"timestamp"`
+	Checksum    string              `json:"checksum,omitempty"`
+	// Into is set when the paste was an update into a destination that was
+	// already there, which can't be undone by deleting it
+	Into bool `json:"into,omitempty"`
+	// Overwrote is set when the paste replaced what was at Destination, and
+	// Backup to where that was kept with --backup
+	Overwrote bool   `json:"overwrote,omitempty"`
+	Backup    string `json:"backup,omitempty"`
+}
+
+// Journal is the undo history, oldest record first
+type Journal struct {
+	Records []JournalRecord `json:"records"`
+}
+
+// siblingPath returns the path of a file kept next to the clipboard file
+// and sharing its name, e.g. clipboard.journal.json for ".journal.json"
+func siblingPath(suffix string) string {
+	dir := filepath.Dir(clipboardPath)
+	base := strings.TrimSuffix(filepath.Base(clipboardPath), filepath.Ext(clipboardPath))
+	return filepath.Join(dir, base+suffix)
+}
+
+// journalPath returns the path of the journal
+func journalPath() string {
+	return siblingPath(".journal.json")
+}
+
+// readJournal reads the journal, returning an empty one if it doesn't exist yet
+func readJournal() (Journal, error) {
+	var journal Journal
+
+	journalJSON, err := os.ReadFile(journalPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		return journal, err
+	}
+
+	err = json.Unmarshal(journalJSON, &journal)
+	return journal, err
+}
+
+// writeJournal writes the journal, dropping the oldest records beyond the limit
+func writeJournal(journal Journal) error {
+	if extra := len(journal.Records) - maxJournalRecords; extra > 0 {
+		journal.Records = journal.Records[extra:]
+	}
+
+	journalJSON, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fsops.WriteFileAtomic(journalPath(), journalJSON, 0o644)
+}
+
+// updateJournal changes the journal with fn under the clipboard lock
+func updateJournal(fn func(*Journal) error) error {
+	return withClipboardLock(func() error {
+		journal, err := readJournal()
+		if err != nil {
+			return err
+		}
+		if err := fn(&journal); err != nil {
+			return err
+		}
+		return writeJournal(journal)
+	})
+}
+
+// recordPaste appends a completed paste of entry to the journal, along with
+// the checksum of the result if it was verified. Downloads of remote entries
+// can't be undone, so they aren't recorded.
+func recordPaste(entry clipboard.Entry, pasted clipboard.Pasted, copied bool) error {
+	if isRemoteEntry(entry) {
+		return nil
+	}
+
+	op := clipboard.OpCut
+	if copied {
+		op = clipboard.OpCopy
+	}
+
+	return updateJournal(func(journal *Journal) error {
+		journal.Records = append(journal.Records, JournalRecord{
+			Op:          op,
+			Source:      entry.CurrentPath,
+			Destination: pasted.Path,
+			Entry:       entry,
+			At:          time.Now(),
+			Checksum:    pasted.Checksum,
+			Into:        pasted.Into,
+			Overwrote:   pasted.Overwrote,
+			Backup:      pasted.Backup,
+		})
+		return nil
+	})
+}
+
+// handleUndo reverses the most recent paste recorded in the journal. A move
+// is put back and its entry returned to the clipboard; a copy is deleted.
+// What the paste overwrote is put back if it was kept with --backup. An
+// update into an existing destination can't be told apart from what was
+// there before, so it's dropped from the journal rather than undone.
+func handleUndo(w io.Writer, opts Options) error {
+	journal, err := readJournal()
+	if err != nil {
+		return err
+	}
+
+	if len(journal.Records) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+
+	record := journal.Records[len(journal.Records)-1]
+
+	if record.Into {
+		if err := dropJournalRecord(record); err != nil {
+			return err
+		}
+		return fmt.Errorf("cannot undo the update of %s, which was there before the paste; dropped it from the undo history", record.Destination)
+	}
+
+	if _, err := os.Lstat(record.Destination); err != nil {
+		return fmt.Errorf("pasted path no longer exists: %s", record.Destination)
+	}
+
+	if record.Op == clipboard.OpCopy {
+		if err := os.RemoveAll(record.Destination); err != nil {
+			return err
+		}
+	} else {
+		if _, err := os.Lstat(record.Source); err == nil {
+			return fmt.Errorf("cannot undo move, %s already exists", record.Source)
+		}
+
+		if err := fsops.Move(record.Destination, record.Source, fsops.Options{}); err != nil {
+			return err
+		}
+	}
+
+	err = updateClipboard(func(board *clipboard.Clipboard) error {
+		if record.Op != clipboard.OpCopy {
+			board.Entries = append([]clipboard.Entry{record.Entry}, board.Entries...)
+			return nil
+		}
+		// a persistent paste points its entry at the copy, so point it back
+		for i, entry := range board.Entries {
+			if entry.CurrentPath == record.Destination {
+				board.Entries[i].CurrentPath = record.Source
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := dropJournalRecord(record); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if record.Op == clipboard.OpCopy {
+		fmt.Fprintf(w, "Removed copy: %s\n", record.Destination)
+	} else {
+		fmt.Fprintf(w, "Moved back: %s -> %s\n", record.Destination, record.Source)
+	}
+
+	switch {
+	case record.Backup != "":
+		if err := os.Rename(record.Backup, record.Destination); err != nil {
+			return fmt.Errorf("failed to put back what the paste overwrote: %w", err)
+		}
+		fmt.Fprintf(w, "Put back: %s -> %s\n", record.Backup, record.Destination)
+	case record.Overwrote:
+		fmt.Fprintf(w, "Warning: what the paste overwrote at %s was deleted and can't be put back (paste with --backup to keep it)\n", record.Destination)
+	}
+	return nil
+}
+
+// dropJournalRecord removes record from the journal
+func dropJournalRecord(record JournalRecord) error {
+	return updateJournal(func(journal *Journal) error {
+		journal.Records = slices.DeleteFunc(journal.Records, func(r JournalRecord) bool {
+			return r.Destination == record.Destination && r.At.Equal(record.At)
+		})
+		return nil
+	})
+}
+
+// handleUndoList shows the undo history, most recent first
+func handleUndoList(w io.Writer) error {
+	journal, err := readJournal()
+	if err != nil {
+		return err
+	}
+
+	if len(journal.Records) == 0 {
+		fmt.Fprintln(w, "Nothing to undo")
+		return nil
+	}
+
+	for i := len(journal.Records) - 1; i >= 0; i-- {
+		record := journal.Records[i]
+		verb := "Moved"
+		if record.Into {
+			verb = "Updated"
+		} else if record.Op == clipboard.OpCopy {
+			verb = "Copied"
+		}
+		fmt.Fprintf(w, "%s: %s -> %s %s\n", verb, record.Source, record.Destination,
+			detailsStyle.Render(FormatCutAtTime(record.At)))
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestUndoMove(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	destDir := filepath.Join(tempDir, "destination")
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(destDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	if err := handlePasteAt(io.Discard, 0, Options{}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if err := handleUndo(io.Discard, Options{}); err != nil {
+		t.Fatalf("handleUndo failed: %v", err)
+	}
+
+	if _, err := os.Stat(sourceFile); os.IsNotExist(err) {
+		t.Errorf("File was not moved back to %s", sourceFile)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); !os.IsNotExist(err) {
+		t.Errorf("Pasted file still exists after undo")
+	}
+
+	// The entry is back in the clipboard
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != sourceFile {
+		t.Errorf("Expected entry for %s to be restored, got %+v", sourceFile, board.Entries)
+	}
+
+	// Nothing left to undo
+	if err := handleUndo(io.Discard, Options{}); err == nil {
+		t.Error("Expected error with empty undo history, got nil")
+	}
+}
+
+func TestUndoCopy(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	destDir := filepath.Join(tempDir, "destination")
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	if err := os.Chdir(destDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	if err := handlePasteAt(io.Discard, 0, Options{persist: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if err := handleUndo(io.Discard, Options{}); err != nil {
+		t.Fatalf("handleUndo failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); !os.IsNotExist(err) {
+		t.Errorf("Copy still exists after undo")
+	}
+	if _, err := os.Stat(sourceFile); os.IsNotExist(err) {
+		t.Errorf("Source was removed by undoing a copy")
+	}
+
+	// The persisted entry points back at the source
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != sourceFile {
+		t.Errorf("Expected entry to point at %s, got %+v", sourceFile, board.Entries)
+	}
+}
+
+func TestUndoUpdate(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceDir := filepath.Join(tempDir, "config")
+	destDir := filepath.Join(tempDir, "destination")
+	precious := filepath.Join(destDir, "config", "precious")
+
+	if err := os.MkdirAll(filepath.Dir(precious), 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	if err := os.WriteFile(precious, []byte("was here first"), 0o644); err != nil {
+		t.Fatalf("Failed to create %s: %v", precious, err)
+	}
+
+	if err := copyFileToClipboard(io.Discard, sourceDir, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir, update: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	// the update can't be undone without deleting precious, so it isn't
+	if err := handleUndo(io.Discard, Options{}); err == nil {
+		t.Error("Expected undoing an update to fail, got nil")
+	}
+	if _, err := os.Stat(precious); err != nil {
+		t.Errorf("Undo removed a file that was there before the paste: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "config", "settings.json")); err != nil {
+		t.Errorf("Undo removed the updated files: %v", err)
+	}
+
+	// and it's out of the way of undoing earlier pastes
+	journal, err := readJournal()
+	if err != nil {
+		t.Fatalf("readJournal failed: %v", err)
+	}
+	if len(journal.Records) != 0 {
+		t.Errorf("Expected the update to be dropped from the journal, got %+v", journal.Records)
+	}
+}
+
+func TestUndoOverwrite(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	destDir := filepath.Join(tempDir, "destination")
+	dest := filepath.Join(destDir, "file1.txt")
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	if err := os.WriteFile(dest, []byte("was here first"), 0o644); err != nil {
+		t.Fatalf("Failed to create %s: %v", dest, err)
+	}
+
+	backupSuffix = "~"
+	defer func() { backupSuffix = "" }()
+
+	if err := copyFileToClipboard(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	opts := Options{dest: destDir, onConflict: clipboard.ConflictOverwrite}
+	if err := handlePasteAt(io.Discard, 0, opts); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	if data, err := os.ReadFile(dest + "~"); err != nil || string(data) != "was here first" {
+		t.Fatalf("Expected the backup to hold the old file, got %q (%v)", data, err)
+	}
+
+	// undoing the paste puts the backup back where it was
+	if err := handleUndo(io.Discard, Options{}); err != nil {
+		t.Fatalf("handleUndo failed: %v", err)
+	}
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "was here first" {
+		t.Errorf("Expected undo to put back the overwritten file, got %q (%v)", data, err)
+	}
+	if _, err := os.Lstat(dest + "~"); !os.IsNotExist(err) {
+		t.Errorf("Expected the backup to be gone, got: %v", err)
+	}
+}
+
+func TestJournalLimit(t *testing.T) {
+	_, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for i := 0; i < maxJournalRecords+5; i++ {
+		if err := recordPaste(clipboard.Entry{CurrentPath: "/src"}, clipboard.Pasted{Path: "/dest"}, true); err != nil {
+			t.Fatalf("recordPaste failed: %v", err)
+		}
+	}
+
+	journal, err := readJournal()
+	if err != nil {
+		t.Fatalf("readJournal failed: %v", err)
+	}
+	if len(journal.Records) != maxJournalRecords {
+		t.Errorf("Expected %d records, got %d", maxJournalRecords, len(journal.Records))
+	}
+}
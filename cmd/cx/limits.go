@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// limits holds the clipboard limits from the config file
+var limits clipboard.Limits
+
+// entrySize measures an entry for the max_size limit, using the size cache
+// so a full clipboard's directories aren't walked on every cut
+func entrySize(entry clipboard.Entry) int64 {
+	return measureEntries([]clipboard.Entry{entry})[entry.CurrentPath].Size
+}
+
+// explainLimit adds to a LimitError how to get under the limit
+func explainLimit(err error) error {
+	var limitErr *clipboard.LimitError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+	key := "max_size"
+	if limitErr.Entries {
+		key = "max_entries"
+	}
+	return fmt.Errorf("%w (drop some entries or raise %s)", err, key)
+}
+
+// printEvicted reports the entries dropped to keep the clipboard within its
+// limits
+func printEvicted(w io.Writer, evicted []clipboard.Entry) {
+	for _, entry := range evicted {
+		fmt.Fprintf(w, "Evicted: %s (the clipboard is full)\n", entry.CurrentPath)
+	}
+}
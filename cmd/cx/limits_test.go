@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestClipboardLimits(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	limits = clipboard.Limits{MaxEntries: 2, Evict: true}
+	defer func() { limits = clipboard.Limits{} }()
+
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	third := filepath.Join(tempDir, "nested", "file3.txt")
+	if err := cutFile(&out, third, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Evicted: "+filepath.Join(tempDir, "file1.txt")) {
+		t.Errorf("Expected the oldest entry reported as evicted, got %q", out.String())
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].CurrentPath != third {
+		t.Fatalf("Expected the two newest entries, got %+v", board.Entries)
+	}
+
+	limits.Evict = false
+	err = cutFile(io.Discard, filepath.Join(tempDir, "config"), Options{})
+	if err == nil || !strings.Contains(err.Error(), "raise max_entries") {
+		t.Fatalf("Expected the cut refused, got: %v", err)
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 2 {
+		t.Errorf("Expected the clipboard left alone, got %+v", board.Entries)
+	}
+}
+
+func TestConfigLimits(t *testing.T) {
+	cfg := &Config{values: make(map[string]string)}
+	for key, value := range map[string]string{"max_entries": "50", "max_size": "2GB", "on_limit": "refuse"} {
+		if err := cfg.Set(key, value); err != nil {
+			t.Fatalf("Set %s failed: %v", key, err)
+		}
+	}
+	got := cfg.limits()
+	if got.MaxEntries != 50 || got.MaxSize != 2_000_000_000 || got.Evict {
+		t.Errorf("Expected 50 entries, 2GB and refusing, got %+v", got)
+	}
+
+	for key, value := range map[string]string{"max_entries": "-1", "max_size": "lots", "on_limit": "panic"} {
+		if err := cfg.Set(key, value); err == nil {
+			t.Errorf("Expected %s: %s to be rejected", key, value)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// localDir is the directory a project keeps its own clipboard in
+const localDir = ".cx"
+
+// localClipboard is set by --local to use the project's clipboard instead
+// of the global one
+var localClipboard bool
+
+// findLocalClipboard returns the path of the project clipboard for dir: the
+// clipboard in the nearest .cx directory at or above dir, or in a new one at
+// the nearest git root. The files in a new .cx directory are kept out of git.
+func findLocalClipboard(dir string) (string, error) {
+	for current := dir; ; {
+		cxDir := filepath.Join(current, localDir)
+		if info, err := os.Stat(cxDir); err == nil && info.IsDir() {
+			return filepath.Join(cxDir, "clipboard.json"), nil
+		}
+
+		// .git is a file rather than a directory in worktrees and submodules
+		if _, err := os.Lstat(filepath.Join(current, ".git")); err == nil {
+			if err := os.Mkdir(cxDir, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+				return "", err
+			}
+			if err := os.WriteFile(filepath.Join(cxDir, ".gitignore"), []byte("*\n"), 0o644); err != nil {
+				return "", err
+			}
+			return filepath.Join(cxDir, "clipboard.json"), nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", fmt.Errorf("--local needs a git repository or a %s directory in %s or above", localDir, dir)
+		}
+		current = parent
+	}
+}
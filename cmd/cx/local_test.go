@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindLocalClipboard(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	nested := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+
+	// The git root gets a .cx directory, kept out of git
+	path, err := findLocalClipboard(nested)
+	if err != nil {
+		t.Fatalf("findLocalClipboard failed: %v", err)
+	}
+	if expected := filepath.Join(repo, ".cx", "clipboard.json"); path != expected {
+		t.Errorf("Expected %s, got %s", expected, path)
+	}
+	if data, err := os.ReadFile(filepath.Join(repo, ".cx", ".gitignore")); err != nil || string(data) != "*\n" {
+		t.Errorf("Expected .cx/.gitignore ignoring everything, got %q (%v)", data, err)
+	}
+
+	// A nearer .cx directory wins over the git root
+	if err := os.Mkdir(filepath.Join(repo, "src", ".cx"), 0o755); err != nil {
+		t.Fatalf("Failed to create .cx: %v", err)
+	}
+	path, err = findLocalClipboard(nested)
+	if err != nil {
+		t.Fatalf("findLocalClipboard failed: %v", err)
+	}
+	if expected := filepath.Join(repo, "src", ".cx", "clipboard.json"); path != expected {
+		t.Errorf("Expected %s, got %s", expected, path)
+	}
+
+	if _, err := findLocalClipboard(root); err == nil {
+		t.Error("Expected error outside a project, got nil")
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+// withClipboardLock runs fn holding the clipboard store's lock, for the
+// files kept beside the clipboard, such as the journal, that are read,
+// changed and written back. fn mustn't change the clipboard itself, which
+// takes the same lock; see updateClipboard.
+func withClipboardLock(fn func() error) error {
+	unlock, err := clipboardStore().Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+	"golang.org/x/sys/unix"
+)
+
+func TestWithClipboardLock(t *testing.T) {
+	_, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// A second open file description can't take the lock while it's held
+	var f *os.File
+	err := withClipboardLock(func() error {
+		var err error
+		if f, err = os.Open(clipboard.NewFileStore(clipboardPath).LockPath()); err != nil {
+			t.Fatalf("Failed to open lock file: %v", err)
+		}
+		return unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	})
+	defer f.Close()
+	if !errors.Is(err, unix.EWOULDBLOCK) {
+		t.Fatalf("Expected lock to be held, got: %v", err)
+	}
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		t.Fatalf("Expected lock to be free after release, got: %v", err)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	path := filepath.Join(tempDir, "file1.txt")
+	if err := fsops.WriteFileAtomic(path, []byte("replaced"), 0o600); err != nil {
+		t.Fatalf("writeFileAtomic failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "replaced" {
+		t.Errorf("Expected replaced content, got %q", content)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// No temporary files are left behind
+	matches, _ := filepath.Glob(filepath.Join(tempDir, ".file1.txt.*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("Temporary files left behind: %v", matches)
+	}
+}
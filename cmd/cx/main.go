@@ -2,44 +2,267 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
 	"github.com/spf13/cobra"
 )
 
 // configuration
 var clipboardPath string
+var defaultClipboardPath string
+var storeKind string
+var stackMode bool
 
 func init() {
-	homeDir, err := os.UserHomeDir()
+	dir, err := stateDir()
 	if err != nil {
 		log.Fatal(err)
 	}
-	defaultClipboardPath := filepath.Join(homeDir, ".cx_clipboard.json")
+	defaultClipboardPath = filepath.Join(dir, "clipboard.json")
 
 	rootCmd.PersistentFlags().StringVar(&clipboardPath, "clipboard", defaultClipboardPath, "path to the clipboard file")
-	rootCmd.Flags().BoolP("quiet", "q", false, "suppress all output, except errors")
+	rootCmd.PersistentFlags().BoolVar(&localClipboard, "local", false, "use the project's clipboard in .cx/ at the nearest git root instead of the global one")
+	rootCmd.PersistentFlags().StringVar(&storeKind, "store", "file", "where to keep the clipboard: file, sqlite, or daemon for a running cx daemon")
+	rootCmd.RegisterFlagCompletionFunc("store", cobra.FixedCompletions(storeKinds, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.PersistentFlags().BoolVar(&confirm, "confirm", false, "ask before overwriting, moving across filesystems or clearing the clipboard")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to --confirm questions, for scripts")
+	rootCmd.PersistentFlags().IntVar(&confirmClearOver, "confirm-clear-over", 0, "with --confirm, only ask before clearing more than this many entries")
+	rootCmd.PersistentFlags().BoolVar(&stackMode, "stack", false, "treat the clipboard as a stack: pasting an entry always removes it, like popd")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "when to use colors: auto (not when piped or $NO_COLOR is set), always or never")
+	rootCmd.RegisterFlagCompletionFunc("color", cobra.FixedCompletions(colorModes, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "suppress all output, except errors")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "list every file copied, with timings and byte counts")
+	rootCmd.PersistentFlags().StringVar(&outputMode, "output", outputText, "text, or json for a JSON result per entry cut, copied, pasted or cleared")
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputModes, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.PersistentFlags().DurationVar(&expireAfter, "expire-after", 0, "drop entries older than this, e.g. 24h (0 keeps them forever)")
+	rootCmd.Flags().Bool("sys", false, "also put the path on the system clipboard")
+	rootCmd.Flags().Bool("stdin", false, "read paths from stdin, one per line or NUL-separated")
+	rootCmd.Flags().BoolP("force", "f", false, "replace entries already in the clipboard for the same paths")
+	rootCmd.Flags().StringSlice("tag", nil, "label the entries, e.g. --tag work (repeatable)")
+	rootCmd.Flags().Bool("from-stdin", false, "save what's piped in as a file and cut that, to paste it somewhere")
+	rootCmd.Flags().String("name", "stdin", "with --from-stdin, the name of the file")
+
+	rootCmd.AddCommand(cutCmd)
+	cutCmd.Flags().Bool("sys", false, "also put the path on the system clipboard")
+	cutCmd.Flags().Bool("stdin", false, "read paths from stdin, one per line or NUL-separated")
+	cutCmd.Flags().BoolP("force", "f", false, "replace entries already in the clipboard for the same paths")
+	cutCmd.Flags().StringSlice("tag", nil, "label the entries, e.g. --tag work (repeatable)")
+
+	rootCmd.AddCommand(copyCmd)
+	copyCmd.Flags().Bool("sys", false, "also put the path on the system clipboard")
+	copyCmd.Flags().Bool("stdin", false, "read paths from stdin, one per line or NUL-separated")
+	copyCmd.Flags().BoolP("force", "f", false, "replace entries already in the clipboard for the same paths")
+	copyCmd.Flags().StringSlice("tag", nil, "label the entries, e.g. --tag work (repeatable)")
+
+	rootCmd.AddCommand(dupCmd)
+	dupCmd.Flags().StringSlice("preserve", nil, "attributes to keep on copies: mode, timestamps, ownership or all")
+	dupCmd.Flags().Lookup("preserve").NoOptDefVal = "all"
+	dupCmd.RegisterFlagCompletionFunc("preserve", cobra.FixedCompletions(fsops.AttrNames, cobra.ShellCompDirectiveNoFileComp))
+	dupCmd.Flags().Bool("no-reflink", false, "always copy file data, even where the filesystem supports copy-on-write clones")
+
+	rootCmd.AddCommand(swapCmd)
+
+	rootCmd.AddCommand(grabCmd)
+	grabCmd.Flags().StringP("exec", "e", "", "shell command whose output lists the paths to add")
+	grabCmd.Flags().Bool("copy", false, "add the files as copies instead of cuts")
+	grabCmd.Flags().BoolP("force", "f", false, "replace entries already in the clipboard for the same paths")
+	grabCmd.Flags().StringSlice("tag", nil, "label the entries, e.g. --tag work (repeatable)")
+
+	rootCmd.AddCommand(importSysCmd)
+	importSysCmd.Flags().Bool("cut", false, "add the files as cuts instead of copies")
+	importSysCmd.Flags().BoolP("force", "f", false, "replace entries already in the clipboard for the same paths")
+	importSysCmd.Flags().StringSlice("tag", nil, "label the entries, e.g. --tag work (repeatable)")
 
 	rootCmd.AddCommand(pasteCmd)
 	pasteCmd.Flags().BoolP("persist", "p", false, "keep file at original path after paste")
-	pasteCmd.Flags().BoolP("quiet", "q", false, "suppress all output, except errors")
+	pasteCmd.Flags().IntP("index", "i", 0, "index of the clipboard entry to paste")
+	pasteCmd.RegisterFlagCompletionFunc("index", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeEntryIndices(nil), cobra.ShellCompDirectiveNoFileComp
+	})
+	pasteCmd.Flags().BoolP("all", "a", false, "paste every clipboard entry")
+	pasteCmd.Flags().BoolP("select", "s", false, "choose the entry to paste with a fuzzy finder")
+	pasteCmd.Flags().String("on-conflict", "", "what to do when the destination exists: overwrite, skip, rename or prompt")
+	pasteCmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions(clipboard.ConflictStrategies, cobra.ShellCompDirectiveNoFileComp))
+	pasteCmd.Flags().StringVar(&backupSuffix, "backup", "", "with --on-conflict overwrite, keep what's overwritten as name~, or with the suffix given (timestamp adds the time)")
+	pasteCmd.Flags().Lookup("backup").NoOptDefVal = "~"
+	pasteCmd.Flags().StringSlice("preserve", nil, "attributes to keep on copies: mode, timestamps, ownership or all")
+	pasteCmd.Flags().Lookup("preserve").NoOptDefVal = "all"
+	pasteCmd.RegisterFlagCompletionFunc("preserve", cobra.FixedCompletions(fsops.AttrNames, cobra.ShellCompDirectiveNoFileComp))
+	pasteCmd.Flags().Bool("preserve-owner", false, "as root, give copies the owner and group of their sources, throughout directories")
+	pasteCmd.Flags().Bool("devices", false, "as root, recreate device nodes inside copied directories instead of skipping them")
+	pasteCmd.Flags().StringSlice("exclude", nil, "gitignore-style pattern to leave out when copying a directory (repeatable)")
+	pasteCmd.Flags().Bool("gitignore", false, "leave out files ignored by .gitignore files when copying a directory")
+	pasteCmd.Flags().Bool("verify", false, "checksum the source and the pasted result, failing on a mismatch")
+	pasteCmd.Flags().Bool("no-reflink", false, "always copy file data, even where the filesystem supports copy-on-write clones")
+	pasteCmd.Flags().Bool("progress", false, "show copy progress even for small or moved entries")
+	pasteCmd.Flags().BoolP("update", "u", false, "copy into an existing destination, skipping files whose size and modification time already match")
+	pasteCmd.Flags().Bool("checksum", false, "with --update, compare files by their contents instead")
+	pasteCmd.Flags().Bool("mirror", false, "make the copy of a directory entry an exact mirror, deleting what the entry doesn't have (always asks first, even with --yes)")
+	pasteCmd.Flags().Bool("delete-extras", false, "with --mirror, delete what the entry doesn't have without asking, for scripts")
+	pasteCmd.Flags().String("bwlimit", "", "limit copying to this many bytes a second, e.g. 10M or 500KiB")
+	pasteCmd.Flags().StringP("to", "t", "", "directory to paste into instead of the current directory")
+	pasteCmd.MarkFlagDirname("to")
+	pasteCmd.Flags().Bool("mkdir", false, "create the destination directory if it doesn't exist")
+	pasteCmd.Flags().BoolP("dry-run", "n", false, "show what would be pasted without touching any files")
+	pasteCmd.Flags().String("archive", "", "pack the entries into an archive with this name (.tar, .tar.gz, .tgz or .zip) instead of pasting them")
+	pasteCmd.Flags().Bool("zip", false, "with --archive, write a zip, adding .zip to the name if needed")
+	pasteCmd.Flags().BoolP("extract", "x", false, "unpack .tar, .tar.gz, .tgz or .zip entries instead of pasting the archive")
+	pasteCmd.Flags().Bool("flatten", false, "paste the files inside directory entries without the directories around them")
+	pasteCmd.Flags().Bool("follow-symlinks", false, "paste what symlinks point to instead of the links")
+	pasteCmd.Flags().Bool("rewrite-relative", false, "adjust relative symlink targets so they still resolve from the destination")
+	pasteCmd.Flags().Bool("hardlink", false, "make copies hard links to the source files instead, on the same filesystem")
+	pasteCmd.Flags().Bool("template", false, "paste a copy with {{key}} placeholders in names and text files filled in from --set")
+	pasteCmd.Flags().StringArray("set", nil, "with --template, a placeholder value as key=value (repeatable)")
+	pasteCmd.Flags().String("tag", "", "paste the most recent entry with this tag, or with --all every one")
+	pasteCmd.Flags().String("preserve-root", "", "recreate each entry's directories relative to this root at the destination, like rsync -R")
+	pasteCmd.Flags().Bool("resume", false, "carry on with an interrupted directory paste, skipping files it already copied")
+	pasteCmd.Flags().Bool("sudo", false, "if the paste is denied permission, redo it as root with sudo")
+	pasteCmd.Flags().String("rename", "", "rename entries as they're pasted, with s/pattern/replacement/[gi] or a template like {{.Stem}}-{{.N}}{{.Ext}}")
+	pasteCmd.Flags().String("as", "", "paste under a different name; a name ending in .* keeps the original extension")
+	pasteCmd.Flags().Bool("stdout", false, "write the entry's contents to stdout instead, leaving it in place, like cx cat")
+	pasteCmd.Flags().String("at", "", "paste later instead, at a time such as 22:00 or \"2006-01-02 15:04\"")
+	pasteCmd.Flags().Duration("after", 0, "paste later instead, once this long has passed, e.g. 2h")
+	// what a scheduled paste was scheduled for, see writePins
+	pasteCmd.Flags().String("pinned", "", "paste the entries saved in this file by --at or --after")
+	pasteCmd.Flags().MarkHidden("pinned")
 
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().BoolP("detailed", "d", false, "show detailed file information")
-	listCmd.Flags().Bool("json", false, "output clipboard as JSON")
+	listCmd.Flags().Bool("json", false, "output clipboard as JSON (same as --format json)")
+	listCmd.Flags().String("format", "plain", "output format: plain, json or tsv")
+	listCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(listFormats, cobra.ShellCompDirectiveNoFileComp))
+	listCmd.Flags().BoolP("all", "a", false, "include expired entries")
+	listCmd.Flags().BoolP("tree", "t", false, "expand directory entries into a tree of their contents")
+	listCmd.Flags().Int("depth", 2, "with --tree, how many levels of each directory to show")
+	listCmd.Flags().Bool("sizes", false, "show the file count and total size of each entry, and of the whole clipboard")
+	listCmd.Flags().String("tag", "", "only list entries with this tag")
+	listCmd.Flags().Bool("relative", false, "show paths relative to the current directory")
+	listCmd.Flags().Bool("basename", false, "show only the name of each entry")
+	listCmd.Flags().String("sort", "", "order entries by time, size, name or type instead of clipboard order")
+	listCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions(listSorts, cobra.ShellCompDirectiveNoFileComp))
+	listCmd.Flags().BoolP("reverse", "r", false, "reverse the order")
+	listCmd.Flags().String("columns", "", "columns to show, in order: "+strings.Join(listColumns, ","))
+
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().Duration("older-than", 0, "prune entries older than this instead of the --expire-after age")
+	pruneCmd.Flags().Bool("missing", false, "prune entries whose files no longer exist")
+
+	rootCmd.AddCommand(pickCmd)
+	pickCmd.Flags().String("on-conflict", "", "what to do when the destination exists: overwrite, skip, rename or prompt")
+	pickCmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions(clipboard.ConflictStrategies, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(moveCmd)
+
+	rootCmd.AddCommand(topCmd)
+
+	rootCmd.AddCommand(popCmd)
+	popCmd.Flags().String("on-conflict", "", "what to do when the destination exists: overwrite, skip, rename or prompt")
+	popCmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions(clipboard.ConflictStrategies, cobra.ShellCompDirectiveNoFileComp))
+	popCmd.Flags().Bool("mkdir", false, "create the destination directory if it doesn't exist")
+	popCmd.Flags().BoolP("dry-run", "n", false, "show what would be pasted without touching any files")
+
+	rootCmd.AddCommand(rotateCmd)
+	rotateCmd.Flags().BoolP("reverse", "r", false, "rotate the other way, bringing the oldest entries to the top")
+
+	rootCmd.AddCommand(statusCmd)
+
+	rootCmd.AddCommand(repairCmd)
+
+	rootCmd.AddCommand(findCmd)
+	findCmd.Flags().BoolP("regex", "r", false, "treat the pattern as a regular expression")
+	findCmd.Flags().Bool("paste-first", false, "paste the best match instead of listing matches")
+	findCmd.Flags().StringP("to", "t", "", "with --paste-first, directory to paste into instead of the current directory")
+	findCmd.MarkFlagDirname("to")
+	findCmd.Flags().BoolP("dry-run", "n", false, "with --paste-first, show what would be pasted without pasting")
+	findCmd.Flags().String("on-conflict", "", "what to do when the destination exists: overwrite, skip, rename or prompt")
+	findCmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions(clipboard.ConflictStrategies, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(noteCmd)
+	noteCmd.Flags().Bool("clear", false, "remove the entry's note")
+
+	rootCmd.AddCommand(catCmd)
+
+	rootCmd.AddCommand(peekCmd)
+	peekCmd.Flags().IntP("lines", "n", 10, "how many lines of a text file to show")
+	peekCmd.Flags().Int("depth", 2, "how many levels of a directory to show")
+
+	rootCmd.AddCommand(dropCmd)
+
+	rootCmd.AddCommand(deleteCmd)
+
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().BoolP("list", "l", false, "show the deleted entries that can be restored")
+
+	rootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().BoolP("list", "l", false, "show the undo history instead of undoing")
+
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().String("since", "", "only show operations since this time: a duration such as 2h, or a date such as 2006-01-02")
+	historyCmd.Flags().String("until", "", "only show operations before this time, in the same forms as --since")
+	historyCmd.Flags().String("path", "", "only show operations on this path or anything under it")
+	historyCmd.Flags().String("op", "", "only show one operation: cut, copy, paste, clear, delete or restore")
+	historyCmd.RegisterFlagCompletionFunc("op", cobra.FixedCompletions(historyActions, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(verifyCmd)
 
 	rootCmd.AddCommand(clearCmd)
-	clearCmd.Flags().BoolP("quiet", "q", false, "suppress all output, except errors")
+
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configGetCmd.ValidArgs = configKeys
+	configCmd.AddCommand(configSetCmd)
+
+	rootCmd.AddCommand(daemonCmd)
+
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().StringSlice("pattern", nil, "only add files whose names match this glob, e.g. '*.pdf' (repeatable)")
+	watchCmd.Flags().Bool("copy", false, "add the files as copies instead of cuts")
+	watchCmd.Flags().StringSlice("tag", nil, "label the entries, e.g. --tag work (repeatable)")
+
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringArray("map", nil, "rewrite paths under a directory, as from=to, e.g. /home/a=/home/b (repeatable)")
+
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringArray("map", nil, "rewrite paths under a directory, as from=to, e.g. /home/a=/home/b (repeatable)")
+	importCmd.Flags().BoolP("force", "f", false, "replace the clipboard instead of adding to it")
+
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotSaveCmd, snapshotLoadCmd, snapshotListCmd, snapshotDeleteCmd)
+	snapshotSaveCmd.Flags().BoolP("force", "f", false, "replace a snapshot already saved under the name")
+
+	rootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionStartCmd)
+	sessionCmd.AddCommand(sessionEndCmd)
+
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.AddCommand(syncPushCmd)
+	syncPushCmd.Flags().String("remote", "", "URL of the sync server, e.g. http://host:7070")
+	syncPushCmd.Flags().String("token", "", "token the sync server expects")
+	syncCmd.AddCommand(syncPullCmd)
+	syncPullCmd.Flags().String("remote", "", "URL of the sync server, e.g. http://host:7070")
+	syncPullCmd.Flags().String("token", "", "token the sync server expects")
+	syncCmd.AddCommand(syncServeCmd)
+	syncServeCmd.Flags().String("addr", "127.0.0.1:7070", "address to listen on")
+	syncServeCmd.Flags().String("token", "", "token clients must send (strongly recommended)")
 
 	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(runAtCmd)
+	rootCmd.AddCommand(shellInitCmd)
+	rootCmd.AddCommand(docsCmd)
+	docsCmd.AddCommand(docsManCmd, docsMarkdownCmd)
 }
 
 // completionCmd generates shell completion scripts
 var completionCmd = &cobra.Command{
-	Use:   "completion [bash|zsh|fish]",
+	Use:   "completion [bash|zsh|fish|powershell]",
 	Short: "Generate shell completion script",
 	Long: `Generate shell completion script for cx.
 
@@ -52,9 +275,12 @@ To load completions:
   source <(cx completion bash)
 
   # fish
-  cx completion fish | source`,
+  cx completion fish | source
+
+  # powershell (add to $PROFILE for persistence)
+  cx completion powershell | Out-String | Invoke-Expression`,
 	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
-	ValidArgs: []string{"bash", "zsh", "fish"},
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
 	Run: func(cmd *cobra.Command, args []string) {
 		switch args[0] {
 		case "bash":
@@ -63,43 +289,653 @@ To load completions:
 			rootCmd.GenZshCompletion(os.Stdout)
 		case "fish":
 			rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+	},
+}
+
+// runAtCmd is what a paste scheduled with --at or --after runs as
+var runAtCmd = &cobra.Command{
+	Use:                "run-at <time> <args>...",
+	Short:              "Wait until a time, then run cx with the given arguments",
+	Hidden:             true,
+	Args:               cobra.MinimumNArgs(2),
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runAt, err := time.Parse(time.RFC3339, args[0])
+		if err != nil {
+			return err
 		}
+		return handleRunAt(cmd.OutOrStdout(), runAt, args[1:])
+	},
+}
+
+// shellInitCmd prints shell functions and key bindings for cx
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init bash|zsh|fish",
+	Short: "Print key bindings that bring cx into the shell",
+	Long: `Print shell functions and key bindings that use cx from the command line
+being edited:
+
+  Ctrl-X Ctrl-P  insert the path of the top clipboard entry at the cursor
+  Ctrl-X Ctrl-K  cut the path under the cursor
+
+To load them, add to your shell's startup file:
+
+  # zsh (~/.zshrc) or bash (~/.bashrc)
+  eval "$(cx shell-init zsh)"
+
+  # fish (~/.config/fish/config.fish)
+  cx shell-init fish | source`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: shellInitShells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return handleShellInit(cmd.OutOrStdout(), args[0])
 	},
 }
 
+// completeEntryIndices offers the clipboard indices not already used, each
+// described by its path
+func completeEntryIndices(used []string) []string {
+	board, err := readClipboard()
+	if err != nil {
+		return nil
+	}
+
+	taken := make(map[string]bool, len(used))
+	for _, arg := range used {
+		taken[arg] = true
+	}
+
+	completions := make([]string, 0, len(board.Entries))
+	for i, entry := range board.Entries {
+		index := strconv.Itoa(i)
+		if !taken[index] {
+			completions = append(completions, index+"\t"+entry.CurrentPath)
+		}
+	}
+	return completions
+}
+
+// pathArgs returns the paths to cut or copy: the path argument, or the list
+// read from stdin when --stdin or "-" is given
+func pathArgs(cmd *cobra.Command, args []string) ([]string, error) {
+	stdin, _ := cmd.Flags().GetBool("stdin")
+
+	if stdin || (len(args) == 1 && args[0] == "-") {
+		if stdin && len(args) == 1 && args[0] != "-" {
+			return nil, fmt.Errorf("a path argument cannot be combined with --stdin")
+		}
+		return readPathList(cmd.InOrStdin())
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("requires a path argument or --stdin")
+	}
+	return args, nil
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "cx [path]",
 	Short: "A command line tool for cut and paste operations on files and directories",
-	Long:  `cx allows you to cut and paste files and directories from the command line.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `cx allows you to cut and paste files and directories from the command line.
+
+Paths can also be piped in with --stdin (or "-" as the path), e.g.
+  find . -name '*.log' | cx --stdin`,
+	Example: `  # cut a file, then paste it somewhere else
+  cx report.pdf
+  cd ~/docs && cx paste
+
+  # cut a directory and tag it, to paste it by tag later
+  cx --tag work ~/drafts
+  cx paste --tag work`,
+	Args: cobra.MaximumNArgs(1),
+	// every command works on the clipboard, so fill in defaults from the
+	// config file and drop expired entries before anything else sees them.
+	// The clipboard is only locked while each change is written, so a long
+	// paste doesn't hold up other commands.
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		switch cmd.Name() {
+		case "completion", "shell-init", "run-at", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return nil
+		}
+		if quiet, _ := cmd.Flags().GetBool("quiet"); quiet && verbose {
+			return fmt.Errorf("--quiet cannot be combined with --verbose")
+		}
+		if err := startOutput(cmd); err != nil {
+			return err
+		}
+		if cmd.Parent() == configCmd || cmd.Parent() == docsCmd {
+			return nil
+		}
+
+		cfg, err := readConfig()
+		if err != nil {
+			return err
+		}
+		if err := applyConfig(cmd, cfg); err != nil {
+			return err
+		}
+		hooks = cfg.hooks()
+		limits = cfg.limits()
+		pruneMissing, _ = strconv.ParseBool(cfg.values["prune_missing"])
+		if err := applyColorMode(colorMode, stdoutIsTerminal()); err != nil {
+			return err
+		}
+		if localClipboard {
+			if cmd.Flags().Changed("clipboard") {
+				return fmt.Errorf("--local cannot be combined with --clipboard")
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			if clipboardPath, err = findLocalClipboard(cwd); err != nil {
+				return err
+			}
+		} else if session := os.Getenv(sessionEnv); session != "" && !cmd.Flags().Changed("clipboard") {
+			if clipboardPath, err = sessionClipboardPath(session); err != nil {
+				return fmt.Errorf("$%s: %w", sessionEnv, err)
+			}
+			if err := os.MkdirAll(sessionsDir(), 0o700); err != nil {
+				return err
+			}
+		}
+		if clipboardPath == defaultClipboardPath {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+			if err := prepareDefaultClipboard(os.Stderr, homeDir, clipboardPath); err != nil {
+				return err
+			}
+		}
+		if !containsString(storeKinds, storeKind) {
+			return fmt.Errorf("invalid store %q (expected one of: %s)", storeKind, strings.Join(storeKinds, ", "))
+		}
+
+		// the servers and watch keep their own clipboard up to date; status
+		// reports on expired entries, so leaves them alone, and dup never
+		// touches the clipboard
+		if cmd == syncServeCmd || cmd == daemonCmd || cmd == watchCmd || cmd == statusCmd || cmd == dupCmd || cmd.Parent() == sessionCmd {
+			return nil
+		}
+
+		// repair must see the file as it is, not as pruning would rewrite it
+		if all, _ := cmd.Flags().GetBool("all"); cmd == listCmd && all || cmd == repairCmd {
+			return nil
+		}
+		if _, err := pruneExpired(expireAfter); err != nil {
+			return err
+		}
+		if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+			return checkMissing(io.Discard, cmd)
+		}
+		return checkMissing(os.Stderr, cmd)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		sys, _ := cmd.Flags().GetBool("sys")
+		force, _ := cmd.Flags().GetBool("force")
+		stdin, _ := cmd.Flags().GetBool("stdin")
+
+		tagFlag, _ := cmd.Flags().GetStringSlice("tag")
+		fromStdin, _ := cmd.Flags().GetBool("from-stdin")
+		name, _ := cmd.Flags().GetString("name")
+
+		if cmd.Flags().Changed("name") && !fromStdin {
+			return fmt.Errorf("--name needs --from-stdin")
+		}
+		if fromStdin {
+			if len(args) > 0 || stdin {
+				return fmt.Errorf("--from-stdin cannot be combined with a path or --stdin")
+			}
+			tags, err := parseTags(tagFlag)
+			if err != nil {
+				return err
+			}
+			return handleFromStdin(cmd.OutOrStdout(), cmd.InOrStdin(), name, Options{quiet: quiet, sys: sys, tags: tags})
+		}
+
+		// a bare cx gives a short overview; --help has the full reference
+		if len(args) == 0 && !stdin {
+			if quiet {
+				return nil
+			}
+			return handleOverview(cmd.OutOrStdout())
+		}
+
+		tags, err := parseTags(tagFlag)
+		if err != nil {
+			return err
+		}
+		paths, err := pathArgs(cmd, args)
+		if err != nil {
+			return err
+		}
+		return addEntries(cmd.OutOrStdout(), paths, clipboard.OpCut, Options{quiet: quiet, sys: sys, force: force, tags: tags})
+	},
+}
+
+// cutCmd represents the cut command, which does what a bare cx does
+var cutCmd = &cobra.Command{
+	Use:     "cut [path]",
+	Short:   "Cut a file or directory to the clipboard",
+	Long:    `Cut a file or directory to the clipboard, the same as cx [path]. Pasting a cut entry moves it.`,
+	Aliases: []string{"x"},
+	Example: `  cx x report.pdf`,
+	Args:    cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		sys, _ := cmd.Flags().GetBool("sys")
+		force, _ := cmd.Flags().GetBool("force")
+
+		tagFlag, _ := cmd.Flags().GetStringSlice("tag")
+
+		tags, err := parseTags(tagFlag)
+		if err != nil {
+			return err
+		}
+		paths, err := pathArgs(cmd, args)
+		if err != nil {
+			return err
+		}
+		return addEntries(cmd.OutOrStdout(), paths, clipboard.OpCut, Options{quiet: quiet, sys: sys, force: force, tags: tags})
+	},
+}
+
+// copyCmd represents the copy command
+var copyCmd = &cobra.Command{
+	Use:   "copy [path]",
+	Short: "Copy a file or directory to the clipboard",
+	Long:  `Copy a file or directory to the clipboard. Pasting a copied entry duplicates it and leaves the original in place.`,
+	Example: `  cx copy config.yaml
+  cx copy --tag work ~/templates/invoice`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		sys, _ := cmd.Flags().GetBool("sys")
+		force, _ := cmd.Flags().GetBool("force")
+
+		tagFlag, _ := cmd.Flags().GetStringSlice("tag")
+
+		tags, err := parseTags(tagFlag)
+		if err != nil {
+			return err
+		}
+		paths, err := pathArgs(cmd, args)
+		if err != nil {
+			return err
+		}
+		return addEntries(cmd.OutOrStdout(), paths, clipboard.OpCopy, Options{quiet: quiet, sys: sys, force: force, tags: tags})
+	},
+}
+
+// dupCmd represents the dup command
+var dupCmd = &cobra.Command{
+	Use:   "dup <path>...",
+	Short: "Copy files or directories next to themselves",
+	Long: `Copy each file or directory next to itself under the next free name, e.g.
+"report copy.pdf", then "report copy 2.pdf". The clipboard is left alone.`,
+	Example: `  cx dup report.pdf
+  cx dup --preserve ~/projects/site`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		preserveFlag, _ := cmd.Flags().GetStringSlice("preserve")
+		noReflink, _ := cmd.Flags().GetBool("no-reflink")
+
+		preserve, err := fsops.ParseAttrs(preserveFlag)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := interruptible(cmd.Context())
+		defer stop()
+		return handleDup(cmd.OutOrStdout(), args, Options{quiet: quiet, preserve: preserve, noReflink: noReflink, ctx: ctx})
+	},
+}
+
+// swapCmd represents the swap command
+var swapCmd = &cobra.Command{
+	Use:   "swap <a> <b>",
+	Short: "Exchange two files or directories",
+	Long: `Exchange two files or directories, so each takes the other's name. On Linux
+and macOS filesystems that support it this happens in one step, so nothing
+ever sees them half swapped; elsewhere they're swapped through a temporary
+name, and put back if that fails. Both must be on the same filesystem.
+Clipboard entries for either follow their files.`,
+	Example: `  cx swap config.yaml config.yaml.new`,
+	Args:    cobra.ExactArgs(2),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 2 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
 		return nil, cobra.ShellCompDirectiveDefault
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
-		return cutFile(cmd.OutOrStdout(), args[0], Options{quiet: quiet})
+		return handleSwap(cmd.OutOrStdout(), args[0], args[1], Options{quiet: quiet})
+	},
+}
+
+// grabCmd represents the grab command
+var grabCmd = &cobra.Command{
+	Use:   "grab --exec <command>",
+	Short: "Add the paths a command prints, such as fd or rg -l",
+	Long: `Run a shell command and add every path it prints to the clipboard, one per
+line or NUL-separated, as cuts unless --copy is given. Relative paths are
+taken from the current directory, and nothing is added unless every path
+exists.`,
+	Example: `  cx grab --exec 'fd -e png'
+  cx grab --copy --exec 'rg -l TODO'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		command, _ := cmd.Flags().GetString("exec")
+		copy, _ := cmd.Flags().GetBool("copy")
+		force, _ := cmd.Flags().GetBool("force")
+		tagFlag, _ := cmd.Flags().GetStringSlice("tag")
+
+		tags, err := parseTags(tagFlag)
+		if err != nil {
+			return err
+		}
+		op := clipboard.OpCut
+		if copy {
+			op = clipboard.OpCopy
+		}
+		return handleGrab(cmd.OutOrStdout(), command, op, Options{quiet: quiet, force: force, tags: tags})
+	},
+}
+
+// importSysCmd represents the import-sys command
+var importSysCmd = &cobra.Command{
+	Use:   "import-sys",
+	Short: "Add files from the system clipboard",
+	Long: `Add the files on the system clipboard, e.g. copied in Finder or a file
+manager, to the cx clipboard. They are added as copies unless --cut is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		cut, _ := cmd.Flags().GetBool("cut")
+		force, _ := cmd.Flags().GetBool("force")
+		tagFlag, _ := cmd.Flags().GetStringSlice("tag")
+
+		tags, err := parseTags(tagFlag)
+		if err != nil {
+			return err
+		}
+		op := clipboard.OpCopy
+		if cut {
+			op = clipboard.OpCut
+		}
+		return handleImportSys(cmd.OutOrStdout(), op, Options{quiet: quiet, force: force, tags: tags})
 	},
 }
 
 // pasteCmd represents the paste command
 var pasteCmd = &cobra.Command{
-	Use:   "paste [index]",
-	Short: "Paste the most recent clipboard entry",
-	Args:  cobra.RangeArgs(0, 1),
+	Use:     "paste [index] [destination]",
+	Short:   "Paste the most recent clipboard entry",
+	Aliases: []string{"v", "p"},
+	Long: `Paste the most recent clipboard entry, or the entry at the given index as shown by "cx list".
+
+Entries are pasted into the current directory unless a destination directory
+is given, either with --to or as an argument after the index. A lone argument
+that isn't a number is taken as the destination.`,
+	Example: `  # paste the most recent entry here
+  cx paste
+
+  # paste entry 2 into ~/backup, keeping the original
+  cx paste 2 ~/backup --persist
+
+  # paste everything, renaming anything that clashes
+  cx paste --all --on-conflict rename`,
+	Args: cobra.RangeArgs(0, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeEntryIndices(nil), cobra.ShellCompDirectiveFilterDirs
+		}
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		persist, _ := cmd.Flags().GetBool("persist")
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		index, _ := cmd.Flags().GetInt("index")
+		all, _ := cmd.Flags().GetBool("all")
+		selectEntry, _ := cmd.Flags().GetBool("select")
+		onConflictFlag, _ := cmd.Flags().GetString("on-conflict")
+		preserveFlag, _ := cmd.Flags().GetStringSlice("preserve")
+		keepOwner, _ := cmd.Flags().GetBool("preserve-owner")
+		devices, _ := cmd.Flags().GetBool("devices")
+		resume, _ := cmd.Flags().GetBool("resume")
+		excludeFlag, _ := cmd.Flags().GetStringSlice("exclude")
+		gitignore, _ := cmd.Flags().GetBool("gitignore")
+		verify, _ := cmd.Flags().GetBool("verify")
+		noReflink, _ := cmd.Flags().GetBool("no-reflink")
+		showProgress, _ := cmd.Flags().GetBool("progress")
+		bwlimitFlag, _ := cmd.Flags().GetString("bwlimit")
+		update, _ := cmd.Flags().GetBool("update")
+		checksum, _ := cmd.Flags().GetBool("checksum")
+		mirror, _ := cmd.Flags().GetBool("mirror")
+		deleteExtras, _ := cmd.Flags().GetBool("delete-extras")
+		to, _ := cmd.Flags().GetString("to")
+		mkdir, _ := cmd.Flags().GetBool("mkdir")
+		as, _ := cmd.Flags().GetString("as")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		archive, _ := cmd.Flags().GetString("archive")
+		zip, _ := cmd.Flags().GetBool("zip")
+		extract, _ := cmd.Flags().GetBool("extract")
+		flatten, _ := cmd.Flags().GetBool("flatten")
+		followSymlinks, _ := cmd.Flags().GetBool("follow-symlinks")
+		rewriteRelative, _ := cmd.Flags().GetBool("rewrite-relative")
+		hardlink, _ := cmd.Flags().GetBool("hardlink")
+		template, _ := cmd.Flags().GetBool("template")
+		sets, _ := cmd.Flags().GetStringArray("set")
+		tag, _ := cmd.Flags().GetString("tag")
+		preserveRoot, _ := cmd.Flags().GetString("preserve-root")
+		renameFlag, _ := cmd.Flags().GetString("rename")
+		sudo, _ := cmd.Flags().GetBool("sudo")
+		at, _ := cmd.Flags().GetString("at")
+		after, _ := cmd.Flags().GetDuration("after")
+		toStdout, _ := cmd.Flags().GetBool("stdout")
+		pinned, _ := cmd.Flags().GetString("pinned")
 
-		index := 0
-		if len(args) == 1 {
-			var err error
-			index, err = strconv.Atoi(args[0])
+		var indexArg, destArg string
+		switch len(args) {
+		case 1:
+			if _, err := strconv.Atoi(args[0]); err == nil {
+				indexArg = args[0]
+			} else {
+				destArg = args[0]
+			}
+		case 2:
+			indexArg, destArg = args[0], args[1]
+		}
+
+		if destArg != "" {
+			if cmd.Flags().Changed("to") {
+				return fmt.Errorf("destination given both as argument and --to flag")
+			}
+			to = destArg
+		}
+		if indexArg != "" && cmd.Flags().Changed("index") {
+			return fmt.Errorf("index given both as argument and --index flag")
+		}
+
+		given := givenFlags(cmd.Flags())
+		_, given[givenRemoteDest] = parseRemotePath(to)
+		given[givenDest] = to != ""
+		given[givenIndex] = indexArg != "" || cmd.Flags().Changed("index")
+		given[givenJSON] = results != nil
+		if err := checkFlagRules(given, pasteConflicts, pasteRequires); err != nil {
+			return err
+		}
+
+		onConflict, err := clipboard.ParseConflictStrategy(onConflictFlag)
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("backup") {
+			if err := clipboard.ValidateBackupSuffix(backupSuffix); err != nil {
+				return err
+			}
+			if onConflict != clipboard.ConflictOverwrite && onConflict != clipboard.ConflictPrompt {
+				return fmt.Errorf("--backup needs --on-conflict overwrite or prompt")
+			}
+		}
+		preserve, err := fsops.ParseAttrs(preserveFlag)
+		if err != nil {
+			return err
+		}
+		if keepOwner {
+			if preserve, err = preserveOwner(preserve, os.Geteuid()); err != nil {
+				return err
+			}
+		}
+		exclude, err := fsops.ParseExcludes(excludeFlag)
+		if err != nil {
+			return err
+		}
+		symlinks := fsops.SymlinksKeep
+		if followSymlinks {
+			symlinks = fsops.SymlinksFollow
+		} else if rewriteRelative {
+			symlinks = fsops.SymlinksRewriteRelative
+		}
+		vars, err := parseTemplateVars(sets)
+		if err != nil {
+			return err
+		}
+		if preserveRoot != "" {
+			if preserveRoot, err = filepath.Abs(preserveRoot); err != nil {
+				return err
+			}
+		}
+		var rename *renamer
+		if renameFlag != "" {
+			if rename, err = parseRename(renameFlag); err != nil {
+				return err
+			}
+		}
+		if devices && os.Geteuid() != 0 {
+			return fmt.Errorf("--devices needs root, e.g. sudo cx paste --devices")
+		}
+		var bwlimit int64
+		if bwlimitFlag != "" {
+			if bwlimit, err = parseBWLimit(bwlimitFlag); err != nil {
+				return err
+			}
+		}
+		opts := Options{persist: persist, quiet: quiet, onConflict: onConflict, preserve: preserve, exclude: exclude, gitignore: gitignore, verify: verify, noReflink: noReflink, showProgress: showProgress, dest: to, mkdir: mkdir, as: as, dryRun: dryRun, pop: stackMode, archive: archive, zip: zip, extract: extract, symlinks: symlinks, hardlink: hardlink, vars: vars, preserveRoot: preserveRoot, rename: rename, sudo: sudo, devices: devices, resume: resume, bwlimit: bwlimit, update: update, checksum: checksum, deleteExtras: deleteExtras}
+
+		var runAt time.Time
+		if at != "" || after != 0 {
+			if runAt, err = parseScheduleTime(at, after, time.Now()); err != nil {
+				return err
+			}
+			if onConflict == clipboard.ConflictPrompt || confirming() {
+				return fmt.Errorf("--at and --after cannot be combined with --on-conflict prompt or --confirm, which need an answer")
+			}
+		}
+
+		ctx, stop := interruptible(cmd.Context())
+		defer stop()
+		opts.ctx = ctx
+
+		if indexArg != "" {
+			index, err = strconv.Atoi(indexArg)
 			if err != nil {
-				return fmt.Errorf("invalid index: %s", args[0])
+				return fmt.Errorf("invalid index: %s", indexArg)
+			}
+		}
+
+		// every entry unless --tag narrows it down
+		var indices []int
+		if tag != "" && pinned == "" {
+			board, err := readClipboard()
+			if err != nil {
+				return err
+			}
+			if indices, err = taggedIndices(board.Entries, tag); err != nil {
+				return err
+			}
+			index = indices[0]
+		}
+
+		// a scheduled paste pastes the entries it was scheduled for, found
+		// wherever they are on the clipboard by now
+		if pinned != "" {
+			if indices, err = pinnedIndices(pinned); err != nil {
+				return err
+			}
+			index = indices[0]
+		}
+		if !runAt.IsZero() {
+			entries, err := scheduledEntries(all, indices, index)
+			if err != nil {
+				return err
+			}
+			return handleSchedule(cmd.OutOrStdout(), runAt, withoutScheduleFlags(commandArgs), entries, opts)
+		}
+
+		if selectEntry {
+			return handlePasteSelect(cmd.OutOrStdout(), opts)
+		}
+
+		if all {
+			if archive != "" {
+				return handlePasteArchive(cmd.OutOrStdout(), indices, opts)
 			}
+			if extract {
+				return handlePasteExtract(cmd.OutOrStdout(), indices, opts)
+			}
+			if flatten {
+				return handlePasteFlatten(cmd.OutOrStdout(), indices, opts)
+			}
+			if remote, ok := parseRemotePath(to); ok {
+				return handleRemotePaste(cmd.OutOrStdout(), indices, remote, opts)
+			}
+			return handlePasteEntries(cmd.OutOrStdout(), indices, opts)
+		}
+
+		if toStdout {
+			return handleCat(cmd.OutOrStdout(), index)
+		}
+		if mirror {
+			return handleMirror(cmd.OutOrStdout(), index, opts)
+		}
+		if archive != "" {
+			return handlePasteArchive(cmd.OutOrStdout(), []int{index}, opts)
+		}
+		if extract {
+			return handlePasteExtract(cmd.OutOrStdout(), []int{index}, opts)
 		}
-		return handlePasteAt(cmd.OutOrStdout(), index, Options{persist: persist, quiet: quiet})
+		if flatten {
+			return handlePasteFlatten(cmd.OutOrStdout(), []int{index}, opts)
+		}
+		if template {
+			return handlePasteTemplate(cmd.OutOrStdout(), index, opts)
+		}
+		if remote, ok := parseRemotePath(to); ok {
+			return handleRemotePaste(cmd.OutOrStdout(), []int{index}, remote, opts)
+		}
+		return handlePasteAt(cmd.OutOrStdout(), index, opts)
 
 	},
 }
@@ -109,25 +945,894 @@ var listCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "List clipboard contents",
 	Aliases: []string{"ls"},
+	Example: `  cx list
+  cx list --sizes --sort size
+  cx list --format json`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		detailed, _ := cmd.Flags().GetBool("detailed")
+		all, _ := cmd.Flags().GetBool("all")
 		json, _ := cmd.Flags().GetBool("json")
-		return handleList(cmd.OutOrStdout(), Options{detailed: detailed, json: json})
+		formatFlag, _ := cmd.Flags().GetString("format")
+
+		format, err := parseListFormat(formatFlag)
+		if err != nil {
+			return err
+		}
+		if json {
+			if cmd.Flags().Changed("format") && format != formatJSON {
+				return fmt.Errorf("--json cannot be combined with --format %s", format)
+			}
+			format = formatJSON
+		}
+		tree, _ := cmd.Flags().GetBool("tree")
+		depth, _ := cmd.Flags().GetInt("depth")
+		if tree && format != formatPlain {
+			return fmt.Errorf("--tree cannot be combined with --format %s", format)
+		}
+		if depth < 1 {
+			return fmt.Errorf("--depth must be at least 1")
+		}
+		sizes, _ := cmd.Flags().GetBool("sizes")
+		tag, _ := cmd.Flags().GetString("tag")
+		relative, _ := cmd.Flags().GetBool("relative")
+		basename, _ := cmd.Flags().GetBool("basename")
+
+		var paths pathMode
+		switch {
+		case relative && basename:
+			return fmt.Errorf("--relative cannot be combined with --basename")
+		case relative:
+			paths = pathRelative
+		case basename:
+			paths = pathBasename
+		}
+		if paths != pathAbsolute && format != formatPlain {
+			return fmt.Errorf("--relative and --basename cannot be combined with --format %s, which always has absolute paths", format)
+		}
+
+		var columns []string
+		if columnsFlag, _ := cmd.Flags().GetString("columns"); cmd.Flags().Changed("columns") {
+			if detailed || tree || format != formatPlain {
+				return fmt.Errorf("--columns cannot be combined with --detailed, --tree or --format json or tsv")
+			}
+			if columns, err = parseColumns(columnsFlag); err != nil {
+				return err
+			}
+		}
+
+		sortBy, _ := cmd.Flags().GetString("sort")
+		reverse, _ := cmd.Flags().GetBool("reverse")
+		if sortBy != "" {
+			if sortBy, err = parseListSort(sortBy); err != nil {
+				return err
+			}
+		}
+		return handleList(cmd.OutOrStdout(), Options{detailed: detailed, format: format, all: all, tree: tree, depth: depth, sizes: sizes, tag: tag, pathMode: paths, columns: columns, sortBy: sortBy, reverse: reverse})
 	},
 }
 
-// clearCmd represents the clear command
-var clearCmd = &cobra.Command{
-	Use:   "clear",
-	Short: "Clear clipboard contents",
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired or missing clipboard entries",
+	Long: `Remove entries older than the --expire-after age, or --older-than if given.
+Expired entries are also pruned automatically before every command.
+
+With --missing, remove the entries whose files no longer exist instead, or
+as well with --older-than. Other commands warn when there are any, or with
+prune_missing set in the config, prune them automatically too.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
-		return handleClear(cmd.OutOrStdout(), Options{quiet: quiet})
+		missing, _ := cmd.Flags().GetBool("missing")
+		maxAge := expireAfter
+		if cmd.Flags().Changed("older-than") {
+			maxAge, _ = cmd.Flags().GetDuration("older-than")
+		} else if missing {
+			maxAge = 0
+		}
+		return handlePrune(cmd.OutOrStdout(), maxAge, missing, Options{quiet: quiet})
+	},
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get or set defaults in the config file",
+	Long: `Get or set defaults in the config file (cx/config.yaml under $XDG_CONFIG_HOME,
+or the user config directory: ~/.config on Linux, ~/Library/Application Support
+on macOS). Flags given on the command line always win.
+
+Keys:
+  clipboard           path to the clipboard file
+  store               where to keep the clipboard: file, sqlite or daemon
+  on_conflict         default paste conflict strategy: overwrite, skip, rename or prompt
+  theme               color theme: default or none
+  color               when to use colors: auto, always or never
+  persist             keep files at their original path after paste: true or false
+  expire_after        drop entries older than this, e.g. 24h
+  confirm             ask before overwriting, moving across filesystems or clearing: true or false
+  confirm_clear_over  only confirm clearing more than this many entries
+  stack               treat the clipboard as a stack, so pasting always removes the entry: true or false
+  max_entries         the most entries the clipboard holds
+  max_size            the most the files of its entries add up to, e.g. 10GB
+  on_limit            what a cut does once the clipboard is full: evict the oldest entries, or refuse
+  prune_missing       remove entries whose files no longer exist before every command: true or false
+  sync_remote         URL of the cx sync server, e.g. http://host:7070
+  sync_token          token sent to, or expected by, the cx sync server
+  exclude             comma-separated gitignore-style patterns to leave out of directory copies
+  pre_cut             shell command run before each path is cut or copied
+  post_cut            shell command run after each path is cut or copied
+  pre_paste           shell command run before each entry is pasted
+  post_paste          shell command run after each entry is pasted
+
+Hook commands can use {source}, {dest}, {name} and {op} (cut or copy), which
+are replaced with shell-quoted values and also set as $CX_SOURCE, $CX_DEST
+and $CX_OP. A failing pre_ hook stops that operation.
+
+Aliases are set as alias.<name>, e.g. cx config set alias.pp "paste --persist"
+makes "cx pp 2" run "cx paste --persist 2". Built-in commands, and their
+aliases x (cut) and v or p (paste), can't be replaced.`,
+}
+
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print a config value, or every set value",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := ""
+		if len(args) == 1 {
+			key = args[0]
+		}
+		return handleConfigGet(cmd.OutOrStdout(), key)
+	},
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a config value (an empty value unsets it)",
+	Args:  cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return configKeys, cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return handleConfigSet(cmd.OutOrStdout(), args[0], args[1], Options{quiet: quiet})
+	},
+}
+
+// pickCmd represents the pick command
+var pickCmd = &cobra.Command{
+	Use:   "pick",
+	Short: "Interactively choose clipboard entries to paste, copy or drop",
+	Long: `Show the clipboard as a navigable list. Select one or more entries, then
+press enter or p to paste them, c to paste copies, or d to drop them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		onConflictFlag, _ := cmd.Flags().GetString("on-conflict")
+
+		onConflict, err := clipboard.ParseConflictStrategy(onConflictFlag)
+		if err != nil {
+			return err
+		}
+		return handlePick(cmd.OutOrStdout(), Options{quiet: quiet, onConflict: onConflict})
+	},
+}
+
+// parseIndexArgs parses clipboard indices given as arguments
+func parseIndexArgs(args []string) ([]int, error) {
+	indices := make([]int, len(args))
+	for i, arg := range args {
+		index, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clipboard index: %s", arg)
+		}
+		indices[i] = index
+	}
+	return indices, nil
+}
+
+// moveCmd represents the move command
+var moveCmd = &cobra.Command{
+	Use:   "move <from> <to>",
+	Short: "Move a clipboard entry to another position",
+	Long: `Move the entry at index from to index to, shifting the entries in between.
+Moving an entry to 0 makes it the one a bare cx paste picks.`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		indices, err := parseIndexArgs(args)
+		if err != nil {
+			return err
+		}
+		return handleReorder(cmd.OutOrStdout(), indices[0], indices[1], Options{quiet: quiet})
+	},
+}
+
+// topCmd represents the top command
+var topCmd = &cobra.Command{
+	Use:   "top <index>",
+	Short: "Make a clipboard entry the most recent",
+	Long:  `Move the entry at index to the top of the clipboard, so a bare cx paste pastes it.`,
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		indices, err := parseIndexArgs(args)
+		if err != nil {
+			return err
+		}
+		return handleReorder(cmd.OutOrStdout(), indices[0], 0, Options{quiet: quiet})
+	},
+}
+
+// popCmd represents the pop command
+var popCmd = &cobra.Command{
+	Use:   "pop [destination]",
+	Short: "Paste the most recent clipboard entry and remove it",
+	Long: `Paste the most recent clipboard entry into the current directory, or the
+given destination, and remove it from the clipboard. Unlike cx paste, a copied
+entry is removed too, so repeated pops work through the clipboard like popd.`,
+	Example: `  cx pop
+  cx pop ~/Downloads`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		onConflictFlag, _ := cmd.Flags().GetString("on-conflict")
+		mkdir, _ := cmd.Flags().GetBool("mkdir")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		onConflict, err := clipboard.ParseConflictStrategy(onConflictFlag)
+		if err != nil {
+			return err
+		}
+
+		dest := ""
+		if len(args) == 1 {
+			dest = args[0]
+		}
+		opts := Options{quiet: quiet, onConflict: onConflict, dest: dest, mkdir: mkdir, dryRun: dryRun, pop: true}
+		ctx, stop := interruptible(cmd.Context())
+		defer stop()
+		opts.ctx = ctx
+		if remote, ok := parseRemotePath(dest); ok {
+			return handleRemotePaste(cmd.OutOrStdout(), []int{0}, remote, opts)
+		}
+		return handlePasteAt(cmd.OutOrStdout(), 0, opts)
+	},
+}
+
+// rotateCmd represents the rotate command
+var rotateCmd = &cobra.Command{
+	Use:   "rotate [n]",
+	Short: "Cycle the clipboard entries",
+	Long: `Cycle the clipboard n places (1 if not given), like pushd +n: the entry at
+index n becomes the most recent and the entries above it wrap round to the
+bottom. With --reverse the oldest entries come to the top instead.`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		reverse, _ := cmd.Flags().GetBool("reverse")
+
+		n := 1
+		if len(args) == 1 {
+			var err error
+			n, err = strconv.Atoi(args[0])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid rotate count: %s", args[0])
+			}
+		}
+		if reverse {
+			n = -n
+		}
+		return handleRotate(cmd.OutOrStdout(), n, Options{quiet: quiet})
+	},
+}
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show an overview of the clipboard's health",
+	Long: `Show where the clipboard is kept, how many entries and how much data are
+queued, whether another process holds the clipboard lock or a cx daemon is
+running, and which entries are stale or point at paths that no longer exist.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return handleStatus(cmd.OutOrStdout())
+	},
+}
+
+// repairCmd represents the repair command
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Rebuild a corrupted clipboard file",
+	Long: `Rebuild the clipboard file if it is truncated or otherwise invalid, from the
+backup kept before each write. Without a usable backup, the entries that can
+still be parsed are kept.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return handleRepair(cmd.OutOrStdout(), Options{quiet: quiet})
+	},
+}
+
+// findCmd represents the find command
+var findCmd = &cobra.Command{
+	Use:   "find <pattern>",
+	Short: "Search clipboard entries by path",
+	Long: `List the entries whose path matches pattern, best match first, with their
+indices. Patterns are fuzzy matched, so "rptpdf" finds report.pdf; use --regex
+for a regular expression. --paste-first pastes the best match straight away.`,
+	Example: `  cx find rptpdf
+  cx find --regex '\.go$'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		regex, _ := cmd.Flags().GetBool("regex")
+		pasteFirst, _ := cmd.Flags().GetBool("paste-first")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		to, _ := cmd.Flags().GetString("to")
+		onConflictFlag, _ := cmd.Flags().GetString("on-conflict")
+
+		if !pasteFirst && (dryRun || to != "" || onConflictFlag != "") {
+			return fmt.Errorf("--to, --dry-run and --on-conflict can only be used with --paste-first")
+		}
+
+		onConflict, err := clipboard.ParseConflictStrategy(onConflictFlag)
+		if err != nil {
+			return err
+		}
+		return handleFind(cmd.OutOrStdout(), args[0], Options{quiet: quiet, regex: regex, pasteFirst: pasteFirst, dest: to, dryRun: dryRun, onConflict: onConflict})
+	},
+}
+
+// noteCmd represents the note command
+var noteCmd = &cobra.Command{
+	Use:   "note <index> [text]",
+	Short: "Attach a note to a clipboard entry",
+	Long: `Attach a free-text note to the entry at index, e.g. cx note 2 "reviewed, move to
+archive". Notes show up in cx list, so a long-lived clipboard can double as a
+to-do list. Without text the note is shown; --clear removes it.`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		clear, _ := cmd.Flags().GetBool("clear")
+
+		index, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid index: %s", args[0])
+		}
+		note := strings.TrimSpace(strings.Join(args[1:], " "))
+		if clear && note != "" {
+			return fmt.Errorf("--clear cannot be combined with note text")
+		}
+		return handleNote(cmd.OutOrStdout(), index, note, Options{quiet: quiet, clear: clear})
+	},
+}
+
+// peekCmd represents the peek command
+var peekCmd = &cobra.Command{
+	Use:   "peek [index]",
+	Short: "Show what a clipboard entry contains",
+	Long: `Show what the most recent clipboard entry, or the one at index, contains
+before pasting it: the first lines of a text file, a tree of a directory,
+or the target of a symlink.`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lines, _ := cmd.Flags().GetInt("lines")
+		depth, _ := cmd.Flags().GetInt("depth")
+
+		index := 0
+		if len(args) == 1 {
+			var err error
+			index, err = strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid clipboard index: %s", args[0])
+			}
+		}
+		if depth < 1 {
+			return fmt.Errorf("--depth must be at least 1")
+		}
+		return handlePeek(cmd.OutOrStdout(), index, Options{lines: lines, depth: depth})
+	},
+}
+
+// catCmd represents the cat command
+var catCmd = &cobra.Command{
+	Use:   "cat [index]",
+	Short: "Write a clipboard entry's contents to stdout",
+	Long: `Write the contents of the most recent clipboard entry, or the one at index,
+to stdout, for use in a pipeline. The entry and its file are left as they
+are. Only files can be streamed, not directories.`,
+	Example: `  cx cat | less
+  cx cat 2 | wc -l`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		index := 0
+		if len(args) == 1 {
+			var err error
+			index, err = strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid clipboard index: %s", args[0])
+			}
+		}
+		return handleCat(cmd.OutOrStdout(), index)
+	},
+}
+
+// dropCmd represents the drop command
+var dropCmd = &cobra.Command{
+	Use:     "drop [index|range|path]...",
+	Short:   "Remove entries from the clipboard",
+	Long:    `Remove entries from the clipboard by index, inclusive range (e.g. 1-3) or path, leaving the files themselves untouched.`,
+	Aliases: []string{"rm"},
+	Args:    cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return handleDrop(cmd.OutOrStdout(), args, Options{quiet: quiet})
+	},
+}
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   "delete [index|range|path]...",
+	Short: "Move clipboard entries' files to the trash",
+	Long: `Move the files of the selected clipboard entries to the trash and remove the
+entries from the clipboard. Entries are selected as for drop. Nothing is
+deleted permanently; use cx restore to get them back.`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return handleDelete(cmd.OutOrStdout(), args, Options{quiet: quiet})
+	},
+}
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the clipboard in memory and follow renamed files",
+	Long: `Hold the clipboard in memory and serve it on a unix socket next to the
+clipboard file, for commands run with --store daemon. While it runs, entries
+whose files are renamed or moved by other programs are updated to follow
+them, as long as the new location is a directory that holds a clipboard entry
+too. Changes are written
+through to the clipboard file, so nothing is lost when the daemon stops.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return handleDaemon(cmd.OutOrStdout())
+	},
+}
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Add files to the clipboard as they appear in a directory",
+	Long: `Watch a directory and add each file written or moved into it to the clipboard,
+as a cut unless --copy is given, until interrupted. With --pattern only files
+whose names match are added, once they've gone a moment without being
+written to. Files already there when the watch starts are left alone, and
+subdirectories aren't watched.`,
+	Example: `  # queue every PDF that lands in Downloads
+  cx watch ~/Downloads --pattern '*.pdf'`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		patterns, _ := cmd.Flags().GetStringSlice("pattern")
+		copy, _ := cmd.Flags().GetBool("copy")
+		tagFlag, _ := cmd.Flags().GetStringSlice("tag")
+
+		if err := parseWatchPatterns(patterns); err != nil {
+			return err
+		}
+		tags, err := parseTags(tagFlag)
+		if err != nil {
+			return err
+		}
+		op := clipboard.OpCut
+		if copy {
+			op = clipboard.OpCopy
+		}
+		return handleWatch(cmd.OutOrStdout(), args[0], patterns, op, Options{quiet: quiet, tags: tags})
+	},
+}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the clipboard as a file to share or move to another machine",
+	Long: `Write the clipboard to stdout as JSON that cx import reads back, to share a
+queue with someone else or move it to another machine. --map rewrites the
+paths as they're written; cx import can do the same when they're read.`,
+	Example: `  cx export > queue.cxq
+  cx export --map /home/a=/home/b > queue.cxq`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		mapFlag, _ := cmd.Flags().GetStringArray("map")
+		mappings, err := parsePathMappings(mapFlag)
+		if err != nil {
+			return err
+		}
+		return handleExport(cmd.OutOrStdout(), mappings)
+	},
+}
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Add the entries from a file written by cx export",
+	Long: `Add the entries from a file written by cx export ("-" reads stdin) on top of
+the clipboard, keeping their tags, notes and whether they were cut or copied.
+--map rewrites paths, e.g. for a home directory with a different name. Paths
+already on the clipboard are skipped, and ones that don't exist here are
+imported with a warning. With --force the clipboard is replaced instead.`,
+	Example: `  cx import queue.cxq --map /home/a=/home/b`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		force, _ := cmd.Flags().GetBool("force")
+		mapFlag, _ := cmd.Flags().GetStringArray("map")
+		mappings, err := parsePathMappings(mapFlag)
+		if err != nil {
+			return err
+		}
+		return handleImport(cmd.OutOrStdout(), cmd.InOrStdin(), args[0], mappings, Options{quiet: quiet, force: force})
+	},
+}
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the clipboard under a name and load it again later",
+	Long: `Save the whole clipboard under a name and load it back later, so a queue of
+files put together for a recurring task can be reused. Loading replaces the
+clipboard and keeps the snapshot. Snapshots are shared by every clipboard,
+including session and --local ones.`,
+	Example: `  cx snapshot save weekly-report
+  cx snapshot load weekly-report`,
+}
+
+// snapshotSaveCmd represents the snapshot save command
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save the clipboard as a snapshot",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		force, _ := cmd.Flags().GetBool("force")
+		return handleSnapshotSave(cmd.OutOrStdout(), args[0], Options{quiet: quiet, force: force})
+	},
+}
+
+// snapshotLoadCmd represents the snapshot load command
+var snapshotLoadCmd = &cobra.Command{
+	Use:   "load <name>",
+	Short: "Replace the clipboard with a snapshot",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeSnapshots(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return handleSnapshotLoad(cmd.OutOrStdout(), args[0], Options{quiet: quiet})
+	},
+}
+
+// snapshotListCmd represents the snapshot list command
+var snapshotListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the saved snapshots",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return handleSnapshotList(cmd.OutOrStdout())
+	},
+}
+
+// snapshotDeleteCmd represents the snapshot delete command
+var snapshotDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Short:   "Delete a saved snapshot",
+	Aliases: []string{"rm"},
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeSnapshots(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return handleSnapshotDelete(cmd.OutOrStdout(), args[0], Options{quiet: quiet})
+	},
+}
+
+// sessionCmd represents the session command
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Give a terminal session its own clipboard",
+	Long: `Give a terminal session its own clipboard, so cuts in one terminal don't end up
+pasted in another. Start one with
+
+  eval "$(cx session start)"
+
+which sets $CX_SESSION for the shell; cx then uses the session's clipboard
+until eval "$(cx session end)" deletes it. Clipboards left by shells that
+exited without ending their session are cleaned up by the next start.`,
+}
+
+// sessionStartCmd represents the session start command
+var sessionStartCmd = &cobra.Command{
+	Use:   "start [name]",
+	Short: "Start a session clipboard, printing shell code to eval",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		return handleSessionStart(cmd.OutOrStdout(), name)
+	},
+}
+
+// sessionEndCmd represents the session end command
+var sessionEndCmd = &cobra.Command{
+	Use:   "end [name]",
+	Short: "Delete the session clipboard, printing shell code to eval",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		return handleSessionEnd(cmd.OutOrStdout(), name)
+	},
+}
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Share the clipboard with other machines",
+	Long: `Share the clipboard with other machines through a small HTTP server.
+
+Run "cx sync serve" on one machine, then "cx sync push" on another to replace
+the server's clipboard with its own, or "cx sync pull" to replace its own with
+the server's. The paths only make sense on machines that see the same
+filesystem, such as an NFS mount. The server URL and token can be kept in the
+config as sync_remote and sync_token.`,
+}
+
+// syncPushCmd represents the sync push command
+var syncPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Replace the sync server's clipboard with this one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		remote, _ := cmd.Flags().GetString("remote")
+		token, _ := cmd.Flags().GetString("token")
+		return handleSyncPush(cmd.OutOrStdout(), remote, token, Options{quiet: quiet})
+	},
+}
+
+// syncPullCmd represents the sync pull command
+var syncPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Replace this clipboard with the sync server's",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		remote, _ := cmd.Flags().GetString("remote")
+		token, _ := cmd.Flags().GetString("token")
+		return handleSyncPull(cmd.OutOrStdout(), remote, token, Options{quiet: quiet})
+	},
+}
+
+// syncServeCmd represents the sync serve command
+var syncServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve this clipboard to other machines",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		return handleSyncServe(cmd.OutOrStdout(), addr, token)
+	},
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore [index]",
+	Short: "Restore an entry deleted to the trash",
+	Long: `Move a file deleted with cx delete back out of the trash to where it was
+and return its entry to the clipboard. Without an index the most recent
+deletion is restored; see cx restore --list for the others.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		list, _ := cmd.Flags().GetBool("list")
+		if list {
+			return handleRestoreList(cmd.OutOrStdout())
+		}
+
+		index := 0
+		if len(args) == 1 {
+			var err error
+			index, err = strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid restore index: %s", args[0])
+			}
+		}
+		return handleRestore(cmd.OutOrStdout(), index, Options{quiet: quiet})
+	},
+}
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the most recent paste",
+	Long: `Undo the most recent paste. A moved entry is moved back to where it came
+from and returned to the clipboard; a copy is deleted. An update pasted into
+a destination that was already there isn't deleted, since it may hold files
+the paste didn't put there. Run repeatedly to go further back in the history.`,
+	Example: `  cx paste
+  cx undo`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		list, _ := cmd.Flags().GetBool("list")
+		if list {
+			return handleUndoList(cmd.OutOrStdout())
+		}
+		return handleUndo(cmd.OutOrStdout(), Options{quiet: quiet})
+	},
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the log of clipboard operations",
+	Long: `Show every cut, copy, paste, clear, delete and restore recorded in the
+history log, most recent first, with whether it succeeded.`,
+	Example: `  cx history --since 2h
+  cx history --path ~/projects`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		since, _ := cmd.Flags().GetString("since")
+		until, _ := cmd.Flags().GetString("until")
+		path, _ := cmd.Flags().GetString("path")
+		op, _ := cmd.Flags().GetString("op")
+
+		var filter historyFilter
+		var err error
+		now := time.Now()
+		if since != "" {
+			if filter.since, err = parseHistoryTime(since, now); err != nil {
+				return err
+			}
+		}
+		if until != "" {
+			if filter.until, err = parseHistoryTime(until, now); err != nil {
+				return err
+			}
+		}
+		if path != "" {
+			if filter.path, err = filepath.Abs(path); err != nil {
+				return err
+			}
+		}
+		if op != "" && !containsString(historyActions, op) {
+			return fmt.Errorf("invalid operation %q (expected one of: %s)", op, strings.Join(historyActions, ", "))
+		}
+		filter.action = op
+
+		return handleHistory(cmd.OutOrStdout(), filter)
+	},
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [index]",
+	Short: "Check that pastes made with --verify still match their checksums",
+	Long: `Check that pastes made with --verify still match the checksums taken when
+they were pasted.
+
+Given the index of a clipboard entry, compare the entry with every copy of it
+pasted with --persist (or of a copied entry) instead, useful after copying to
+a flaky external drive. Both trees are walked and their files and sizes
+compared first, then files whose sizes match are compared by checksum, and
+any drift is reported.`,
+	Example: `  cx verify
+  cx verify 0`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeEntryIndices(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		if len(args) == 1 {
+			index, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid clipboard index: %s", args[0])
+			}
+			return handleVerifyEntry(cmd.OutOrStdout(), index, Options{quiet: quiet})
+		}
+		return handleVerify(cmd.OutOrStdout(), Options{quiet: quiet})
+	},
+}
+
+// clearCmd represents the clear command
+var clearCmd = &cobra.Command{
+	Use:     "clear",
+	Short:   "Clear clipboard contents",
+	Example: `  cx clear`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return handleClear(cmd.OutOrStdout(), Options{quiet: quiet})
 	},
 }
 
 func main() {
+	// aliases are expanded before cobra picks the command; a config that
+	// can't be read is reported once the command starts
+	if cfg, err := readConfig(); err == nil {
+		args, err := expandAliases(os.Args[1:], cfg.aliases())
+		if err != nil {
+			log.Print(err)
+			os.Exit(1)
+		}
+		commandArgs = args
+		rootCmd.SetArgs(args)
+	}
+
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// handleMirror makes the directory entry at index's copy in the destination
+// an exact mirror of it: files that changed are copied over, as with
+// --update, and anything the entry doesn't have is deleted, once the
+// deletions are confirmed or with --delete-extras. The entry stays on the
+// clipboard, cut or copied.
+func handleMirror(w io.Writer, index int, opts Options) error {
+	destDir, err := resolveDestDir(opts)
+	if err != nil {
+		return err
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+	if index < 0 || index >= len(board.Entries) {
+		return fmt.Errorf("invalid clipboard index: %d", index)
+	}
+
+	entry := board.Entries[index]
+	info, err := os.Stat(entry.CurrentPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory; only directories can be mirrored", entry.CurrentPath)
+	}
+	if err := clipboard.CheckNotInside(entry.CurrentPath, destDir); err != nil {
+		return err
+	}
+
+	name, err := clipboard.PasteName(filepath.Base(entry.CurrentPath), opts.as)
+	if err != nil {
+		return err
+	}
+	destPath := filepath.Join(destDir, name)
+	if destInfo, err := os.Lstat(destPath); err == nil && !destInfo.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory, so it cannot be mirrored into", clipboard.ErrConflict, destPath)
+	}
+	if clipboard.SamePath(entry.CurrentPath, destPath) {
+		return fmt.Errorf("cannot mirror %s onto itself", entry.CurrentPath)
+	}
+
+	opts.persist, opts.update = true, true
+	fsOpts := opts.pasteOptions().FS
+	extras, err := fsops.MirrorExtras(entry.CurrentPath, destPath, fsOpts)
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		size, _ := fsops.TreeSize(entry.CurrentPath)
+		fmt.Fprintf(w, "Would mirror: %s -> %s (%s)\n", entry.CurrentPath, destPath, FormatSize(size))
+		for _, extra := range extras {
+			fmt.Fprintf(w, "Would delete: %s\n", extra)
+		}
+		fmt.Fprintf(w, "Total: %d to delete\n", len(extras))
+		return nil
+	}
+
+	// deleting is never done without asking, even with --yes; scripts say
+	// so with --delete-extras
+	if len(extras) > 0 && !opts.deleteExtras {
+		for _, extra := range extras {
+			fmt.Fprintf(os.Stderr, "  %s\n", extra)
+		}
+		question := fmt.Sprintf("Mirroring %s deletes the %d paths above from %s. Continue?", entry.CurrentPath, len(extras), destPath)
+		ok, err := confirmAction(question)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if !opts.quiet {
+				fmt.Fprintf(w, "Skipped: %s (nothing changed)\n", entry.CurrentPath)
+			}
+			return nil
+		}
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if err := runPrePasteHook(entry, destDir, opts); err != nil {
+		return err
+	}
+
+	printPasteSummary(w, []clipboard.Entry{entry})
+	opts.progress = startProgress(w, []clipboard.Entry{entry}, opts)
+	fsOpts = opts.pasteOptions().FS
+	err = fsops.Copy(entry.CurrentPath, destPath, info, fsOpts)
+	opts.progress.finish()
+	if err == nil {
+		// only once everything is copied, so a failed mirror loses nothing
+		for _, extra := range extras {
+			if removeErr := os.RemoveAll(extra); removeErr != nil {
+				err = errors.Join(err, removeErr)
+			}
+		}
+	}
+	if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, destPath, err)); histErr != nil {
+		return errors.Join(err, histErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := runHook("post_paste", hookVars{source: entry.CurrentPath, dest: destPath, op: pasteOp(true)}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Mirrored: %s -> %s (%d deleted)\n", entry.CurrentPath, destPath, len(extras))
+	return nil
+}
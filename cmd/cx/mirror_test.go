@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+func TestMirror(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	src := filepath.Join(tempDir, "nested")
+	destDir := t.TempDir()
+	mirrored := filepath.Join(destDir, "nested")
+	stale := filepath.Join(mirrored, "stale.txt")
+	if err := os.MkdirAll(mirrored, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(stale, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := copyFileToClipboard(io.Discard, src, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	// a dry run and a declined mirror change nothing
+	restore := withConfirm("n\n")
+	defer restore()
+	if err := handleMirror(io.Discard, 0, Options{dest: destDir, dryRun: true}); err != nil {
+		t.Fatalf("handleMirror dry run failed: %v", err)
+	}
+	if err := handleMirror(io.Discard, 0, Options{dest: destDir}); err != nil {
+		t.Fatalf("handleMirror failed: %v", err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("Expected %s to be kept: %v", stale, err)
+	}
+
+	// --yes doesn't answer for deletions
+	assumeYes = true
+	stdin = strings.NewReader("")
+	err := handleMirror(io.Discard, 0, Options{dest: destDir})
+	assumeYes = false
+	if err == nil {
+		t.Error("Expected --yes to still ask before deleting")
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("Expected %s to be kept: %v", stale, err)
+	}
+
+	stdin = strings.NewReader("y\n")
+	if err := handleMirror(io.Discard, 0, Options{dest: destDir}); err != nil {
+		t.Fatalf("handleMirror failed: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be deleted, got: %v", stale, err)
+	}
+	if err := fsops.VerifyTree(src, mirrored); err != nil {
+		t.Errorf("Expected an exact mirror: %v", err)
+	}
+
+	// --delete-extras deletes without asking
+	if err := os.WriteFile(stale, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	stdin = strings.NewReader("")
+	if err := handleMirror(io.Discard, 0, Options{dest: destDir, deleteExtras: true}); err != nil {
+		t.Fatalf("handleMirror failed: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be deleted with --delete-extras, got: %v", stale, err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if len(board.Entries) != 1 {
+		t.Errorf("Expected the entry to stay on the clipboard, got %d entries", len(board.Entries))
+	}
+
+	if err := handleMirror(io.Discard, 0, Options{dest: filepath.Join(tempDir, "file1.txt")}); err == nil {
+		t.Error("Expected error mirroring into a file")
+	}
+}
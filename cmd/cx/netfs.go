@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// warnNetworkMove warns that moving src into destDir may be slow when it
+// crosses to or from a network filesystem, since the move is then a copy
+// over the network, checked by checksums before the original is deleted
+func warnNetworkMove(w io.Writer, src, destDir string) {
+	if fsops.SameDevice(src, destDir) {
+		return
+	}
+	fstype, ok := fsops.NetworkFS(src)
+	if !ok {
+		fstype, ok = fsops.NetworkFS(destDir)
+	}
+	if ok {
+		fmt.Fprintf(w, "Warning: moving %s over a network filesystem (%s) copies and checks it before deleting the original, which may be slow\n", src, fstype)
+	}
+}
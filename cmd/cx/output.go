@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+	"github.com/spf13/cobra"
+)
+
+// output modes for --output
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var outputModes = []string{outputText, outputJSON}
+
+// outputCommands are the subcommands --output json works for, besides a bare
+// cx cutting
+var outputCommands = []string{"cut", "copy", "grab", "paste", "pop", "clear"}
+
+// outputMode is set by --output. With json, each cut, copy, paste or
+// cleared entry is reported as a JSON object on stdout instead of the usual
+// messages, for wrappers to parse.
+var outputMode = outputText
+
+// results writes those JSON objects while --output json is in effect
+var results *resultWriter
+
+// opResult is the JSON object --output json prints for an operation
+type opResult struct {
+	Operation   string `json:"operation"`
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Bytes       int64  `json:"bytes"`
+	DurationMS  int64  `json:"duration_ms"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// resultWriter prints an opResult per line. Each result's duration is the
+// time since the previous one, or since the command started.
+type resultWriter struct {
+	w    io.Writer
+	last time.Time
+}
+
+// startOutput checks --output for cmd and, with json, sends the command's
+// usual messages nowhere so stdout only has results on it
+func startOutput(cmd *cobra.Command) error {
+	switch outputMode {
+	case outputText:
+		return nil
+	case outputJSON:
+	default:
+		return fmt.Errorf("invalid output %q (expected one of: %s)", outputMode, strings.Join(outputModes, ", "))
+	}
+
+	// cutting is the root command
+	if cmd.HasParent() && !containsString(outputCommands, cmd.Name()) {
+		return fmt.Errorf("--output json is only supported by cut, copy, grab, paste, pop and clear")
+	}
+	results = &resultWriter{w: cmd.OutOrStdout(), last: time.Now()}
+	cmd.SetOut(io.Discard)
+	return nil
+}
+
+// write reports records as results. What was cut or copied is measured at
+// its path, and what was pasted at its destination.
+func (rw *resultWriter) write(records []HistoryRecord) {
+	if rw == nil {
+		return
+	}
+	for _, record := range records {
+		now := time.Now()
+		result := opResult{
+			Operation:   record.Action,
+			Source:      record.Path,
+			Destination: record.Destination,
+			DurationMS:  now.Sub(rw.last).Milliseconds(),
+			Status:      record.Outcome,
+			Error:       record.Error,
+		}
+		rw.last = now
+
+		measured := record.Path
+		if record.Action == "paste" {
+			measured = record.Destination
+		}
+		if record.Outcome == outcomeOK && record.Action != "clear" && measured != "" {
+			result.Bytes, _ = fsops.TreeSize(measured)
+		}
+
+		line, err := json.Marshal(result)
+		if err == nil {
+			fmt.Fprintf(rw.w, "%s\n", line)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputJSON(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	results = &resultWriter{w: &out, last: time.Now()}
+	defer func() { results = nil }()
+
+	source := filepath.Join(tempDir, "file1.txt")
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := cutFile(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 results, got %q", out.String())
+	}
+	var cut, paste opResult
+	if err := json.Unmarshal([]byte(lines[0]), &cut); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &paste); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	if cut.Operation != "cut" || cut.Source != source || cut.Status != outcomeOK || cut.Bytes != 14 {
+		t.Errorf("Unexpected cut result: %+v", cut)
+	}
+	want := filepath.Join(destDir, "file1.txt")
+	if paste.Operation != "paste" || paste.Destination != want || paste.Status != outcomeOK || paste.Bytes != 14 {
+		t.Errorf("Unexpected paste result: %+v", paste)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// legacyClipboardName is the clipboard file in the home directory that
+// older versions of cx used by default
+const legacyClipboardName = ".cx_clipboard.json"
+
+// legacySiblings are the files kept next to the clipboard that move with it
+// out of the home directory. Caches, sockets and locks are left behind.
+var legacySiblings = []string{".journal.json", ".history.jsonl", ".trash.json"}
+
+// configDir returns the directory holding cx's config: $XDG_CONFIG_HOME/cx,
+// or the platform's user config directory
+func configDir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, "cx"), nil
+}
+
+// stateDir returns the directory holding the clipboard and the files kept
+// next to it: $XDG_STATE_HOME/cx, ~/.local/state/cx, or on macOS and
+// Windows, which have no separate place for state, the user config directory
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "cx"), nil
+	}
+
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "cx"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".local", "state", "cx"), nil
+}
+
+// prepareDefaultClipboard creates the directory of the default clipboard at
+// path and, the first time, moves the legacy clipboard in homeDir and the
+// files next to it there
+func prepareDefaultClipboard(w io.Writer, homeDir, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	legacy := filepath.Join(homeDir, legacyClipboardName)
+	if _, err := os.Lstat(path); err == nil {
+		return nil
+	}
+	if _, err := os.Lstat(legacy); err != nil {
+		return nil
+	}
+
+	legacyBase := strings.TrimSuffix(legacy, filepath.Ext(legacy))
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	moves := [][2]string{{legacy + ".bak", path + ".bak"}}
+	for _, suffix := range legacySiblings {
+		moves = append(moves, [2]string{legacyBase + suffix, base + suffix})
+	}
+	// the clipboard goes last, so an interrupted migration starts over
+	moves = append(moves, [2]string{legacy, path})
+
+	for _, move := range moves {
+		err := fsops.Move(move[0], move[1], fsops.Options{})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to move %s to %s: %w", move[0], move[1], err)
+		}
+	}
+
+	fmt.Fprintf(w, "Moved the clipboard from %s to %s\n", legacy, path)
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXDGDirs(t *testing.T) {
+	stateHome, configHome := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	if dir, err := stateDir(); err != nil || dir != filepath.Join(stateHome, "cx") {
+		t.Errorf("Expected state dir %s, got %s (%v)", filepath.Join(stateHome, "cx"), dir, err)
+	}
+	if path, err := configPath(); err != nil || path != filepath.Join(configHome, "cx", "config.yaml") {
+		t.Errorf("Expected config in %s, got %s (%v)", configHome, path, err)
+	}
+}
+
+func TestPrepareDefaultClipboard(t *testing.T) {
+	homeDir := t.TempDir()
+	path := filepath.Join(homeDir, ".local", "state", "cx", "clipboard.json")
+
+	legacy := map[string]string{
+		".cx_clipboard.json":          `{"entries": []}`,
+		".cx_clipboard.json.bak":      `{"entries": []}`,
+		".cx_clipboard.journal.json":  `[]`,
+		".cx_clipboard.history.jsonl": "{}\n",
+		".cx_clipboard.sizes.json":    `{}`,
+	}
+	for name, content := range legacy {
+		if err := os.WriteFile(filepath.Join(homeDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := prepareDefaultClipboard(&buf, homeDir, path); err != nil {
+		t.Fatalf("prepareDefaultClipboard failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Moved the clipboard from ") {
+		t.Errorf("Expected a notice about the move, got %q", buf.String())
+	}
+
+	dir := filepath.Dir(path)
+	for _, name := range []string{"clipboard.json", "clipboard.json.bak", "clipboard.journal.json", "clipboard.history.jsonl"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be moved: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, ".cx_clipboard.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected the legacy clipboard to be gone, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, ".cx_clipboard.sizes.json")); err != nil {
+		t.Errorf("Expected the size cache to be left behind: %v", err)
+	}
+
+	// Once moved, a new legacy file is left alone
+	if err := os.WriteFile(filepath.Join(homeDir, ".cx_clipboard.json"), []byte(`{"entries": []}`), 0o644); err != nil {
+		t.Fatalf("Failed to write legacy clipboard: %v", err)
+	}
+	buf.Reset()
+	if err := prepareDefaultClipboard(&buf, homeDir, path); err != nil {
+		t.Fatalf("prepareDefaultClipboard failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no migration the second time, got %q", buf.String())
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// sniffSize is how much of a file is read to decide whether it is text
+const sniffSize = 8192
+
+// isText reports whether data looks like text rather than binary data
+func isText(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	// a multi-byte character may have been cut off at the end
+	for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return utf8.Valid(data)
+}
+
+// peekFile writes the first lines of a text file, or notes that it's binary
+func peekFile(w io.Writer, path string, size int64, lines int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, sniffSize)
+	head, err := reader.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return err
+	}
+	if !isText(head) {
+		fmt.Fprintln(w, detailsStyle.Render(fmt.Sprintf("binary file, %s", FormatSize(size))))
+		return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 0; scanner.Scan(); n++ {
+		if n == lines {
+			fmt.Fprintln(w, detailsStyle.Render("…"))
+			break
+		}
+		fmt.Fprintln(w, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// handlePeek shows what the clipboard entry at index holds: the head of a
+// text file, a tree of a directory, or the target of a symlink
+func handlePeek(w io.Writer, index int, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	if index < 0 || index >= len(board.Entries) {
+		return fmt.Errorf("invalid clipboard index: %d", index)
+	}
+
+	path := board.Entries[index].CurrentPath
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, path)
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s -> %s\n", symlinkStyle.Render(path), target)
+		return nil
+	case info.IsDir():
+		files, size := dirSummary(path)
+		fmt.Fprintf(w, "%s %s\n", dirStyle.Render(path+"/"), detailsStyle.Render(formatDirSummary(files, size)))
+		return printTree(w, path, "", opts.depth)
+	case !info.Mode().IsRegular():
+		fmt.Fprintf(w, "%s %s\n", fileStyle.Render(path), detailsStyle.Render("("+info.Mode().Type().String()+")"))
+		return nil
+	default:
+		fmt.Fprintf(w, "%s %s\n", fileStyle.Render(path), detailsStyle.Render(FormatSize(info.Size())))
+		return peekFile(w, path, info.Size(), opts.lines)
+	}
+}
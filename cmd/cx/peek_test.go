@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPeekTextFile(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	path := filepath.Join(tempDir, "lines.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := cutFile(io.Discard, path, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handlePeek(&buf, 0, Options{lines: 2, depth: 2}); err != nil {
+		t.Fatalf("handlePeek failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "one\ntwo\n") || strings.Contains(output, "three") {
+		t.Errorf("Expected the first two lines, got %q", output)
+	}
+	if !strings.Contains(output, "…") {
+		t.Errorf("Expected a marker for the remaining lines, got %q", output)
+	}
+}
+
+func TestPeekBinaryFile(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	path := filepath.Join(tempDir, "data.bin")
+	if err := os.WriteFile(path, []byte{0x7f, 'E', 'L', 'F', 0, 1, 2}, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := cutFile(io.Discard, path, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handlePeek(&buf, 0, Options{lines: 10, depth: 2}); err != nil {
+		t.Fatalf("handlePeek failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "binary file") {
+		t.Errorf("Expected a binary file notice, got %q", buf.String())
+	}
+}
+
+func TestPeekDirectory(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "config", "deep", "deeper"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "config"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handlePeek(&buf, 0, Options{lines: 10, depth: 1}); err != nil {
+		t.Fatalf("handlePeek failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"(2 files, 29 B)", "├── config.ini", "deep/", "└── settings.json"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the tree, got %q", expected, output)
+		}
+	}
+	if strings.Contains(output, "deeper") {
+		t.Errorf("Expected the tree to stop at depth 1, got %q", output)
+	}
+}
+
+func TestPeekSymlink(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink("file1.txt", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := cutFile(io.Discard, link, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handlePeek(&buf, 0, Options{lines: 10, depth: 2}); err != nil {
+		t.Fatalf("handlePeek failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), link+" -> file1.txt") {
+		t.Errorf("Expected the symlink target, got %q", buf.String())
+	}
+
+	if err := handlePeek(io.Discard, 5, Options{}); err == nil {
+		t.Error("Expected error for an invalid index, got nil")
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// pickAction is what to do with the entries chosen in the picker
+type pickAction int
+
+const (
+	pickNone pickAction = iota
+	pickPaste
+	pickCopy
+	pickDrop
+)
+
+var (
+	cursorStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(colorCyan)
+
+	helpStyle = lipgloss.NewStyle().
+			Foreground(colorMuted)
+)
+
+const (
+	pickerHelp = "↑/k ↓/j move • space select • a all • enter/p paste • c copy • d drop • q quit"
+	finderHelp = "type to filter • ↑/↓ move • enter paste • esc quit"
+)
+
+// picker holds the state of the interactive entry selector. In fuzzy mode
+// typed characters go to input and filter the entries instead of acting as
+// shortcuts, and a single entry is chosen.
+type picker struct {
+	entries  []clipboard.Entry
+	fuzzy    bool
+	input    textinput.Model
+	visible  []int
+	cursor   int
+	selected map[int]bool
+	action   pickAction
+	done     bool
+}
+
+func newPicker(entries []clipboard.Entry) *picker {
+	p := &picker{entries: entries, selected: make(map[int]bool)}
+	p.filter()
+	return p
+}
+
+func newFinder(entries []clipboard.Entry) *picker {
+	p := newPicker(entries)
+	p.fuzzy = true
+	p.input = textinput.New()
+	p.input.Prompt = cursorStyle.Render(">") + " "
+	p.input.Focus()
+	return p
+}
+
+// filter recomputes the visible entries for the current query, best match first
+func (p *picker) filter() {
+	type match struct{ index, score int }
+
+	var matches []match
+	for i, entry := range p.entries {
+		if score, ok := fuzzyMatch(p.input.Value(), entry.CurrentPath); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].score > matches[b].score })
+
+	p.visible = p.visible[:0]
+	for _, m := range matches {
+		p.visible = append(p.visible, m.index)
+	}
+	p.cursor = 0
+}
+
+// handleKey updates the picker state for a single key press, returning
+// what the query input needs done next
+func (p *picker) handleKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "ctrl+p":
+		p.moveCursor(-1)
+		return nil
+	case "down", "ctrl+n":
+		p.moveCursor(1)
+		return nil
+	case "esc", "ctrl+c":
+		p.action, p.done = pickNone, true
+		return nil
+	case "enter":
+		if len(p.visible) > 0 {
+			p.action, p.done = pickPaste, true
+		}
+		return nil
+	}
+
+	if p.fuzzy {
+		query := p.input.Value()
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		if p.input.Value() != query {
+			p.filter()
+		}
+		return cmd
+	}
+
+	switch msg.String() {
+	case "k":
+		p.moveCursor(-1)
+	case "j":
+		p.moveCursor(1)
+	case " ":
+		index := p.visible[p.cursor]
+		p.selected[index] = !p.selected[index]
+	case "a":
+		all := len(p.chosenSelected()) == len(p.entries)
+		for i := range p.entries {
+			p.selected[i] = !all
+		}
+	case "p":
+		p.action, p.done = pickPaste, true
+	case "c":
+		p.action, p.done = pickCopy, true
+	case "d":
+		p.action, p.done = pickDrop, true
+	case "q":
+		p.action, p.done = pickNone, true
+	}
+	return nil
+}
+
+func (p *picker) moveCursor(delta int) {
+	p.cursor = max(0, min(p.cursor+delta, len(p.visible)-1))
+}
+
+// chosenSelected returns the explicitly selected indices in ascending order
+func (p *picker) chosenSelected() []int {
+	var indices []int
+	for i, ok := range p.selected {
+		if ok {
+			indices = append(indices, i)
+		}
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// chosen returns the selected indices, or the one under the cursor if nothing
+// has been selected
+func (p *picker) chosen() []int {
+	if indices := p.chosenSelected(); len(indices) > 0 {
+		return indices
+	}
+	if len(p.visible) == 0 {
+		return nil
+	}
+	return []int{p.visible[p.cursor]}
+}
+
+// view renders the picker as a block of lines
+func (p *picker) view() string {
+	var b strings.Builder
+	idxStyle := indexStyle(len(fmt.Sprint(len(p.entries))) + 1)
+
+	if p.fuzzy {
+		fmt.Fprintf(&b, "%s\n\n", p.input.View())
+	}
+
+	for row, i := range p.visible {
+		entry := p.entries[i]
+
+		cursor := "  "
+		if row == p.cursor {
+			cursor = cursorStyle.Render("> ")
+		}
+
+		check := ""
+		if !p.fuzzy {
+			check = "[ ] "
+			if p.selected[i] {
+				check = cursorStyle.Render("[x]") + " "
+			}
+		}
+
+		path := entry.CurrentPath
+		if info, err := os.Lstat(path); err != nil {
+			path = missingPathStyle.Render(path)
+		} else if info.IsDir() {
+			path = dirStyle.Render(path)
+		} else if info.Mode()&os.ModeSymlink != 0 {
+			path = symlinkStyle.Render(path)
+		} else {
+			path = fileStyle.Render(path)
+		}
+
+		fmt.Fprintf(&b, "%s%s%s %s\n", cursor, check, idxStyle.Render(fmt.Sprintf("%d:", i)), path)
+	}
+
+	help := pickerHelp
+	if p.fuzzy {
+		help = fmt.Sprintf("%d/%d • %s", len(p.visible), len(p.entries), finderHelp)
+	}
+	fmt.Fprintf(&b, "\n%s\n", helpStyle.Render(help))
+	return b.String()
+}
+
+// fuzzyMatch reports whether the characters of query appear in order in
+// candidate, ignoring case. Higher scores go to runs of consecutive matches
+// and to matches at the start of a path component or within the base name.
+func fuzzyMatch(query, candidate string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	q := []rune(strings.ToLower(query))
+	c := []rune(strings.ToLower(candidate))
+	baseStart := strings.LastIndex(candidate, "/") + 1
+	baseStart = len([]rune(candidate[:baseStart]))
+
+	score, qi, prev := 0, 0, -2
+	for ci := 0; ci < len(c) && qi < len(q); ci++ {
+		if c[ci] != q[qi] {
+			continue
+		}
+
+		score++
+		if ci == prev+1 {
+			score += 5
+		}
+		if ci == 0 || strings.ContainsRune("/-_. ", c[ci-1]) {
+			score += 3
+		}
+		if ci >= baseStart {
+			score += 2
+		}
+
+		prev = ci
+		qi++
+	}
+
+	if qi < len(q) {
+		return 0, false
+	}
+	return score, true
+}
+
+// Init starts the query input's cursor blinking in fuzzy mode
+func (p *picker) Init() tea.Cmd {
+	if p.fuzzy {
+		return textinput.Blink
+	}
+	return nil
+}
+
+// Update handles key presses, quitting once an action has been chosen, and
+// passes anything else on to the query input
+func (p *picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if key, ok := msg.(tea.KeyMsg); ok {
+		cmd = p.handleKey(key)
+	} else if p.fuzzy {
+		p.input, cmd = p.input.Update(msg)
+	}
+	if p.done {
+		return p, tea.Quit
+	}
+	return p, cmd
+}
+
+// View renders the picker for bubbletea
+func (p *picker) View() string {
+	return p.view()
+}
+
+// runPicker shows p on the terminal until the user chooses an action
+func runPicker(p *picker) (pickAction, []int, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return pickNone, nil, fmt.Errorf("interactive selection requires a terminal")
+	}
+
+	// draw on the alternate screen so the picker leaves no trace behind
+	program := tea.NewProgram(p, tea.WithAltScreen(), tea.WithOutput(os.Stderr))
+	if _, err := program.Run(); err != nil {
+		return pickNone, nil, err
+	}
+	if !p.done {
+		return pickNone, nil, nil
+	}
+	return p.action, p.chosen(), nil
+}
+
+// handlePick lets the user choose entries interactively, then pastes, copies
+// or drops them
+func handlePick(w io.Writer, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		fmt.Fprintln(w, "Clipboard is empty")
+		return nil
+	}
+
+	action, indices, err := runPicker(newPicker(board.Entries))
+	if err != nil {
+		return err
+	}
+
+	picked := make([]clipboard.Entry, 0, len(indices))
+	for _, index := range indices {
+		picked = append(picked, board.Entries[index])
+	}
+
+	switch action {
+	case pickPaste, pickCopy:
+		// the clipboard may have changed while the picker was open
+		indices, err := entryIndices(picked)
+		if err != nil {
+			return err
+		}
+		opts.persist = opts.persist || action == pickCopy
+		return handlePasteEntries(w, indices, opts)
+	case pickDrop:
+		if err := engine().RemoveEntries(picked...); err != nil {
+			return err
+		}
+		if opts.quiet {
+			w = io.Discard
+		}
+		for _, entry := range picked {
+			fmt.Fprintf(w, "Dropped: %s\n", entry.CurrentPath)
+		}
+	}
+
+	return nil
+}
+
+// handlePasteSelect lets the user fuzzy-find a single entry, then pastes it
+func handlePasteSelect(w io.Writer, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	action, indices, err := runPicker(newFinder(board.Entries))
+	if err != nil {
+		return err
+	}
+
+	if action != pickPaste {
+		return nil
+	}
+	return handlePasteAt(w, indices[0], opts)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// keyMsg returns the message bubbletea sends when key is pressed
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(key)}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestPickerSelection(t *testing.T) {
+	entries := []clipboard.Entry{
+		{CurrentPath: "/tmp/a"},
+		{CurrentPath: "/tmp/b"},
+		{CurrentPath: "/tmp/c"},
+	}
+
+	p := newPicker(entries)
+
+	// Nothing selected: the entry under the cursor is chosen
+	p.handleKey(keyMsg("down"))
+	if got := p.chosen(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("Expected cursor entry [1], got %v", got)
+	}
+
+	// Cursor stays within bounds
+	p.handleKey(keyMsg("down"))
+	p.handleKey(keyMsg("down"))
+	if p.cursor != 2 {
+		t.Errorf("Expected cursor to stop at 2, got %d", p.cursor)
+	}
+
+	p.handleKey(keyMsg(" "))
+	p.handleKey(keyMsg("up"))
+	p.handleKey(keyMsg("up"))
+	p.handleKey(keyMsg(" "))
+	if got := p.chosen(); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("Expected selection [0 2], got %v", got)
+	}
+
+	// Toggling all selects the remaining entry, toggling again clears
+	p.handleKey(keyMsg("a"))
+	if got := p.chosen(); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("Expected all entries selected, got %v", got)
+	}
+	p.handleKey(keyMsg("a"))
+	if got := p.chosenSelected(); len(got) != 0 {
+		t.Errorf("Expected selection to be cleared, got %v", got)
+	}
+
+	p.handleKey(keyMsg("d"))
+	if !p.done || p.action != pickDrop {
+		t.Errorf("Expected drop action to finish the picker")
+	}
+}
+
+func TestPickerQuit(t *testing.T) {
+	p := newPicker([]clipboard.Entry{{CurrentPath: "/tmp/a"}})
+	p.handleKey(keyMsg("esc"))
+	if !p.done || p.action != pickNone {
+		t.Errorf("Expected esc to quit without an action")
+	}
+}
+
+func TestPickerUpdate(t *testing.T) {
+	p := newPicker([]clipboard.Entry{{CurrentPath: "/tmp/a"}, {CurrentPath: "/tmp/b"}})
+
+	if _, cmd := p.Update(keyMsg("down")); cmd != nil {
+		t.Error("Expected moving the cursor not to quit")
+	}
+	_, cmd := p.Update(keyMsg("c"))
+	if cmd == nil {
+		t.Fatal("Expected choosing an action to quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Expected choosing an action to quit")
+	}
+	if p.action != pickCopy || !reflect.DeepEqual(p.chosen(), []int{1}) {
+		t.Errorf("Expected entry 1 to be copied, got %v %v", p.action, p.chosen())
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	if _, ok := fuzzyMatch("rpt", "/home/me/report.pdf"); !ok {
+		t.Error("Expected subsequence to match")
+	}
+	if _, ok := fuzzyMatch("RPT", "/home/me/report.pdf"); !ok {
+		t.Error("Expected match to ignore case")
+	}
+	if _, ok := fuzzyMatch("tpr", "/home/me/report.pdf"); ok {
+		t.Error("Expected out-of-order characters not to match")
+	}
+
+	// Consecutive matches in the base name beat scattered ones in the directory
+	base, _ := fuzzyMatch("conf", "/srv/app/config.yaml")
+	scattered, _ := fuzzyMatch("conf", "/code/old/nfs/file.txt")
+	if base <= scattered {
+		t.Errorf("Expected base name match to score higher (%d <= %d)", base, scattered)
+	}
+}
+
+func TestFinderFiltering(t *testing.T) {
+	entries := []clipboard.Entry{
+		{CurrentPath: "/code/old/nfs/file.txt"},
+		{CurrentPath: "/srv/app/config.yaml"},
+		{CurrentPath: "/tmp/notes.md"},
+	}
+
+	p := newFinder(entries)
+	for _, key := range []string{"c", "o", "n", "f"} {
+		p.handleKey(keyMsg(key))
+	}
+
+	// Letters are part of the query in fuzzy mode, not shortcuts
+	if p.done {
+		t.Fatal("Expected typing to filter rather than finish the finder")
+	}
+	if got := p.visible; !reflect.DeepEqual(got, []int{1, 0}) {
+		t.Errorf("Expected best match first [1 0], got %v", got)
+	}
+
+	p.handleKey(keyMsg("backspace"))
+	if query := p.input.Value(); query != "con" {
+		t.Errorf("Expected query %q after backspace, got %q", "con", query)
+	}
+
+	p.handleKey(keyMsg("enter"))
+	if !p.done || p.action != pickPaste || !reflect.DeepEqual(p.chosen(), []int{1}) {
+		t.Errorf("Expected enter to choose entry 1, got %v", p.chosen())
+	}
+}
+
+func TestFinderNoMatches(t *testing.T) {
+	p := newFinder([]clipboard.Entry{{CurrentPath: "/tmp/a"}})
+	p.handleKey(keyMsg("z"))
+	p.handleKey(keyMsg("enter"))
+	if p.done {
+		t.Error("Expected enter to do nothing without matches")
+	}
+}
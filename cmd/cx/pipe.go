@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// handleCat writes the contents of the file at index to w, leaving the
+// entry and the file where they are
+func handleCat(w io.Writer, index int) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+	if index < 0 || index >= len(board.Entries) {
+		return fmt.Errorf("invalid clipboard index: %d", index)
+	}
+
+	entry := board.Entries[index]
+	if isRemoteEntry(entry) {
+		return fmt.Errorf("cannot stream remote entry %s; paste it first", entry.CurrentPath)
+	}
+	f, err := os.Open(entry.CurrentPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory; only files can be streamed (try cx paste --archive)", entry.CurrentPath)
+	}
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// stdinDir returns the directory files made from stdin are kept in until
+// they're pasted
+func stdinDir() string {
+	return siblingPath(".stdin")
+}
+
+// handleFromStdin saves what's read from r as a file called name and cuts
+// it to the clipboard, so pasting it puts the file where it's wanted, e.g.
+// some-cmd | cx --from-stdin --name output.txt
+func handleFromStdin(w io.Writer, r io.Reader, name string, opts Options) error {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return fmt.Errorf("invalid --name %q (expected a file name, without directories)", name)
+	}
+
+	removeEmptyDirs(stdinDir())
+	if err := os.MkdirAll(stdinDir(), 0o700); err != nil {
+		return err
+	}
+	// each file gets its own directory, so names never clash
+	dir, err := os.MkdirTemp(stdinDir(), "")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	if err := addEntries(w, []string{path}, clipboard.OpCut, opts); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// removeEmptyDirs removes the directories in dir left empty once the file
+// made from stdin in them was pasted
+func removeEmptyDirs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			// only succeeds if it's empty
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCat(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	file := filepath.Join(tempDir, "file1.txt")
+	expected, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if err := copyFileToClipboard(io.Discard, file, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handleCat(&out, 0); err != nil {
+		t.Fatalf("handleCat failed: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if len(board.Entries) != 1 {
+		t.Errorf("Expected the entry to stay on the clipboard, got %d entries", len(board.Entries))
+	}
+
+	if err := copyFileToClipboard(io.Discard, tempDir, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := handleCat(io.Discard, 0); err == nil {
+		t.Error("Expected error streaming a directory")
+	}
+	if err := handleCat(io.Discard, 5); err == nil {
+		t.Error("Expected error for an out of range index")
+	}
+}
+
+func TestFromStdin(t *testing.T) {
+	_, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := handleFromStdin(io.Discard, strings.NewReader("hello\n"), "output.txt", Options{}); err != nil {
+		t.Fatalf("handleFromStdin failed: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if len(board.Entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(board.Entries))
+	}
+	entry := board.Entries[0]
+	if filepath.Base(entry.CurrentPath) != "output.txt" {
+		t.Errorf("Expected output.txt, got %s", entry.CurrentPath)
+	}
+	data, err := os.ReadFile(entry.CurrentPath)
+	if err != nil {
+		t.Fatalf("Failed to read the saved file: %v", err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("Expected %q, got %q", "hello\n", data)
+	}
+
+	for _, bad := range []string{"", "dir/output.txt", ".."} {
+		if err := handleFromStdin(io.Discard, strings.NewReader("x"), bad, Options{}); err == nil {
+			t.Errorf("Expected error for --name %q", bad)
+		}
+	}
+}
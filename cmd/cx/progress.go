@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// progressThreshold is the total copy size above which progress is shown
+// without --progress being passed
+const progressThreshold = 256 << 20
+
+const (
+	progressBarWidth = 30
+	progressInterval = 100 * time.Millisecond
+)
+
+var (
+	progressFilledStyle = lipgloss.NewStyle().
+				Foreground(colorCyan)
+
+	progressEmptyStyle = lipgloss.NewStyle().
+				Foreground(colorMuted)
+)
+
+// progress tracks and renders the aggregate and per-file progress of a paste
+type progress struct {
+	out      io.Writer
+	total    int64
+	done     int64
+	file     string
+	fileSize int64
+	fileDone int64
+	start    time.Time
+	lastDraw time.Time
+	drawn    bool
+	finished bool
+	// list prints each file as it is copied instead of drawing a bar
+	list bool
+}
+
+func newProgress(out io.Writer, total int64) *progress {
+	return &progress{out: out, total: total, start: time.Now()}
+}
+
+// startProgress returns a progress tracker for pasting entries, or nil if no
+// progress should be shown. Without --progress it is only shown for copies
+// larger than progressThreshold when stderr is a terminal; moves are usually
+// instant renames, so they aren't measured unless asked for. With --verbose
+// the files are listed on w instead.
+func startProgress(w io.Writer, entries []clipboard.Entry, opts Options) *progress {
+	if opts.quiet {
+		return nil
+	}
+
+	if verbose {
+		return &progress{out: w, start: time.Now(), list: true}
+	}
+
+	if !opts.showProgress && !term.IsTerminal(os.Stderr.Fd()) {
+		return nil
+	}
+
+	var counted []clipboard.Entry
+	for _, entry := range entries {
+		if opts.showProgress || opts.persist || entry.IsCopy() {
+			counted = append(counted, entry)
+		}
+	}
+	var total int64
+	for _, stats := range measureEntries(counted) {
+		total += stats.Size
+	}
+
+	if !opts.showProgress && total < progressThreshold {
+		return nil
+	}
+
+	return newProgress(os.Stderr, total)
+}
+
+// StartFile begins tracking a new file being copied
+func (p *progress) StartFile(name string, size int64) {
+	if p == nil {
+		return
+	}
+	p.file = name
+	p.fileSize = size
+	p.fileDone = 0
+	if p.list {
+		fmt.Fprintf(p.out, "  %s %s\n", name, detailsStyle.Render("("+FormatSize(size)+")"))
+	}
+}
+
+// Add records n more bytes copied and redraws if enough time has passed
+func (p *progress) Add(n int64) {
+	if p == nil {
+		return
+	}
+	p.done += n
+	p.fileDone += n
+	if p.list || p.finished {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(p.lastDraw) >= progressInterval || p.fileDone == p.fileSize {
+		p.lastDraw = now
+		p.drawn = true
+		fmt.Fprintf(p.out, "\r%s\x1b[K", p.render(now))
+	}
+}
+
+// clear erases the progress line so that a message can be printed; the
+// next Add draws it again
+func (p *progress) clear() {
+	if p == nil || !p.drawn {
+		return
+	}
+	fmt.Fprint(p.out, "\r\x1b[K")
+	p.drawn = false
+}
+
+// finish clears the progress line once a paste is over so that its summary
+// can follow, and stops it being drawn again
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	p.clear()
+	p.finished = true
+}
+
+// render formats the progress line as of now
+func (p *progress) render(now time.Time) string {
+	fraction := 1.0
+	if p.total > 0 {
+		fraction = min(float64(p.done)/float64(p.total), 1)
+	}
+
+	filled := int(fraction * progressBarWidth)
+	bar := progressFilledStyle.Render(strings.Repeat("█", filled)) +
+		progressEmptyStyle.Render(strings.Repeat("░", progressBarWidth-filled))
+
+	line := fmt.Sprintf("%s %3.0f%%  %s / %s", bar, fraction*100, FormatSize(p.done), FormatSize(p.total))
+
+	if elapsed := now.Sub(p.start).Seconds(); elapsed > 0 && p.done > 0 {
+		rate := float64(p.done) / elapsed
+		line += fmt.Sprintf("  %s/s", FormatSize(int64(rate)))
+		if remaining := p.total - p.done; remaining > 0 {
+			eta := time.Duration(float64(remaining)/rate) * time.Second
+			line += fmt.Sprintf("  ETA %s", eta.Round(time.Second))
+		}
+	}
+
+	if p.file != "" {
+		fileFraction := 1.0
+		if p.fileSize > 0 {
+			fileFraction = float64(p.fileDone) / float64(p.fileSize)
+		}
+		line += detailsStyle.Render(fmt.Sprintf("  %s (%.0f%%)", filepath.Base(p.file), fileFraction*100))
+	}
+
+	return line
+}
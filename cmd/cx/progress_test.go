@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+func TestProgressRender(t *testing.T) {
+	p := newProgress(io.Discard, 1000)
+	p.start = time.Now().Add(-2 * time.Second)
+	p.StartFile("/tmp/big.iso", 1000)
+	p.done, p.fileDone = 500, 500
+
+	line := p.render(p.start.Add(2 * time.Second))
+
+	for _, want := range []string{"50%", "500 B / 1.0 kB", "250 B/s", "ETA 2s", "big.iso (50%)"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected progress line to contain %q, got %q", want, line)
+		}
+	}
+}
+
+func TestProgressCopy(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	src := filepath.Join(tempDir, "config")
+	size, err := fsops.TreeSize(src)
+	if err != nil {
+		t.Fatalf("treeSize failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	p := newProgress(&out, size)
+	if err := fsops.CopyDir(src, filepath.Join(tempDir, "copy"), fsops.Options{Progress: p}); err != nil {
+		t.Fatalf("copyDir failed: %v", err)
+	}
+	p.finish()
+
+	if p.done != size {
+		t.Errorf("Expected %d bytes tracked, got %d", size, p.done)
+	}
+	if !strings.Contains(out.String(), "100%") {
+		t.Errorf("Expected final progress to reach 100%%, got %q", out.String())
+	}
+}
+
+func TestNoProgressWhenQuiet(t *testing.T) {
+	if p := startProgress(io.Discard, []clipboard.Entry{{CurrentPath: os.TempDir()}}, Options{quiet: true, showProgress: true}); p != nil {
+		t.Error("Expected no progress in quiet mode")
+	}
+}
+
+func TestProgressFinish(t *testing.T) {
+	var out bytes.Buffer
+	p := newProgress(&out, 100)
+	p.StartFile("a", 50)
+	p.Add(50)
+
+	// clearing for a message between entries lets the next one draw again
+	p.clear()
+	out.Reset()
+	p.StartFile("b", 50)
+	p.Add(50)
+	if !strings.Contains(out.String(), "100%") {
+		t.Errorf("Expected progress to be drawn again after clear, got %q", out.String())
+	}
+
+	p.finish()
+	out.Reset()
+	p.Add(10)
+	p.finish()
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing drawn after finish, got %q", out.String())
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/spf13/cobra"
+)
+
+// expireAfter is how long entries stay in the clipboard; zero keeps them forever
+var expireAfter time.Duration
+
+// pruneMissing is whether entries whose paths no longer exist are removed
+// before every command, instead of only being flagged
+var pruneMissing bool
+
+// pruneExpired removes entries older than maxAge from the clipboard and
+// returns them
+func pruneExpired(maxAge time.Duration) ([]clipboard.Entry, error) {
+	if maxAge <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	return pruneEntries(func(entry clipboard.Entry) bool {
+		return entry.Expired(maxAge, now)
+	})
+}
+
+// entryMissing reports whether entry is a local one whose path no longer
+// exists. Remote entries aren't checked, since that takes a connection.
+func entryMissing(entry clipboard.Entry) bool {
+	if isRemoteEntry(entry) {
+		return false
+	}
+	_, err := os.Lstat(entry.CurrentPath)
+	return err != nil
+}
+
+// pruneEntries removes the entries that prune picks from the clipboard and
+// returns them
+func pruneEntries(prune func(clipboard.Entry) bool) ([]clipboard.Entry, error) {
+	var pruned []clipboard.Entry
+	err := updateClipboard(func(board *clipboard.Clipboard) error {
+		kept := make([]clipboard.Entry, 0, len(board.Entries))
+		for _, entry := range board.Entries {
+			if prune(entry) {
+				pruned = append(pruned, entry)
+			} else {
+				kept = append(kept, entry)
+			}
+		}
+
+		if len(pruned) == 0 {
+			return clipboard.ErrUnchanged
+		}
+		board.Entries = kept
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// checkMissing runs before each command: with pruneMissing it removes the
+// entries whose paths no longer exist, saying which, and otherwise it
+// warns that there are some, except before list, which shows them anyway,
+// and prune. Paste is left alone either way: it looks for missing entries'
+// files first, in case they were moved, and prunes only those it can't
+// find.
+func checkMissing(w io.Writer, cmd *cobra.Command) error {
+	if cmd == pasteCmd {
+		return nil
+	}
+	if !pruneMissing {
+		if cmd == listCmd || cmd == pruneCmd {
+			return nil
+		}
+		board, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		missing := 0
+		for _, entry := range board.Entries {
+			if entryMissing(entry) {
+				missing++
+			}
+		}
+		switch {
+		case missing == 1:
+			fmt.Fprintln(w, "Warning: 1 clipboard entry no longer exists (cx prune --missing removes it)")
+		case missing > 1:
+			fmt.Fprintf(w, "Warning: %d clipboard entries no longer exist (cx prune --missing removes them)\n", missing)
+		}
+		return nil
+	}
+
+	pruned, err := pruneEntries(entryMissing)
+	for _, entry := range pruned {
+		fmt.Fprintf(w, "Pruned: %s (no longer exists)\n", entry.CurrentPath)
+	}
+	return err
+}
+
+// handlePrune removes expired entries, or with missing those whose paths
+// no longer exist, and reports what was removed. Both are pruned if missing
+// is set along with a maxAge.
+func handlePrune(w io.Writer, maxAge time.Duration, missing bool, opts Options) error {
+	if maxAge <= 0 && !missing {
+		return fmt.Errorf("no expiry configured, use --older-than, --expire-after or --missing")
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if maxAge > 0 {
+		pruned, err := pruneExpired(maxAge)
+		if err != nil {
+			return err
+		}
+		for _, entry := range pruned {
+			fmt.Fprintf(w, "Pruned: %s %s\n", entry.CurrentPath, detailsStyle.Render(FormatCutAtTime(entry.CutAt)))
+		}
+		fmt.Fprintf(w, "Pruned %d expired entries\n", len(pruned))
+	}
+
+	if missing {
+		pruned, err := pruneEntries(entryMissing)
+		if err != nil {
+			return err
+		}
+		for _, entry := range pruned {
+			fmt.Fprintf(w, "Pruned: %s %s\n", entry.CurrentPath, detailsStyle.Render("(no longer exists)"))
+		}
+		fmt.Fprintf(w, "Pruned %d missing entries\n", len(pruned))
+	}
+	return nil
+}
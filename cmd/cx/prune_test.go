@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// ageEntries backdates the clipboard entries by the given ages, in order
+func ageEntries(t *testing.T, ages ...time.Duration) {
+	t.Helper()
+
+	err := updateClipboard(func(board *clipboard.Clipboard) error {
+		for i, age := range ages {
+			board.Entries[i].CutAt = time.Now().Add(-age)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to update clipboard: %v", err)
+	}
+}
+
+func TestPruneExpired(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+	ageEntries(t, time.Hour, 48*time.Hour)
+
+	// Zero max age never prunes
+	pruned, err := pruneExpired(0)
+	if err != nil || len(pruned) != 0 {
+		t.Fatalf("Expected nothing pruned without expiry, got %v, %v", pruned, err)
+	}
+
+	pruned, err = pruneExpired(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("pruneExpired failed: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].OriginalPath != filepath.Join(tempDir, "file1.txt") {
+		t.Errorf("Expected file1.txt to be pruned, got %+v", pruned)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].OriginalPath != filepath.Join(tempDir, "file2.txt") {
+		t.Errorf("Unexpected entries after prune: %+v", board.Entries)
+	}
+}
+
+func TestListShowsExpired(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalExpireAfter := expireAfter
+	defer func() { expireAfter = originalExpireAfter }()
+	expireAfter = time.Hour
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	ageEntries(t, 2*time.Hour)
+
+	var out bytes.Buffer
+	if err := handleList(&out, Options{all: true}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "(expired)") {
+		t.Errorf("Expected expired entry to be marked, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := handlePrune(&out, expireAfter, false, Options{}); err != nil {
+		t.Fatalf("handlePrune failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Pruned 1 expired entries") {
+		t.Errorf("Unexpected prune output: %q", out.String())
+	}
+}
+
+func TestPruneMissing(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	gone := filepath.Join(tempDir, "file1.txt")
+	for _, path := range []string{gone, filepath.Join(tempDir, "file2.txt")} {
+		if err := cutFile(io.Discard, path, Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := checkMissing(&out, rootCmd); err != nil {
+		t.Fatalf("checkMissing failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "1 clipboard entry no longer exists") {
+		t.Errorf("Expected a warning about the missing entry, got %q", out.String())
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 2 {
+		t.Fatalf("Expected the missing entry only flagged, got %+v", board.Entries)
+	}
+
+	out.Reset()
+	if err := handlePrune(&out, 0, true, Options{}); err != nil {
+		t.Fatalf("handlePrune failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Pruned: "+gone) || !strings.Contains(out.String(), "Pruned 1 missing entries") {
+		t.Errorf("Unexpected prune output: %q", out.String())
+	}
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath == gone {
+		t.Errorf("Expected only the existing entry left, got %+v", board.Entries)
+	}
+}
+
+func TestAutoPruneMissing(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	pruneMissing = true
+	defer func() { pruneMissing = false }()
+
+	gone := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, gone, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := checkMissing(&out, listCmd); err != nil {
+		t.Fatalf("checkMissing failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Pruned: "+gone) {
+		t.Errorf("Expected the pruned entry reported, got %q", out.String())
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 0 {
+		t.Errorf("Expected the missing entry pruned, got %+v", board.Entries)
+	}
+}
+
+func TestAutoPruneMissingPaste(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	pruneMissing = true
+	defer func() { pruneMissing = false }()
+	originalStdin := stdin
+	defer func() { stdin = originalStdin }()
+
+	moved := filepath.Join(tempDir, "file1.txt")
+	gone := filepath.Join(tempDir, "file2.txt")
+	for _, path := range []string{moved, gone} {
+		if err := cutFile(io.Discard, path, Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+	renamed := filepath.Join(tempDir, "nested", "renamed.txt")
+	if err := os.Rename(moved, renamed); err != nil {
+		t.Fatalf("Failed to move file: %v", err)
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	// paste gets to look for them before anything is pruned
+	var out bytes.Buffer
+	if err := checkMissing(&out, pasteCmd); err != nil {
+		t.Fatalf("checkMissing failed: %v", err)
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 2 {
+		t.Fatalf("Expected nothing pruned before paste, got %+v", board.Entries)
+	}
+
+	stdin = strings.NewReader("y\n")
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := handlePasteAll(&out, Options{dest: destDir}); err != nil {
+		t.Fatalf("handlePasteAll failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "renamed.txt")); err != nil {
+		t.Errorf("Expected the moved file to be found and pasted: %v", err)
+	}
+	if !strings.Contains(out.String(), "Pruned: "+gone) {
+		t.Errorf("Expected the lost entry pruned, got %q", out.String())
+	}
+	if board, _ := readClipboard(); len(board.Entries) != 0 {
+		t.Errorf("Expected the clipboard empty, got %+v", board.Entries)
+	}
+}
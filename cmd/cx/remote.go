@@ -0,0 +1,433 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// remotePath is a path on another machine, written scp-style as
+// [user@]host:path. An empty path is the remote home directory.
+type remotePath struct {
+	host string
+	path string
+}
+
+// parseRemotePath reports whether s names a remote path. Like scp, a colon
+// only makes a remote path if no slash comes before it, so local paths with
+// colons can be written as ./name:with:colons. A host starting with a dash
+// would be read by ssh as an option, such as -oProxyCommand=..., so it
+// doesn't make a remote path either.
+func parseRemotePath(s string) (remotePath, bool) {
+	host, p, ok := strings.Cut(s, ":")
+	if !ok || host == "" || strings.HasPrefix(host, "-") || strings.Contains(host, "/") || strings.HasSuffix(host, "@") {
+		return remotePath{}, false
+	}
+	// the remote shell starts in the home directory, so ~/ adds nothing
+	p = strings.TrimPrefix(p, "~/")
+	if p == "~" {
+		p = ""
+	}
+	return remotePath{host: host, path: p}, true
+}
+
+// String returns the path in scp form
+func (r remotePath) String() string {
+	return r.host + ":" + r.path
+}
+
+// join returns the remote path of name inside r
+func (r remotePath) join(name string) remotePath {
+	return remotePath{host: r.host, path: path.Join(r.path, name)}
+}
+
+// shell runs command with the remote shell over ssh, returning its exit code.
+// Host keys are checked as ssh is configured to, and ssh's own errors (exit
+// code 255) are returned as errors.
+func (r remotePath) shell(command string) (int, error) {
+	cmd := exec.Command("ssh", "--", r.host, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() != 255 {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ssh %s: %w", r.host, err)
+	}
+	return 0, nil
+}
+
+// exists reports whether the remote path exists
+func (r remotePath) exists() (bool, error) {
+	code, err := r.shell("test -e " + shellQuote(r.remoteShellPath()))
+	return code == 0, err
+}
+
+// mkdirAll creates the remote directory and any missing parents
+func (r remotePath) mkdirAll() error {
+	code, err := r.shell("mkdir -p " + shellQuote(r.remoteShellPath()))
+	if err == nil && code != 0 {
+		err = fmt.Errorf("failed to create %s", r)
+	}
+	return err
+}
+
+// removeAll removes the remote path and anything inside it
+func (r remotePath) removeAll() error {
+	code, err := r.shell("rm -rf " + shellQuote(r.remoteShellPath()))
+	if err == nil && code != 0 {
+		err = fmt.Errorf("failed to remove %s", r)
+	}
+	return err
+}
+
+// remoteShellPath returns the path for use in a remote shell command, where
+// the empty home directory path has to be spelled out
+func (r remotePath) remoteShellPath() string {
+	if r.path == "" {
+		return "."
+	}
+	return r.path
+}
+
+// scp copies source to dest with scp, recursively for directories. One of
+// them is a remote path in scp form. scp shows its own progress meter unless
+// quiet, and -p keeps modes and timestamps; ownership isn't preserved.
+// --bwlimit becomes scp's -l, which is in Kbit/s.
+func scp(source, dest string, opts Options) error {
+	args := []string{"-r"}
+	if opts.quiet {
+		args = append(args, "-q")
+	}
+	if opts.bwlimit > 0 {
+		args = append(args, "-l", strconv.FormatInt(max(opts.bwlimit*8/1000, 1), 10))
+	}
+	if opts.preserve.Mode || opts.preserve.Timestamps {
+		args = append(args, "-p")
+	}
+	args = append(args, "--", source, dest)
+
+	cmd := exec.Command("scp", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("scp %s to %s: %w", source, dest, err)
+	}
+	return nil
+}
+
+// addRemoteEntries records paths on other machines in the clipboard, to be
+// downloaded on paste. Nothing is added unless every path exists.
+func addRemoteEntries(w io.Writer, remotes []remotePath, op clipboard.Operation, opts Options) error {
+	if opts.sys {
+		return fmt.Errorf("--sys cannot be used with remote paths")
+	}
+
+	for _, remote := range remotes {
+		if remote.path == "" {
+			return fmt.Errorf("%s: give the path of a file or directory on %s", remote, remote.host)
+		}
+		if err := runHook("pre_cut", hookVars{source: remote.String(), op: op}); err != nil {
+			return err
+		}
+		exists, err := remote.exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("remote path does not exist: %s", remote)
+		}
+	}
+
+	// the last path goes on top, since the clipboard is a stack
+	now := time.Now()
+	seen := make(map[string]bool, len(remotes))
+	added := make([]clipboard.Entry, 0, len(remotes))
+	records := make([]HistoryRecord, 0, len(remotes))
+	for i := len(remotes) - 1; i >= 0; i-- {
+		path := remotes[i].String()
+		if !seen[path] {
+			seen[path] = true
+			added = append(added, clipboard.Entry{OriginalPath: path, CurrentPath: path, CutAt: now, Op: op, Tags: opts.tags})
+			records = append(records, newHistoryRecord(string(op), path, "", nil))
+		}
+	}
+
+	var evicted []clipboard.Entry
+	err := updateClipboard(func(board *clipboard.Clipboard) error {
+		kept := make([]clipboard.Entry, 0, len(board.Entries))
+		for i, entry := range board.Entries {
+			if !seen[entry.CurrentPath] {
+				kept = append(kept, entry)
+				continue
+			}
+			if !opts.force {
+				return fmt.Errorf("%w (use --force to replace it)", &clipboard.DuplicateError{Path: entry.CurrentPath, Index: i})
+			}
+		}
+
+		kept, dropped, err := limits.Apply(kept, added)
+		if err != nil {
+			return explainLimit(err)
+		}
+		evicted = dropped
+		board.Entries = append(added, kept...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := appendHistory(records...); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	for i := len(added) - 1; i >= 0; i-- {
+		path := added[i].CurrentPath
+		if err := runHook("post_cut", hookVars{source: path, op: op}); err != nil {
+			return err
+		}
+		if op == clipboard.OpCopy {
+			fmt.Fprintf(w, "Copy: %s\n", path)
+		} else {
+			fmt.Fprintf(w, "Cut: %s\n", path)
+		}
+	}
+	printEvicted(w, evicted)
+	return nil
+}
+
+// splitRemotePaths separates remote paths from local ones, refusing a mix
+func splitRemotePaths(paths []string) ([]remotePath, error) {
+	var remotes []remotePath
+	for _, path := range paths {
+		if remote, ok := parseRemotePath(path); ok {
+			remotes = append(remotes, remote)
+		}
+	}
+	if len(remotes) > 0 && len(remotes) < len(paths) {
+		return nil, fmt.Errorf("local and remote paths cannot be added together")
+	}
+	return remotes, nil
+}
+
+// isRemoteEntry reports whether entry is a path on another machine
+func isRemoteEntry(entry clipboard.Entry) bool {
+	_, ok := parseRemotePath(entry.CurrentPath)
+	return ok
+}
+
+// download copies a remote path into destDir with scp, resolving conflicts
+// like a local paste. Unless opts.persist is set, the remote path is removed
+// once it has arrived.
+func download(remote remotePath, destDir string, opts Options) (string, error) {
+	if opts.verify {
+		return "", fmt.Errorf("--verify is not supported for remote entries")
+	}
+	if len(opts.exclude) > 0 || opts.gitignore {
+		return "", fmt.Errorf("--exclude and --gitignore are not supported for remote entries")
+	}
+	if opts.hardlink {
+		return "", fmt.Errorf("--hardlink is not supported for remote entries")
+	}
+
+	name, err := clipboard.PasteName(path.Base(remote.path), opts.as)
+	if err != nil {
+		return "", err
+	}
+	destPath, overwrite, err := engine().ResolveConflict(remote.String(), filepath.Join(destDir, name), opts.onConflict)
+	if err != nil {
+		return "", err
+	}
+
+	if err := overwrite.Finish(scp(remote.String(), destPath, opts)); err != nil {
+		return "", err
+	}
+
+	if !opts.persist {
+		if err := remote.removeAll(); err != nil {
+			return destPath, fmt.Errorf("downloaded, but failed to remove the source: %w", err)
+		}
+	}
+	return destPath, nil
+}
+
+// handleRemotePaste pastes the clipboard entries at the given indices, or
+// every entry if indices is nil, into a directory on another machine over
+// ssh. Cut entries are removed locally once they have been uploaded.
+func handleRemotePaste(w io.Writer, indices []int, dest remotePath, opts Options) error {
+	switch {
+	case len(opts.exclude) > 0 || opts.gitignore:
+		return fmt.Errorf("--exclude and --gitignore are not supported for remote pastes")
+	case opts.verify:
+		return fmt.Errorf("--verify is not supported for remote pastes")
+	case opts.onConflict == clipboard.ConflictRename || opts.onConflict == clipboard.ConflictPrompt:
+		return fmt.Errorf("--on-conflict %s is not supported for remote pastes", opts.onConflict)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	selected := make(map[int]bool, len(indices))
+	for _, index := range indices {
+		if index < 0 || index >= len(board.Entries) {
+			return fmt.Errorf("invalid clipboard index: %d", index)
+		}
+		selected[index] = true
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if opts.mkdir && !opts.dryRun {
+		if err := dest.mkdirAll(); err != nil {
+			return err
+		}
+	}
+
+	total := 0
+	var errs []error
+	var removed []clipboard.Entry
+
+	for i, entry := range board.Entries {
+		if indices != nil && !selected[i] {
+			continue
+		}
+		total++
+
+		target, err := remotePasteTarget(entry, dest, opts)
+		if err == nil && opts.dryRun {
+			fmt.Fprintf(w, "Would paste: %s -> %s\n", entry.CurrentPath, target)
+			continue
+		}
+		if err == nil {
+			err = pasteRemote(entry, target, opts)
+		}
+		destination := ""
+		if target.host != "" {
+			destination = target.String()
+		}
+		if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, destination, err)); histErr != nil {
+			errs = append(errs, histErr)
+		}
+		if errors.Is(err, clipboard.ErrSkipped) {
+			fmt.Fprintf(w, "Skipped: %s (destination already exists)\n", entry.CurrentPath)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+
+		persist := opts.persist || entry.IsCopy()
+		switch {
+		case persist:
+			fmt.Fprintf(w, "Copied: %s -> %s\n", entry.CurrentPath, target)
+			if opts.pop {
+				removed = append(removed, entry)
+			}
+		default:
+			fmt.Fprintf(w, "Moved: %s -> %s\n", entry.CurrentPath, target)
+			removed = append(removed, entry)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := engine().RemoveEntries(removed...); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to paste %d of %d entries:\n%w", len(errs), total, errors.Join(errs...))
+	}
+	return nil
+}
+
+// remotePasteTarget returns where entry would be pasted inside dest
+func remotePasteTarget(entry clipboard.Entry, dest remotePath, opts Options) (remotePath, error) {
+	if isRemoteEntry(entry) {
+		return remotePath{}, fmt.Errorf("%s is on another machine; paste it locally first", entry.CurrentPath)
+	}
+	if _, err := os.Lstat(entry.CurrentPath); err != nil {
+		return remotePath{}, fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+	}
+	name, err := clipboard.PasteName(filepath.Base(entry.CurrentPath), opts.as)
+	if err != nil {
+		return remotePath{}, err
+	}
+	return dest.join(name), nil
+}
+
+// pasteRemote uploads a single entry to target, running the paste hooks
+// around it and removing the local source of a cut once it has arrived
+func pasteRemote(entry clipboard.Entry, target remotePath, opts Options) error {
+	persist := opts.persist || entry.IsCopy()
+	vars := hookVars{source: entry.CurrentPath, dest: target.String(), op: pasteOp(persist)}
+
+	if err := runHook("pre_paste", vars); err != nil {
+		return err
+	}
+
+	exists, err := target.exists()
+	if err != nil {
+		return err
+	}
+	if exists {
+		switch opts.onConflict {
+		case clipboard.ConflictSkip:
+			return clipboard.ErrSkipped
+		case clipboard.ConflictOverwrite:
+			if confirming() {
+				ok, err := confirmAction(fmt.Sprintf("Overwrite %s?", target))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return clipboard.ErrSkipped
+				}
+			}
+			// scp would copy a directory into the existing one
+			if err := target.removeAll(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s", clipboard.ErrConflict, target)
+		}
+	}
+
+	if err := scp(entry.CurrentPath, target.String(), opts); err != nil {
+		return err
+	}
+
+	if !persist {
+		if err := os.RemoveAll(entry.CurrentPath); err != nil {
+			return fmt.Errorf("uploaded, but failed to remove the source: %w", err)
+		}
+	}
+
+	return runHook("post_paste", vars)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestParseRemotePath(t *testing.T) {
+	tests := []struct {
+		input    string
+		remote   bool
+		expected remotePath
+	}{
+		{"user@host:/srv/data", true, remotePath{host: "user@host", path: "/srv/data"}},
+		{"host:backups", true, remotePath{host: "host", path: "backups"}},
+		{"host:", true, remotePath{host: "host", path: ""}},
+		{"host:~/backups", true, remotePath{host: "host", path: "backups"}},
+		{"./name:with:colons", false, remotePath{}},
+		{"/tmp/a:b", false, remotePath{}},
+		{"plain", false, remotePath{}},
+		{":path", false, remotePath{}},
+		{"-oProxyCommand=touch pwned:x", false, remotePath{}},
+	}
+
+	for _, tt := range tests {
+		remote, ok := parseRemotePath(tt.input)
+		if ok != tt.remote || remote != tt.expected {
+			t.Errorf("parseRemotePath(%q): expected %+v (%v), got %+v (%v)", tt.input, tt.expected, tt.remote, remote, ok)
+		}
+	}
+}
+
+// fakeSSH puts ssh and scp stand-ins on PATH that work on the local machine,
+// ignoring the host
+func fakeSSH(t *testing.T) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	scripts := map[string]string{
+		"ssh": "#!/bin/sh\nwhile [ \"$1\" != -- ]; do shift; done\nexec sh -c \"$3\"\n",
+		"scp": "#!/bin/sh\nwhile [ \"$1\" != -- ]; do shift; done\nexec cp -R \"${2#*:}\" \"${3#*:}\"\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(script), 0o755); err != nil {
+			t.Fatalf("Failed to write fake %s: %v", name, err)
+		}
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestHandleRemotePaste(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	fakeSSH(t)
+
+	destDir := filepath.Join(tempDir, "remote", "srv")
+	dest := remotePath{host: "user@host", path: destDir}
+
+	cutSource := filepath.Join(tempDir, "nested")
+	copySource := filepath.Join(tempDir, "file1.txt")
+	if err := copyFileToClipboard(io.Discard, copySource, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	if err := cutFile(io.Discard, cutSource, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	if err := handleRemotePaste(io.Discard, nil, dest, Options{mkdir: true}); err != nil {
+		t.Fatalf("handleRemotePaste failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "nested", "file3.txt")); err != nil {
+		t.Errorf("Expected cut directory to be uploaded: %v", err)
+	}
+	if _, err := os.Stat(cutSource); !os.IsNotExist(err) {
+		t.Error("Expected cut source to be removed after upload")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file1.txt")); err != nil {
+		t.Errorf("Expected copied file to be uploaded: %v", err)
+	}
+	if _, err := os.Stat(copySource); err != nil {
+		t.Errorf("Expected copied source to remain: %v", err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != copySource {
+		t.Errorf("Expected only the copy to remain in the clipboard, got %+v", board.Entries)
+	}
+
+	// The copy is now on the remote side, so pasting it again conflicts
+	if err := handleRemotePaste(io.Discard, []int{0}, dest, Options{}); err == nil {
+		t.Error("Expected error for an existing remote destination, got nil")
+	}
+	if err := handleRemotePaste(io.Discard, []int{0}, dest, Options{onConflict: clipboard.ConflictSkip}); err != nil {
+		t.Errorf("Expected skip to succeed, got %v", err)
+	}
+	if err := handleRemotePaste(io.Discard, []int{0}, dest, Options{onConflict: clipboard.ConflictRename}); err == nil {
+		t.Error("Expected error for --on-conflict rename, got nil")
+	}
+}
+
+func TestRemoteSource(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	fakeSSH(t)
+
+	serverDir := filepath.Join(tempDir, "config")
+	destDir := filepath.Join(tempDir, "empty_dir")
+	remote := "user@host:" + filepath.Join(serverDir, "config.ini")
+
+	if err := addEntries(io.Discard, []string{remote}, clipboard.OpCut, Options{}); err != nil {
+		t.Fatalf("addEntries failed: %v", err)
+	}
+	if err := addEntries(io.Discard, []string{remote}, clipboard.OpCut, Options{}); err == nil {
+		t.Error("Expected error adding the remote path twice, got nil")
+	}
+	if err := addEntries(io.Discard, []string{"user@host:" + filepath.Join(serverDir, "missing")}, clipboard.OpCut, Options{}); err == nil {
+		t.Error("Expected error for a missing remote path, got nil")
+	}
+	if err := addEntries(io.Discard, []string{remote, filepath.Join(tempDir, "file1.txt")}, clipboard.OpCut, Options{}); err == nil {
+		t.Error("Expected error mixing local and remote paths, got nil")
+	}
+
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(destDir, "config.ini")); err != nil || string(data) != "key=value" {
+		t.Errorf("Expected config.ini to be downloaded, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(serverDir, "config.ini")); !os.IsNotExist(err) {
+		t.Error("Expected remote source of a cut to be removed")
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 0 {
+		t.Errorf("Expected empty clipboard after pasting the cut, got %+v", board.Entries)
+	}
+}
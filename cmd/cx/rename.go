@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// renamer renames entries as they're pasted with --rename: either a sed-style
+// s/pattern/replacement/flags substitution or a Go template
+type renamer struct {
+	pattern     *regexp.Regexp
+	replacement string
+	global      bool
+	tmpl        *template.Template
+}
+
+// renameData is what a --rename template sees: {{.Name}}, {{.Stem}},
+// {{.Ext}} and {{.N}}, the entry's position among those pasted, from 1
+type renameData struct {
+	Name string
+	Stem string
+	Ext  string
+	N    int
+}
+
+// parseRename parses a --rename expression. Anything containing {{ is a Go
+// template; otherwise it must be s/pattern/replacement/ with an optional g
+// (every match) or i (ignore case) flag, any delimiter after the s, and \1
+// or & in the replacement, like sed.
+func parseRename(expr string) (*renamer, error) {
+	if strings.Contains(expr, "{{") {
+		tmpl, err := template.New("rename").Option("missingkey=error").Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --rename template: %w", err)
+		}
+		return &renamer{tmpl: tmpl}, nil
+	}
+
+	if len(expr) < 2 || expr[0] != 's' {
+		return nil, fmt.Errorf("invalid --rename %q, expected s/pattern/replacement/ or a {{...}} template", expr)
+	}
+	parts := splitUnescaped(expr[2:], expr[1])
+	if len(parts) != 3 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid --rename %q, expected s/pattern/replacement/", expr)
+	}
+
+	r := &renamer{replacement: sedReplacement(parts[1])}
+	pattern := parts[0]
+	for _, flag := range parts[2] {
+		switch flag {
+		case 'g':
+			r.global = true
+		case 'i':
+			pattern = "(?i)" + pattern
+		default:
+			return nil, fmt.Errorf("invalid --rename flag %q, expected g or i", flag)
+		}
+	}
+
+	var err error
+	if r.pattern, err = regexp.Compile(pattern); err != nil {
+		return nil, fmt.Errorf("invalid --rename pattern: %w", err)
+	}
+	return r, nil
+}
+
+// splitUnescaped splits s on delim, except where it's escaped with a
+// backslash; the backslash is dropped from an escaped delim
+func splitUnescaped(s string, delim byte) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			part.WriteByte(delim)
+			i++
+		case s[i] == '\\' && i+1 < len(s):
+			part.WriteString(s[i : i+2])
+			i++
+		case s[i] == delim:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(s[i])
+		}
+	}
+	return append(parts, part.String())
+}
+
+// sedReplacement turns a sed replacement, with \1 and & for groups, into
+// one for regexp.Expand
+func sedReplacement(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			out.WriteString("${" + string(s[i+1]) + "}")
+			i++
+		case c == '\\' && i+1 < len(s) && s[i+1] == '$':
+			out.WriteString("$$")
+			i++
+		case c == '\\' && i+1 < len(s):
+			out.WriteByte(s[i+1])
+			i++
+		case c == '&':
+			out.WriteString("${0}")
+		case c == '$':
+			out.WriteString("$$")
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// apply returns the name to paste base under, n being its position among
+// the entries being pasted, from 1
+func (r *renamer) apply(base string, n int) (string, error) {
+	if r.tmpl != nil {
+		ext := filepath.Ext(base)
+		var out strings.Builder
+		data := renameData{Name: base, Stem: strings.TrimSuffix(base, ext), Ext: ext, N: n}
+		if err := r.tmpl.Execute(&out, data); err != nil {
+			return "", fmt.Errorf("--rename template: %w", err)
+		}
+		return out.String(), nil
+	}
+
+	if r.global {
+		return r.pattern.ReplaceAllString(base, r.replacement), nil
+	}
+	match := r.pattern.FindStringSubmatchIndex(base)
+	if match == nil {
+		return base, nil
+	}
+	renamed := r.pattern.ExpandString(nil, r.replacement, base, match)
+	return base[:match[0]] + string(renamed) + base[match[1]:], nil
+}
+
+// renamedOpts returns opts with as set to the name --rename gives base, the
+// nth entry being pasted, so it's pasted under that name like with --as
+func renamedOpts(base string, n int, opts Options) (Options, error) {
+	if opts.rename == nil {
+		return opts, nil
+	}
+	name, err := opts.rename.apply(base, n)
+	if err != nil {
+		return opts, err
+	}
+	if name == "" {
+		return opts, fmt.Errorf("--rename turned %q into an empty name", base)
+	}
+	opts.as = name
+	return opts, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenamer(t *testing.T) {
+	tests := []struct {
+		expr     string
+		base     string
+		n        int
+		expected string
+	}{
+		{`s/\.log$/.log.bak/`, "app.log", 1, "app.log.bak"},
+		{`s/\.log$/.log.bak/`, "app.txt", 1, "app.txt"},
+		{`s/o/0/`, "foo.go", 1, "f0o.go"},
+		{`s/o/0/g`, "foo.go", 1, "f00.g0"},
+		{`s/REPORT/summary/i`, "report.pdf", 1, "summary.pdf"},
+		{`s/^(\w+)-(\w+)/\2-\1/`, "draft-notes.md", 1, "notes-draft.md"},
+		{`s/.*/old-&/`, "a.txt", 1, "old-a.txt"},
+		{`s|a/b|c|`, "a/b", 1, "c"},
+		{`s/x/$1/`, "x", 1, "$1"},
+		{`{{.Stem}}-{{.N}}{{.Ext}}`, "photo.jpg", 3, "photo-3.jpg"},
+	}
+	for _, tt := range tests {
+		r, err := parseRename(tt.expr)
+		if err != nil {
+			t.Fatalf("parseRename(%q) failed: %v", tt.expr, err)
+		}
+		got, err := r.apply(tt.base, tt.n)
+		if err != nil {
+			t.Fatalf("apply(%q) failed: %v", tt.base, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Expected %q for %s on %q, got %q", tt.expected, tt.expr, tt.base, got)
+		}
+	}
+
+	for _, bad := range []string{"", "x/a/b/", "s/a/b", "s/a/b/q", "s/(/b/", "{{.Nope"} {
+		if _, err := parseRename(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestPasteRename(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	rename, err := parseRename(`s/\.txt$/.bak/`)
+	if err != nil {
+		t.Fatalf("parseRename failed: %v", err)
+	}
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := handlePasteAll(io.Discard, Options{dest: destDir, rename: rename}); err != nil {
+		t.Fatalf("handlePasteAll with rename failed: %v", err)
+	}
+
+	for _, name := range []string{"file1.bak", "file2.bak"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); err != nil {
+			t.Errorf("Expected %s to be pasted: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// warnRecovered tells the user a corrupted clipboard file was read around
+func warnRecovered(salvaged int, err error) {
+	fmt.Fprintf(os.Stderr, "Warning: the clipboard file is corrupted (%v); recovered %d entries. Run cx repair to restore the last backup.\n", err, salvaged)
+}
+
+// handleRepair rebuilds a corrupted clipboard file from its last-known-good
+// backup, or without one keeps the entries that can still be parsed
+func handleRepair(w io.Writer, opts Options) error {
+	if storeKind == "sqlite" {
+		return fmt.Errorf("the sqlite store has no clipboard file to repair; SQLite recovers from interrupted writes itself")
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	store := clipboard.NewFileStore(clipboardPath)
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	damage := store.Verify()
+	if damage == nil {
+		fmt.Fprintf(w, "Clipboard file is intact: %s\n", clipboardPath)
+		return nil
+	}
+
+	board, err := store.Restore()
+	if err == nil {
+		fmt.Fprintf(w, "Restored %d entries from %s\n", len(board.Entries), store.BackupPath())
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(w, "Warning: cannot restore %s: %v\n", store.BackupPath(), err)
+	}
+
+	// no usable backup, so keep whatever can still be read
+	if board, err = store.Read(); err != nil {
+		return err
+	}
+	if err := store.Write(board); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Rebuilt the clipboard file from the %d entries that could be read (%v)\n", len(board.Entries), damage)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandleRepair(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	if err := handleRepair(&buf, Options{}); err != nil {
+		t.Fatalf("handleRepair failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Clipboard file is intact") {
+		t.Errorf("Expected an intact clipboard, got %q", buf.String())
+	}
+
+	for _, path := range []string{"file1.txt", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, path), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+	if err := os.WriteFile(clipboardPath, []byte(`{"entries": [{"current_path": "/x"`), 0o644); err != nil {
+		t.Fatalf("Failed to corrupt clipboard file: %v", err)
+	}
+
+	// Commands keep working on a corrupted file
+	if _, err := readClipboard(); err != nil {
+		t.Fatalf("Expected readClipboard to recover, got %v", err)
+	}
+
+	buf.Reset()
+	if err := handleRepair(&buf, Options{}); err != nil {
+		t.Fatalf("handleRepair failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Restored 1 entries from ") {
+		t.Errorf("Expected the backup to be restored, got %q", buf.String())
+	}
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != filepath.Join(tempDir, "file1.txt") {
+		t.Errorf("Expected file1.txt to be restored, got %+v", board.Entries)
+	}
+}
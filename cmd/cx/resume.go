@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// transfersDir returns the directory holding the manifests of directory
+// pastes in progress, kept next to the clipboard file
+func transfersDir() string {
+	return siblingPath(".transfers")
+}
+
+// transferManifestPath returns the manifest path for pasting source into
+// destDir
+func transferManifestPath(source, destDir string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + destDir))
+	return filepath.Join(transfersDir(), hex.EncodeToString(sum[:8])+".jsonl")
+}
+
+// openTransferManifest opens the manifest recording the progress of pasting
+// a directory entry into destDir, returning nil for anything else. Without
+// --resume it starts afresh; with it, the destination an interrupted paste
+// left behind is returned, to be carried on with.
+func openTransferManifest(entry clipboard.Entry, destDir string, opts Options) (*fsops.Manifest, string, error) {
+	if info, err := os.Lstat(entry.CurrentPath); err != nil || !info.IsDir() {
+		return nil, "", nil
+	}
+
+	manifest, err := fsops.OpenManifest(transferManifestPath(entry.CurrentPath, destDir), opts.resume)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var resume string
+	if dest := manifest.Dest(); opts.resume && dest != "" {
+		if info, err := os.Lstat(dest); err == nil && info.IsDir() {
+			resume = dest
+		}
+	}
+	return manifest, resume, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+func TestPasteResume(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	source := filepath.Join(tempDir, "config")
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := copyFileToClipboard(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	// an earlier paste that stopped before copying config.ini
+	manifest, err := fsops.OpenManifest(transferManifestPath(source, destDir), false)
+	if err != nil {
+		t.Fatalf("OpenManifest failed: %v", err)
+	}
+	dest := filepath.Join(destDir, "config")
+	if err := fsops.CopyDir(source, dest, fsops.Options{Manifest: manifest}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+	manifest.Close()
+	os.Remove(filepath.Join(dest, "config.ini"))
+
+	err = handlePasteAt(io.Discard, 0, Options{dest: destDir})
+	if err == nil || !strings.Contains(err.Error(), "use --resume") {
+		t.Errorf("Expected a conflict suggesting --resume, got %v", err)
+	}
+
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir, resume: true}); err != nil {
+		t.Fatalf("handlePasteAt with resume failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "config.ini")); err != nil {
+		t.Errorf("Expected the rest of the directory to be copied: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "config (1)")); err == nil {
+		t.Error("Expected the paste to carry on in place, not beside it")
+	}
+	if _, err := os.Stat(transferManifestPath(source, destDir)); !os.IsNotExist(err) {
+		t.Error("Expected the manifest to be removed once the paste finished")
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// commandArgs are the arguments cx was run with, after aliases are
+// expanded, for a scheduled paste to run again later
+var commandArgs = os.Args[1:]
+
+// scheduleLayouts are the forms --at accepts besides a bare time of day
+var scheduleLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339}
+
+// parseScheduleTime returns when a paste given --at or --after should run.
+// --at takes a time of day such as 22:00, the next time it comes round, or
+// a date and time such as "2006-01-02 15:04"; --after takes a duration.
+func parseScheduleTime(at string, after time.Duration, now time.Time) (time.Time, error) {
+	if at != "" && after != 0 {
+		return time.Time{}, fmt.Errorf("--at cannot be combined with --after")
+	}
+	if after != 0 {
+		if after < 0 {
+			return time.Time{}, fmt.Errorf("--after must not be negative")
+		}
+		return now.Add(after), nil
+	}
+
+	if clock, err := time.ParseInLocation("15:04", at, now.Location()); err == nil {
+		next := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next, nil
+	}
+	for _, layout := range scheduleLayouts {
+		if t, err := time.ParseInLocation(layout, at, now.Location()); err == nil {
+			if !t.After(now) {
+				return time.Time{}, fmt.Errorf("--at %s is in the past", at)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --at time %q (expected e.g. 22:00 or \"2006-01-02 15:04\")", at)
+}
+
+// withoutScheduleFlags returns args without --at and --after, so the
+// scheduled command pastes instead of scheduling again
+func withoutScheduleFlags(args []string) []string {
+	kept := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(kept, args[i:]...)
+		}
+		if arg == "--at" || arg == "--after" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "--at=") || strings.HasPrefix(arg, "--after=") {
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept
+}
+
+// startScheduled starts cmd in its own session, so it carries on after the
+// terminal that scheduled it is closed
+var startScheduled = func(cmd *exec.Cmd) (int, error) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	return pid, cmd.Process.Release()
+}
+
+// scheduleLogPath returns the file scheduled commands write their output to
+func scheduleLogPath() string {
+	return siblingPath(".scheduled.log")
+}
+
+// scheduledEntries returns the entries a paste scheduled now would paste:
+// those at indices with all, every one if indices is nil, or otherwise the
+// one at index. Each is identified as it is now, so the paste can tell if
+// it has changed by the time it runs.
+func scheduledEntries(all bool, indices []int, index int) ([]clipboard.Entry, error) {
+	board, err := readClipboard()
+	if err != nil {
+		return nil, err
+	}
+	if len(board.Entries) == 0 {
+		return nil, clipboard.ErrEmptyClipboard
+	}
+
+	switch {
+	case all && indices == nil:
+		indices = make([]int, len(board.Entries))
+		for i := range indices {
+			indices[i] = i
+		}
+	case !all:
+		if index < 0 || index >= len(board.Entries) {
+			return nil, fmt.Errorf("invalid clipboard index: %d", index)
+		}
+		indices = []int{index}
+	}
+
+	entries := make([]clipboard.Entry, 0, len(indices))
+	for _, i := range indices {
+		entry := board.Entries[i]
+		if !isRemoteEntry(entry) {
+			if _, err := os.Lstat(entry.CurrentPath); err != nil {
+				return nil, fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+			}
+			entry.Identify()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// writePins saves the entries a scheduled paste is for next to the
+// clipboard, returning the file to give it as --pinned
+func writePins(entries []clipboard.Entry) (string, error) {
+	file, err := os.CreateTemp(filepath.Dir(clipboardPath), ".scheduled-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(entries); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// pinnedIndices reads the entries saved by writePins and returns where they
+// are on the clipboard now. It fails if any is no longer on it, or if its
+// file is gone or has changed since the paste was scheduled, rather than
+// paste something else. The file is removed, since the paste it was for is
+// now running.
+func pinnedIndices(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	var pinned []clipboard.Entry
+	if err := json.Unmarshal(data, &pinned); err != nil {
+		return nil, fmt.Errorf("invalid pinned entries in %s: %w", path, err)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]int, 0, len(pinned))
+	for _, pin := range pinned {
+		i := slices.IndexFunc(board.Entries, pin.Is)
+		if i < 0 {
+			return nil, fmt.Errorf("%s was scheduled to be pasted but is no longer on the clipboard", pin.CurrentPath)
+		}
+		entry := board.Entries[i]
+		if !isRemoteEntry(entry) {
+			if _, err := os.Lstat(entry.CurrentPath); err != nil {
+				return nil, fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+			}
+			entry.Identify()
+			if entry.CurrentPath != pin.CurrentPath || !entry.SameIdentity(pin) {
+				return nil, fmt.Errorf("%s has changed since the paste was scheduled", pin.CurrentPath)
+			}
+		}
+		indices = append(indices, i)
+	}
+	return indices, nil
+}
+
+// handleSchedule starts a detached cx that waits until runAt and then runs
+// cx with args, writing what it prints to the schedule log. The paste is
+// pinned to entries, so what's cut in the meantime doesn't change it.
+func handleSchedule(w io.Writer, runAt time.Time, args []string, entries []clipboard.Entry, opts Options) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	log, err := os.OpenFile(scheduleLogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	pins, err := writePins(entries)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(self, append([]string{"run-at", runAt.Format(time.RFC3339)}, append(args, "--pinned", pins)...)...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	pid, err := startScheduled(cmd)
+	if err != nil {
+		os.Remove(pins)
+		return fmt.Errorf("failed to schedule the paste: %w", err)
+	}
+
+	if !opts.quiet {
+		fmt.Fprintf(w, "Scheduled: cx %s at %s (pid %d, output in %s)\n", strings.Join(args, " "), runAt.Format("2006-01-02 15:04"), pid, scheduleLogPath())
+		for _, entry := range entries {
+			fmt.Fprintf(w, "  %s\n", quotePath(entry.CurrentPath))
+		}
+	}
+	return nil
+}
+
+// handleRunAt waits until runAt, checking the clock each minute so time
+// spent suspended counts, then runs cx with args
+func handleRunAt(w io.Writer, runAt time.Time, args []string) error {
+	for {
+		wait := time.Until(runAt)
+		if wait <= 0 {
+			break
+		}
+		time.Sleep(min(wait, time.Minute))
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s: cx %s\n", time.Now().Format(time.RFC3339), strings.Join(args, " "))
+	cmd := exec.Command(self, args...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 21, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		at       string
+		after    time.Duration
+		expected time.Time
+	}{
+		{"22:00", 0, time.Date(2024, 3, 1, 22, 0, 0, 0, time.Local)},
+		// a time already gone today is tomorrow's
+		{"06:15", 0, time.Date(2024, 3, 2, 6, 15, 0, 0, time.Local)},
+		{"2024-03-05 01:00", 0, time.Date(2024, 3, 5, 1, 0, 0, 0, time.Local)},
+		{"", 2 * time.Hour, now.Add(2 * time.Hour)},
+	}
+	for _, test := range tests {
+		runAt, err := parseScheduleTime(test.at, test.after, now)
+		if err != nil {
+			t.Errorf("parseScheduleTime(%q, %v) failed: %v", test.at, test.after, err)
+			continue
+		}
+		if !runAt.Equal(test.expected) {
+			t.Errorf("parseScheduleTime(%q, %v): expected %v, got %v", test.at, test.after, test.expected, runAt)
+		}
+	}
+
+	for _, at := range []string{"25:00", "tonight", "2024-02-01 10:00"} {
+		if _, err := parseScheduleTime(at, 0, now); err == nil {
+			t.Errorf("Expected error for --at %q", at)
+		}
+	}
+	if _, err := parseScheduleTime("22:00", time.Hour, now); err == nil {
+		t.Error("Expected error combining --at and --after")
+	}
+}
+
+func TestWithoutScheduleFlags(t *testing.T) {
+	args := withoutScheduleFlags([]string{"paste", "--at", "22:00", "2", "--after=1h", "-t", "dest", "--", "--at"})
+	expected := []string{"paste", "2", "-t", "dest", "--", "--at"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %q, got %q", expected, args)
+	}
+}
+
+func TestHandleSchedule(t *testing.T) {
+	saved, savedStart := clipboardPath, startScheduled
+	defer func() { clipboardPath, startScheduled = saved, savedStart }()
+	clipboardPath = filepath.Join(t.TempDir(), "clipboard.json")
+
+	var started *exec.Cmd
+	startScheduled = func(cmd *exec.Cmd) (int, error) {
+		started = cmd
+		return 42, nil
+	}
+
+	runAt := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	entries := []clipboard.Entry{{OriginalPath: "/tmp/a", CurrentPath: "/tmp/a"}}
+	if err := handleSchedule(&buf, runAt, []string{"paste", "--all"}, entries, Options{}); err != nil {
+		t.Fatalf("handleSchedule failed: %v", err)
+	}
+
+	if started == nil {
+		t.Fatal("Expected a scheduled process to be started")
+	}
+	args := started.Args[1:]
+	expected := []string{"run-at", "2024-03-01T22:00:00Z", "paste", "--all", "--pinned"}
+	if len(args) != len(expected)+1 || !reflect.DeepEqual(args[:len(expected)], expected) {
+		t.Fatalf("Expected arguments %q and the pinned entries, got %q", expected, args)
+	}
+	if _, err := os.Stat(args[len(expected)]); err != nil {
+		t.Errorf("Expected the pinned entries to be saved: %v", err)
+	}
+	if !strings.Contains(buf.String(), "pid 42") {
+		t.Errorf("Expected the pid to be reported, got %q", buf.String())
+	}
+}
+
+func TestPinnedIndices(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	file1 := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, file1, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	entries, err := scheduledEntries(false, nil, 0)
+	if err != nil {
+		t.Fatalf("scheduledEntries failed: %v", err)
+	}
+	pin := func() string {
+		path, err := writePins(entries)
+		if err != nil {
+			t.Fatalf("writePins failed: %v", err)
+		}
+		return path
+	}
+
+	// something cut since lands on top, but the paste is still for file1
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file2.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	indices, err := pinnedIndices(pin())
+	if err != nil {
+		t.Fatalf("pinnedIndices failed: %v", err)
+	}
+	if !reflect.DeepEqual(indices, []int{1}) {
+		t.Errorf("Expected the pinned entry at index 1, got %v", indices)
+	}
+
+	if err := os.WriteFile(file1, []byte("rewritten since"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := pinnedIndices(pin()); err == nil || !strings.Contains(err.Error(), "changed since") {
+		t.Errorf("Expected a changed file to fail the paste, got %v", err)
+	}
+
+	if err := engine().Remove(1); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := pinnedIndices(pin()); err == nil || !strings.Contains(err.Error(), "no longer on the clipboard") {
+		t.Errorf("Expected a removed entry to fail the paste, got %v", err)
+	}
+}
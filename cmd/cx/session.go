@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"golang.org/x/sys/unix"
+)
+
+// sessionEnv names the environment variable holding the current session
+const sessionEnv = "CX_SESSION"
+
+// sessionNamePattern matches the names a session can be given
+var sessionNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// sessionsDir returns the directory session clipboards are kept in
+func sessionsDir() string {
+	return filepath.Join(filepath.Dir(defaultClipboardPath), "sessions")
+}
+
+// sessionClipboardPath returns the clipboard file of the named session
+func sessionClipboardPath(name string) (string, error) {
+	if !sessionNamePattern.MatchString(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid session name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return filepath.Join(sessionsDir(), name+".json"), nil
+}
+
+// sessionSuffixes are the suffixes of a session's clipboard file and the
+// files kept next to it, which go when the session ends
+var sessionSuffixes = []string{".json", ".json.lock", ".json.bak", ".journal.json", ".history.jsonl", ".sizes.json", ".trash.json", ".sock", ".transfers", ".stdin"}
+
+// fishShell reports whether the user's shell is fish, which has its own
+// syntax for exporting variables
+func fishShell() bool {
+	return filepath.Base(os.Getenv("SHELL")) == "fish"
+}
+
+// cleanDeadSessions removes the clipboards of sessions named after shells
+// that have since exited without ending them
+func cleanDeadSessions() {
+	entries, err := os.ReadDir(sessionsDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(name)
+		if err != nil || pid <= 0 {
+			continue
+		}
+		if err := unix.Kill(pid, 0); errors.Is(err, unix.ESRCH) {
+			removeSession(name)
+		}
+	}
+}
+
+// removeSession deletes the named session's clipboard and the files next to
+// it, returning how many entries it held
+func removeSession(name string) (int, error) {
+	path, err := sessionClipboardPath(name)
+	if err != nil {
+		return 0, err
+	}
+
+	entries := 0
+	if boardJSON, err := os.ReadFile(path); err == nil {
+		if board, err := clipboard.Decode(boardJSON); err == nil {
+			entries = len(board.Entries)
+		}
+	}
+
+	base := strings.TrimSuffix(path, ".json")
+	for _, suffix := range sessionSuffixes {
+		if err := os.RemoveAll(base + suffix); err != nil {
+			return 0, err
+		}
+	}
+	return entries, nil
+}
+
+// handleSessionStart creates a clipboard for the session, named after the
+// calling shell's process ID unless name is given, and writes the shell
+// code that points cx at it, for eval "$(cx session start)"
+func handleSessionStart(w io.Writer, name string) error {
+	if name == "" {
+		name = strconv.Itoa(os.Getppid())
+	}
+	path, err := sessionClipboardPath(name)
+	if err != nil {
+		return err
+	}
+
+	cleanDeadSessions()
+
+	if err := os.MkdirAll(sessionsDir(), 0o700); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(path, []byte(`{"entries": []}`), 0o644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "# cx session %s: %s\n", name, path)
+	if fishShell() {
+		fmt.Fprintf(w, "set -gx %s %s\n", sessionEnv, name)
+	} else {
+		fmt.Fprintf(w, "export %s=%s\n", sessionEnv, name)
+	}
+	return nil
+}
+
+// handleSessionEnd deletes the current session's clipboard, or the named
+// one's, and writes the shell code that points cx back at the global
+// clipboard, for eval "$(cx session end)"
+func handleSessionEnd(w io.Writer, name string) error {
+	if name == "" {
+		name = os.Getenv(sessionEnv)
+	}
+	if name == "" {
+		return fmt.Errorf("not in a cx session (start one with: eval \"$(cx session start)\")")
+	}
+
+	dropped, err := removeSession(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "# cx session %s ended", name)
+	if dropped > 0 {
+		fmt.Fprintf(w, ", dropping %d entries", dropped)
+	}
+	fmt.Fprintln(w)
+	if name == os.Getenv(sessionEnv) {
+		if fishShell() {
+			fmt.Fprintf(w, "set -e %s\n", sessionEnv)
+		} else {
+			fmt.Fprintf(w, "unset %s\n", sessionEnv)
+		}
+	}
+	return nil
+}
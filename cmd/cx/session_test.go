@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSessions(t *testing.T) {
+	saved := defaultClipboardPath
+	defer func() { defaultClipboardPath = saved }()
+	defaultClipboardPath = filepath.Join(t.TempDir(), "clipboard.json")
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv(sessionEnv, "")
+
+	var buf bytes.Buffer
+	if err := handleSessionStart(&buf, "work"); err != nil {
+		t.Fatalf("handleSessionStart failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "export CX_SESSION=work\n") {
+		t.Errorf("Expected an export line, got %q", buf.String())
+	}
+
+	path, err := sessionClipboardPath("work")
+	if err != nil {
+		t.Fatalf("sessionClipboardPath failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{"entries": [{"current_path": "/a"}]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write session clipboard: %v", err)
+	}
+	if err := os.WriteFile(path+".lock", nil, 0o644); err != nil {
+		t.Fatalf("Failed to write lock file: %v", err)
+	}
+
+	t.Setenv(sessionEnv, "work")
+	buf.Reset()
+	if err := handleSessionEnd(&buf, ""); err != nil {
+		t.Fatalf("handleSessionEnd failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "dropping 1 entries") || !strings.HasSuffix(buf.String(), "unset CX_SESSION\n") {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+	for _, file := range []string{path, path + ".lock"} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", file, err)
+		}
+	}
+
+	t.Setenv(sessionEnv, "")
+	if err := handleSessionEnd(&buf, ""); err == nil {
+		t.Error("Expected error ending a session outside one, got nil")
+	}
+	if err := handleSessionStart(&buf, "../escape"); err == nil {
+		t.Error("Expected error for an invalid session name, got nil")
+	}
+}
+
+func TestCleanDeadSessions(t *testing.T) {
+	saved := defaultClipboardPath
+	defer func() { defaultClipboardPath = saved }()
+	defaultClipboardPath = filepath.Join(t.TempDir(), "clipboard.json")
+
+	if err := os.MkdirAll(sessionsDir(), 0o700); err != nil {
+		t.Fatalf("Failed to create sessions dir: %v", err)
+	}
+	alive := filepath.Join(sessionsDir(), "1.json")
+	// PIDs are capped well below this on Linux and macOS
+	dead := filepath.Join(sessionsDir(), "999999999.json")
+	for _, path := range []string{alive, dead} {
+		if err := os.WriteFile(path, []byte(`{"entries": []}`), 0o644); err != nil {
+			t.Fatalf("Failed to write session clipboard: %v", err)
+		}
+	}
+
+	cleanDeadSessions()
+
+	if _, err := os.Stat(alive); err != nil {
+		t.Errorf("Expected the live session to be kept: %v", err)
+	}
+	if _, err := os.Stat(dead); !os.IsNotExist(err) {
+		t.Errorf("Expected the dead session to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// shellInitShells lists the shells cx shell-init has integration for
+var shellInitShells = []string{"bash", "zsh", "fish"}
+
+// topEntryCommand prints the current path of the top clipboard entry, taken
+// from the third column of cx list --format tsv
+const topEntryCommand = `command cx list --format tsv 2>/dev/null | head -n 1 | cut -f 3`
+
+// shellInitScripts hold the integration for each shell: Ctrl-X Ctrl-P
+// inserts the top entry's path at the cursor and Ctrl-X Ctrl-K cuts the path
+// under the cursor
+var shellInitScripts = map[string]string{
+	"bash": `_cx_insert_top() {
+  local entry
+  entry=$(` + topEntryCommand + `)
+  [[ -n $entry ]] || return
+  entry=$(printf '%q' "$entry")
+  READLINE_LINE=${READLINE_LINE:0:READLINE_POINT}$entry${READLINE_LINE:READLINE_POINT}
+  READLINE_POINT=$((READLINE_POINT + ${#entry}))
+}
+bind -x '"\C-x\C-p": _cx_insert_top'
+
+_cx_cut_word() {
+  local before=${READLINE_LINE:0:READLINE_POINT} after=${READLINE_LINE:READLINE_POINT}
+  local word=${before##* }${after%% *}
+  [[ -n $word ]] || return
+  command cx cut -- "${word/#\~/$HOME}"
+}
+bind -x '"\C-x\C-k": _cx_cut_word'
+`,
+	"zsh": `cx-insert-top() {
+  local entry
+  entry=$(` + topEntryCommand + `)
+  [[ -n $entry ]] && LBUFFER+=${(q)entry}
+  zle reset-prompt
+}
+zle -N cx-insert-top
+bindkey '^X^P' cx-insert-top
+
+cx-cut-word() {
+  local word=${LBUFFER##* }${RBUFFER%% *}
+  [[ -n $word ]] || return
+  word=${(Q)word}
+  zle -M "$(command cx cut -- "${word/#\~/$HOME}" 2>&1)"
+}
+zle -N cx-cut-word
+bindkey '^X^K' cx-cut-word
+`,
+	"fish": `function __cx_insert_top
+    set -l entry (` + topEntryCommand + `)
+    test -n "$entry"; and commandline -i -- (string escape -- $entry)
+end
+bind \cx\cp __cx_insert_top
+
+function __cx_cut_word
+    set -l word (commandline -t)
+    test -n "$word"; or return
+    echo
+    command cx cut -- (string replace -r '^~' $HOME -- $word)
+    commandline -f repaint
+end
+bind \cx\ck __cx_cut_word
+`,
+}
+
+// handleShellInit writes the integration script for shell, for
+// eval "$(cx shell-init zsh)" in the shell's startup file
+func handleShellInit(w io.Writer, shell string) error {
+	script, ok := shellInitScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (expected one of: %s)", shell, strings.Join(shellInitShells, ", "))
+	}
+
+	fmt.Fprintf(w, "# cx shell integration for %s\n", shell)
+	fmt.Fprint(w, script)
+	return nil
+}
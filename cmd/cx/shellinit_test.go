@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandleShellInit(t *testing.T) {
+	for _, shell := range shellInitShells {
+		var buf bytes.Buffer
+		if err := handleShellInit(&buf, shell); err != nil {
+			t.Fatalf("handleShellInit(%s) failed: %v", shell, err)
+		}
+		if !strings.Contains(buf.String(), "cx cut -- ") || !strings.Contains(buf.String(), "cx list --format tsv") {
+			t.Errorf("Expected %s script to cut and insert entries, got:\n%s", shell, buf.String())
+		}
+
+		// check the script parses, where the shell is installed
+		if _, err := exec.LookPath(shell); err != nil || shell == "fish" {
+			continue
+		}
+		script := filepath.Join(t.TempDir(), "init."+shell)
+		if err := os.WriteFile(script, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("Failed to write script: %v", err)
+		}
+		if out, err := exec.Command(shell, "-n", script).CombinedOutput(); err != nil {
+			t.Errorf("%s script doesn't parse: %v\n%s", shell, err, out)
+		}
+	}
+
+	if err := handleShellInit(&bytes.Buffer{}, "csh"); err == nil {
+		t.Error("Expected error for an unsupported shell")
+	}
+}
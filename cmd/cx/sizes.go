@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// sizeCacheTTL is how long a directory's cached size is trusted while its
+// modification time stays the same. Changes deeper down don't touch the
+// top directory's modification time, so the cache can't be kept forever.
+const sizeCacheTTL = 10 * time.Minute
+
+// largePasteFiles is the file count above which a paste prints a summary
+// first, as does a total size above progressThreshold
+const largePasteFiles = 1000
+
+// treeStats is the number of files under an entry and their total size
+type treeStats struct {
+	Files int   `json:"files"`
+	Size  int64 `json:"size"`
+}
+
+// cachedStats is a directory's treeStats as last measured
+type cachedStats struct {
+	treeStats
+	ModTime   time.Time `json:"mod_time"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// parseBWLimit parses a --bwlimit rate like 10M (SI) or 500KiB (binary)
+// into bytes a second
+func parseBWLimit(s string) (int64, error) {
+	rate, err := humanize.ParseBytes(strings.TrimSuffix(s, "/s"))
+	if err != nil || rate == 0 {
+		return 0, fmt.Errorf("invalid --bwlimit %q, expected a rate like 10M or 500KiB", s)
+	}
+	return int64(rate), nil
+}
+
+// sizeCachePath returns the path of the size cache
+func sizeCachePath() string {
+	return siblingPath(".sizes.json")
+}
+
+// readSizeCache reads the size cache, keyed by directory path. A missing or
+// unreadable cache is treated as empty.
+func readSizeCache() map[string]cachedStats {
+	cache := make(map[string]cachedStats)
+	cacheJSON, err := os.ReadFile(sizeCachePath())
+	if err == nil {
+		json.Unmarshal(cacheJSON, &cache)
+	}
+	return cache
+}
+
+// measureEntries returns the treeStats of each local entry that exists,
+// keyed by current path. Directories are walked once and cached for
+// sizeCacheTTL, after which they drop out of the cache.
+func measureEntries(entries []clipboard.Entry) map[string]treeStats {
+	now := time.Now()
+	cache := readSizeCache()
+	changed := false
+	for path, cached := range cache {
+		if now.Sub(cached.CheckedAt) > sizeCacheTTL {
+			delete(cache, path)
+			changed = true
+		}
+	}
+
+	stats := make(map[string]treeStats, len(entries))
+
+	for _, entry := range entries {
+		if isRemoteEntry(entry) {
+			continue
+		}
+		info, err := os.Lstat(entry.CurrentPath)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			stats[entry.CurrentPath] = treeStats{Files: 1, Size: info.Size()}
+			continue
+		}
+
+		cached, ok := cache[entry.CurrentPath]
+		if !ok || !cached.ModTime.Equal(info.ModTime()) {
+			files, size := dirSummary(entry.CurrentPath)
+			cached = cachedStats{treeStats: treeStats{Files: files, Size: size}, ModTime: info.ModTime(), CheckedAt: now}
+			cache[entry.CurrentPath] = cached
+			changed = true
+		}
+		stats[entry.CurrentPath] = cached.treeStats
+	}
+
+	// the cache only saves time, so failing to write it isn't an error
+	if changed {
+		if cacheJSON, err := json.MarshalIndent(cache, "", "  "); err == nil {
+			fsops.WriteFileAtomic(sizeCachePath(), cacheJSON, 0o644)
+		}
+	}
+	return stats
+}
+
+// formatTotals describes how many entries and files there are, and their
+// total size, e.g. "3 entries, 142 files, 1.3 GB"
+func formatTotals(entries int, total treeStats) string {
+	entryNoun, fileNoun := "entries", "files"
+	if entries == 1 {
+		entryNoun = "entry"
+	}
+	if total.Files == 1 {
+		fileNoun = "file"
+	}
+	return fmt.Sprintf("%d %s, %d %s, %s", entries, entryNoun, total.Files, fileNoun, FormatSize(total.Size))
+}
+
+// printPasteSummary writes a summary of what is about to be pasted when it
+// is large enough that the paste will take a while
+func printPasteSummary(w io.Writer, entries []clipboard.Entry) {
+	var total treeStats
+	for _, stats := range measureEntries(entries) {
+		total.Files += stats.Files
+		total.Size += stats.Size
+	}
+
+	if total.Files > largePasteFiles || total.Size > progressThreshold {
+		fmt.Fprintf(w, "Pasting %s\n", formatTotals(len(entries), total))
+	}
+}
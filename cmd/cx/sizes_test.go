@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestMeasureEntries(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	config := filepath.Join(tempDir, "config")
+	entries := []clipboard.Entry{
+		{CurrentPath: config},
+		{CurrentPath: filepath.Join(tempDir, "file1.txt")},
+		{CurrentPath: filepath.Join(tempDir, "missing")},
+	}
+
+	stats := measureEntries(entries)
+	if len(stats) != 2 {
+		t.Fatalf("Expected stats for 2 entries, got %v", stats)
+	}
+	if got := stats[config]; got.Files != 2 || got.Size != 29 {
+		t.Errorf("Expected 2 files, 29 B for config, got %+v", got)
+	}
+	if got := stats[filepath.Join(tempDir, "file1.txt")]; got.Files != 1 || got.Size != 14 {
+		t.Errorf("Expected 1 file, 14 B for file1.txt, got %+v", got)
+	}
+
+	// Directories are served from the cache while they look unchanged
+	cache := readSizeCache()
+	cached := cache[config]
+	cached.Files = 99
+	cache[config] = cached
+	cacheJSON, _ := json.Marshal(cache)
+	if err := os.WriteFile(sizeCachePath(), cacheJSON, 0o644); err != nil {
+		t.Fatalf("Failed to write size cache: %v", err)
+	}
+	if got := measureEntries(entries)[config].Files; got != 99 {
+		t.Errorf("Expected the cached file count 99, got %d", got)
+	}
+
+	// ...and measured again once they change
+	if err := os.WriteFile(filepath.Join(config, "extra.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if got := measureEntries(entries)[config].Files; got != 3 {
+		t.Errorf("Expected 3 files after adding one, got %d", got)
+	}
+}
+
+func TestFormatTotals(t *testing.T) {
+	if got := formatTotals(3, treeStats{Files: 142, Size: 1300 << 20}); !strings.HasPrefix(got, "3 entries, 142 files, ") {
+		t.Errorf("Unexpected totals: %q", got)
+	}
+	if got := formatTotals(1, treeStats{Files: 1, Size: 14}); !strings.HasPrefix(got, "1 entry, 1 file, ") {
+		t.Errorf("Unexpected totals: %q", got)
+	}
+}
+
+func TestHandleListSizes(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, path := range []string{"config", "file1.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, path), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := handleList(&buf, Options{sizes: true}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 2 entries and a summary line, got %q", buf.String())
+	}
+	if !strings.Contains(lines[1], "(2 files,") {
+		t.Errorf("Expected the config entry to show its file count, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "2 entries, 3 files, ") {
+		t.Errorf("Unexpected summary line: %q", lines[2])
+	}
+
+	buf.Reset()
+	if err := handleList(&buf, Options{sizes: true, format: formatTSV}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if !strings.HasSuffix(strings.Split(buf.String(), "\n")[0], "\t1\t14") {
+		t.Errorf("Expected the TSV line to end with the file count and size, got %q", buf.String())
+	}
+}
+
+func TestParseBWLimit(t *testing.T) {
+	tests := map[string]int64{"10M": 10_000_000, "500KiB": 500 << 10, "1.5MB/s": 1_500_000, "2048": 2048}
+	for input, want := range tests {
+		got, err := parseBWLimit(input)
+		if err != nil || got != want {
+			t.Errorf("Expected %s to parse as %d, got %d (%v)", input, want, got, err)
+		}
+	}
+
+	for _, input := range []string{"", "0", "fast", "-1M"} {
+		if _, err := parseBWLimit(input); err == nil {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// snapshotsDir returns the directory saved clipboards are kept in. It's
+// shared by every clipboard, so a snapshot saved in one session or project
+// can be loaded in another.
+func snapshotsDir() string {
+	return filepath.Join(filepath.Dir(defaultClipboardPath), "snapshots")
+}
+
+// snapshotPath returns the file the named snapshot is saved in
+func snapshotPath(name string) (string, error) {
+	if !sessionNamePattern.MatchString(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid snapshot name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return filepath.Join(snapshotsDir(), name+".json"), nil
+}
+
+// readSnapshot reads the named snapshot
+func readSnapshot(name string) (clipboard.Clipboard, error) {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return clipboard.Clipboard{}, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return clipboard.Clipboard{}, fmt.Errorf("no snapshot named %s (see cx snapshot list)", name)
+	}
+	if err != nil {
+		return clipboard.Clipboard{}, err
+	}
+	board, err := clipboard.Decode(data)
+	if err != nil {
+		return clipboard.Clipboard{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return board, nil
+}
+
+// handleSnapshotSave saves the clipboard as the named snapshot, replacing
+// one already saved under that name only with opts.force
+func handleSnapshotSave(w io.Writer, name string, opts Options) error {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil && !opts.force {
+		return fmt.Errorf("a snapshot named %s already exists (use --force to replace it)", name)
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	board.Version = clipboard.CurrentVersion
+	data, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(snapshotsDir(), 0o755); err != nil {
+		return err
+	}
+	if err := fsops.WriteFileAtomic(path, data, 0o644); err != nil {
+		return err
+	}
+
+	if !opts.quiet {
+		fmt.Fprintf(w, "Saved snapshot %s: %s\n", name, formatEntryCount(len(board.Entries)))
+	}
+	return nil
+}
+
+// handleSnapshotLoad replaces the clipboard with the named snapshot, which
+// stays saved so it can be loaded again
+func handleSnapshotLoad(w io.Writer, name string, opts Options) error {
+	snapshot, err := readSnapshot(name)
+	if err != nil {
+		return err
+	}
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	if confirming() && len(board.Entries) > 0 {
+		question := fmt.Sprintf("Replace %s with snapshot %s?", formatEntryCount(len(board.Entries)), name)
+		ok, err := confirmAction(question)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(w, "Clipboard left as it was")
+			return nil
+		}
+	}
+
+	if snapshot.Entries == nil {
+		snapshot.Entries = []clipboard.Entry{}
+	}
+	err = updateClipboard(func(board *clipboard.Clipboard) error {
+		board.Entries = snapshot.Entries
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Loaded snapshot %s: %s\n", name, formatEntryCount(len(snapshot.Entries)))
+	return nil
+}
+
+// handleSnapshotList shows the saved snapshots, with how many entries each
+// holds and when it was saved
+func handleSnapshotList(w io.Writer) error {
+	files, err := os.ReadDir(snapshotsDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var names []string
+	for _, file := range files {
+		if name, ok := strings.CutSuffix(file.Name(), ".json"); ok && !file.IsDir() {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(w, "No snapshots saved")
+		return nil
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		snapshot, err := readSnapshot(name)
+		if err != nil {
+			fmt.Fprintf(w, "%s %s\n", name, missingPathStyle.Render(err.Error()))
+			continue
+		}
+		details := formatEntryCount(len(snapshot.Entries))
+		if path, err := snapshotPath(name); err == nil {
+			if info, err := os.Stat(path); err == nil {
+				details += ", saved " + humanize.Time(info.ModTime())
+			}
+		}
+		fmt.Fprintf(w, "%s %s\n", name, detailsStyle.Render("("+details+")"))
+	}
+	return nil
+}
+
+// handleSnapshotDelete removes the named snapshot
+func handleSnapshotDelete(w io.Writer, name string, opts Options) error {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no snapshot named %s (see cx snapshot list)", name)
+	} else if err != nil {
+		return err
+	}
+
+	if !opts.quiet {
+		fmt.Fprintf(w, "Deleted snapshot %s\n", name)
+	}
+	return nil
+}
+
+// formatEntryCount returns "1 entry" or "n entries"
+func formatEntryCount(n int) string {
+	if n == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", n)
+}
+
+// completeSnapshots offers the names of the saved snapshots, for the first
+// argument only
+func completeSnapshots(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	files, err := os.ReadDir(snapshotsDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, file := range files {
+		if name, ok := strings.CutSuffix(file.Name(), ".json"); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
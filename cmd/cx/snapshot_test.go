@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshots(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	saved := defaultClipboardPath
+	defer func() { defaultClipboardPath = saved }()
+	defaultClipboardPath = filepath.Join(tempDir, "state", "clipboard.json")
+
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("copyFileToClipboard failed: %v", err)
+		}
+	}
+
+	if err := handleSnapshotSave(io.Discard, "weekly", Options{}); err != nil {
+		t.Fatalf("handleSnapshotSave failed: %v", err)
+	}
+	if err := handleSnapshotSave(io.Discard, "weekly", Options{}); err == nil {
+		t.Error("Expected error saving over a snapshot without --force")
+	}
+	if err := handleSnapshotSave(io.Discard, "../escape", Options{}); err == nil {
+		t.Error("Expected error for an invalid snapshot name")
+	}
+
+	if err := handleClear(io.Discard, Options{}); err != nil {
+		t.Fatalf("handleClear failed: %v", err)
+	}
+	if err := handleSnapshotLoad(io.Discard, "weekly", Options{}); err != nil {
+		t.Fatalf("handleSnapshotLoad failed: %v", err)
+	}
+	board, _ := readClipboard()
+	if len(board.Entries) != 2 || filepath.Base(board.Entries[0].CurrentPath) != "file2.txt" {
+		t.Fatalf("Expected the two saved entries back in order, got %+v", board.Entries)
+	}
+
+	var buf bytes.Buffer
+	if err := handleSnapshotList(&buf); err != nil {
+		t.Fatalf("handleSnapshotList failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "weekly") || !strings.Contains(buf.String(), "2 entries") {
+		t.Errorf("Expected the snapshot to be listed, got %q", buf.String())
+	}
+
+	if err := handleSnapshotDelete(io.Discard, "weekly", Options{}); err != nil {
+		t.Fatalf("handleSnapshotDelete failed: %v", err)
+	}
+	if err := handleSnapshotLoad(io.Discard, "weekly", Options{}); err == nil {
+		t.Error("Expected error loading a deleted snapshot")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// listSorts lists the orders cx list --sort accepts
+var listSorts = []string{"time", "size", "name", "type"}
+
+// typeRank orders entry kinds for --sort type: directories first, missing
+// entries last
+var typeRank = map[string]int{"directory": 0, "symlink": 1, "file": 2, "": 3}
+
+// sortKind returns the kind entry is ranked by for --sort type, which is
+// none for a missing entry even if what it was is known
+func sortKind(entry listEntry) string {
+	if entry.isMissing {
+		return ""
+	}
+	return entry.kind()
+}
+
+// parseListSort validates a --sort value
+func parseListSort(s string) (string, error) {
+	if !containsString(listSorts, s) {
+		return "", fmt.Errorf("invalid sort %q (expected one of: %s)", s, strings.Join(listSorts, ", "))
+	}
+	return s, nil
+}
+
+// sortEntries orders entries by the given key: newest, largest, or
+// directories first, or by name. Ties keep clipboard order, and reverse
+// turns the whole order around. Entries keep their indices, so they can
+// still be pasted by the numbers shown. With no key, reverse lists the
+// oldest entries first.
+func sortEntries(entries []listEntry, by string, reverse bool) {
+	if by == "" {
+		if reverse {
+			slices.Reverse(entries)
+		}
+		return
+	}
+
+	var sizes map[string]treeStats
+	if by == "size" {
+		measuring := make([]clipboard.Entry, len(entries))
+		for i, entry := range entries {
+			measuring[i] = clipboard.Entry{CurrentPath: entry.currentPath}
+		}
+		sizes = measureEntries(measuring)
+	}
+
+	name := func(entry listEntry) string {
+		return strings.ToLower(filepath.Base(entry.basePath))
+	}
+
+	less := func(a, b listEntry) bool {
+		switch by {
+		case "time":
+			return a.cutTime.After(b.cutTime)
+		case "size":
+			return sizes[a.currentPath].Size > sizes[b.currentPath].Size
+		case "name":
+			return name(a) < name(b)
+		case "type":
+			if typeRank[sortKind(a)] != typeRank[sortKind(b)] {
+				return typeRank[sortKind(a)] < typeRank[sortKind(b)]
+			}
+			return name(a) < name(b)
+		}
+		return false
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if reverse {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
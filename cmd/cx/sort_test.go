@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseListSort(t *testing.T) {
+	for _, s := range listSorts {
+		if _, err := parseListSort(s); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", s, err)
+		}
+	}
+	if _, err := parseListSort("date"); err == nil {
+		t.Error("Expected an error for an unknown sort")
+	}
+}
+
+func TestHandleListSort(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, name := range []string{"file2.txt", "config", "file1.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+
+	order := func(opts Options) string {
+		var buf bytes.Buffer
+		opts.format = formatTSV
+		if err := handleList(&buf, opts); err != nil {
+			t.Fatalf("handleList failed: %v", err)
+		}
+		var names []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			fields := strings.Split(line, "\t")
+			names = append(names, fields[0]+":"+filepath.Base(fields[1]))
+		}
+		return strings.Join(names, " ")
+	}
+
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{Options{}, "0:file1.txt 1:config 2:file2.txt"},
+		{Options{sortBy: "size"}, "1:config 0:file1.txt 2:file2.txt"},
+		{Options{sortBy: "name"}, "1:config 0:file1.txt 2:file2.txt"},
+		{Options{sortBy: "name", reverse: true}, "2:file2.txt 0:file1.txt 1:config"},
+		{Options{sortBy: "type"}, "1:config 0:file1.txt 2:file2.txt"},
+		{Options{reverse: true}, "2:file2.txt 1:config 0:file1.txt"},
+	}
+	for _, tt := range tests {
+		if got := order(tt.opts); got != tt.expected {
+			t.Errorf("Expected %q for sort %q (reverse %t), got %q", tt.expected, tt.opts.sortBy, tt.opts.reverse, got)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// freeSpace returns the bytes free on the filesystem holding a path
+var freeSpace = fsops.FreeSpace
+
+// checkRoom fails before anything is pasted if destDir's filesystem hasn't
+// room for the entries, or copies of them would have paths too long for the
+// system, rather than running out halfway. Only copies and moves from other
+// filesystems are checked; moves within it are renames, which keep working
+// however deep they leave the files inside. Hard links take no space, and
+// neither is --resume checked for it, since part of the paste is already
+// there. Remote entries aren't counted, and if the free space can't be found
+// out the paste goes ahead.
+func checkRoom(entries []clipboard.Entry, destDir string, opts Options) error {
+	var needed []clipboard.Entry
+	for _, entry := range entries {
+		if isRemoteEntry(entry) {
+			continue
+		}
+		if opts.persist || entry.IsCopy() || !fsops.SameDevice(entry.CurrentPath, destDir) {
+			needed = append(needed, entry)
+		}
+	}
+
+	for _, entry := range needed {
+		if err := fsops.CheckPathLen(entry.CurrentPath, destDir); err != nil {
+			return fmt.Errorf("cannot paste %s into %s: %w", entry.CurrentPath, destDir, err)
+		}
+	}
+	if opts.hardlink || opts.resume || len(needed) == 0 {
+		return nil
+	}
+
+	var total int64
+	for _, stats := range measureEntries(needed) {
+		total += stats.Size
+	}
+	free, err := freeSpace(destDir)
+	if err != nil || uint64(total) <= free {
+		return nil
+	}
+	return fmt.Errorf("not enough space in %s: the paste needs %s but only %s is free", destDir, FormatSize(total), FormatSize(int64(free)))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+func TestPasteFreeSpace(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	original := freeSpace
+	defer func() { freeSpace = original }()
+	freeSpace = func(string) (uint64, error) { return 1, nil }
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	err := handlePasteAt(io.Discard, 0, Options{dest: destDir})
+	if err == nil || !strings.Contains(err.Error(), "not enough space") {
+		t.Errorf("Expected the copy to be refused for lack of space, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(destDir, "file1.txt")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be pasted")
+	}
+
+	// moving within the filesystem takes no space
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file2.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir}); err != nil {
+		t.Errorf("Expected the move to go ahead, got %v", err)
+	}
+}
+
+func TestPastePathTooLong(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// a destination so deep that the pasted file's path would be too long
+	destDir := tempDir
+	for len(destDir)+len("/file1.txt") <= fsops.MaxPathLen {
+		destDir = filepath.Join(destDir, strings.Repeat("d", min(200, fsops.MaxPathLen-len(destDir)-1)))
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	err := handlePasteAt(io.Discard, 0, Options{dest: destDir})
+	var tooLong *fsops.PathTooLongError
+	if !errors.As(err, &tooLong) {
+		t.Errorf("Expected the copy to be refused for its path length, got %v", err)
+	}
+}
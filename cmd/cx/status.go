@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// staleAfter is how old an entry has to be for cx status to call it stale
+// when no --expire-after is set
+const staleAfter = 7 * 24 * time.Hour
+
+// daemonRunning reports whether a cx daemon is answering on its socket
+func daemonRunning() bool {
+	conn, err := net.DialTimeout("unix", socketPath(), time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// handleStatus prints an overview of the clipboard's health: where it is
+// kept, what is queued, who holds the lock, and which entries are stale or
+// point at paths that no longer exist
+func handleStatus(w io.Writer) error {
+	// where the lock is taken and who holds it
+	var lockHolder func() (int, bool, error)
+	path := clipboardPath
+	if storeKind == "sqlite" {
+		path = sqlitePath()
+		lockHolder = clipboard.NewSQLiteStore(path).LockHolder
+	} else {
+		lockHolder = clipboard.NewFileStore(clipboardPath).LockHolder
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	running := daemonRunning()
+	board, err := readClipboard()
+	if err != nil && storeKind == "daemon" && !running {
+		// still report on the clipboard file the daemon would serve
+		board, err = clipboard.NewFileStore(clipboardPath).Read()
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Clipboard: %s %s\n", path, detailsStyle.Render("("+storeKind+" store)"))
+
+	copies := 0
+	for _, entry := range board.Entries {
+		if entry.IsCopy() {
+			copies++
+		}
+	}
+	fmt.Fprintf(w, "Entries:   %d %s\n", len(board.Entries), detailsStyle.Render(fmt.Sprintf("(%d cut, %d copied)", len(board.Entries)-copies, copies)))
+
+	var total treeStats
+	for _, stats := range measureEntries(board.Entries) {
+		total.Files += stats.Files
+		total.Size += stats.Size
+	}
+	fmt.Fprintf(w, "Queued:    %s\n", formatTotals(len(board.Entries), total))
+
+	lock := "free"
+	if fstype, ok := fsops.NetworkFS(path); ok {
+		lock = fmt.Sprintf("not used, the clipboard is on a network filesystem (%s)", fstype)
+	} else if pid, held, err := lockHolder(); err != nil {
+		lock = fmt.Sprintf("unknown (%v)", err)
+	} else if held && pid > 0 {
+		lock = fmt.Sprintf("held by process %d", pid)
+	} else if held {
+		lock = "held by another process"
+	}
+	fmt.Fprintf(w, "Lock:      %s\n", lock)
+
+	daemon := "not running"
+	if running {
+		daemon = "running on " + socketPath()
+	}
+	fmt.Fprintf(w, "Daemon:    %s\n", daemon)
+
+	maxAge := expireAfter
+	if maxAge <= 0 {
+		maxAge = staleAfter
+	}
+	now := time.Now()
+	var stale, missing []int
+	for i, entry := range board.Entries {
+		if entry.Expired(maxAge, now) {
+			stale = append(stale, i)
+		}
+		if isRemoteEntry(entry) {
+			continue
+		}
+		if _, err := os.Lstat(entry.CurrentPath); err != nil {
+			missing = append(missing, i)
+		}
+	}
+
+	age := maxAge.String()
+	if day := 24 * time.Hour; maxAge%day == 0 {
+		age = fmt.Sprintf("%d days", maxAge/day)
+	}
+	fmt.Fprintf(w, "Stale:     %d %s\n", len(stale), detailsStyle.Render("(older than "+age+")"))
+	for _, i := range stale {
+		fmt.Fprintf(w, "  %d %s %s\n", i, board.Entries[i].CurrentPath, detailsStyle.Render(FormatCutAtTime(board.Entries[i].CutAt)))
+	}
+	fmt.Fprintf(w, "Missing:   %d\n", len(missing))
+	for _, i := range missing {
+		fmt.Fprintf(w, "  %d %s\n", i, board.Entries[i].CurrentPath)
+	}
+	return nil
+}
+
+// overviewExamples are the commands a bare cx suggests
+const overviewExamples = `Common commands:
+  cx <path>           cut a file or directory, to move it on paste
+  cx copy <path>      copy one, to duplicate it on paste
+  cx paste            paste the top entry into the current directory
+  cx paste --all      paste every entry
+  cx list             show what's on the clipboard
+  cx undo             undo the last paste
+`
+
+// handleOverview is what a bare cx prints instead of the full help: how many
+// entries there are, the top one, and the commands used most
+func handleOverview(w io.Writer) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	switch len(board.Entries) {
+	case 0:
+		fmt.Fprintln(w, "The clipboard is empty.")
+	case 1:
+		fmt.Fprintln(w, "1 entry on the clipboard.")
+	default:
+		fmt.Fprintf(w, "%d entries on the clipboard.\n", len(board.Entries))
+	}
+	if len(board.Entries) > 0 {
+		top := board.Entries[0]
+		op := "cut"
+		if top.IsCopy() {
+			op = "copied"
+		}
+		fmt.Fprintf(w, "Top: %s %s\n", top.CurrentPath, detailsStyle.Render(fmt.Sprintf("(%s %s)", op, humanize.Time(top.CutAt))))
+	}
+
+	fmt.Fprintf(w, "\n%s\nRun cx --help for every command and flag.\n", overviewExamples)
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestHandleStatus(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	for _, path := range []string{"file1.txt", "config", "file2.txt"} {
+		if err := cutFile(io.Discard, filepath.Join(tempDir, path), Options{}); err != nil {
+			t.Fatalf("cutFile failed: %v", err)
+		}
+	}
+	if err := os.Remove(filepath.Join(tempDir, "file2.txt")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	err := updateClipboard(func(board *clipboard.Clipboard) error {
+		board.Entries[2].CutAt = time.Now().Add(-30 * 24 * time.Hour)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("updateClipboard failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handleStatus(&buf); err != nil {
+		t.Fatalf("handleStatus failed: %v", err)
+	}
+	output := buf.String()
+
+	for _, want := range []string{
+		"Clipboard: " + clipboardPath,
+		"Entries:   3 (3 cut, 0 copied)",
+		"Queued:    3 entries, 3 files, ",
+		"Lock:      free",
+		"Daemon:    not running",
+		"Stale:     1 (older than 7 days)\n  2 " + filepath.Join(tempDir, "file1.txt"),
+		"Missing:   1\n  0 " + filepath.Join(tempDir, "file2.txt"),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected status to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestHandleOverview(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := handleOverview(&out); err != nil {
+		t.Fatalf("handleOverview failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "The clipboard is empty") || !strings.Contains(out.String(), "cx paste") {
+		t.Errorf("Unexpected overview of an empty clipboard:\n%s", out.String())
+	}
+
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, name), Options{}); err != nil {
+			t.Fatalf("copyFileToClipboard failed: %v", err)
+		}
+	}
+	out.Reset()
+	if err := handleOverview(&out); err != nil {
+		t.Fatalf("handleOverview failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "2 entries") || !strings.Contains(out.String(), "Top: "+filepath.Join(tempDir, "file2.txt")) {
+		t.Errorf("Expected the count and the top entry, got:\n%s", out.String())
+	}
+}
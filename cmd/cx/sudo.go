@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// runSudo runs a command as root with sudo, which may ask for a password on
+// the terminal
+var runSudo = func(args []string) error {
+	cmd := exec.Command("sudo", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sudo %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// sudoArgs returns the command that pastes src to destPath: mv, or with
+// persist cp -R, keeping attributes if any are preserved
+func sudoArgs(src, destPath string, opts Options) []string {
+	if !opts.persist {
+		return []string{"mv", "--", src, destPath}
+	}
+
+	args := []string{"cp", "-R", "-P"}
+	if opts.symlinks == fsops.SymlinksFollow {
+		args[2] = "-L"
+	}
+	if opts.preserve != (fsops.Attrs{}) {
+		args = append(args, "-p")
+	}
+	return append(args, "--", src, destPath)
+}
+
+// preserveOwner turns on keeping ownership for --preserve-owner. Unlike
+// --preserve=ownership, which like cp quietly leaves other users' copies
+// their own, it fails unless running as root, the only user who can give
+// files away.
+func preserveOwner(preserve fsops.Attrs, euid int) (fsops.Attrs, error) {
+	if euid != 0 {
+		return preserve, fmt.Errorf("--preserve-owner needs root, e.g. sudo cx paste --preserve-owner")
+	}
+	preserve.Ownership = true
+	return preserve, nil
+}
+
+// pastePath returns where entry would be pasted in destDir, before any
+// conflict is resolved
+func pastePath(entry clipboard.Entry, destDir string, opts Options) (string, error) {
+	name, err := clipboard.PasteName(filepath.Base(entry.CurrentPath), opts.as)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(destDir, name), nil
+}
+
+// pasteWithSudo redoes a paste that was denied permission, running cp or mv
+// as root. partial, if set, is what the denied paste left behind, which is
+// removed first: cp -R into a directory that already exists would copy the
+// entry inside it instead of replacing it.
+func pasteWithSudo(entry clipboard.Entry, destDir, partial string, opts Options) (string, error) {
+	if partial != "" {
+		if err := runSudo([]string{"rm", "-rf", "--", partial}); err != nil {
+			return "", err
+		}
+	}
+
+	destPath, err := pastePath(entry, destDir, opts)
+	if err != nil {
+		return "", err
+	}
+	destPath, overwrite, err := engine().ResolveConflict(entry.CurrentPath, destPath, opts.onConflict)
+	if err != nil {
+		return "", err
+	}
+	if err := overwrite.Finish(runSudo(sudoArgs(entry.CurrentPath, destPath, opts))); err != nil {
+		return "", err
+	}
+	return destPath, nil
+}
+
+// permissionHint adds to a permission error how to paste with root
+// privileges instead: --sudo, or the command to run by hand
+func permissionHint(err error, entry clipboard.Entry, destDir string, opts Options) error {
+	destPath, pathErr := pastePath(entry, destDir, opts)
+	if pathErr != nil {
+		return err
+	}
+
+	// the last two arguments are the paths
+	args := sudoArgs(entry.CurrentPath, destPath, opts)
+	command := fmt.Sprintf("sudo %s %s %s", strings.Join(args[:len(args)-2], " "), shellQuote(entry.CurrentPath), shellQuote(destPath))
+	return fmt.Errorf("%w (rerun with --sudo, or run: %s)", err, command)
+}
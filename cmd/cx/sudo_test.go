@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+	"golang.org/x/sys/unix"
+)
+
+func TestSudoArgs(t *testing.T) {
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{Options{}, "mv -- /src/a /dest/a"},
+		{Options{persist: true}, "cp -R -P -- /src/a /dest/a"},
+		{Options{persist: true, preserve: fsops.AllAttrs}, "cp -R -P -p -- /src/a /dest/a"},
+		{Options{persist: true, symlinks: fsops.SymlinksFollow}, "cp -R -L -- /src/a /dest/a"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(sudoArgs("/src/a", "/dest/a", tt.opts), " "); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestPermissionHint(t *testing.T) {
+	denied := &fs.PathError{Op: "rename", Path: "/src/it's", Err: fs.ErrPermission}
+	entry := clipboard.Entry{CurrentPath: "/src/it's"}
+
+	err := permissionHint(denied, entry, "/dest", Options{})
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Expected the permission error to be kept, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `rerun with --sudo, or run: sudo mv -- '/src/it'\''s' '/dest/it'\''s'`) {
+		t.Errorf("Expected the command to run by hand, got %v", err)
+	}
+}
+
+func TestPasteWithSudo(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	var ran []string
+	originalRunSudo := runSudo
+	defer func() { runSudo = originalRunSudo }()
+	runSudo = func(args []string) error {
+		ran = args
+		return exec.Command(args[0], args[1:]...).Run()
+	}
+
+	source := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	dest, err := pasteWithSudo(board.Entries[0], destDir, "", Options{})
+	if err != nil {
+		t.Fatalf("pasteWithSudo failed: %v", err)
+	}
+	if dest != filepath.Join(destDir, "file1.txt") || len(ran) == 0 || ran[0] != "mv" {
+		t.Errorf("Expected mv to %s, got %v to %s", filepath.Join(destDir, "file1.txt"), ran, dest)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("Expected the file to be moved: %v", err)
+	}
+}
+
+func TestPasteWithSudoPartial(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalRunSudo := runSudo
+	defer func() { runSudo = originalRunSudo }()
+	runSudo = func(args []string) error {
+		return exec.Command(args[0], args[1:]...).Run()
+	}
+
+	source := filepath.Join(tempDir, "config")
+	if err := copyFileToClipboard(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+
+	// the denied copy got as far as creating the directory
+	destDir := filepath.Join(tempDir, "empty_dir")
+	partial := filepath.Join(destDir, "config")
+	if err := os.Mkdir(partial, 0o755); err != nil {
+		t.Fatalf("Failed to create partial copy: %v", err)
+	}
+
+	dest, err := pasteWithSudo(board.Entries[0], destDir, partial, Options{persist: true})
+	if err != nil {
+		t.Fatalf("pasteWithSudo failed: %v", err)
+	}
+	if dest != partial {
+		t.Errorf("Expected the copy at %s, got %s", partial, dest)
+	}
+	if _, err := os.Stat(filepath.Join(partial, "settings.json")); err != nil {
+		t.Errorf("Expected settings.json in the copy: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(partial, "config")); err == nil {
+		t.Error("Expected the copy to replace the partial one, not go inside it")
+	}
+
+	// a destination that was already there isn't taken for a partial copy
+	if _, err := pasteWithSudo(board.Entries[0], destDir, "", Options{persist: true}); !errors.Is(err, clipboard.ErrConflict) {
+		t.Errorf("Expected a conflict with the existing copy, got %v", err)
+	}
+}
+
+func TestPreserveOwner(t *testing.T) {
+	if _, err := preserveOwner(fsops.Attrs{}, 1000); err == nil {
+		t.Error("Expected --preserve-owner to need root")
+	}
+	preserve, err := preserveOwner(fsops.Attrs{Mode: true}, 0)
+	if err != nil {
+		t.Fatalf("preserveOwner failed: %v", err)
+	}
+	if preserve != (fsops.Attrs{Mode: true, Ownership: true}) {
+		t.Errorf("Expected mode and ownership to be kept, got %+v", preserve)
+	}
+}
+
+func TestPastePreserveOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing ownership needs root")
+	}
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	source := filepath.Join(tempDir, "config")
+	err := filepath.Walk(source, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, 1234, 5678)
+	})
+	if err != nil {
+		t.Fatalf("Failed to change ownership: %v", err)
+	}
+
+	if err := copyFileToClipboard(io.Discard, source, Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	preserve, _ := preserveOwner(fsops.Attrs{}, 0)
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := handlePasteAt(io.Discard, 0, Options{dest: destDir, preserve: preserve}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	for _, path := range []string{"config", filepath.Join("config", "settings.json")} {
+		var st unix.Stat_t
+		if err := unix.Lstat(filepath.Join(destDir, path), &st); err != nil {
+			t.Fatalf("Expected %s to be pasted: %v", path, err)
+		}
+		if st.Uid != 1234 || st.Gid != 5678 {
+			t.Errorf("Expected %s to be owned by 1234:5678, got %d:%d", path, st.Uid, st.Gid)
+		}
+	}
+}
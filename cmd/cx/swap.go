@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// handleSwap exchanges the paths a and b, and has clipboard entries for
+// either, or for anything inside them, follow their files to the new names
+func handleSwap(w io.Writer, a, b string, opts Options) error {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return err
+	}
+
+	if err := fsops.Swap(absA, absB); err != nil {
+		return err
+	}
+
+	err = updateClipboard(func(board *clipboard.Clipboard) error {
+		changed := false
+		for i, entry := range board.Entries {
+			if isRemoteEntry(entry) {
+				continue
+			}
+			if path, ok := swappedPath(entry.CurrentPath, absA, absB); ok {
+				board.Entries[i].CurrentPath = path
+				changed = true
+			}
+		}
+		if !changed {
+			return clipboard.ErrUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !opts.quiet {
+		fmt.Fprintf(w, "Swapped: %s <-> %s\n", absA, absB)
+	}
+	return nil
+}
+
+// swappedPath returns where path is once a and b have been swapped, if it's
+// either of them or inside one
+func swappedPath(path, a, b string) (string, bool) {
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		from, to := pair[0], pair[1]
+		if path == from {
+			return to, true
+		}
+		if rest, ok := strings.CutPrefix(path, from+string(filepath.Separator)); ok {
+			return filepath.Join(to, rest), true
+		}
+	}
+	return path, false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwapFollowsEntries(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	file := filepath.Join(tempDir, "file1.txt")
+	other := filepath.Join(tempDir, "file2.txt")
+	if err := cutFile(io.Discard, file, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	if err := handleSwap(io.Discard, file, other, Options{}); err != nil {
+		t.Fatalf("handleSwap failed: %v", err)
+	}
+
+	moved, err := os.ReadFile(other)
+	if err != nil || string(moved) != string(data) {
+		t.Errorf("Expected %s to hold %q, got %q (%v)", other, data, moved, err)
+	}
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("readClipboard failed: %v", err)
+	}
+	if board.Entries[0].CurrentPath != other {
+		t.Errorf("Expected the entry to follow its file to %s, got %s", other, board.Entries[0].CurrentPath)
+	}
+}
+
+func TestSwappedPath(t *testing.T) {
+	tests := map[string]string{
+		"/a":     "/b",
+		"/b/x/y": "/a/x/y",
+		"/ab":    "/ab",
+		"/c/a":   "/c/a",
+	}
+	for path, expected := range tests {
+		if got, _ := swappedPath(path, "/a", "/b"); got != expected {
+			t.Errorf("swappedPath(%q): expected %q, got %q", path, expected, got)
+		}
+	}
+}
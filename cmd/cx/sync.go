@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// syncTimeout bounds a single push or pull, and how long the server gives
+// a request to arrive and its answer to be sent
+const syncTimeout = 30 * time.Second
+
+// maxSyncBody caps the size of a clipboard pushed to the sync server, which
+// would otherwise read whatever it's sent into memory
+const maxSyncBody = 16 << 20
+
+// syncHandler serves store's clipboard at /clipboard: GET returns it and PUT
+// replaces it. Each request holds the store's lock while it reads or
+// replaces the clipboard, so local cx commands on the serving machine can
+// run alongside it. If token isn't empty, requests must carry it as a
+// bearer token.
+func syncHandler(store clipboard.Store, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/clipboard", func(w http.ResponseWriter, r *http.Request) {
+		auth := []byte(r.Header.Get("Authorization"))
+		if token != "" && subtle.ConstantTimeCompare(auth, []byte("Bearer "+token)) != 1 {
+			http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var pushed clipboard.Clipboard
+		if r.Method == http.MethodPut {
+			boardJSON, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSyncBody))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, fmt.Sprintf("clipboard larger than %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if pushed, err = clipboard.Decode(boardJSON); err != nil {
+				http.Error(w, "invalid clipboard: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		unlock, err := store.Lock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer unlock()
+
+		if r.Method == http.MethodPut {
+			if err := store.Write(pushed); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		board, err := store.Read()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(board)
+	})
+	return mux
+}
+
+// handleSyncServe serves the clipboard over HTTP on addr until it fails
+func handleSyncServe(w io.Writer, addr, token string) error {
+	if token == "" {
+		fmt.Fprintln(w, "Warning: serving without --token, anyone who can reach this address can change the clipboard")
+	}
+	fmt.Fprintf(w, "Serving clipboard on http://%s/clipboard\n", addr)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           syncHandler(clipboardStore(), token),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       syncTimeout,
+		WriteTimeout:      syncTimeout,
+	}
+	return server.ListenAndServe()
+}
+
+// syncRequest sends a request for the clipboard to a sync server and
+// decodes the clipboard it answers with
+func syncRequest(method, remote, token string, body io.Reader) (clipboard.Clipboard, error) {
+	var board clipboard.Clipboard
+	if remote == "" {
+		return board, fmt.Errorf("no sync server given (use --remote or set sync_remote in the config)")
+	}
+
+	url := strings.TrimSuffix(remote, "/") + "/clipboard"
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return board, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: syncTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return board, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return board, fmt.Errorf("%s: %s: %s", url, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	boardJSON, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return board, err
+	}
+	if board, err = clipboard.Decode(boardJSON); err != nil {
+		return board, fmt.Errorf("%s: invalid clipboard: %w", url, err)
+	}
+	return board, nil
+}
+
+// handleSyncPush replaces the sync server's clipboard with the local one
+func handleSyncPush(w io.Writer, remote, token string, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	boardJSON, err := json.Marshal(board)
+	if err != nil {
+		return err
+	}
+
+	if _, err := syncRequest(http.MethodPut, remote, token, bytes.NewReader(boardJSON)); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	fmt.Fprintf(w, "Pushed %d entries to %s\n", len(board.Entries), remote)
+	return nil
+}
+
+// handleSyncPull replaces the local clipboard with the sync server's
+func handleSyncPull(w io.Writer, remote, token string, opts Options) error {
+	pulled, err := syncRequest(http.MethodGet, remote, token, nil)
+	if err != nil {
+		return err
+	}
+
+	err = updateClipboard(func(board *clipboard.Clipboard) error {
+		*board = pulled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	fmt.Fprintf(w, "Pulled %d entries from %s\n", len(pulled.Entries), remote)
+	return nil
+}
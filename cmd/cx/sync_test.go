@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestSyncPushPull(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	serverStore := clipboard.NewMemoryStore()
+	server := httptest.NewServer(syncHandler(serverStore, "secret"))
+	defer server.Close()
+
+	sourceFile := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, sourceFile, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	if err := handleSyncPush(io.Discard, server.URL, "wrong", Options{}); err == nil {
+		t.Error("Expected error pushing with the wrong token, got nil")
+	}
+	if err := handleSyncPush(io.Discard, server.URL, "secret", Options{}); err != nil {
+		t.Fatalf("handleSyncPush failed: %v", err)
+	}
+
+	board, _ := serverStore.Read()
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != sourceFile {
+		t.Errorf("Expected the server to hold file1.txt, got %+v", board.Entries)
+	}
+
+	// Another machine's clipboard is replaced by the server's on pull
+	clipboardPath = filepath.Join(tempDir, "other_clipboard.json")
+	if err := handleSyncPull(io.Discard, server.URL, "secret", Options{}); err != nil {
+		t.Fatalf("handleSyncPull failed: %v", err)
+	}
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != sourceFile {
+		t.Errorf("Expected the pulled clipboard to hold file1.txt, got %+v", board.Entries)
+	}
+
+	if err := handleSyncPull(io.Discard, "", "", Options{}); err == nil {
+		t.Error("Expected error without a sync server, got nil")
+	}
+}
+
+func TestSyncHandlerLimits(t *testing.T) {
+	server := httptest.NewServer(syncHandler(clipboard.NewMemoryStore(), "secret"))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		auth   string
+		body   string
+		status int
+	}{
+		{"no token", "", "{}", http.StatusUnauthorized},
+		{"token as prefix", "Bearer secretx", "{}", http.StatusUnauthorized},
+		{"too large", "Bearer secret", `{"entries": [` + strings.Repeat(" ", maxSyncBody) + `]}`, http.StatusRequestEntityTooLarge},
+		{"valid", "Bearer secret", `{"entries": []}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/clipboard", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, resp.StatusCode)
+		}
+	}
+}
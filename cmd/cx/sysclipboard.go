@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// sysClipboardCommand returns the command that writes (or reads) a file
+// list on the OS clipboard, along with the input it expects on stdin
+func sysClipboardCommand(paths []string, write bool) (*exec.Cmd, string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if write {
+			files := make([]string, len(paths))
+			for i, path := range paths {
+				files[i] = fmt.Sprintf("POSIX file %q", path)
+			}
+			script := fmt.Sprintf("tell application \"Finder\" to set the clipboard to {%s}", strings.Join(files, ", "))
+			return exec.Command("osascript", "-e", script), "", nil
+		}
+		script := `set output to ""
+repeat with f in (the clipboard as «class furl») as list
+	set output to output & POSIX path of f & linefeed
+end repeat
+output`
+		return exec.Command("osascript", "-e", script), "", nil
+
+	case "windows":
+		if write {
+			quoted := make([]string, len(paths))
+			for i, path := range paths {
+				quoted[i] = "'" + strings.ReplaceAll(path, "'", "''") + "'"
+			}
+			return exec.Command("powershell", "-NoProfile", "-Command", "Set-Clipboard -Path "+strings.Join(quoted, ",")), "", nil
+		}
+		return exec.Command("powershell", "-NoProfile", "-Command", "Get-Clipboard -Format FileDropList | ForEach-Object { $_.FullName }"), "", nil
+	}
+
+	// X11 and Wayland share file lists as text/uri-list
+	uris := toURIList(paths)
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-copy"); err == nil {
+			if write {
+				return exec.Command("wl-copy", "--type", "text/uri-list"), uris, nil
+			}
+			return exec.Command("wl-paste", "--no-newline", "--type", "text/uri-list"), "", nil
+		}
+	}
+
+	if _, err := exec.LookPath("xclip"); err == nil {
+		if write {
+			return exec.Command("xclip", "-selection", "clipboard", "-t", "text/uri-list"), uris, nil
+		}
+		return exec.Command("xclip", "-selection", "clipboard", "-o", "-t", "text/uri-list"), "", nil
+	}
+
+	return nil, "", fmt.Errorf("no system clipboard tool found (install wl-clipboard or xclip)")
+}
+
+// writeSysClipboard puts paths on the OS clipboard as files, so they can be
+// pasted in a graphical file manager
+func writeSysClipboard(paths []string) error {
+	cmd, input, err := sysClipboardCommand(paths, true)
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdin = strings.NewReader(input)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write system clipboard: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// readSysClipboard returns the files currently on the OS clipboard
+func readSysClipboard() ([]string, error) {
+	cmd, _, err := sysClipboardCommand(nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system clipboard: %w", err)
+	}
+
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		var paths []string
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				paths = append(paths, line)
+			}
+		}
+		return paths, nil
+	}
+
+	return parseURIList(string(out))
+}
+
+// toURIList formats paths as a text/uri-list of file:// URIs
+func toURIList(paths []string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+		b.WriteString(u.String())
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+// parseURIList extracts local paths from a text/uri-list, ignoring comments
+// and anything that isn't a file:// URI
+func parseURIList(list string) ([]string, error) {
+	var paths []string
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		u, err := url.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI on system clipboard: %s", line)
+		}
+		if u.Scheme == "file" {
+			paths = append(paths, filepath.FromSlash(u.Path))
+		}
+	}
+	return paths, nil
+}
+
+// handleImportSys adds the files on the OS clipboard to the cx clipboard
+func handleImportSys(w io.Writer, op clipboard.Operation, opts Options) error {
+	paths, err := readSysClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("no files on the system clipboard")
+	}
+
+	return addEntries(w, paths, op, opts)
+}
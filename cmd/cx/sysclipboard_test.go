@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestURIListRoundTrip(t *testing.T) {
+	paths := []string{"/tmp/plain.txt", "/tmp/with space/ünïcode #1.txt"}
+
+	list := toURIList(paths)
+	if list != "file:///tmp/plain.txt\r\nfile:///tmp/with%20space/%C3%BCn%C3%AFcode%20%231.txt\r\n" {
+		t.Errorf("Unexpected uri-list: %q", list)
+	}
+
+	got, err := parseURIList(list)
+	if err != nil {
+		t.Fatalf("parseURIList failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, paths) {
+		t.Errorf("Expected %v, got %v", paths, got)
+	}
+}
+
+func TestParseURIListSkipsNonFiles(t *testing.T) {
+	got, err := parseURIList("# copied from nautilus\nhttps://example.com/x\nfile:///home/a/b.txt\n")
+	if err != nil {
+		t.Fatalf("parseURIList failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"/home/a/b.txt"}) {
+		t.Errorf("Unexpected paths: %v", got)
+	}
+}
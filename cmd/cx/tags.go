@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// parseTags validates the labels given with --tag, dropping repeats
+func parseTags(tags []string) ([]string, error) {
+	var parsed []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || strings.ContainsAny(tag, " \t\n") {
+			return nil, fmt.Errorf("invalid tag %q, expected a single word", tag)
+		}
+		if !slices.Contains(parsed, tag) {
+			parsed = append(parsed, tag)
+		}
+	}
+	return parsed, nil
+}
+
+// taggedIndices returns the indices of the entries labelled with tag, most
+// recent first
+func taggedIndices(entries []clipboard.Entry, tag string) ([]int, error) {
+	var indices []int
+	for i, entry := range entries {
+		if entry.HasTag(tag) {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no clipboard entries are tagged %s", tag)
+	}
+	return indices, nil
+}
+
+// formatTags shows an entry's tags in list output
+func formatTags(tags []string) string {
+	return "[" + strings.Join(tags, ", ") + "]"
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags([]string{"work", " urgent ", "work"})
+	if err != nil {
+		t.Fatalf("parseTags failed: %v", err)
+	}
+	if got := strings.Join(tags, ","); got != "work,urgent" {
+		t.Errorf("Expected work,urgent, got %s", got)
+	}
+	for _, bad := range []string{"", "two words"} {
+		if _, err := parseTags([]string{bad}); err == nil {
+			t.Errorf("Expected error for tag %q, got nil", bad)
+		}
+	}
+}
+
+func TestTaggedEntries(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file1.txt"), Options{tags: []string{"work"}}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := cutFile(io.Discard, filepath.Join(tempDir, "file2.txt"), Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "nested"), Options{tags: []string{"work", "later"}}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handleList(&buf, Options{tag: "work"}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries tagged work, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "[work, later]") || !strings.HasPrefix(strings.TrimSpace(lines[1]), "2:") {
+		t.Errorf("Unexpected list output: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := handleList(&buf, Options{tag: "missing"}); err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No entries tagged missing") {
+		t.Errorf("Unexpected output for an unused tag: %q", buf.String())
+	}
+
+	board, _ := readClipboard()
+	indices, err := taggedIndices(board.Entries, "work")
+	if err != nil {
+		t.Fatalf("taggedIndices failed: %v", err)
+	}
+	destDir := filepath.Join(tempDir, "empty_dir")
+	if err := handlePasteEntries(io.Discard, indices, Options{dest: destDir}); err != nil {
+		t.Fatalf("handlePasteEntries failed: %v", err)
+	}
+	for _, name := range []string{"file1.txt", "nested"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); err != nil {
+			t.Errorf("Expected tagged entry %s to be pasted: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file2.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the untagged entry not to be pasted")
+	}
+
+	if _, err := taggedIndices(board.Entries, "missing"); err == nil {
+		t.Error("Expected error for an unused tag, got nil")
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// parseTemplateVars parses --set values of the form key=value
+func parseTemplateVars(sets []string) (map[string]string, error) {
+	vars := make(map[string]string, len(sets))
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set %q, expected key=value", set)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// templateReplacer replaces {{key}}, or {{ key }}, with each key's value
+func templateReplacer(vars map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, 4*len(vars))
+	for key, value := range vars {
+		pairs = append(pairs, "{{"+key+"}}", value, "{{ "+key+" }}", value)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// handlePasteTemplate pastes a copy of the entry at index with {{key}}
+// placeholders in file names and text file contents replaced by the values
+// in opts.vars. Placeholders without a value are left alone. The template
+// and its entry always stay, so it can be pasted again.
+func handlePasteTemplate(w io.Writer, index int, opts Options) error {
+	destDir, err := resolveDestDir(opts)
+	if err != nil {
+		return err
+	}
+
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	if index < 0 || index >= len(board.Entries) {
+		return fmt.Errorf("invalid clipboard index: %d", index)
+	}
+
+	entry := board.Entries[index]
+	if isRemoteEntry(entry) {
+		return fmt.Errorf("%s is on another machine; paste it locally before using it as a template", entry.CurrentPath)
+	}
+	srcInfo, err := os.Lstat(entry.CurrentPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+	}
+	if srcInfo.IsDir() {
+		if err := clipboard.CheckNotInside(entry.CurrentPath, destDir); err != nil {
+			return err
+		}
+	}
+	if !opts.dryRun {
+		if err := checkRoom([]clipboard.Entry{entry}, destDir, Options{persist: true}); err != nil {
+			return err
+		}
+	}
+
+	replacer := templateReplacer(opts.vars)
+	name, err := clipboard.PasteName(filepath.Base(entry.CurrentPath), opts.as)
+	if err != nil {
+		return err
+	}
+	name = replacer.Replace(name)
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	destPath := filepath.Join(destDir, name)
+	if opts.dryRun {
+		fmt.Fprintf(w, "Would scaffold %s -> %s\n", entry.CurrentPath, destPath)
+		return nil
+	}
+
+	destPath, overwrite, err := engine().ResolveConflict(entry.CurrentPath, destPath, opts.onConflict)
+	if errors.Is(err, clipboard.ErrSkipped) {
+		fmt.Fprintf(w, "Skipped: %s (destination already exists)\n", entry.CurrentPath)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	vars := hookVars{source: entry.CurrentPath, dest: destPath, op: clipboard.OpCopy}
+	if err := runHook("pre_paste", vars); err != nil {
+		return overwrite.Finish(err)
+	}
+
+	err = fsops.Copy(entry.CurrentPath, destPath, srcInfo, opts.pasteOptions().FS)
+	var changed int
+	if err == nil {
+		changed, err = applyTemplate(destPath, replacer)
+	}
+	err = overwrite.Finish(err)
+	if histErr := appendHistory(newHistoryRecord("paste", entry.CurrentPath, destPath, err)); histErr != nil || err != nil {
+		return errors.Join(err, histErr)
+	}
+
+	if err := runHook("post_paste", vars); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Scaffolded: %s -> %s (%d files filled in)\n", entry.CurrentPath, destPath, changed)
+	return nil
+}
+
+// applyTemplate fills in the placeholders under root, which has just been
+// copied from a template: in the names of everything below root, and in
+// the contents of text files. It returns how many files' contents changed.
+func applyTemplate(root string, replacer *strings.Replacer) (int, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	// deepest first, so renaming a directory doesn't move paths still to come
+	for i := len(paths) - 1; i >= 0; i-- {
+		path := paths[i]
+		info, err := os.Lstat(path)
+		if err != nil {
+			return changed, err
+		}
+
+		if info.Mode().IsRegular() {
+			filled, err := fillTemplateFile(path, replacer)
+			if err != nil {
+				return changed, err
+			}
+			if filled {
+				changed++
+			}
+		}
+
+		if path == root {
+			continue
+		}
+		name := replacer.Replace(info.Name())
+		if name == info.Name() {
+			continue
+		}
+		if strings.ContainsRune(name, filepath.Separator) || name == "." || name == ".." {
+			return changed, fmt.Errorf("%s would be renamed to %q, which is not a valid file name", path, name)
+		}
+		renamed := filepath.Join(filepath.Dir(path), name)
+		if _, err := os.Lstat(renamed); err == nil {
+			return changed, fmt.Errorf("cannot rename %s to %s: it already exists", path, renamed)
+		}
+		if err := os.Rename(path, renamed); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// fillTemplateFile replaces placeholders in a text file, reporting whether
+// it changed. Binary files are left alone.
+func fillTemplateFile(path string, replacer *strings.Replacer) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if !isText(content[:min(len(content), sniffSize)]) || !bytes.Contains(content, []byte("{{")) {
+		return false, nil
+	}
+
+	filled := replacer.Replace(string(content))
+	if filled == string(content) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, []byte(filled), 0)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTemplateVars(t *testing.T) {
+	vars, err := parseTemplateVars([]string{"project=foo", "greeting=a=b"})
+	if err != nil {
+		t.Fatalf("parseTemplateVars failed: %v", err)
+	}
+	if vars["project"] != "foo" || vars["greeting"] != "a=b" {
+		t.Errorf("Unexpected vars: %v", vars)
+	}
+	if _, err := parseTemplateVars([]string{"project"}); err == nil {
+		t.Error("Expected error for a --set without =, got nil")
+	}
+}
+
+func TestHandlePasteTemplate(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	template := filepath.Join(tempDir, "{{project}}-template")
+	files := map[string]string{
+		"README.md":               "# {{project}}\n\nBy {{ author }}, {{unset}}\n",
+		"cmd/{{project}}/main.go": "package main // {{project}}\n",
+		"logo.bin":                "{{project}}\x00",
+	}
+	for path, content := range files {
+		full := filepath.Join(template, path)
+		os.MkdirAll(filepath.Dir(full), 0o755)
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	if err := cutFile(io.Discard, template, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "empty_dir")
+	opts := Options{dest: destDir, vars: map[string]string{"project": "foo", "author": "me"}}
+	if err := handlePasteTemplate(io.Discard, 0, opts); err != nil {
+		t.Fatalf("handlePasteTemplate failed: %v", err)
+	}
+
+	scaffold := filepath.Join(destDir, "foo-template")
+	for path, expected := range map[string]string{
+		"README.md":       "# foo\n\nBy me, {{unset}}\n",
+		"cmd/foo/main.go": "package main // foo\n",
+		"logo.bin":        "{{project}}\x00",
+	} {
+		content, err := os.ReadFile(filepath.Join(scaffold, path))
+		if err != nil {
+			t.Errorf("Expected %s in the scaffold: %v", path, err)
+			continue
+		}
+		if string(content) != expected {
+			t.Errorf("Expected %s to hold %q, got %q", path, expected, content)
+		}
+	}
+
+	// the template and its entry stay, even though it was cut
+	if _, err := os.Stat(template); err != nil {
+		t.Errorf("Expected the template to remain: %v", err)
+	}
+	board, _ := readClipboard()
+	if len(board.Entries) != 1 {
+		t.Errorf("Expected the template entry to remain, got %d entries", len(board.Entries))
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// TrashRecord describes a clipboard entry deleted to the trash, so that it
+// can be restored
+type TrashRecord struct {
+	fsops.Trashed
+	Entry clipboard.Entry `json:"entry"`
+}
+
+// TrashLog lists the entries deleted with cx delete, oldest first
+type TrashLog struct {
+	Records []TrashRecord `json:"records"`
+}
+
+// trashLogPath returns the path of the trash log
+func trashLogPath() string {
+	return siblingPath(".trash.json")
+}
+
+// readTrashLog reads the trash log, returning an empty one if it doesn't
+// exist yet
+func readTrashLog() (TrashLog, error) {
+	var trashLog TrashLog
+
+	logJSON, err := os.ReadFile(trashLogPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return trashLog, nil
+	}
+	if err != nil {
+		return trashLog, err
+	}
+
+	err = json.Unmarshal(logJSON, &trashLog)
+	return trashLog, err
+}
+
+// writeTrashLog writes the trash log
+func writeTrashLog(trashLog TrashLog) error {
+	logJSON, err := json.MarshalIndent(trashLog, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fsops.WriteFileAtomic(trashLogPath(), logJSON, 0o644)
+}
+
+// updateTrashLog changes the trash log with fn under the clipboard lock
+func updateTrashLog(fn func(*TrashLog) error) error {
+	return withClipboardLock(func() error {
+		trashLog, err := readTrashLog()
+		if err != nil {
+			return err
+		}
+		if err := fn(&trashLog); err != nil {
+			return err
+		}
+		return writeTrashLog(trashLog)
+	})
+}
+
+// handleDelete moves the files of the selected entries to the trash and
+// removes the entries from the clipboard. A failing entry does not stop the
+// others; it is left in the clipboard and its error reported at the end.
+func handleDelete(w io.Writer, args []string, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+
+	indices, err := parseEntrySelectors(args, board.Entries)
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	var records []TrashRecord
+	var deleted []clipboard.Entry
+	var errs []error
+	for _, index := range indices {
+		entry := board.Entries[index]
+		trashed, err := fsops.Trash(entry.CurrentPath)
+		if histErr := appendHistory(newHistoryRecord("delete", entry.CurrentPath, trashed.TrashPath, err)); histErr != nil {
+			errs = append(errs, histErr)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.CurrentPath, err))
+			continue
+		}
+
+		records = append(records, TrashRecord{Trashed: trashed, Entry: entry})
+		deleted = append(deleted, entry)
+		fmt.Fprintf(w, "Deleted: %s (moved to %s)\n", entry.CurrentPath, trashed.TrashPath)
+	}
+
+	if len(deleted) > 0 {
+		err := updateTrashLog(func(trashLog *TrashLog) error {
+			trashLog.Records = append(trashLog.Records, records...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := engine().RemoveEntries(deleted...); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete %d of %d entries:\n%w", len(indices)-len(deleted), len(indices), errors.Join(errs...))
+	}
+	return nil
+}
+
+// handleRestore moves a deleted entry's file back out of the trash and
+// returns the entry to the clipboard. Index 0 is the most recent deletion.
+func handleRestore(w io.Writer, index int, opts Options) error {
+	trashLog, err := readTrashLog()
+	if err != nil {
+		return err
+	}
+
+	if len(trashLog.Records) == 0 {
+		return fmt.Errorf("nothing to restore")
+	}
+	if index < 0 || index >= len(trashLog.Records) {
+		return fmt.Errorf("invalid restore index: %d", index)
+	}
+
+	i := len(trashLog.Records) - 1 - index
+	record := trashLog.Records[i]
+
+	err = record.Restore()
+	if histErr := appendHistory(newHistoryRecord("restore", record.TrashPath, record.OriginalPath, err)); histErr != nil {
+		return errors.Join(err, histErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = updateClipboard(func(board *clipboard.Clipboard) error {
+		board.Entries = append([]clipboard.Entry{record.Entry}, board.Entries...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = updateTrashLog(func(trashLog *TrashLog) error {
+		trashLog.Records = slices.DeleteFunc(trashLog.Records, func(r TrashRecord) bool {
+			return r.TrashPath == record.TrashPath
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	fmt.Fprintf(w, "Restored: %s\n", record.OriginalPath)
+	return nil
+}
+
+// handleRestoreList shows the deleted entries that can be restored, most
+// recent first
+func handleRestoreList(w io.Writer) error {
+	trashLog, err := readTrashLog()
+	if err != nil {
+		return err
+	}
+
+	if len(trashLog.Records) == 0 {
+		fmt.Fprintln(w, "Nothing to restore")
+		return nil
+	}
+
+	for i := len(trashLog.Records) - 1; i >= 0; i-- {
+		record := trashLog.Records[i]
+		fmt.Fprintf(w, "%d: %s %s\n", len(trashLog.Records)-1-i, record.OriginalPath,
+			detailsStyle.Render(FormatCutAtTime(record.DeletedAt)))
+	}
+	return nil
+}
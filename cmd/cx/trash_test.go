@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeleteAndRestore(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+
+	file1 := filepath.Join(tempDir, "file1.txt")
+	file2 := filepath.Join(tempDir, "file2.txt")
+	if err := cutFile(io.Discard, file1, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := cutFile(io.Discard, file2, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+
+	if err := handleDelete(io.Discard, []string{file1}, Options{}); err != nil {
+		t.Fatalf("handleDelete failed: %v", err)
+	}
+
+	if _, err := os.Lstat(file1); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be moved to the trash, got: %v", file1, err)
+	}
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != file2 {
+		t.Fatalf("Expected only %s left in the clipboard, got %+v", file2, board.Entries)
+	}
+
+	var buf bytes.Buffer
+	if err := handleRestoreList(&buf); err != nil {
+		t.Fatalf("handleRestoreList failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "0: "+file1) {
+		t.Errorf("Expected %s in the restore list, got %q", file1, buf.String())
+	}
+
+	if err := handleRestore(io.Discard, 0, Options{}); err != nil {
+		t.Fatalf("handleRestore failed: %v", err)
+	}
+	if content, err := os.ReadFile(file1); err != nil || string(content) != "This is file 1" {
+		t.Errorf("Expected %s to be restored, got %q (%v)", file1, content, err)
+	}
+
+	board, err = readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].CurrentPath != file1 {
+		t.Errorf("Expected %s back on top of the clipboard, got %+v", file1, board.Entries)
+	}
+
+	if err := handleRestore(io.Discard, 0, Options{}); err == nil {
+		t.Error("Expected error with nothing to restore, got nil")
+	}
+}
+
+func TestDeleteMissingFile(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+
+	file1 := filepath.Join(tempDir, "file1.txt")
+	if err := cutFile(io.Discard, file1, Options{}); err != nil {
+		t.Fatalf("cutFile failed: %v", err)
+	}
+	if err := os.Remove(file1); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	if err := handleDelete(io.Discard, []string{"0"}, Options{}); err == nil {
+		t.Fatal("Expected error deleting a missing file, got nil")
+	}
+
+	// The entry stays in the clipboard
+	board, err := readClipboard()
+	if err != nil {
+		t.Fatalf("Failed to read clipboard: %v", err)
+	}
+	if len(board.Entries) != 1 {
+		t.Errorf("Expected the entry to stay in the clipboard, got %+v", board.Entries)
+	}
+}
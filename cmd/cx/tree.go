@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// maxTreeEntries is how many entries of one directory a tree shows before
+// summarising the rest
+const maxTreeEntries = 50
+
+// dirSummary counts the files under dir and adds up their sizes
+func dirSummary(dir string) (files int, size int64) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		files++
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return files, size
+}
+
+// formatDirSummary describes a directory's file count and total size
+func formatDirSummary(files int, size int64) string {
+	noun := "files"
+	if files == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("(%d %s, %s)", files, noun, FormatSize(size))
+}
+
+// printTree writes the contents of dir as an indented tree, each line
+// starting with indent, going at most maxDepth levels deep. Directories show
+// how many files they hold and their total size.
+func printTree(w io.Writer, dir, indent string, maxDepth int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	shown := entries
+	if len(shown) > maxTreeEntries {
+		shown = shown[:maxTreeEntries]
+	}
+
+	for i, entry := range shown {
+		last := i == len(shown)-1 && len(shown) == len(entries)
+		branch, childIndent := "├── ", indent+"│   "
+		if last {
+			branch, childIndent = "└── ", indent+"    "
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			fmt.Fprintf(w, "%s%s%s\n", indent, branch, missingPathStyle.Render(quotePath(entry.Name())))
+			continue
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, _ := os.Readlink(path)
+			fmt.Fprintf(w, "%s%s%s -> %s\n", indent, branch, symlinkStyle.Render(quotePath(entry.Name())), quotePath(target))
+		case info.IsDir():
+			files, size := dirSummary(path)
+			fmt.Fprintf(w, "%s%s%s %s\n", indent, branch, dirStyle.Render(quotePath(entry.Name())+"/"),
+				detailsStyle.Render(formatDirSummary(files, size)))
+			if maxDepth > 1 {
+				if err := printTree(w, path, childIndent, maxDepth-1); err != nil {
+					fmt.Fprintf(w, "%s└── %s\n", childIndent, missingPathStyle.Render(err.Error()))
+				}
+			}
+		default:
+			fmt.Fprintf(w, "%s%s%s %s\n", indent, branch, fileStyle.Render(quotePath(entry.Name())),
+				detailsStyle.Render(FormatSize(info.Size())))
+		}
+	}
+
+	if more := len(entries) - len(shown); more > 0 {
+		fmt.Fprintf(w, "%s└── %s\n", indent, detailsStyle.Render(fmt.Sprintf("… %d more", more)))
+	}
+	return nil
+}
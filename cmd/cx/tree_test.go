@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintTree(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/b/c", "many"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", "b", "c", "leaf.txt"), []byte("leaf"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	for i := 0; i < maxTreeEntries+3; i++ {
+		if err := os.WriteFile(filepath.Join(root, "many", fmt.Sprintf("f%03d", i)), nil, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := printTree(&buf, root, "", 2); err != nil {
+		t.Fatalf("printTree failed: %v", err)
+	}
+	output := buf.String()
+
+	expected := "├── a/ (1 file, 4 B)\n│   └── b/ (1 file, 4 B)\n"
+	if !strings.HasPrefix(output, expected) {
+		t.Errorf("Expected tree to start with %q, got %q", expected, output)
+	}
+	if strings.Contains(output, "c/") {
+		t.Errorf("Expected the tree to stop at depth 2, got %q", output)
+	}
+	if !strings.Contains(output, "    └── … 3 more\n") {
+		t.Errorf("Expected long directories to be cut short, got %q", output)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// verbose is set by --verbose to list every file copied and report how long
+// each paste took and how much it moved
+var verbose bool
+
+// pasteTiming measures entries before they are pasted, for --verbose, so
+// moved entries can still be described afterwards
+type pasteTiming struct {
+	stats map[string]treeStats
+	start time.Time
+}
+
+// startTiming returns a pasteTiming for entries, or nil without --verbose
+func startTiming(entries []clipboard.Entry) *pasteTiming {
+	if !verbose {
+		return nil
+	}
+	return &pasteTiming{stats: measureEntries(entries), start: time.Now()}
+}
+
+// begin starts timing the next entry
+func (t *pasteTiming) begin() {
+	if t != nil {
+		t.start = time.Now()
+	}
+}
+
+// describe returns what pasting the entry at path involved since begin, to
+// follow its paste message, e.g. " (3 files, 1.2 MB in 340ms)"
+func (t *pasteTiming) describe(path string) string {
+	if t == nil {
+		return ""
+	}
+	stats := t.stats[path]
+	noun := "files"
+	if stats.Files == 1 {
+		noun = "file"
+	}
+	elapsed := time.Since(t.start).Round(time.Millisecond)
+	return " " + detailsStyle.Render(fmt.Sprintf("(%d %s, %s in %s)", stats.Files, noun, FormatSize(stats.Size), elapsed))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerbosePaste(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	verbose = true
+	defer func() { verbose = false }()
+
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "config"), Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	dest := filepath.Join(tempDir, "dest")
+	if err := os.Mkdir(dest, 0o755); err != nil {
+		t.Fatalf("Failed to create destination: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := handlePasteAt(&buf, 0, Options{dest: dest}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+	output := buf.String()
+
+	for _, name := range []string{"settings.json", "config.ini"} {
+		if !strings.Contains(output, filepath.Join(tempDir, "config", name)) {
+			t.Errorf("Expected %s to be listed, got %q", name, output)
+		}
+	}
+	if !strings.Contains(output, "Copied: ") || !strings.Contains(output, "(2 files, 29 B in ") {
+		t.Errorf("Expected the paste line to report files, size and time, got %q", output)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// handleVerify re-checks every paste recorded with a checksum in the journal
+// against what is on disk now
+func handleVerify(w io.Writer, opts Options) error {
+	journal, err := readJournal()
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	checked, failed := 0, 0
+	for _, record := range journal.Records {
+		if record.Checksum == "" {
+			continue
+		}
+		checked++
+
+		actual, err := fsops.Checksum(record.Destination)
+		switch {
+		case os.IsNotExist(err):
+			failed++
+			fmt.Fprintf(w, "Missing: %s\n", record.Destination)
+		case err != nil:
+			failed++
+			fmt.Fprintf(w, "Failed: %s (%v)\n", record.Destination, err)
+		case actual != record.Checksum:
+			failed++
+			fmt.Fprintf(w, "Changed: %s %s\n", record.Destination, detailsStyle.Render("(checksum mismatch)"))
+		default:
+			fmt.Fprintf(w, "OK: %s\n", record.Destination)
+		}
+	}
+
+	if checked == 0 {
+		fmt.Fprintln(w, "No verified pastes to check (paste with --verify to record checksums)")
+		return nil
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d verified pastes no longer match", failed, checked)
+	}
+	return nil
+}
+
+// handleVerifyEntry compares the entry at index with every copy of it the
+// journal has, from pastes with --persist or of copied entries, reporting
+// any drift between them: missing or extra files, changed sizes, and for
+// files whose sizes still match, changed contents
+func handleVerifyEntry(w io.Writer, index int, opts Options) error {
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	if len(board.Entries) == 0 {
+		return clipboard.ErrEmptyClipboard
+	}
+	if index < 0 || index >= len(board.Entries) {
+		return fmt.Errorf("invalid clipboard index: %d", index)
+	}
+	entry := board.Entries[index]
+	if _, err := os.Lstat(entry.CurrentPath); err != nil {
+		return fmt.Errorf("%w: %s", clipboard.ErrSourceMissing, entry.CurrentPath)
+	}
+
+	journal, err := readJournal()
+	if err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		w = io.Discard
+	}
+
+	checked, drifted := 0, 0
+	for _, record := range journal.Records {
+		if record.Op != clipboard.OpCopy {
+			continue
+		}
+		// a persistent paste of a cut entry points the entry at its copy
+		src, dst := record.Source, record.Destination
+		if src != entry.CurrentPath && dst != entry.CurrentPath {
+			continue
+		}
+		if dst == entry.CurrentPath {
+			src, dst = dst, src
+		}
+		checked++
+
+		drift, err := fsops.CompareTrees(src, dst)
+		switch {
+		case os.IsNotExist(err):
+			drifted++
+			fmt.Fprintf(w, "Missing: %s\n", dst)
+			continue
+		case err != nil:
+			drifted++
+			fmt.Fprintf(w, "Failed: %s (%v)\n", dst, err)
+			continue
+		case len(drift) == 0:
+			fmt.Fprintf(w, "OK: %s\n", dst)
+			continue
+		}
+
+		drifted++
+		fmt.Fprintf(w, "Drifted: %s %s\n", dst, detailsStyle.Render(fmt.Sprintf("(%d differences from %s)", len(drift), src)))
+		for _, d := range drift {
+			fmt.Fprintf(w, "  %s\n", describeDrift(d))
+		}
+		if record.Checksum != "" {
+			if sum, err := fsops.Checksum(src); err == nil && sum == record.Checksum {
+				fmt.Fprintf(w, "  %s\n", detailsStyle.Render("the source still matches the checksum taken when it was pasted, so the copy changed"))
+			}
+		}
+	}
+
+	if checked == 0 {
+		fmt.Fprintf(w, "No copies of %s to check (copies are recorded by pastes with --persist or of copied entries)\n", entry.CurrentPath)
+		return nil
+	}
+	if drifted > 0 {
+		return fmt.Errorf("%d of %d copies of %s have drifted", drifted, checked, entry.CurrentPath)
+	}
+	return nil
+}
+
+// describeDrift says how one path in a copy differs from its source
+func describeDrift(d fsops.Drift) string {
+	switch d.Kind {
+	case fsops.DriftMissing:
+		return "missing: " + d.Path
+	case fsops.DriftExtra:
+		return "extra:   " + d.Path
+	case fsops.DriftType:
+		return "type:    " + d.Path
+	case fsops.DriftSize:
+		return fmt.Sprintf("size:    %s %s", d.Path, detailsStyle.Render(fmt.Sprintf("(%s, copy has %s)", FormatSize(d.SrcSize), FormatSize(d.DstSize))))
+	case fsops.DriftTarget:
+		return "target:  " + d.Path
+	}
+	return "content: " + d.Path
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPasteVerify(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "nested"), Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "destination")
+	if err := handlePasteAt(io.Discard, 0, Options{verify: true, dest: destDir, mkdir: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	journal, err := readJournal()
+	if err != nil {
+		t.Fatalf("readJournal failed: %v", err)
+	}
+	if len(journal.Records) != 1 || !strings.HasPrefix(journal.Records[0].Checksum, "sha256:") {
+		t.Fatalf("Expected the journal to record a checksum, got %+v", journal.Records)
+	}
+
+	var out bytes.Buffer
+	if err := handleVerify(&out, Options{}); err != nil {
+		t.Fatalf("handleVerify failed on an untouched paste: %v", err)
+	}
+	if !strings.Contains(out.String(), "OK: ") {
+		t.Errorf("Expected an OK line, got %q", out.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(destDir, "nested", "file3.txt"), []byte("tampered"), 0o644); err != nil {
+		t.Fatalf("Failed to modify paste: %v", err)
+	}
+
+	out.Reset()
+	if err := handleVerify(&out, Options{}); err == nil {
+		t.Error("Expected handleVerify to fail after the paste changed")
+	}
+	if !strings.Contains(out.String(), "Changed: ") {
+		t.Errorf("Expected a Changed line, got %q", out.String())
+	}
+}
+
+func TestVerifyEntry(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	if err := copyFileToClipboard(io.Discard, filepath.Join(tempDir, "nested"), Options{}); err != nil {
+		t.Fatalf("copyFileToClipboard failed: %v", err)
+	}
+	destDir := filepath.Join(tempDir, "destination")
+	if err := handlePasteAt(io.Discard, 0, Options{verify: true, dest: destDir, mkdir: true}); err != nil {
+		t.Fatalf("handlePasteAt failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := handleVerifyEntry(&out, 0, Options{}); err != nil {
+		t.Fatalf("handleVerifyEntry failed on an untouched copy: %v", err)
+	}
+	if !strings.Contains(out.String(), "OK: ") {
+		t.Errorf("Expected an OK line, got %q", out.String())
+	}
+
+	// the same size, so only the checksums tell them apart
+	if err := os.WriteFile(filepath.Join(destDir, "nested", "file3.txt"), []byte("This is a NESTED file"), 0o644); err != nil {
+		t.Fatalf("Failed to modify copy: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "nested", "stray.txt"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to add to copy: %v", err)
+	}
+
+	out.Reset()
+	if err := handleVerifyEntry(&out, 0, Options{}); err == nil {
+		t.Error("Expected handleVerifyEntry to fail after the copy drifted")
+	}
+	for _, expected := range []string{"Drifted: ", "content: file3.txt", "extra:   stray.txt", "so the copy changed"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in %q", expected, out.String())
+		}
+	}
+}
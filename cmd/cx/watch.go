@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+// parseWatchPatterns checks the --pattern globs cx watch matches names with
+func parseWatchPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// watchMatches reports whether name matches any of patterns, or whether
+// there are none to match
+func watchMatches(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// handleWatch adds files that appear in dir to the clipboard until
+// interrupted
+func handleWatch(w io.Writer, dir string, patterns []string, op clipboard.Operation, opts Options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return watchDir(ctx, w, dir, patterns, op, opts)
+}
+
+// watchDir adds files written, or moved, into dir whose names match
+// patterns to the clipboard with op, until ctx is done. Written files are
+// added once they've settled, so a download isn't added half done. Files already in
+// dir are left alone, as are files already on the clipboard. The clipboard
+// is only locked while each file is added, so other commands can use it
+// meanwhile.
+func watchDir(ctx context.Context, w io.Writer, dir string, patterns []string, op clipboard.Operation, opts Options) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+
+	watcher, err := newPathWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.close()
+	watcher.watch([]string{dir})
+
+	events := make(chan pathEvent, 64)
+	go watcher.run(events)
+
+	if !opts.quiet {
+		fmt.Fprintf(w, "Watching %s, press Ctrl-C to stop\n", dir)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			if filepath.Dir(event.to) != dir || !watchMatches(filepath.Base(event.to), patterns) {
+				continue
+			}
+			if err := addWatched(w, event.to, op, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+}
+
+// addWatched adds path to the clipboard. A path that is already on the
+// clipboard, or gone again, is skipped.
+func addWatched(w io.Writer, path string, op clipboard.Operation, opts Options) error {
+	if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	// files are written more than once, but only added the first time
+	board, err := readClipboard()
+	if err != nil {
+		return err
+	}
+	for _, entry := range board.Entries {
+		if entry.CurrentPath == path {
+			return nil
+		}
+	}
+
+	// another cx may have added it since
+	err = addEntries(w, []string{path}, op, opts)
+	var dup *clipboard.DuplicateError
+	if errors.As(err, &dup) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/clipboard"
+)
+
+func TestWatchDir(t *testing.T) {
+	tempDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	downloads := filepath.Join(tempDir, "downloads")
+	if err := os.Mkdir(downloads, 0o755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(downloads, "old.pdf"), []byte("old"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watchDir(ctx, io.Discard, downloads, []string{"*.pdf"}, clipboard.OpCut, Options{quiet: true})
+	}()
+	// give the watch time to start
+	time.Sleep(200 * time.Millisecond)
+
+	for _, name := range []string{"notes.txt", "report.pdf"} {
+		if err := os.WriteFile(filepath.Join(downloads, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	// a download finished by renaming its partial file
+	partial := filepath.Join(downloads, "scan.pdf.part")
+	if err := os.WriteFile(partial, []byte("scan"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Rename(partial, filepath.Join(downloads, "scan.pdf")); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	var board clipboard.Clipboard
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if board, _ = readClipboard(); len(board.Entries) >= 2 {
+			break
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchDir failed: %v", err)
+	}
+
+	var names []string
+	for _, entry := range board.Entries {
+		names = append(names, filepath.Base(entry.CurrentPath))
+	}
+	// written files are added once they settle, so after renamed ones
+	slices.Sort(names)
+	if len(names) != 2 || names[0] != "report.pdf" || names[1] != "scan.pdf" {
+		t.Errorf("Expected scan.pdf and report.pdf to be added, got %v", names)
+	}
+}
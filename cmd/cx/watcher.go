@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// writeSettle is how long a file has to go unwritten before writes to it
+// are reported, since there's no portable way to see its writer close it
+const writeSettle = 250 * time.Millisecond
+
+// pathWatcher reports renames and writes in a set of directories using
+// fsnotify
+type pathWatcher struct {
+	fsw  *fsnotify.Watcher
+	quit chan struct{}
+
+	mu   sync.Mutex
+	dirs map[string]bool
+}
+
+// newPathWatcher starts a watcher with nothing watched yet
+func newPathWatcher() (*pathWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start watching files: %w", err)
+	}
+	return &pathWatcher{fsw: fsw, quit: make(chan struct{}), dirs: make(map[string]bool)}, nil
+}
+
+// watch makes dirs the set of watched directories, adding and removing
+// watches as needed. Directories that can't be watched are left out.
+func (w *pathWatcher) watch(dirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wanted := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		wanted[dir] = true
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.fsw.Add(dir); err != nil {
+			continue
+		}
+		w.dirs[dir] = true
+	}
+
+	for dir := range w.dirs {
+		if !wanted[dir] {
+			w.fsw.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+}
+
+// run sends events on events until the watcher is closed. A rename is seen
+// as the old name going, straight followed by the new one appearing; one
+// whose other half is in an unwatched directory can't be followed, and a
+// file moved in from one is sent as written. Writes are sent once the file
+// has settled, see writeSettle.
+func (w *pathWatcher) run(events chan<- pathEvent) error {
+	send := func(event pathEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-w.quit:
+			return false
+		}
+	}
+
+	// renamed is the old name of a rename whose new one is still to come
+	var renamed string
+	// written holds when each file written to will have settled
+	written := make(map[string]time.Time)
+	settle := time.NewTimer(writeSettle)
+	settle.Stop()
+
+	for {
+		select {
+		case <-w.quit:
+			return nil
+		case _, ok := <-w.fsw.Errors:
+			// overflowed events are lost either way
+			if !ok {
+				return nil
+			}
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			from := renamed
+			renamed = ""
+			switch {
+			case event.Has(fsnotify.Rename), event.Has(fsnotify.Remove):
+				delete(written, event.Name)
+				if event.Has(fsnotify.Rename) {
+					renamed = event.Name
+				}
+			case event.Has(fsnotify.Create) && from != "":
+				if !send(pathEvent{from: from, to: event.Name}) {
+					return nil
+				}
+			case event.Has(fsnotify.Create), event.Has(fsnotify.Write):
+				if len(written) == 0 {
+					settle.Reset(writeSettle)
+				}
+				written[event.Name] = time.Now().Add(writeSettle)
+			}
+		case now := <-settle.C:
+			next := writeSettle
+			for path, at := range written {
+				if wait := at.Sub(now); wait > 0 {
+					next = min(next, wait)
+					continue
+				}
+				delete(written, path)
+				if !send(pathEvent{to: path}) {
+					return nil
+				}
+			}
+			if len(written) > 0 {
+				settle.Reset(next)
+			}
+		}
+	}
+}
+
+// close stops watching, and run with it
+func (w *pathWatcher) close() error {
+	close(w.quit)
+	return w.fsw.Close()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPathWatcher(t *testing.T) {
+	tempDir := t.TempDir()
+	oldPath := filepath.Join(tempDir, "draft.txt")
+	if err := os.WriteFile(oldPath, []byte("draft"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	watcher, err := newPathWatcher()
+	if err != nil {
+		t.Fatalf("newPathWatcher failed: %v", err)
+	}
+	defer watcher.close()
+	watcher.watch([]string{tempDir})
+
+	events := make(chan pathEvent, 10)
+	go watcher.run(events)
+
+	newPath := filepath.Join(tempDir, "final.txt")
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.from != oldPath || event.to != newPath {
+			t.Errorf("Expected rename %s -> %s, got %+v", oldPath, newPath, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a rename event, got none")
+	}
+
+	// a new file is sent once it's done being written
+	written := filepath.Join(tempDir, "notes.txt")
+	if err := os.WriteFile(written, []byte("notes"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	select {
+	case event := <-events:
+		if event.from != "" || event.to != written {
+			t.Errorf("Expected a write to %s, got %+v", written, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a write event, got none")
+	}
+}
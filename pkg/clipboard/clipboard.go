@@ -0,0 +1,180 @@
+// Package clipboard implements the cx clipboard: a stack of files and
+// directories that have been cut or copied, kept in a Store, and an Engine
+// that adds entries to it and pastes them.
+//
+// A minimal embedding cuts a file and later pastes it into another
+// directory:
+//
+//	engine := clipboard.NewEngine(clipboard.NewFileStore("/tmp/clipboard.json"))
+//	if _, err := engine.Add([]string{"report.pdf"}, clipboard.OpCut, clipboard.AddOptions{}); err != nil {
+//		return err
+//	}
+//	board, err := engine.Store.Read()
+//	if err != nil {
+//		return err
+//	}
+//	pasted, err := engine.Paste(board.Entries[0], "/home/me/docs", clipboard.PasteOptions{})
+package clipboard
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrEmptyClipboard is returned when there's nothing on the clipboard to
+	// act on
+	ErrEmptyClipboard = errors.New("clipboard is empty")
+	// ErrSourceMissing is returned when an entry's path no longer exists
+	ErrSourceMissing = errors.New("source path no longer exists")
+)
+
+// Operation describes what pasting an entry does with its source
+type Operation string
+
+const (
+	// OpCut moves the source to the paste destination
+	OpCut Operation = "cut"
+	// OpCopy duplicates the source, leaving it in place
+	OpCopy Operation = "copy"
+)
+
+// Kind is the type of file an entry was when it was added
+type Kind string
+
+const (
+	KindFile    Kind = "file"
+	KindDir     Kind = "directory"
+	KindSymlink Kind = "symlink"
+	// KindSpecial is a device, FIFO or socket
+	KindSpecial Kind = "special"
+)
+
+// KindOf returns the Kind of a file with the given mode
+func KindOf(mode os.FileMode) Kind {
+	switch {
+	case mode.IsRegular():
+		return KindFile
+	case mode.IsDir():
+		return KindDir
+	case mode&os.ModeSymlink != 0:
+		return KindSymlink
+	default:
+		return KindSpecial
+	}
+}
+
+// Entry represents a clipboard entry containing file/directory information
+type Entry struct {
+	OriginalPath string    `json:"original_path"`
+	CurrentPath  string    `json:"current_path"`
+	CutAt        time.Time `json:"timestamp"`
+	Op           Operation `json:"operation,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	// Kind, Mode and Size describe the file as it was when added, and with
+	// Device, Inode, Born, Hash and Fingerprint identify it; see Identify.
+	// Born is its birth time in nanoseconds since the Unix epoch, where the
+	// filesystem records one.
+	Kind        Kind        `json:"kind,omitempty"`
+	Mode        os.FileMode `json:"mode,omitempty"`
+	Size        int64       `json:"size,omitempty"`
+	Device      uint64      `json:"device,omitempty"`
+	Inode       uint64      `json:"inode,omitempty"`
+	Born        int64       `json:"born,omitempty"`
+	Hash        string      `json:"hash,omitempty"`
+	Fingerprint string      `json:"fingerprint,omitempty"`
+}
+
+// entryFields is Entry without its JSON methods
+type entryFields Entry
+
+// entryJSON is how an Entry is stored. JSON strings can only hold valid
+// UTF-8, so a path that isn't is kept in full, base64-encoded, next to the
+// lossy string that older versions still read.
+type entryJSON struct {
+	entryFields
+	OriginalPathBytes []byte `json:"original_path_bytes,omitempty"`
+	CurrentPathBytes  []byte `json:"current_path_bytes,omitempty"`
+}
+
+// MarshalJSON encodes the entry, keeping paths that aren't valid UTF-8
+func (e Entry) MarshalJSON() ([]byte, error) {
+	stored := entryJSON{entryFields: entryFields(e)}
+	if !utf8.ValidString(e.OriginalPath) {
+		stored.OriginalPathBytes = []byte(e.OriginalPath)
+	}
+	if !utf8.ValidString(e.CurrentPath) {
+		stored.CurrentPathBytes = []byte(e.CurrentPath)
+	}
+	return json.Marshal(stored)
+}
+
+// UnmarshalJSON decodes an entry written by MarshalJSON
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var stored entryJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	*e = Entry(stored.entryFields)
+	if stored.OriginalPathBytes != nil {
+		e.OriginalPath = string(stored.OriginalPathBytes)
+	}
+	if stored.CurrentPathBytes != nil {
+		e.CurrentPath = string(stored.CurrentPathBytes)
+	}
+	return nil
+}
+
+// IsCopy reports whether the entry was added with copy semantics. Entries
+// without an Op are treated as cuts.
+func (e Entry) IsCopy() bool {
+	return e.Op == OpCopy
+}
+
+// HasTag reports whether the entry was labelled with tag
+func (e Entry) HasTag(tag string) bool {
+	return slices.Contains(e.Tags, tag)
+}
+
+// Expired reports whether the entry is older than maxAge as of now. A zero
+// maxAge never expires entries.
+func (e Entry) Expired(maxAge time.Duration, now time.Time) bool {
+	return maxAge > 0 && now.Sub(e.CutAt) > maxAge
+}
+
+// Is reports whether e and other are the same clipboard entry: the same path
+// added at the same moment, wherever it has been pasted to since
+func (e Entry) Is(other Entry) bool {
+	return e.OriginalPath == other.OriginalPath && e.CutAt.Equal(other.CutAt)
+}
+
+// Clipboard is the collection of clipboard entries, most recent first
+type Clipboard struct {
+	// Version is the schema version, see CurrentVersion
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Drop removes the given entries, wherever they now are on the clipboard.
+// Entries that are no longer on it are ignored.
+func (c *Clipboard) Drop(entries ...Entry) {
+	c.Entries = slices.DeleteFunc(c.Entries, func(entry Entry) bool {
+		return slices.ContainsFunc(entries, entry.Is)
+	})
+}
+
+// Replace swaps each of the given entries in for the version of it on the
+// clipboard, e.g. after pasting it somewhere new. Entries that are no longer
+// on the clipboard are ignored.
+func (c *Clipboard) Replace(entries ...Entry) {
+	for _, entry := range entries {
+		if i := slices.IndexFunc(c.Entries, entry.Is); i >= 0 {
+			c.Entries[i] = entry
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// ConflictStrategy decides what happens when a paste destination already exists
+type ConflictStrategy string
+
+const (
+	// ConflictFail refuses to paste over an existing path
+	ConflictFail ConflictStrategy = ""
+	// ConflictOverwrite replaces the existing path
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// ConflictSkip leaves the existing path alone and doesn't paste the entry
+	ConflictSkip ConflictStrategy = "skip"
+	// ConflictRename pastes under the first free name, e.g. "file (1).txt"
+	ConflictRename ConflictStrategy = "rename"
+	// ConflictPrompt asks Engine.Prompt which of the above to do
+	ConflictPrompt ConflictStrategy = "prompt"
+)
+
+// ConflictStrategies lists the names accepted by ParseConflictStrategy
+var ConflictStrategies = []string{
+	string(ConflictOverwrite),
+	string(ConflictSkip),
+	string(ConflictRename),
+	string(ConflictPrompt),
+}
+
+// ErrConflict is returned when a paste's destination already exists and the
+// conflict strategy doesn't say what to do about it
+var ErrConflict = errors.New("destination already exists")
+
+// ErrSkipped is returned by Engine.Paste when the destination exists and the
+// conflict strategy chose to skip the entry
+var ErrSkipped = errors.New("destination already exists, skipped")
+
+// CheckNotInside returns an error if destDir is the directory src or lies
+// inside it, where pasting src would copy it into itself over and over.
+// Symlinks are resolved first, so a link leading into src is caught too.
+func CheckNotInside(src, destDir string) error {
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return nil
+	}
+	realDest, err := filepath.EvalSymlinks(destDir)
+	if err != nil {
+		return nil
+	}
+
+	rel, err := filepath.Rel(realSrc, realDest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	if rel == "." {
+		return fmt.Errorf("cannot paste %s into itself", src)
+	}
+	return fmt.Errorf("cannot paste %s into itself: %s is inside it", src, destDir)
+}
+
+// ErrAlreadyThere is returned by Engine.Paste when moving an entry to where it
+// already is, which leaves nothing to do
+var ErrAlreadyThere = errors.New("already at the destination")
+
+// SamePath reports whether a and b are the same path, however they're
+// written: the same name in the same directory, through symlinks or not. A
+// hard link elsewhere or under another name isn't the same path.
+func SamePath(a, b string) bool {
+	if filepath.Base(a) != filepath.Base(b) {
+		return false
+	}
+	aInfo, aErr := os.Lstat(a)
+	bInfo, bErr := os.Lstat(b)
+	if aErr != nil || bErr != nil || !os.SameFile(aInfo, bInfo) {
+		return false
+	}
+	aDir, aErr := os.Stat(filepath.Dir(a))
+	bDir, bErr := os.Stat(filepath.Dir(b))
+	return aErr == nil && bErr == nil && os.SameFile(aDir, bDir)
+}
+
+// ParseConflictStrategy validates a strategy name
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	switch strategy := ConflictStrategy(s); strategy {
+	case ConflictFail, ConflictOverwrite, ConflictSkip, ConflictRename, ConflictPrompt:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("invalid conflict strategy %q (expected one of: %s)", s, strings.Join(ConflictStrategies, ", "))
+}
+
+// ResolveConflict returns the path src should be pasted to when destPath may
+// already exist. It returns ErrSkipped if the entry shouldn't be pasted.
+// Overwriting moves what's at destPath aside rather than deleting it, so a
+// paste that fails can put it back: the Overwrite returned, nil unless
+// something is overwritten, has to be finished once the paste is done.
+// Paste calls it itself; it is exported for callers that fetch the source
+// some other way.
+func (e *Engine) ResolveConflict(src, destPath string, strategy ConflictStrategy) (string, *Overwrite, error) {
+	destInfo, err := os.Lstat(destPath)
+	if err != nil {
+		return destPath, nil, nil
+	}
+
+	prompted := false
+	if strategy == ConflictPrompt {
+		if e.Prompt == nil {
+			return "", nil, fmt.Errorf("%w: %s (no prompt available)", ErrConflict, destPath)
+		}
+		var err error
+		strategy, err = e.Prompt(destPath)
+		if err != nil {
+			return "", nil, err
+		}
+		prompted = true
+	}
+
+	switch strategy {
+	case ConflictOverwrite:
+		if srcInfo, err := os.Lstat(src); err == nil && os.SameFile(srcInfo, destInfo) {
+			return "", nil, fmt.Errorf("cannot overwrite %s with itself", destPath)
+		}
+		if !prompted {
+			if err := e.confirm(fmt.Sprintf("Overwrite %s?", destPath)); err != nil {
+				return "", nil, err
+			}
+		}
+		overwrite, err := setAside(destPath)
+		if err != nil {
+			return "", nil, err
+		}
+		if e.Backup != "" {
+			overwrite.Backup = BackupPath(destPath, e.Backup, time.Now())
+		}
+		return destPath, overwrite, nil
+	case ConflictSkip:
+		return "", nil, ErrSkipped
+	case ConflictRename:
+		name, err := NextFreeName(destPath)
+		return name, nil, err
+	default:
+		return "", nil, fmt.Errorf("%w: %s (use --on-conflict to resolve)", ErrConflict, destPath)
+	}
+}
+
+// Overwrite is an existing destination that ResolveConflict moved aside so
+// a paste could take its place
+type Overwrite struct {
+	// Path is the destination, and Aside where what was there is kept while
+	// the paste runs
+	Path, Aside string
+	// Backup, if set, is where what was there is kept once the paste is
+	// done; see Engine.Backup
+	Backup string
+}
+
+// setAside renames path to a hidden name next to it
+func setAside(path string) (*Overwrite, error) {
+	dir, base := filepath.Split(path)
+	aside, err := NextFreeName(filepath.Join(dir, fsops.FitName("."+base, ".cx-overwritten")))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(path, aside); err != nil {
+		return nil, fmt.Errorf("failed to move %s aside to overwrite it: %w", path, err)
+	}
+	return &Overwrite{Path: path, Aside: aside}, nil
+}
+
+// Finish ends the overwrite once the paste into Path is done, err being the
+// paste's error. After a paste that worked, what was there is deleted, or
+// kept at Backup, replacing an older backup. After one that failed, what
+// the paste left at Path is removed and what was there is put back. Finish
+// returns err along with any error of its own, and on a nil Overwrite just
+// returns err.
+func (o *Overwrite) Finish(err error) error {
+	if o == nil {
+		return err
+	}
+
+	if err != nil {
+		if rmErr := os.RemoveAll(o.Path); rmErr != nil {
+			return fmt.Errorf("%w; what was at %s is kept at %s", err, o.Path, o.Aside)
+		}
+		if mvErr := os.Rename(o.Aside, o.Path); mvErr != nil {
+			return fmt.Errorf("%w; what was at %s is kept at %s", err, o.Path, o.Aside)
+		}
+		return err
+	}
+
+	if o.Backup == "" {
+		if err := os.RemoveAll(o.Aside); err != nil {
+			return fmt.Errorf("pasted, but failed to remove the overwritten %s: %w", o.Aside, err)
+		}
+		return nil
+	}
+	if err := os.RemoveAll(o.Backup); err != nil {
+		return fmt.Errorf("pasted, but failed to replace the backup %s: %w", o.Backup, err)
+	}
+	if err := os.Rename(o.Aside, o.Backup); err != nil {
+		return fmt.Errorf("pasted, but failed to back up %s: %w", o.Path, err)
+	}
+	return nil
+}
+
+// BackupTimestamp as a backup suffix names backups after when they were made,
+// e.g. "report.pdf.20060102-150405~"
+const BackupTimestamp = "timestamp"
+
+// ValidateBackupSuffix checks that suffix can be added to a file name
+func ValidateBackupSuffix(suffix string) error {
+	if suffix == "" || strings.ContainsRune(suffix, filepath.Separator) {
+		return fmt.Errorf("invalid backup suffix %q, expected e.g. ~ or .bak", suffix)
+	}
+	return nil
+}
+
+// BackupPath returns the path an overwritten destPath is kept at: destPath
+// with suffix added, or with BackupTimestamp, the time now
+func BackupPath(destPath, suffix string, now time.Time) string {
+	if suffix == BackupTimestamp {
+		suffix = now.Format(".20060102-150405") + "~"
+	}
+	dir, base := filepath.Split(destPath)
+	return filepath.Join(dir, fsops.FitName(base, suffix))
+}
+
+// NextFreeName finds the first "name (n).ext" next to path that doesn't exist
+func NextFreeName(path string) (string, error) {
+	dir := filepath.Dir(path)
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	for n := 1; n < 10000; n++ {
+		candidate := filepath.Join(dir, fsops.FitName(stem, fmt.Sprintf(" (%d)%s", n, ext)))
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free name found for %s", path)
+}
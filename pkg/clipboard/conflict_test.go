@@ -0,0 +1,272 @@
+package clipboard
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNextFreeName(t *testing.T) {
+	tempDir := setupTree(t)
+
+	path := filepath.Join(tempDir, "file1.txt")
+
+	name, err := NextFreeName(path)
+	if err != nil {
+		t.Fatalf("NextFreeName failed: %v", err)
+	}
+	if want := filepath.Join(tempDir, "file1 (1).txt"); name != want {
+		t.Errorf("Expected %s, got %s", want, name)
+	}
+
+	// Take the first suffix so the next one is chosen
+	if err := os.WriteFile(name, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	name, err = NextFreeName(path)
+	if err != nil {
+		t.Fatalf("NextFreeName failed: %v", err)
+	}
+	if want := filepath.Join(tempDir, "file1 (2).txt"); name != want {
+		t.Errorf("Expected %s, got %s", want, name)
+	}
+
+	// Directories have no extension to preserve
+	name, err = NextFreeName(filepath.Join(tempDir, "config"))
+	if err != nil {
+		t.Fatalf("NextFreeName failed: %v", err)
+	}
+	if want := filepath.Join(tempDir, "config (1)"); name != want {
+		t.Errorf("Expected %s, got %s", want, name)
+	}
+}
+
+func TestParseConflictStrategy(t *testing.T) {
+	for _, s := range append(ConflictStrategies, "") {
+		if _, err := ParseConflictStrategy(s); err != nil {
+			t.Errorf("ParseConflictStrategy(%q) failed: %v", s, err)
+		}
+	}
+
+	if _, err := ParseConflictStrategy("clobber"); err == nil {
+		t.Error("Expected error for unknown strategy, got nil")
+	}
+}
+
+func TestOverwriteSelf(t *testing.T) {
+	tempDir := setupTree(t)
+
+	path := filepath.Join(tempDir, "file1.txt")
+
+	_, _, err := NewEngine(nil).ResolveConflict(path, path, ConflictOverwrite)
+	if err == nil || errors.Is(err, ErrSkipped) {
+		t.Fatalf("Expected error when overwriting a path with itself, got: %v", err)
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		t.Error("Source was removed when overwriting it with itself")
+	}
+}
+
+func TestConfirmOverwrite(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "file1.txt")
+	dest := filepath.Join(tempDir, "file2.txt")
+
+	var asked []string
+	engine := NewEngine(nil)
+	engine.Confirm = func(question string) (bool, error) {
+		asked = append(asked, question)
+		return false, nil
+	}
+
+	if _, _, err := engine.ResolveConflict(src, dest, ConflictOverwrite); !errors.Is(err, ErrSkipped) {
+		t.Fatalf("Expected a declined overwrite to skip, got: %v", err)
+	}
+	if len(asked) != 1 || asked[0] != "Overwrite "+dest+"?" {
+		t.Errorf("Expected to be asked about overwriting %s, got %v", dest, asked)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("Destination was removed despite declining: %v", err)
+	}
+
+	// Choosing overwrite at the conflict prompt is confirmation enough
+	engine.Prompt = func(string) (ConflictStrategy, error) { return ConflictOverwrite, nil }
+	asked = nil
+	got, overwrite, err := engine.ResolveConflict(src, dest, ConflictPrompt)
+	if err != nil || got != dest || overwrite == nil {
+		t.Fatalf("Expected to overwrite %s, got %q (%v)", dest, got, err)
+	}
+	if err := overwrite.Finish(nil); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if len(asked) != 0 {
+		t.Errorf("Expected no confirmation after the prompt, got %v", asked)
+	}
+}
+
+func TestOverwriteBackup(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "file1.txt")
+	dest := filepath.Join(tempDir, "file2.txt")
+
+	engine := NewEngine(nil)
+	engine.Backup = "~"
+	got, overwrite, err := engine.ResolveConflict(src, dest, ConflictOverwrite)
+	if err != nil || got != dest || overwrite == nil || overwrite.Backup != dest+"~" {
+		t.Fatalf("Expected to overwrite %s, got %q, %+v (%v)", dest, got, overwrite, err)
+	}
+	if _, err := os.Lstat(dest); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be moved out of the way, got: %v", dest, err)
+	}
+	if err := os.WriteFile(dest, []byte("new"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", dest, err)
+	}
+	if err := overwrite.Finish(nil); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if data, err := os.ReadFile(dest + "~"); err != nil || string(data) != "This is file 2" {
+		t.Errorf("Expected the backup to hold the old file, got %q (%v)", data, err)
+	}
+
+	now := time.Date(2024, 3, 1, 9, 30, 5, 0, time.UTC)
+	if got := BackupPath(dest, BackupTimestamp, now); got != dest+".20240301-093005~" {
+		t.Errorf("Unexpected timestamped backup path %s", got)
+	}
+
+	for _, bad := range []string{"", "/x"} {
+		if err := ValidateBackupSuffix(bad); err == nil {
+			t.Errorf("Expected error for backup suffix %q", bad)
+		}
+	}
+}
+
+func TestOverwriteFinish(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "file1.txt")
+	dest := filepath.Join(tempDir, "config")
+	engine := NewEngine(nil)
+	engine.Backup = "~"
+
+	entries := func() []string {
+		t.Helper()
+		items, err := os.ReadDir(tempDir)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", tempDir, err)
+		}
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name())
+		}
+		return names
+	}
+	before := entries()
+
+	// a paste that fails part way puts back what it was to overwrite
+	_, overwrite, err := engine.ResolveConflict(src, dest, ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+	if err := os.WriteFile(dest, []byte("partial"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", dest, err)
+	}
+	failed := errors.New("no space left on device")
+	if err := overwrite.Finish(failed); !errors.Is(err, failed) {
+		t.Fatalf("Expected Finish to return the paste's error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "settings.json")); err != nil {
+		t.Errorf("Expected the overwritten directory back: %v", err)
+	}
+	if after := entries(); !slices.Equal(before, after) {
+		t.Errorf("Expected %v left behind, got %v", before, after)
+	}
+
+	// without a backup, one that works deletes it
+	engine.Backup = ""
+	_, overwrite, err = engine.ResolveConflict(src, dest, ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+	if err := os.WriteFile(dest, []byte("pasted"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", dest, err)
+	}
+	if err := overwrite.Finish(nil); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "pasted" {
+		t.Errorf("Expected the paste at %s, got %q (%v)", dest, data, err)
+	}
+	if after := entries(); !slices.Equal(before, after) {
+		t.Errorf("Expected %v left behind, got %v", before, after)
+	}
+
+	// nothing to overwrite, nothing to finish
+	var none *Overwrite
+	if err := none.Finish(failed); err != failed {
+		t.Errorf("Expected a nil Overwrite to return the error as is, got %v", err)
+	}
+}
+
+func TestCheckNotInside(t *testing.T) {
+	tempDir := setupTree(t)
+
+	config := filepath.Join(tempDir, "config")
+	sub := filepath.Join(config, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink(sub, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	// shares a prefix with config without being inside it
+	sibling := filepath.Join(tempDir, "config2")
+	if err := os.Mkdir(sibling, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	for _, dest := range []string{config, sub, link} {
+		if err := CheckNotInside(config, dest); err == nil {
+			t.Errorf("Expected pasting %s into %s to be refused", config, dest)
+		}
+	}
+	for _, dest := range []string{tempDir, sibling} {
+		if err := CheckNotInside(config, dest); err != nil {
+			t.Errorf("Expected pasting %s into %s to be allowed, got %v", config, dest, err)
+		}
+	}
+}
+
+func TestSamePath(t *testing.T) {
+	tempDir := setupTree(t)
+
+	file := filepath.Join(tempDir, "file1.txt")
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink(tempDir, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	hardlink := filepath.Join(tempDir, "config", "file1.txt")
+	if err := os.Link(file, hardlink); err != nil {
+		t.Fatalf("Failed to create hard link: %v", err)
+	}
+
+	if !SamePath(file, filepath.Join(link, "file1.txt")) {
+		t.Error("Expected a path through a symlinked directory to be the same path")
+	}
+	if !SamePath(file, filepath.Join(tempDir, "config", "..", "file1.txt")) {
+		t.Error("Expected an unclean path to be the same path")
+	}
+	if SamePath(file, hardlink) {
+		t.Error("Expected a hard link in another directory not to be the same path")
+	}
+	if SamePath(file, filepath.Join(tempDir, "file2.txt")) {
+		t.Error("Expected another file not to be the same path")
+	}
+}
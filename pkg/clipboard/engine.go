@@ -0,0 +1,441 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+	"golang.org/x/sys/unix"
+)
+
+// Engine adds entries to a clipboard Store and pastes them
+type Engine struct {
+	Store Store
+	// Prompt is asked how to resolve a conflict under ConflictPrompt. It
+	// returns one of the other strategies.
+	Prompt func(destPath string) (ConflictStrategy, error)
+	// Confirm, if set, is asked before destructive steps: overwriting an
+	// existing destination other than by Prompt's choice, or moving an entry
+	// across filesystems. Declining skips the entry with ErrSkipped.
+	Confirm func(question string) (bool, error)
+	// Backup, if set, is the suffix an existing destination is renamed with
+	// when it's overwritten, instead of deleting it; see BackupPath
+	Backup string
+	// Limits caps how much Add lets the clipboard hold
+	Limits Limits
+	// Evicted, if set, is called with each entry Add drops to keep within
+	// Limits
+	Evicted func(Entry)
+}
+
+// NewEngine returns an Engine working on store
+func NewEngine(store Store) *Engine {
+	return &Engine{Store: store}
+}
+
+// AddOptions controls how Engine.Add treats paths already in the clipboard
+type AddOptions struct {
+	// Replace drops existing entries for the paths being added instead of
+	// failing with a DuplicateError
+	Replace bool
+	// Tags labels the added entries
+	Tags []string
+}
+
+// DuplicateError reports that a path being added is already in the
+// clipboard
+type DuplicateError struct {
+	Path  string
+	Index int
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s is already in the clipboard at index %d", e.Path, e.Index)
+}
+
+// Add records files or directories in the clipboard with the given
+// operation, as if each had been added in turn, so the last path ends up on
+// top. Nothing is added unless every path is readable, without
+// opts.Replace not already in the clipboard, and the clipboard stays within
+// e.Limits, evicting its oldest entries if they allow. It returns the
+// absolute paths added.
+func (e *Engine) Add(paths []string, op Operation, opts AddOptions) ([]string, error) {
+	absPaths := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fileInfo, err := os.Lstat(absPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !(fileInfo.Mode()&os.ModeSymlink != 0) {
+			err = unix.Access(absPath, unix.R_OK)
+			if err != nil {
+				return nil, fmt.Errorf("no read permission for %s: %w", absPath, err)
+			}
+		}
+
+		// a path given twice is only added once
+		if !seen[absPath] {
+			seen[absPath] = true
+			absPaths = append(absPaths, absPath)
+		}
+	}
+
+	// the last path goes on top, since the clipboard is a stack
+	now := time.Now()
+	added := make([]Entry, 0, len(absPaths))
+	for i := len(absPaths) - 1; i >= 0; i-- {
+		entry := Entry{
+			OriginalPath: absPaths[i],
+			CurrentPath:  absPaths[i],
+			CutAt:        now,
+			Op:           op,
+			Tags:         opts.Tags,
+		}
+		entry.Identify()
+		added = append(added, entry)
+	}
+
+	var evicted []Entry
+	err := e.Update(func(clipboard *Clipboard) error {
+		kept := make([]Entry, 0, len(clipboard.Entries))
+		for i, entry := range clipboard.Entries {
+			if !seen[entry.CurrentPath] {
+				kept = append(kept, entry)
+				continue
+			}
+			if !opts.Replace {
+				return &DuplicateError{Path: entry.CurrentPath, Index: i}
+			}
+		}
+
+		kept, dropped, err := e.Limits.Apply(kept, added)
+		if err != nil {
+			return err
+		}
+		evicted = dropped
+		clipboard.Entries = append(added, kept...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Evicted != nil {
+		for _, entry := range evicted {
+			e.Evicted(entry)
+		}
+	}
+	return absPaths, nil
+}
+
+// ErrUnchanged can be returned by the function passed to Engine.Update to
+// leave the clipboard as it is without failing
+var ErrUnchanged = errors.New("clipboard unchanged")
+
+// Update reads the clipboard, passes it to fn to change and writes it back,
+// holding the store's lock only for that long so that a concurrent cx
+// doesn't lose its change or ours. If fn fails, nothing is written.
+func (e *Engine) Update(fn func(*Clipboard) error) error {
+	unlock, err := e.Store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	clipboard, err := e.Store.Read()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&clipboard); err != nil {
+		if errors.Is(err, ErrUnchanged) {
+			return nil
+		}
+		return err
+	}
+	return e.Store.Write(clipboard)
+}
+
+// RemoveEntries removes the given entries from the clipboard, wherever they
+// now are on it, so they can be read before a long operation and removed
+// after it without tracking what changed in between
+func (e *Engine) RemoveEntries(entries ...Entry) error {
+	return e.Update(func(clipboard *Clipboard) error {
+		clipboard.Drop(entries...)
+		return nil
+	})
+}
+
+// ReplaceEntries swaps each of the given entries in for the version of it on
+// the clipboard; see Clipboard.Replace
+func (e *Engine) ReplaceEntries(entries ...Entry) error {
+	return e.Update(func(clipboard *Clipboard) error {
+		clipboard.Replace(entries...)
+		return nil
+	})
+}
+
+// PasteOptions controls how Engine.Paste pastes an entry
+type PasteOptions struct {
+	// Persist copies the entry instead of moving it
+	Persist bool
+	// OnConflict decides what happens when the destination already exists
+	OnConflict ConflictStrategy
+	// As pastes under a different name; see PasteName
+	As string
+	// Verify checksums the source before pasting and the result afterwards,
+	// failing on a mismatch
+	Verify bool
+	// Resume, if set, is the destination of an interrupted copy to carry
+	// on with, which FS.Manifest has the progress of, instead of resolving
+	// a conflict with it
+	Resume string
+	// FS controls how files are copied
+	FS fsops.Options
+}
+
+// Pasted describes an entry Paste pasted
+type Pasted struct {
+	// Path is where the entry was pasted
+	Path string
+	// Checksum is the entry's checksum, taken with PasteOptions.Verify
+	Checksum string
+	// Into is set when an update copied the entry into what was already at
+	// Path, which may hold files the paste didn't put there
+	Into bool
+	// Overwrote is set when the paste replaced what was at Path, and Backup
+	// to where that is kept, if it is; see Engine.Backup
+	Overwrote bool
+	Backup    string
+}
+
+// Paste copies or moves entry into destDir. It doesn't update the
+// clipboard; copied entries are only duplicated if opts.Persist is set.
+// Moving an entry to where it already is returns ErrAlreadyThere; copying it
+// there pastes it under the next free name. A destination being overwritten
+// is only deleted once the paste has worked, and put back if it fails, so
+// an overwriting paste isn't recorded in opts.FS.Manifest to be resumed.
+// Once the destination is decided, a paste that fails still returns it, so
+// callers can tell what it may have left behind.
+func (e *Engine) Paste(entry Entry, destDir string, opts PasteOptions) (Pasted, error) {
+	srcInfo, err := os.Lstat(entry.CurrentPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Pasted{}, fmt.Errorf("%w: %s", ErrSourceMissing, entry.CurrentPath)
+	}
+	if err != nil {
+		return Pasted{}, err
+	}
+
+	name, err := PasteName(filepath.Base(entry.CurrentPath), opts.As)
+	if err != nil {
+		return Pasted{}, err
+	}
+
+	if srcInfo.IsDir() {
+		if err := CheckNotInside(entry.CurrentPath, destDir); err != nil {
+			return Pasted{}, err
+		}
+	}
+
+	if !opts.Persist && !fsops.SameDevice(entry.CurrentPath, destDir) {
+		question := fmt.Sprintf("%s is on another filesystem, so moving it copies it and deletes the original. Continue?", entry.CurrentPath)
+		if err := e.confirm(question); err != nil {
+			return Pasted{}, err
+		}
+	}
+
+	var pasted Pasted
+	var overwrite *Overwrite
+	destPath := opts.Resume
+	if destPath == "" {
+		destPath = filepath.Join(destDir, name)
+		// pasting an entry where it already is: a move has nothing to do and
+		// a copy goes beside the original
+		if SamePath(entry.CurrentPath, destPath) {
+			if !opts.Persist {
+				return Pasted{}, ErrAlreadyThere
+			}
+			destPath, err = NextFreeName(destPath)
+		} else if opts.Persist && opts.FS.Update && sameKind(srcInfo, destPath) {
+			// an update copies into what's already there
+			pasted.Into = true
+		} else {
+			destPath, overwrite, err = e.ResolveConflict(entry.CurrentPath, destPath, opts.OnConflict)
+		}
+		if err != nil {
+			return Pasted{}, err
+		}
+	}
+	pasted.Path = destPath
+	if overwrite != nil {
+		pasted.Overwrote, pasted.Backup = true, overwrite.Backup
+		opts.FS.Manifest = nil
+	}
+
+	if opts.Verify {
+		if pasted.Checksum, err = fsops.Checksum(entry.CurrentPath); err != nil {
+			return pasted, overwrite.Finish(err)
+		}
+	}
+
+	if opts.Persist {
+		err = fsops.Copy(entry.CurrentPath, destPath, srcInfo, opts.FS)
+	} else {
+		err = fsops.Move(entry.CurrentPath, destPath, opts.FS)
+	}
+
+	if err != nil {
+		return pasted, overwrite.Finish(err)
+	}
+
+	if opts.Verify {
+		if err := fsops.VerifyChecksum(entry.CurrentPath, destPath, pasted.Checksum); err != nil {
+			if opts.Persist && !pasted.Into {
+				// the source is intact, so don't leave a bad copy behind
+				os.RemoveAll(destPath)
+				return pasted, overwrite.Finish(err)
+			}
+			if overwrite != nil {
+				// the moved entry is all there is of it now, so both stay
+				return pasted, fmt.Errorf("%w; what was at %s is kept at %s", err, destPath, overwrite.Aside)
+			}
+			return pasted, err
+		}
+	}
+
+	return pasted, overwrite.Finish(nil)
+}
+
+// sameKind reports whether path exists and, like the source described by
+// srcInfo, is a directory, or like it isn't one
+func sameKind(srcInfo os.FileInfo, path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir() == srcInfo.IsDir()
+}
+
+// confirm asks Confirm, if set, returning ErrSkipped if the answer is no
+func (e *Engine) confirm(question string) error {
+	if e.Confirm == nil {
+		return nil
+	}
+	ok, err := e.Confirm(question)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSkipped
+	}
+	return nil
+}
+
+// PasteName returns the name to paste base under. An empty newName keeps
+// base, and a newName ending in ".*" takes on base's extension, so
+// "report-final.*" pastes "report.pdf" as "report-final.pdf".
+func PasteName(base, newName string) (string, error) {
+	if newName == "" {
+		return base, nil
+	}
+
+	if stem, ok := strings.CutSuffix(newName, ".*"); ok {
+		newName = stem + filepath.Ext(base)
+	}
+
+	if newName == "." || newName == ".." || strings.ContainsRune(newName, filepath.Separator) {
+		return "", fmt.Errorf("invalid name %q, expected a file name without a directory", newName)
+	}
+
+	return newName, nil
+}
+
+// Remove removes the entries at the given indices from the clipboard
+func (e *Engine) Remove(indices ...int) error {
+	return e.Update(func(clipboard *Clipboard) error {
+		drop := make(map[int]bool, len(indices))
+		for _, index := range indices {
+			if index < 0 || index >= len(clipboard.Entries) {
+				return fmt.Errorf("invalid clipboard index: %d", index)
+			}
+			drop[index] = true
+		}
+
+		kept := make([]Entry, 0, len(clipboard.Entries))
+		for i, entry := range clipboard.Entries {
+			if !drop[i] {
+				kept = append(kept, entry)
+			}
+		}
+		clipboard.Entries = kept
+		return nil
+	})
+}
+
+// SetCurrentPath records that the entry at index now lives at path
+func (e *Engine) SetCurrentPath(index int, path string) error {
+	return e.Update(func(clipboard *Clipboard) error {
+		if index < 0 || index >= len(clipboard.Entries) {
+			return fmt.Errorf("invalid clipboard index: %d", index)
+		}
+
+		clipboard.Entries[index].CurrentPath = path
+		clipboard.Entries[index].Identify()
+		return nil
+	})
+}
+
+// SetNote attaches a free-text note to the entry at index, or removes it if
+// note is empty
+func (e *Engine) SetNote(index int, note string) error {
+	return e.Update(func(clipboard *Clipboard) error {
+		if index < 0 || index >= len(clipboard.Entries) {
+			return fmt.Errorf("invalid clipboard index: %d", index)
+		}
+
+		clipboard.Entries[index].Note = note
+		return nil
+	})
+}
+
+// Reorder moves the entry at index from to index to, shifting the entries
+// in between. Moving an entry to 0 makes it the most recent.
+func (e *Engine) Reorder(from, to int) error {
+	return e.Update(func(clipboard *Clipboard) error {
+		for _, index := range []int{from, to} {
+			if index < 0 || index >= len(clipboard.Entries) {
+				return fmt.Errorf("invalid clipboard index: %d", index)
+			}
+		}
+
+		entry := clipboard.Entries[from]
+		entries := append(clipboard.Entries[:from:from], clipboard.Entries[from+1:]...)
+		entries = append(entries[:to], append([]Entry{entry}, entries[to:]...)...)
+		clipboard.Entries = entries
+		return nil
+	})
+}
+
+// Rotate cycles the entries n places towards the top, like pushd +n: the
+// entry at index n becomes the most recent and the ones above it wrap round
+// to the bottom. A negative n rotates the other way.
+func (e *Engine) Rotate(n int) error {
+	return e.Update(func(clipboard *Clipboard) error {
+		count := len(clipboard.Entries)
+		if count == 0 {
+			return ErrUnchanged
+		}
+
+		n = ((n % count) + count) % count
+		clipboard.Entries = append(clipboard.Entries[n:count:count], clipboard.Entries[:n]...)
+		return nil
+	})
+}
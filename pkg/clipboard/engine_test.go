@@ -0,0 +1,357 @@
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// setupTree creates a temporary directory with a few test files and a
+// clipboard file, returning its path
+func setupTree(t *testing.T) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"file1.txt":            "This is file 1",
+		"file2.txt":            "This is file 2",
+		"config/settings.json": `{"setting": "value"}`,
+	}
+
+	for relativePath, content := range testFiles {
+		fullPath := filepath.Join(tempDir, relativePath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", fullPath, err)
+		}
+	}
+
+	return tempDir
+}
+
+func TestEngineAddAndPaste(t *testing.T) {
+	tempDir := setupTree(t)
+	engine := NewEngine(NewFileStore(filepath.Join(tempDir, "clipboard.json")))
+
+	files := []string{filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "file2.txt")}
+	if _, err := engine.Add(files[:1], OpCut, AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := engine.Add(files[1:], OpCopy, AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	board, err := engine.Store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].CurrentPath != files[1] || !board.Entries[0].IsCopy() {
+		t.Fatalf("Expected the copied file on top, got %+v", board.Entries)
+	}
+
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.Mkdir(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination: %v", err)
+	}
+
+	pasted, err := engine.Paste(board.Entries[1], destDir, PasteOptions{})
+	if err != nil {
+		t.Fatalf("Paste failed: %v", err)
+	}
+	if pasted.Path != filepath.Join(destDir, "file1.txt") {
+		t.Errorf("Expected paste to %s, got %s", filepath.Join(destDir, "file1.txt"), pasted.Path)
+	}
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected cut source to be moved away")
+	}
+
+	// A second paste of the same name conflicts
+	if _, err := engine.Paste(board.Entries[0], destDir, PasteOptions{Persist: true, As: "file1.txt"}); err == nil {
+		t.Error("Expected a conflict error, got nil")
+	}
+	pasted, err = engine.Paste(board.Entries[0], destDir, PasteOptions{Persist: true, As: "file1.txt", OnConflict: ConflictRename})
+	if err != nil {
+		t.Fatalf("Paste failed: %v", err)
+	}
+	if pasted.Path != filepath.Join(destDir, "file1 (1).txt") || pasted.Into {
+		t.Errorf("Expected renamed paste, got %+v", pasted)
+	}
+
+	// one that fails still says where it went, to clear what it left
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := PasteOptions{Persist: true, As: "file1.txt", OnConflict: ConflictRename}
+	opts.FS.Context = canceled
+	pasted, err = engine.Paste(board.Entries[0], destDir, opts)
+	if !errors.Is(err, context.Canceled) || pasted.Path != filepath.Join(destDir, "file1 (2).txt") {
+		t.Errorf("Expected a canceled paste to %s, got %+v (%v)", filepath.Join(destDir, "file1 (2).txt"), pasted, err)
+	}
+
+	if err := engine.Remove(1); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	board, err = engine.Store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != files[1] {
+		t.Errorf("Unexpected entries after Remove: %+v", board.Entries)
+	}
+}
+
+func TestPasteUpdate(t *testing.T) {
+	tempDir := setupTree(t)
+	engine := NewEngine(nil)
+
+	entry := Entry{CurrentPath: filepath.Join(tempDir, "config"), Op: OpCopy}
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.Mkdir(destDir, 0o755); err != nil {
+		t.Fatalf("Failed to create destination: %v", err)
+	}
+
+	opts := PasteOptions{Persist: true}
+	opts.FS.Update = true
+	for i := 0; i < 2; i++ {
+		pasted, err := engine.Paste(entry, destDir, opts)
+		if err != nil {
+			t.Fatalf("Paste %d failed: %v", i+1, err)
+		}
+		if pasted.Path != filepath.Join(destDir, "config") {
+			t.Errorf("Expected the update to go into %s, got %s", filepath.Join(destDir, "config"), pasted.Path)
+		}
+		// only the second paste found it there already
+		if pasted.Into != (i == 1) {
+			t.Errorf("Paste %d: expected Into to be %v", i+1, i == 1)
+		}
+	}
+
+	// a file where the directory would go is still a conflict
+	fileEntry := Entry{CurrentPath: filepath.Join(tempDir, "file1.txt"), Op: OpCopy}
+	if _, err := engine.Paste(fileEntry, destDir, PasteOptions{Persist: true, As: "config", FS: opts.FS}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected a conflict, got: %v", err)
+	}
+}
+
+func TestPasteName(t *testing.T) {
+	tests := []struct {
+		base, as, want string
+	}{
+		{"config.json", "", "config.json"},
+		{"config.json", "config.bak.json", "config.bak.json"},
+		{"report.pdf", "report-final.*", "report-final.pdf"},
+		{"Makefile", "Makefile.old", "Makefile.old"},
+		{"Makefile", "GNUmakefile.*", "GNUmakefile"},
+	}
+
+	for _, tt := range tests {
+		got, err := PasteName(tt.base, tt.as)
+		if err != nil {
+			t.Errorf("PasteName(%q, %q) failed: %v", tt.base, tt.as, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("PasteName(%q, %q) = %q, want %q", tt.base, tt.as, got, tt.want)
+		}
+	}
+
+	for _, as := range []string{"..", "sub/name.txt"} {
+		if _, err := PasteName("file.txt", as); err == nil {
+			t.Errorf("Expected error for new name %q, got nil", as)
+		}
+	}
+}
+
+func TestAddDuplicates(t *testing.T) {
+	tempDir := setupTree(t)
+	engine := NewEngine(NewMemoryStore())
+
+	file1 := filepath.Join(tempDir, "file1.txt")
+	file2 := filepath.Join(tempDir, "file2.txt")
+	if _, err := engine.Add([]string{file1, file2, file1}, OpCut, AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	board, _ := engine.Store.Read()
+	if len(board.Entries) != 2 {
+		t.Fatalf("Expected a path given twice to be added once, got %+v", board.Entries)
+	}
+
+	var dup *DuplicateError
+	_, err := engine.Add([]string{file1}, OpCopy, AddOptions{})
+	if !errors.As(err, &dup) || dup.Path != file1 || dup.Index != 1 {
+		t.Fatalf("Expected a DuplicateError for %s at index 1, got: %v", file1, err)
+	}
+
+	if _, err := engine.Add([]string{file1}, OpCopy, AddOptions{Replace: true}); err != nil {
+		t.Fatalf("Add with Replace failed: %v", err)
+	}
+	board, _ = engine.Store.Read()
+	if len(board.Entries) != 2 || board.Entries[0].CurrentPath != file1 || !board.Entries[0].IsCopy() || board.Entries[1].CurrentPath != file2 {
+		t.Errorf("Expected the old entry replaced by a copy on top, got %+v", board.Entries)
+	}
+}
+
+func TestSetNote(t *testing.T) {
+	store := NewMemoryStore()
+	store.Write(Clipboard{Entries: []Entry{{CurrentPath: "a"}, {CurrentPath: "b"}}})
+	engine := NewEngine(store)
+
+	if err := engine.SetNote(1, "move to archive"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	board, _ := store.Read()
+	if board.Entries[1].Note != "move to archive" || board.Entries[0].Note != "" {
+		t.Errorf("Expected only entry 1 to have a note, got %+v", board.Entries)
+	}
+
+	if err := engine.SetNote(1, ""); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	board, _ = store.Read()
+	if board.Entries[1].Note != "" {
+		t.Errorf("Expected the note to be removed, got %q", board.Entries[1].Note)
+	}
+
+	if err := engine.SetNote(2, "x"); err == nil {
+		t.Error("Expected error for an invalid index, got nil")
+	}
+}
+
+func TestReorder(t *testing.T) {
+	store := NewMemoryStore()
+	store.Write(Clipboard{Entries: []Entry{{CurrentPath: "a"}, {CurrentPath: "b"}, {CurrentPath: "c"}, {CurrentPath: "d"}}})
+	engine := NewEngine(store)
+
+	tests := []struct {
+		from, to int
+		expected string
+	}{
+		{2, 0, "cabd"},
+		{0, 3, "abdc"},
+		{1, 1, "abdc"},
+		{3, 1, "acbd"},
+	}
+
+	for _, tt := range tests {
+		if err := engine.Reorder(tt.from, tt.to); err != nil {
+			t.Fatalf("Reorder(%d, %d) failed: %v", tt.from, tt.to, err)
+		}
+		board, _ := store.Read()
+		got := ""
+		for _, entry := range board.Entries {
+			got += entry.CurrentPath
+		}
+		if got != tt.expected {
+			t.Errorf("Expected %s after Reorder(%d, %d), got %s", tt.expected, tt.from, tt.to, got)
+		}
+	}
+
+	if err := engine.Reorder(0, 4); err == nil {
+		t.Error("Expected error for an invalid index, got nil")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	store := NewMemoryStore()
+	store.Write(Clipboard{Entries: []Entry{{CurrentPath: "a"}, {CurrentPath: "b"}, {CurrentPath: "c"}, {CurrentPath: "d"}}})
+	engine := NewEngine(store)
+
+	tests := []struct {
+		n        int
+		expected string
+	}{
+		{1, "bcda"},
+		{2, "dabc"},
+		{-1, "cdab"},
+		{4, "cdab"},
+		{-6, "abcd"},
+	}
+
+	for _, tt := range tests {
+		if err := engine.Rotate(tt.n); err != nil {
+			t.Fatalf("Rotate(%d) failed: %v", tt.n, err)
+		}
+		board, _ := store.Read()
+		got := ""
+		for _, entry := range board.Entries {
+			got += entry.CurrentPath
+		}
+		if got != tt.expected {
+			t.Errorf("Expected %s after Rotate(%d), got %s", tt.expected, tt.n, got)
+		}
+	}
+
+	if err := NewEngine(NewMemoryStore()).Rotate(1); err != nil {
+		t.Errorf("Expected rotating an empty clipboard to succeed, got %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	store := NewMemoryStore()
+	store.Write(Clipboard{Entries: []Entry{{CurrentPath: "a"}}})
+	engine := NewEngine(store)
+
+	err := engine.Update(func(clipboard *Clipboard) error {
+		if store.lock.TryLock() {
+			t.Error("Expected the store to be locked during Update")
+		}
+		clipboard.Entries = append(clipboard.Entries, Entry{CurrentPath: "b"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !store.lock.TryLock() {
+		t.Fatal("Expected the store to be unlocked after Update")
+	}
+	store.lock.Unlock()
+
+	failed := errors.New("failed")
+	for _, fnErr := range []error{ErrUnchanged, failed} {
+		err := engine.Update(func(clipboard *Clipboard) error {
+			clipboard.Entries = nil
+			return fnErr
+		})
+		if fnErr == failed && err != failed {
+			t.Errorf("Expected Update to fail with %v, got %v", failed, err)
+		}
+		if fnErr == ErrUnchanged && err != nil {
+			t.Errorf("Expected ErrUnchanged not to fail Update, got %v", err)
+		}
+		if board, _ := store.Read(); len(board.Entries) != 2 {
+			t.Errorf("Expected %v to leave the clipboard alone, got %+v", fnErr, board.Entries)
+		}
+	}
+}
+
+func TestRemoveAndReplaceEntries(t *testing.T) {
+	now := time.Now()
+	a := Entry{OriginalPath: "a", CurrentPath: "a", CutAt: now}
+	b := Entry{OriginalPath: "b", CurrentPath: "b", CutAt: now}
+	store := NewMemoryStore()
+	store.Write(Clipboard{Entries: []Entry{a, b}})
+	engine := NewEngine(store)
+
+	// entries read before another change to the clipboard are still found
+	c := Entry{OriginalPath: "c", CurrentPath: "c", CutAt: now.Add(time.Second)}
+	store.Append(c)
+
+	pasted := b
+	pasted.CurrentPath = "elsewhere/b"
+	if err := engine.ReplaceEntries(pasted); err != nil {
+		t.Fatalf("ReplaceEntries failed: %v", err)
+	}
+	if err := engine.RemoveEntries(a, Entry{OriginalPath: "gone", CutAt: now}); err != nil {
+		t.Fatalf("RemoveEntries failed: %v", err)
+	}
+
+	board, _ := store.Read()
+	if len(board.Entries) != 2 || board.Entries[0].CurrentPath != "c" || board.Entries[1].CurrentPath != "elsewhere/b" {
+		t.Errorf("Expected c and the pasted b to be left, got %+v", board.Entries)
+	}
+}
@@ -0,0 +1,60 @@
+package clipboard
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte(`{"version": 2, "entries": [{"current_path": "/tmp/a\nb", "kind": "file"}]}`))
+	f.Add([]byte(`[{"original_path": "/tmp/a", "current_path": "/tmp/a"}]`))
+	f.Add([]byte(`{"version": -1}`))
+	f.Add([]byte(`{"version": 1e300}`))
+	f.Add([]byte("{\"entries\": [{\"current_path\": \"\xff\xfe\"}]}"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// a clipboard file can be anything, but reading it mustn't panic
+		Decode(data)
+		salvageEntries(data)
+	})
+}
+
+func FuzzPasteName(f *testing.F) {
+	for _, seed := range [][2]string{{"report.pdf", "notes.*"}, {"a", ".."}, {"x.tar.gz", "y/z"}, {"\xff", "\n.*"}} {
+		f.Add(seed[0], seed[1])
+	}
+
+	f.Fuzz(func(t *testing.T, base, newName string) {
+		name, err := PasteName(base, newName)
+		if err != nil {
+			return
+		}
+		if name == "." || name == ".." || strings.ContainsRune(name, filepath.Separator) && newName != "" {
+			t.Errorf("PasteName(%q, %q) = %q, which isn't a plain file name", base, newName, name)
+		}
+	})
+}
+
+func FuzzEntryJSON(f *testing.F) {
+	for _, seed := range []string{"/tmp/report.pdf", "/tmp/a\nb", "/tmp/\xff\xfe", "/tmp/日本語", "/tmp/\x00"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		entry := Entry{OriginalPath: path, CurrentPath: path + "/moved", Op: OpCut}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var decoded Entry
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		// any path a file can have survives being stored
+		if decoded.OriginalPath != entry.OriginalPath || decoded.CurrentPath != entry.CurrentPath || decoded.Op != entry.Op {
+			t.Errorf("Expected %+v back, got %+v", entry, decoded)
+		}
+	})
+}
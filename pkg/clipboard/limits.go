@@ -0,0 +1,102 @@
+package clipboard
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// Limits caps how much the clipboard holds, so it can't grow without bound
+// for someone who never clears it. A zero limit is no limit.
+type Limits struct {
+	// MaxEntries is the most entries the clipboard holds
+	MaxEntries int
+	// MaxSize is the most bytes the files of its entries add up to
+	MaxSize int64
+	// Evict makes room for new entries by dropping the oldest ones, instead
+	// of refusing to add them with a LimitError
+	Evict bool
+	// Size measures the files of an entry; if nil, fsops.TreeSize is used,
+	// counting entries that can't be measured, like remote ones, as empty
+	Size func(Entry) int64
+}
+
+// LimitError reports that adding entries would take the clipboard over one
+// of its Limits
+type LimitError struct {
+	// Entries is set if the limit is MaxEntries, and Size if it's MaxSize;
+	// Have is how much the clipboard would hold and Max the limit
+	Entries bool
+	Have    int64
+	Max     int64
+}
+
+func (e *LimitError) Error() string {
+	if e.Entries {
+		return fmt.Sprintf("the clipboard would hold %d entries, over its limit of %d", e.Have, e.Max)
+	}
+	return fmt.Sprintf("the clipboard would hold %s, over its limit of %s", humanize.Bytes(uint64(e.Have)), humanize.Bytes(uint64(e.Max)))
+}
+
+// IsZero reports whether l sets no limits
+func (l Limits) IsZero() bool {
+	return l.MaxEntries <= 0 && l.MaxSize <= 0
+}
+
+// Apply checks that the clipboard entries, most recent first, still fit
+// the limits with added on top of them. If they don't and l.Evict is set,
+// it returns entries without the oldest ones it had to drop, and those
+// separately; otherwise it returns a LimitError. Added entries are never
+// dropped, so if they alone are over a limit it's always an error.
+func (l Limits) Apply(entries, added []Entry) (kept, evicted []Entry, err error) {
+	if l.IsZero() {
+		return entries, nil, nil
+	}
+
+	n := len(entries)
+	if l.MaxEntries > 0 && n+len(added) > l.MaxEntries {
+		if !l.Evict || len(added) > l.MaxEntries {
+			return nil, nil, &LimitError{Entries: true, Have: int64(n + len(added)), Max: int64(l.MaxEntries)}
+		}
+		n = l.MaxEntries - len(added)
+	}
+
+	if l.MaxSize > 0 {
+		var total int64
+		for _, entry := range added {
+			total += l.size(entry)
+		}
+		if total > l.MaxSize {
+			return nil, nil, &LimitError{Have: total, Max: l.MaxSize}
+		}
+		// the newest entries that still fit are kept
+		for i, entry := range entries[:n] {
+			total += l.size(entry)
+			if total > l.MaxSize {
+				if !l.Evict {
+					for _, entry := range entries[i+1:] {
+						total += l.size(entry)
+					}
+					return nil, nil, &LimitError{Have: total, Max: l.MaxSize}
+				}
+				n = i
+				break
+			}
+		}
+	}
+
+	return entries[:n], entries[n:], nil
+}
+
+// size measures entry's files with l.Size, or fsops.TreeSize
+func (l Limits) size(entry Entry) int64 {
+	if l.Size != nil {
+		return l.Size(entry)
+	}
+	size, err := fsops.TreeSize(entry.CurrentPath)
+	if err != nil {
+		return 0
+	}
+	return size
+}
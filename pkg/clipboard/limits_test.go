@@ -0,0 +1,50 @@
+package clipboard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimitsApply(t *testing.T) {
+	sizes := map[string]int64{"new": 40, "a": 10, "b": 20, "c": 30}
+	size := func(entry Entry) int64 { return sizes[entry.CurrentPath] }
+	entries := []Entry{{CurrentPath: "a"}, {CurrentPath: "b"}, {CurrentPath: "c"}}
+	added := []Entry{{CurrentPath: "new"}}
+
+	tests := []struct {
+		name    string
+		limits  Limits
+		kept    int
+		limited bool
+	}{
+		{name: "no limits", limits: Limits{}, kept: 3},
+		{name: "within limits", limits: Limits{MaxEntries: 4, MaxSize: 100, Size: size}, kept: 3},
+		{name: "evict for entries", limits: Limits{MaxEntries: 3, Evict: true, Size: size}, kept: 2},
+		{name: "evict for size", limits: Limits{MaxSize: 75, Evict: true, Size: size}, kept: 2},
+		{name: "evict for both", limits: Limits{MaxEntries: 3, MaxSize: 55, Evict: true, Size: size}, kept: 1},
+		{name: "refuse for entries", limits: Limits{MaxEntries: 3, Size: size}, limited: true},
+		{name: "refuse for size", limits: Limits{MaxSize: 75, Size: size}, limited: true},
+		{name: "added alone too big", limits: Limits{MaxSize: 30, Evict: true, Size: size}, limited: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, evicted, err := tt.limits.Apply(entries, added)
+			var limitErr *LimitError
+			if tt.limited {
+				if !errors.As(err, &limitErr) {
+					t.Fatalf("Expected a LimitError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			if len(kept) != tt.kept || len(kept)+len(evicted) != len(entries) {
+				t.Fatalf("Expected %d entries kept, got %+v kept and %+v evicted", tt.kept, kept, evicted)
+			}
+			if len(evicted) > 0 && evicted[len(evicted)-1].CurrentPath != "c" {
+				t.Errorf("Expected the oldest entries evicted, got %+v", evicted)
+			}
+		})
+	}
+}
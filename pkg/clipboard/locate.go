@@ -0,0 +1,240 @@
+package clipboard
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+const (
+	// hashLimit is the largest file whose content hash is recorded, so
+	// cutting big files stays fast
+	hashLimit = 1 << 20
+	// locateLevels is how many directories above a missing entry Locate
+	// starts searching from
+	locateLevels = 2
+	// locateDepth is how far below its starting directory Locate searches
+	locateDepth = locateLevels + 2
+	// locateBudget caps the number of paths Locate looks at
+	locateBudget = 20000
+)
+
+// Identify records what the entry's file is, so it can still be described
+// while it's unreachable, and what Locate needs to find it again if it's
+// moved: its device, inode and birth time and, for regular files, their
+// quick fingerprint, plus the content hash of small ones
+func (e *Entry) Identify() {
+	e.Kind, e.Mode, e.Size = "", 0, 0
+	e.Device, e.Inode, e.Born, e.Hash, e.Fingerprint = 0, 0, 0, "", ""
+
+	info, err := os.Lstat(e.CurrentPath)
+	if err != nil {
+		return
+	}
+	e.Kind, e.Mode, e.Size = KindOf(info.Mode()), info.Mode(), info.Size()
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		e.Device, e.Inode = uint64(st.Dev), uint64(st.Ino)
+	}
+	if born, ok := fsops.BirthTime(e.CurrentPath); ok {
+		e.Born = born.UnixNano()
+	}
+
+	if !info.Mode().IsRegular() {
+		return
+	}
+	if fingerprint, err := fsops.Fingerprint(e.CurrentPath); err == nil {
+		e.Fingerprint = fingerprint
+	}
+	if info.Size() <= hashLimit {
+		if hash, err := fsops.Checksum(e.CurrentPath); err == nil {
+			e.Hash = hash
+		}
+	}
+}
+
+// SameIdentity reports whether e and other, both identified, describe the
+// same file in the same state: the same kind, mode, size, device, inode,
+// birth time and content fingerprints
+func (e Entry) SameIdentity(other Entry) bool {
+	return e.Kind == other.Kind && e.Mode == other.Mode && e.Size == other.Size &&
+		e.Device == other.Device && e.Inode == other.Inode && e.Born == other.Born &&
+		e.Hash == other.Hash && e.Fingerprint == other.Fingerprint
+}
+
+// sameContent reports whether the regular file at path, described by info,
+// has the entry's content: the same content hash if one was taken, or
+// otherwise the same fingerprint. Every empty file has the same content, so
+// an empty entry never matches by content.
+func (e Entry) sameContent(path string, info os.FileInfo) bool {
+	if e.Kind != KindFile || e.Size == 0 || !info.Mode().IsRegular() || info.Size() != e.Size {
+		return false
+	}
+	if e.Hash != "" {
+		hash, err := fsops.Checksum(path)
+		return err == nil && hash == e.Hash
+	}
+	if e.Fingerprint != "" {
+		ok, err := fsops.MatchFingerprint(path, e.Fingerprint)
+		return err == nil && ok
+	}
+	return false
+}
+
+// sameFile reports whether the file at path, described by info, is the
+// entry's file: the same device and inode, born at the same time if that was
+// recorded, and still the kind, mode and size it was when added. Inodes are
+// reused once a file is deleted, so a match on them alone could be some
+// unrelated file created since.
+func (e Entry) sameFile(path string, info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || e.Inode == 0 || e.Kind == "" || uint64(st.Dev) != e.Device || uint64(st.Ino) != e.Inode {
+		return false
+	}
+	if KindOf(info.Mode()) != e.Kind || info.Mode() != e.Mode || info.Size() != e.Size {
+		return false
+	}
+	if e.Born != 0 {
+		born, ok := fsops.BirthTime(path)
+		return ok && born.UnixNano() == e.Born
+	}
+	return true
+}
+
+// MovedTo reports whether the file at path can be the entry's file renamed
+// or moved there: a rename keeps its device and inode, and its birth time if
+// that was recorded. Its size and content may have changed since it was
+// added. Entries added before inodes were recorded can't be told apart, so
+// any file will do.
+func (e Entry) MovedTo(path string) bool {
+	if e.Inode == 0 {
+		return true
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || uint64(st.Dev) != e.Device || uint64(st.Ino) != e.Inode {
+		return false
+	}
+	if e.Born != 0 {
+		born, ok := fsops.BirthTime(path)
+		return !ok || born.UnixNano() == e.Born
+	}
+	return true
+}
+
+// renamedInPlace looks for the entry's file under another name in the
+// directory it was in, the likeliest place for it to have gone. exact is the
+// same file, see sameFile, and similar any files with the same content.
+func renamedInPlace(entry Entry) (exact string, similar []string) {
+	dir := filepath.Dir(entry.CurrentPath)
+	items, err := os.ReadDir(dir)
+	if err != nil || len(items) > locateBudget {
+		return "", nil
+	}
+
+	for _, item := range items {
+		candidate := filepath.Join(dir, item.Name())
+		info, err := os.Lstat(candidate)
+		if err != nil {
+			continue
+		}
+		if entry.sameFile(candidate, info) {
+			return candidate, nil
+		}
+		if entry.sameContent(candidate, info) {
+			similar = append(similar, candidate)
+		}
+	}
+	return "", similar
+}
+
+// Locate looks for a missing entry's file in the directories around where
+// it used to be, returning its new path. The same file, by device and inode
+// and still the kind, mode and size it was, wins; failing that, a file with
+// the same size and content hash, or for files too big to hash, the same
+// fingerprint. The directory it was in is checked first, since a file
+// renamed in place is the commonest case, then directories closer to the old
+// path before those further away. If more than one file has the entry's
+// content, there's no telling which it became, so none is returned.
+func Locate(entry Entry) (string, bool) {
+	if entry.Inode == 0 && entry.Hash == "" && entry.Fingerprint == "" {
+		return "", false
+	}
+	// a file with the same content next to it beats one further away, but
+	// not the same file further away
+	exact, nearby := renamedInPlace(entry)
+	if exact != "" {
+		return exact, true
+	}
+
+	// the nearest directory that still exists, then a few levels up
+	dir := filepath.Dir(entry.CurrentPath)
+	root := dir
+	for {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", false
+		}
+		root = parent
+	}
+	for range locateLevels {
+		root = filepath.Dir(root)
+	}
+
+	var further []string
+	seen := 0
+	level := []string{root}
+	for depth := 0; depth <= locateDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, parent := range level {
+			items, err := os.ReadDir(parent)
+			if err != nil {
+				continue
+			}
+			for _, item := range items {
+				if seen++; seen > locateBudget {
+					return closest(nearby, further)
+				}
+				path := filepath.Join(parent, item.Name())
+
+				info, err := os.Lstat(path)
+				if err != nil {
+					continue
+				}
+				if entry.sameFile(path, info) {
+					return path, true
+				}
+				// two are already ambiguous, and renamedInPlace looked
+				// at the entry's own directory
+				if len(further) < 2 && parent != dir && entry.sameContent(path, info) {
+					further = append(further, path)
+				}
+				if item.IsDir() {
+					next = append(next, path)
+				}
+			}
+		}
+		level = next
+	}
+	return closest(nearby, further)
+}
+
+// closest returns the one file with the entry's content in its own
+// directory, or failing that the one further away. Any more than one is
+// ambiguous.
+func closest(nearby, further []string) (string, bool) {
+	switch {
+	case len(nearby) == 1:
+		return nearby[0], true
+	case len(nearby) == 0 && len(further) == 1:
+		return further[0], true
+	}
+	return "", false
+}
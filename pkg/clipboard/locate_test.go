@@ -0,0 +1,221 @@
+package clipboard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+func TestLocate(t *testing.T) {
+	tempDir := setupTree(t)
+
+	entry := Entry{CurrentPath: filepath.Join(tempDir, "config", "settings.json")}
+	entry.Identify()
+	if entry.Inode == 0 || entry.Hash == "" {
+		t.Fatalf("Expected the entry to be identified, got %+v", entry)
+	}
+
+	// renamed: found by inode, even under another name
+	renamed := filepath.Join(tempDir, "moved", "prefs.json")
+	if err := os.MkdirAll(filepath.Dir(renamed), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Rename(entry.CurrentPath, renamed); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	if found, ok := Locate(entry); !ok || found != renamed {
+		t.Errorf("Expected %s, got %q (found %t)", renamed, found, ok)
+	}
+
+	// rewritten elsewhere: found by content
+	data, _ := os.ReadFile(renamed)
+	rewritten := filepath.Join(tempDir, "settings.json")
+	if err := os.WriteFile(rewritten, data, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	os.Remove(renamed)
+	if found, ok := Locate(entry); !ok || found != rewritten {
+		t.Errorf("Expected %s, got %q (found %t)", rewritten, found, ok)
+	}
+
+	os.Remove(rewritten)
+	if found, ok := Locate(entry); ok {
+		t.Errorf("Expected nothing to be found, got %s", found)
+	}
+}
+
+func TestLocateReusedInode(t *testing.T) {
+	tempDir := setupTree(t)
+
+	// an empty file is cut and deleted, and its inode goes to a directory
+	// made afterwards; simulated by recording the directory as the file
+	dir := filepath.Join(tempDir, "config")
+	entry := Entry{CurrentPath: dir}
+	entry.Identify()
+	entry.CurrentPath = filepath.Join(tempDir, "empty.txt")
+	entry.Kind, entry.Mode, entry.Size = KindFile, 0o644, 0
+
+	if found, ok := Locate(entry); ok {
+		t.Errorf("Expected a directory with the entry's inode not to match a file, got %s", found)
+	}
+}
+
+func TestLocateRecreated(t *testing.T) {
+	tempDir := setupTree(t)
+	if _, ok := fsops.BirthTime(tempDir); !ok {
+		t.Skip("the filesystem doesn't record birth times")
+	}
+
+	// deleted and remade with the same size and mode, most likely with the
+	// same inode; it's still not the file that was cut
+	path := filepath.Join(tempDir, "config", "notes.txt")
+	if err := os.WriteFile(path, []byte("first"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	entry := Entry{CurrentPath: path}
+	entry.Identify()
+	os.Remove(path)
+	recreated := filepath.Join(tempDir, "config", "other.txt")
+	if err := os.WriteFile(recreated, []byte("other"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if found, ok := Locate(entry); ok {
+		t.Errorf("Expected a recreated file not to be taken for the entry, got %s", found)
+	}
+}
+
+func TestLocateByFingerprint(t *testing.T) {
+	tempDir := setupTree(t)
+
+	// too big to hash, so only fingerprinted
+	data := make([]byte, hashLimit+1)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	path := filepath.Join(tempDir, "config", "big.bin")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	entry := Entry{CurrentPath: path}
+	entry.Identify()
+	if entry.Hash != "" || entry.Fingerprint == "" || entry.Size != int64(len(data)) {
+		t.Fatalf("Expected a fingerprint and no hash, got %+v", entry)
+	}
+
+	// rewritten under a new name beside it, and a copy further away: the
+	// one in the same directory wins
+	for _, copyPath := range []string{filepath.Join(tempDir, "big.bin"), filepath.Join(tempDir, "config", "big-renamed.bin")} {
+		if err := os.WriteFile(copyPath, data, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	os.Remove(path)
+	if found, ok := Locate(entry); !ok || found != filepath.Join(tempDir, "config", "big-renamed.bin") {
+		t.Errorf("Expected the file renamed in place, got %q (found %t)", found, ok)
+	}
+}
+
+func TestIdentifyKind(t *testing.T) {
+	tempDir := setupTree(t)
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink("file1.txt", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		kind Kind
+	}{
+		{filepath.Join(tempDir, "file1.txt"), KindFile},
+		{filepath.Join(tempDir, "config"), KindDir},
+		{link, KindSymlink},
+	}
+	for _, tt := range tests {
+		entry := Entry{CurrentPath: tt.path}
+		entry.Identify()
+		info, err := os.Lstat(tt.path)
+		if err != nil {
+			t.Fatalf("Lstat failed: %v", err)
+		}
+		if entry.Kind != tt.kind || entry.Mode != info.Mode() || entry.Size != info.Size() {
+			t.Errorf("Expected %s with mode %s and size %d for %s, got %+v", tt.kind, info.Mode(), info.Size(), tt.path, entry)
+		}
+	}
+}
+
+func TestLocateByContentAmbiguous(t *testing.T) {
+	tempDir := setupTree(t)
+
+	path := filepath.Join(tempDir, "config", "settings.json")
+	entry := Entry{CurrentPath: path}
+	entry.Identify()
+	data, _ := os.ReadFile(path)
+	os.Remove(path)
+
+	// two files with its content: no telling which it became
+	for _, name := range []string{"a.json", "b.json"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), data, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	if found, ok := Locate(entry); ok {
+		t.Errorf("Expected two matches to be ambiguous, got %s", found)
+	}
+}
+
+func TestLocateEmptyFile(t *testing.T) {
+	tempDir := setupTree(t)
+
+	path := filepath.Join(tempDir, "config", "empty")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	entry := Entry{CurrentPath: path}
+	entry.Identify()
+
+	// any empty file has its content, so none of them is it
+	if err := os.WriteFile(filepath.Join(tempDir, "other"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	os.Remove(path)
+	if found, ok := Locate(entry); ok {
+		t.Errorf("Expected an empty file not to be matched by content, got %s", found)
+	}
+}
+
+func TestMovedTo(t *testing.T) {
+	tempDir := setupTree(t)
+
+	path := filepath.Join(tempDir, "config", "moved.txt")
+	if err := os.WriteFile(path, []byte("draft"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	entry := Entry{CurrentPath: path}
+	entry.Identify()
+
+	renamed := filepath.Join(tempDir, "final.txt")
+	if err := os.Rename(path, renamed); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	// edited after the rename, but still the same file
+	if err := os.WriteFile(renamed, []byte("final draft"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if !entry.MovedTo(renamed) {
+		t.Errorf("Expected %s to be the entry's file moved", renamed)
+	}
+
+	other := filepath.Join(tempDir, "other.txt")
+	if err := os.WriteFile(other, []byte("draft"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if entry.MovedTo(other) {
+		t.Errorf("Expected %s, a different file, not to match", other)
+	}
+	if !(Entry{CurrentPath: path}).MovedTo(other) {
+		t.Error("Expected an entry without an inode to match any file")
+	}
+}
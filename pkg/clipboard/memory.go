@@ -0,0 +1,45 @@
+package clipboard
+
+import "sync"
+
+// MemoryStore keeps the clipboard in memory, which is mostly useful for
+// tests. The zero value is an empty clipboard ready to use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	lock      sync.Mutex
+	clipboard Clipboard
+}
+
+// NewMemoryStore returns an empty in-memory Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Read returns a copy of the stored clipboard
+func (s *MemoryStore) Read() (Clipboard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Clipboard{Entries: append([]Entry{}, s.clipboard.Entries...)}, nil
+}
+
+// Write replaces the stored clipboard with a copy of clipboard
+func (s *MemoryStore) Write(clipboard Clipboard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clipboard.Entries = append([]Entry{}, clipboard.Entries...)
+	return nil
+}
+
+// Append adds entries on top of the stored clipboard
+func (s *MemoryStore) Append(entries ...Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clipboard.Entries = append(append([]Entry{}, entries...), s.clipboard.Entries...)
+	return nil
+}
+
+// Lock takes the store's lock, waiting for any other holder
+func (s *MemoryStore) Lock() (func(), error) {
+	s.lock.Lock()
+	return s.lock.Unlock, nil
+}
@@ -0,0 +1,76 @@
+package clipboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// CurrentVersion is the schema version of the clipboard JSON this package
+// writes. Bump it, and add a migration, whenever a change to Entry or
+// Clipboard would be misread by older code or would misread older files.
+const CurrentVersion = 1
+
+// ErrNewerVersion is returned for clipboard JSON written by a newer version
+// of cx than this one, which can't be read without losing data
+var ErrNewerVersion = errors.New("clipboard was written by a newer version of cx")
+
+// migrations[v] upgrades raw clipboard JSON from version v to v+1. They work
+// on the decoded JSON rather than on Clipboard so fields can be renamed or
+// restructured.
+var migrations = []func(raw map[string]any) error{
+	migrateOperations,
+}
+
+// migrateOperations upgrades version 0, written before the clipboard was
+// versioned, in which entries without an operation were cuts
+func migrateOperations(raw map[string]any) error {
+	entries, _ := raw["entries"].([]any)
+	for _, entry := range entries {
+		if fields, ok := entry.(map[string]any); ok {
+			if op, _ := fields["operation"].(string); op == "" {
+				fields["operation"] = string(OpCut)
+			}
+		}
+	}
+	return nil
+}
+
+// Decode parses clipboard JSON of any version up to CurrentVersion,
+// migrating it to the current schema
+func Decode(data []byte) (Clipboard, error) {
+	var clipboard Clipboard
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return clipboard, err
+	}
+
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		if v > CurrentVersion {
+			return clipboard, fmt.Errorf("%w (version %v, this cx reads up to %d)", ErrNewerVersion, v, CurrentVersion)
+		}
+		if v < 0 || v != float64(int(v)) {
+			return clipboard, fmt.Errorf("invalid clipboard version %v", v)
+		}
+		version = int(v)
+	}
+
+	if version < CurrentVersion {
+		for _, migrate := range migrations[version:] {
+			if err := migrate(raw); err != nil {
+				return clipboard, fmt.Errorf("failed to migrate clipboard from version %d: %w", version, err)
+			}
+		}
+		raw["version"] = CurrentVersion
+
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return clipboard, err
+		}
+	}
+
+	err := json.Unmarshal(data, &clipboard)
+	return clipboard, err
+}
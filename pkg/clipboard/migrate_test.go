@@ -0,0 +1,81 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeFixtures(t *testing.T) {
+	for _, fixture := range []string{"clipboard-v0.json", "clipboard-v1.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", fixture))
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+
+			board, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if board.Version != CurrentVersion {
+				t.Errorf("Expected version %d, got %d", CurrentVersion, board.Version)
+			}
+			if len(board.Entries) != 2 {
+				t.Fatalf("Expected 2 entries, got %+v", board.Entries)
+			}
+			if board.Entries[0].Op != OpCopy || board.Entries[1].Op != OpCut {
+				t.Errorf("Expected copy then cut, got %q and %q", board.Entries[0].Op, board.Entries[1].Op)
+			}
+			if board.Entries[1].CurrentPath != "/home/me/report.pdf" || board.Entries[1].CutAt.IsZero() {
+				t.Errorf("Unexpected entry: %+v", board.Entries[1])
+			}
+		})
+	}
+}
+
+func TestDecodeNewerVersion(t *testing.T) {
+	_, err := Decode([]byte(`{"version": 99, "entries": []}`))
+	if !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("Expected ErrNewerVersion, got %v", err)
+	}
+
+	// A file store refuses to read it rather than salvaging and overwriting it
+	store := NewFileStore(filepath.Join(t.TempDir(), "clipboard.json"))
+	if err := os.WriteFile(store.Path, []byte(`{"version": 99, "entries": []}`), 0o644); err != nil {
+		t.Fatalf("Failed to write clipboard file: %v", err)
+	}
+	if _, err := store.Read(); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Expected ErrNewerVersion from Read, got %v", err)
+	}
+}
+
+func TestFileStoreMigrates(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "clipboard-v0.json"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	store := NewFileStore(filepath.Join(t.TempDir(), "clipboard.json"))
+	if err := os.WriteFile(store.Path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write clipboard file: %v", err)
+	}
+
+	board, err := store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if err := store.Write(board); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	written, err := os.ReadFile(store.Path)
+	if err != nil {
+		t.Fatalf("Failed to read clipboard file: %v", err)
+	}
+	if want := fmt.Sprintf(`"version": %d`, CurrentVersion); !bytes.Contains(written, []byte(want)) {
+		t.Errorf("Expected the file to be written with %s, got %s", want, written)
+	}
+}
@@ -0,0 +1,199 @@
+package clipboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+)
+
+// ErrNoSQLite is returned by SQLiteStore when cx was built without cgo,
+// which its SQLite driver needs
+var ErrNoSQLite = errors.New("the SQLite store needs a cx built with cgo")
+
+// SQLiteStore keeps the clipboard in a SQLite database, one row per entry,
+// so adding entries to a long clipboard doesn't rewrite all of it. Entries
+// are stored as the same JSON as in the clipboard file, and the schema
+// version in the database's user_version, so both stores share Decode's
+// migrations. It needs cgo; without it every method fails with
+// ErrNoSQLite.
+type SQLiteStore struct {
+	Path string
+	// Waiting, if set, is called when Lock has to wait for another process
+	Waiting func()
+}
+
+// NewSQLiteStore returns a Store backed by the SQLite database at path
+func NewSQLiteStore(path string) *SQLiteStore {
+	return &SQLiteStore{Path: path}
+}
+
+// sqliteSchema creates the entries table; seq orders them, the highest
+// being the most recent
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS entries (
+	seq   INTEGER PRIMARY KEY AUTOINCREMENT,
+	entry TEXT NOT NULL
+)`
+
+// open opens the database, creating it with the current schema version if
+// it's new, and returns its schema version
+func (s *SQLiteStore) open() (*sql.DB, int, error) {
+	db, err := openSQLite(s.Path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var version int
+	err = db.QueryRow("PRAGMA user_version").Scan(&version)
+	if err == nil && version == 0 {
+		// a new database, or one that has never had entries written
+		var tables int
+		err = db.QueryRow("SELECT count(*) FROM sqlite_master WHERE name = 'entries'").Scan(&tables)
+		if err == nil && tables == 0 {
+			if _, err = db.Exec(sqliteSchema); err == nil {
+				_, err = db.Exec(fmt.Sprintf("PRAGMA user_version = %d", CurrentVersion))
+				version = CurrentVersion
+			}
+		}
+	}
+	if err != nil {
+		db.Close()
+		return nil, 0, fmt.Errorf("failed to open %s: %w", s.Path, err)
+	}
+	if version > CurrentVersion {
+		db.Close()
+		return nil, 0, fmt.Errorf("%w (version %d, this cx reads up to %d)", ErrNewerVersion, version, CurrentVersion)
+	}
+	return db, version, nil
+}
+
+// Read reads the clipboard from the database, creating it if it doesn't
+// exist, and migrates entries written by older versions
+func (s *SQLiteStore) Read() (Clipboard, error) {
+	db, version, err := s.open()
+	if err != nil {
+		return Clipboard{}, err
+	}
+	defer db.Close()
+	return readSQLite(db, version)
+}
+
+// readSQLite reads the entries, most recent first, as Decode would read a
+// clipboard file of the given version
+func readSQLite(db *sql.DB, version int) (Clipboard, error) {
+	rows, err := db.Query("SELECT entry FROM entries ORDER BY seq DESC")
+	if err != nil {
+		return Clipboard{}, err
+	}
+	defer rows.Close()
+
+	var entries []string
+	for rows.Next() {
+		var entry string
+		if err := rows.Scan(&entry); err != nil {
+			return Clipboard{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return Clipboard{}, err
+	}
+
+	raw := fmt.Sprintf(`{"version": %d, "entries": [%s]}`, version, strings.Join(entries, ","))
+	return Decode([]byte(raw))
+}
+
+// Write replaces the clipboard in the database in one transaction
+func (s *SQLiteStore) Write(clipboard Clipboard) error {
+	db, _, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return writeSQLite(db, clipboard.Entries, true)
+}
+
+// Append adds entries on top of the clipboard. Entries written by an older
+// version are migrated first, since the database has one schema version.
+func (s *SQLiteStore) Append(entries ...Entry) error {
+	db, version, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if version < CurrentVersion {
+		clipboard, err := readSQLite(db, version)
+		if err != nil {
+			return err
+		}
+		return writeSQLite(db, append(append([]Entry{}, entries...), clipboard.Entries...), true)
+	}
+	return writeSQLite(db, entries, false)
+}
+
+// writeSQLite inserts entries so that entries[0] is the most recent, first
+// deleting every row if replace is set
+func writeSQLite(db *sql.DB, entries []Entry, replace bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.Exec("DELETE FROM entries"); err != nil {
+			return err
+		}
+	}
+	insert, err := tx.Prepare("INSERT INTO entries (entry) VALUES (?)")
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for i := len(entries) - 1; i >= 0; i-- {
+		entryJSON, err := json.Marshal(entries[i])
+		if err != nil {
+			return err
+		}
+		if _, err := insert.Exec(string(entryJSON)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", CurrentVersion)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LockPath returns the path of the lock file guarding the database
+func (s *SQLiteStore) LockPath() string {
+	return s.Path + ".lock"
+}
+
+// Lock takes an exclusive advisory lock on the database's lock file. SQLite
+// keeps each write whole by itself; the lock keeps other processes out
+// between a read and the write based on it. As with FileStore, nothing is
+// locked on a network filesystem; see Unlocked.
+func (s *SQLiteStore) Lock() (func(), error) {
+	if s.Unlocked() {
+		return func() {}, nil
+	}
+	return lockFile(s.LockPath(), s.Waiting)
+}
+
+// Unlocked reports whether the database is on a network filesystem, where
+// Lock doesn't lock it
+func (s *SQLiteStore) Unlocked() bool {
+	_, ok := fsops.NetworkFS(s.Path)
+	return ok
+}
+
+// LockHolder reports whether another open file holds the database's lock
+// and, if it was recorded, the process ID of its holder
+func (s *SQLiteStore) LockHolder() (pid int, held bool, err error) {
+	return lockHolder(s.LockPath())
+}
@@ -0,0 +1,15 @@
+//go:build cgo
+
+package clipboard
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openSQLite opens the SQLite database at path, waiting for other writers
+// rather than failing while they hold it
+func openSQLite(path string) (*sql.DB, error) {
+	return sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL")
+}
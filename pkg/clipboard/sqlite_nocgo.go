@@ -0,0 +1,11 @@
+//go:build !cgo
+
+package clipboard
+
+import "database/sql"
+
+// openSQLite can't open databases in a build without cgo, which the SQLite
+// driver needs
+func openSQLite(_ string) (*sql.DB, error) {
+	return nil, ErrNoSQLite
+}
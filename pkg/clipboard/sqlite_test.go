@@ -0,0 +1,68 @@
+//go:build cgo
+
+package clipboard
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clipboard.db")
+	testStore(t, NewSQLiteStore(path))
+
+	// the entries outlive the store that wrote them
+	board, err := NewSQLiteStore(path).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].CurrentPath != "/b" || board.Entries[1].CurrentPath != "/a" {
+		t.Errorf("Expected [/b /a] from a new store, got %+v", board.Entries)
+	}
+}
+
+func TestSQLiteStoreVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clipboard.db")
+	store := NewSQLiteStore(path)
+	if err := store.Append(Entry{CurrentPath: "/a", Op: OpCopy}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	exec := func(query string) {
+		t.Helper()
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", path, err)
+		}
+		defer db.Close()
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+
+	// entries from before operations were recorded are migrated as cuts
+	exec("UPDATE entries SET entry = json_remove(entry, '$.operation')")
+	exec("PRAGMA user_version = 0")
+	if err := store.Append(Entry{CurrentPath: "/b", Op: OpCopy}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	board, err := store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].Op != OpCopy || board.Entries[1].Op != OpCut {
+		t.Errorf("Expected a copy over a migrated cut, got %+v", board.Entries)
+	}
+
+	// a newer cx's database isn't read or written
+	exec(fmt.Sprintf("PRAGMA user_version = %d", CurrentVersion+1))
+	if _, err := store.Read(); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Expected ErrNewerVersion reading, got %v", err)
+	}
+	if err := store.Write(Clipboard{}); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Expected ErrNewerVersion writing, got %v", err)
+	}
+}
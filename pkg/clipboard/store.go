@@ -0,0 +1,266 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkitazos/cx/pkg/fsops"
+	"golang.org/x/sys/unix"
+)
+
+// Store loads and saves a Clipboard
+type Store interface {
+	// Read returns the stored clipboard, which is empty if nothing has been
+	// stored yet
+	Read() (Clipboard, error)
+	// Write replaces the stored clipboard
+	Write(Clipboard) error
+	// Append adds entries on top of the stored clipboard, keeping their
+	// order, so entries[0] becomes the most recent
+	Append(entries ...Entry) error
+	// Lock takes an exclusive lock on the store, waiting for other holders,
+	// and returns a function that releases it
+	Lock() (unlock func(), err error)
+}
+
+// FileStore keeps the clipboard as a JSON file
+type FileStore struct {
+	Path string
+	// Waiting, if set, is called when Lock has to wait for another process
+	Waiting func()
+	// Recovered, if set, is called when Read finds the clipboard file
+	// corrupted, with the number of entries salvaged from it and the error
+	Recovered func(salvaged int, err error)
+}
+
+// NewFileStore returns a Store backed by the JSON file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// BackupPath returns the path of the last-known-good copy of the clipboard
+// file, which Write keeps before replacing it
+func (s *FileStore) BackupPath() string {
+	return s.Path + ".bak"
+}
+
+// Read reads and parses the clipboard file, creating it if it doesn't exist.
+// If the file is corrupted, the entries that can still be parsed are
+// returned instead, so a damaged file doesn't stop every command.
+func (s *FileStore) Read() (Clipboard, error) {
+	var clipboard Clipboard
+
+	clipboardJSON, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		clipboard = Clipboard{Version: CurrentVersion, Entries: []Entry{}}
+		return clipboard, s.Write(clipboard)
+	}
+	if err != nil {
+		return clipboard, err
+	}
+
+	clipboard, err = Decode(clipboardJSON)
+	if errors.Is(err, ErrNewerVersion) {
+		return clipboard, err
+	}
+	if err != nil {
+		clipboard = Clipboard{Version: CurrentVersion, Entries: salvageEntries(clipboardJSON)}
+		if s.Recovered != nil {
+			s.Recovered(len(clipboard.Entries), err)
+		}
+	}
+	return clipboard, nil
+}
+
+// salvageEntries decodes entries from a corrupted clipboard file one at a
+// time, stopping at the first that can't be parsed
+func salvageEntries(data []byte) []Entry {
+	entries := []Entry{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return entries
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return entries
+		}
+		if key != "entries" {
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return entries
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return entries
+		}
+		for dec.More() {
+			var entry Entry
+			if err := dec.Decode(&entry); err != nil {
+				return entries
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	return entries
+}
+
+// Verify reports whether the clipboard file is corrupted. A missing file is
+// fine, as Read creates it.
+func (s *FileStore) Verify() error {
+	clipboardJSON, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = Decode(clipboardJSON)
+	return err
+}
+
+// Write writes the clipboard to the clipboard file, first saving the current
+// file as the backup if it is intact
+func (s *FileStore) Write(clipboard Clipboard) error {
+	clipboard.Version = CurrentVersion
+	clipboardJSON, err := json.MarshalIndent(clipboard, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// a corrupted file never replaces the last good backup
+	if current, err := os.ReadFile(s.Path); err == nil {
+		if _, err := Decode(current); err == nil {
+			if err := fsops.WriteFileAtomic(s.BackupPath(), current, 0o644); err != nil {
+				return fmt.Errorf("failed to back up clipboard: %w", err)
+			}
+		}
+	}
+
+	return fsops.WriteFileAtomic(s.Path, clipboardJSON, 0o644)
+}
+
+// Restore replaces the clipboard file with its backup and returns the
+// restored clipboard
+func (s *FileStore) Restore() (Clipboard, error) {
+	var clipboard Clipboard
+
+	backupJSON, err := os.ReadFile(s.BackupPath())
+	if err != nil {
+		return clipboard, err
+	}
+	if clipboard, err = Decode(backupJSON); err != nil {
+		return clipboard, fmt.Errorf("backup is unusable too: %w", err)
+	}
+
+	return clipboard, s.Write(clipboard)
+}
+
+// Append adds entries on top of the clipboard file
+func (s *FileStore) Append(entries ...Entry) error {
+	clipboard, err := s.Read()
+	if err != nil {
+		return err
+	}
+	clipboard.Entries = append(append([]Entry{}, entries...), clipboard.Entries...)
+	return s.Write(clipboard)
+}
+
+// LockPath returns the path of the lock file guarding the clipboard file
+func (s *FileStore) LockPath() string {
+	return s.Path + ".lock"
+}
+
+// Lock takes an exclusive advisory lock on the clipboard file so that
+// concurrent processes don't interleave their read-modify-write cycles. The
+// holder's process ID is written to the lock file for LockHolder. On a
+// network filesystem, where flock can hang or only lock out processes on
+// the same machine, nothing is locked; see Unlocked.
+func (s *FileStore) Lock() (func(), error) {
+	if s.Unlocked() {
+		return func() {}, nil
+	}
+	return lockFile(s.LockPath(), s.Waiting)
+}
+
+// lockFile takes an exclusive flock on the file at path, creating it, and
+// records the process ID in it. waiting, if set, is called when another
+// process holds the lock.
+func lockFile(path string, waiting func()) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		if waiting != nil {
+			waiting()
+		}
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock clipboard: %w", err)
+	}
+
+	// the PID is only informational, so failing to record it isn't an error
+	if f.Truncate(0) == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+
+	return func() {
+		f.Truncate(0)
+		unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// Unlocked reports whether the clipboard file is on a network filesystem,
+// where Lock doesn't lock it
+func (s *FileStore) Unlocked() bool {
+	_, ok := fsops.NetworkFS(s.Path)
+	return ok
+}
+
+// LockHolder reports whether another open file holds the clipboard file's
+// lock and, if it was recorded, the process ID of its holder
+func (s *FileStore) LockHolder() (pid int, held bool, err error) {
+	return lockHolder(s.LockPath())
+}
+
+// lockHolder reports whether the lock file at path is held by another open
+// file and, if it was recorded, the process ID of its holder
+func lockHolder(path string) (pid int, held bool, err error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	err = unix.Flock(int(f.Fd()), unix.LOCK_SH|unix.LOCK_NB)
+	if err == nil {
+		unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		return 0, false, nil
+	}
+	if !errors.Is(err, unix.EWOULDBLOCK) {
+		return 0, false, err
+	}
+
+	content, _ := os.ReadFile(path)
+	pid, _ = strconv.Atoi(strings.TrimSpace(string(content)))
+	return pid, true, nil
+}
@@ -0,0 +1,167 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]Store{
+		"file":   NewFileStore(filepath.Join(t.TempDir(), "clipboard.json")),
+		"memory": NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			testStore(t, store)
+		})
+	}
+}
+
+// testStore checks that store keeps entries in order through Append and
+// Write, starting from an empty clipboard
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+
+	board, err := store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 0 {
+		t.Fatalf("Expected an empty clipboard, got %+v", board.Entries)
+	}
+
+	if err := store.Append(Entry{CurrentPath: "/a"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := store.Append(Entry{CurrentPath: "/c"}, Entry{CurrentPath: "/b"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	board, err = store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	var paths []string
+	for _, entry := range board.Entries {
+		paths = append(paths, entry.CurrentPath)
+	}
+	if len(paths) != 3 || paths[0] != "/c" || paths[1] != "/b" || paths[2] != "/a" {
+		t.Fatalf("Expected [/c /b /a], got %v", paths)
+	}
+
+	board.Entries = board.Entries[1:]
+	if err := store.Write(board); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	board, err = store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].CurrentPath != "/b" {
+		t.Errorf("Expected /b on top after Write, got %+v", board.Entries)
+	}
+
+	unlock, err := store.Lock()
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	unlock()
+}
+
+func TestFileStoreLock(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "clipboard.json"))
+
+	unlock, err := store.Lock()
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	// A second open file description can't take the lock while it's held
+	f, err := os.Open(store.LockPath())
+	if err != nil {
+		t.Fatalf("Failed to open lock file: %v", err)
+	}
+	defer f.Close()
+
+	err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if !errors.Is(err, unix.EWOULDBLOCK) {
+		t.Fatalf("Expected lock to be held, got: %v", err)
+	}
+
+	pid, held, err := store.LockHolder()
+	if err != nil {
+		t.Fatalf("LockHolder failed: %v", err)
+	}
+	if !held || pid != os.Getpid() {
+		t.Errorf("Expected the lock to be held by %d, got held=%v pid=%d", os.Getpid(), held, pid)
+	}
+
+	unlock()
+
+	if _, held, _ := store.LockHolder(); held {
+		t.Error("Expected LockHolder to report the lock free after release")
+	}
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		t.Fatalf("Expected lock to be free after release, got: %v", err)
+	}
+}
+
+func TestFileStoreRecovery(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "clipboard.json"))
+
+	if err := store.Append(Entry{CurrentPath: "/a"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := store.Append(Entry{CurrentPath: "/c"}, Entry{CurrentPath: "/b"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// Truncate the file partway through the last entry
+	content, err := os.ReadFile(store.Path)
+	if err != nil {
+		t.Fatalf("Failed to read clipboard file: %v", err)
+	}
+	cut := bytes.LastIndex(content, []byte(`"/a"`))
+	if err := os.WriteFile(store.Path, content[:cut], 0o644); err != nil {
+		t.Fatalf("Failed to truncate clipboard file: %v", err)
+	}
+	if err := store.Verify(); err == nil {
+		t.Fatal("Expected Verify to report the truncated file, got nil")
+	}
+
+	var salvaged int
+	store.Recovered = func(n int, _ error) { salvaged = n }
+	board, err := store.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(board.Entries) != 2 || salvaged != 2 || board.Entries[0].CurrentPath != "/c" {
+		t.Errorf("Expected /c and /b to be salvaged, got %+v (reported %d)", board.Entries, salvaged)
+	}
+
+	// Writing over a corrupted file keeps the last good backup
+	if err := store.Write(board); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := os.WriteFile(store.Path, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("Failed to corrupt clipboard file: %v", err)
+	}
+	board, err = store.Restore()
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	// ...which is the file as it was before the last intact one was replaced
+	if len(board.Entries) != 1 || board.Entries[0].CurrentPath != "/a" {
+		t.Errorf("Expected the backup to hold /a, got %+v", board.Entries)
+	}
+	if err := store.Verify(); err != nil {
+		t.Errorf("Expected the restored file to be intact, got %v", err)
+	}
+}
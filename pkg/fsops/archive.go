@@ -0,0 +1,438 @@
+package fsops
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveFormat is a kind of archive WriteArchive can write
+type ArchiveFormat string
+
+const (
+	// ArchiveTar is an uncompressed tarball
+	ArchiveTar ArchiveFormat = "tar"
+	// ArchiveTarGz is a gzip-compressed tarball
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+	// ArchiveZip is a zip file
+	ArchiveZip ArchiveFormat = "zip"
+)
+
+// ArchiveFormatFor picks the archive format from a file name's extension:
+// .tar, .tar.gz or .tgz, or .zip
+func ArchiveFormatFor(name string) (ArchiveFormat, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, nil
+	}
+	return "", fmt.Errorf("unknown archive type for %s (expected .tar, .tar.gz, .tgz or .zip)", name)
+}
+
+// archiveWriter adds files to an archive being written
+type archiveWriter interface {
+	addDir(name string, info os.FileInfo) error
+	addFile(name string, info os.FileInfo, r io.Reader) error
+	addSymlink(name string, info os.FileInfo, target string) error
+	Close() error
+}
+
+// WriteArchive packs sources into a new archive at dst, each under its base
+// name, leaving out paths matched by opts.Exclude or, with opts.Gitignore, by
+// .gitignore files in the archived directories. The archive is written to a
+// temporary file first, so dst never holds a partial archive.
+func WriteArchive(dst string, format ArchiveFormat, sources []string, opts Options) (err error) {
+	seen := make(map[string]string, len(sources))
+	for _, src := range sources {
+		name := filepath.Base(src)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("%s and %s would have the same name in the archive", other, src)
+		}
+		seen[name] = src
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	var aw archiveWriter
+	switch format {
+	case ArchiveTar:
+		aw = &tarArchive{tw: tar.NewWriter(tmp)}
+	case ArchiveTarGz:
+		gz := gzip.NewWriter(tmp)
+		aw = &tarArchive{tw: tar.NewWriter(gz), gz: gz}
+	case ArchiveZip:
+		aw = &zipArchive{zw: zip.NewWriter(tmp)}
+	default:
+		return fmt.Errorf("unknown archive format %q", format)
+	}
+
+	for _, src := range sources {
+		if err := addToArchive(aw, src, filepath.Base(src), "", opts); err != nil {
+			return err
+		}
+	}
+
+	if err := aw.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// addToArchive adds the file, directory or symlink at src to the archive as
+// name, which is at rel inside an archived directory
+func addToArchive(aw archiveWriter, src, name, rel string, opts Options) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return aw.addSymlink(name, info, target)
+	case info.IsDir():
+		if err := aw.addDir(name, info); err != nil {
+			return err
+		}
+		if opts.Gitignore {
+			if opts.Exclude, err = opts.Exclude.WithGitignore(src, rel); err != nil {
+				return err
+			}
+		}
+		dirEntries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range dirEntries {
+			entryRel := filepath.Join(rel, entry.Name())
+			if opts.Exclude.Excluded(entryRel, entry.IsDir()) {
+				continue
+			}
+			if err := addToArchive(aw, filepath.Join(src, entry.Name()), name+"/"+entry.Name(), entryRel, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	case info.Mode().IsRegular():
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		progress := opts.progress()
+		progress.StartFile(src, info.Size())
+		return aw.addFile(name, info, io.TeeReader(f, progressWriter{w: io.Discard, p: progress}))
+	default:
+		return fmt.Errorf("cannot archive %s: not a regular file, directory or symlink", src)
+	}
+}
+
+// tarArchive writes a tarball, gzip-compressed if gz is set
+type tarArchive struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+func (a *tarArchive) addDir(name string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name + "/"
+	return a.tw.WriteHeader(header)
+}
+
+func (a *tarArchive) addFile(name string, info os.FileInfo, r io.Reader) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(a.tw, r)
+	return err
+}
+
+func (a *tarArchive) addSymlink(name string, info os.FileInfo, target string) error {
+	header, err := tar.FileInfoHeader(info, target)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	return a.tw.WriteHeader(header)
+}
+
+func (a *tarArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	if a.gz != nil {
+		return a.gz.Close()
+	}
+	return nil
+}
+
+// zipArchive writes a zip file
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchive) addDir(name string, info os.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name + "/"
+	_, err = a.zw.CreateHeader(header)
+	return err
+}
+
+func (a *zipArchive) addFile(name string, info os.FileInfo, r io.Reader) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	w, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// addSymlink stores the link target as the entry's contents, as zip tools
+// on Unix do
+func (a *zipArchive) addSymlink(name string, info os.FileInfo, target string) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	w, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, target)
+	return err
+}
+
+func (a *zipArchive) Close() error {
+	return a.zw.Close()
+}
+
+// ExtractArchive unpacks the archive at src into the directory dst, creating
+// it if needed. Entries that would land outside dst, through absolute paths,
+// ".." or symlinks pointing out of it, are refused before anything is
+// written for them.
+func ExtractArchive(src, dst string, format ArchiveFormat) error {
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	dst, err := filepath.EvalSymlinks(dst)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case ArchiveTar, ArchiveTarGz:
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		var r io.Reader = f
+		if format == ArchiveTarGz {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", src, err)
+			}
+			defer gz.Close()
+			r = gz
+		}
+		return extractTar(tar.NewReader(r), dst)
+	case ArchiveZip:
+		zr, err := zip.OpenReader(src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		defer zr.Close()
+		return extractZip(&zr.Reader, dst)
+	}
+	return fmt.Errorf("unknown archive format %q", format)
+}
+
+// extractPath returns where the archive entry name goes inside dst, or an
+// error if it would escape dst
+func extractPath(dst, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("unsafe path in archive: %s", name)
+	}
+	return filepath.Join(dst, clean), nil
+}
+
+// insideDir reports whether path is dst or somewhere under it
+func insideDir(dst, path string) bool {
+	rel, err := filepath.Rel(dst, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkLinkTarget refuses a symlink at path whose target points outside dst
+func checkLinkTarget(dst, path, target string) error {
+	resolved := target
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(path), target)
+	}
+	if !insideDir(dst, filepath.Clean(resolved)) {
+		return fmt.Errorf("unsafe symlink in archive: %s -> %s", path, target)
+	}
+	return nil
+}
+
+// makeParent creates the directory path goes in, making sure symlinks
+// extracted earlier don't lead it outside dst
+func makeParent(dst, path string) error {
+	parent := filepath.Dir(path)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return err
+	}
+	real, err := filepath.EvalSymlinks(parent)
+	if err != nil {
+		return err
+	}
+	if !insideDir(dst, real) {
+		return fmt.Errorf("unsafe path in archive: %s leads outside %s", path, dst)
+	}
+	return nil
+}
+
+// extractTar writes the entries of a tarball into dst
+func extractTar(tr *tar.Reader, dst string) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path, err := extractPath(dst, header.Name)
+		if err != nil {
+			return err
+		}
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = makeParent(dst, path); err == nil {
+				err = os.MkdirAll(path, mode|0o700)
+			}
+		case tar.TypeReg:
+			err = writeExtracted(dst, path, mode, tr)
+		case tar.TypeSymlink:
+			if err = checkLinkTarget(dst, path, header.Linkname); err == nil {
+				err = writeSymlink(dst, path, header.Linkname)
+			}
+		default:
+			// hard links, devices and the like are left out
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip writes the entries of a zip file into dst
+func extractZip(zr *zip.Reader, dst string) error {
+	for _, file := range zr.File {
+		path, err := extractPath(dst, file.Name)
+		if err != nil {
+			return err
+		}
+		mode := file.Mode()
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		switch {
+		case mode.IsDir():
+			if err = makeParent(dst, path); err == nil {
+				err = os.MkdirAll(path, mode.Perm()|0o700)
+			}
+		case mode&os.ModeSymlink != 0:
+			var target []byte
+			if target, err = io.ReadAll(io.LimitReader(rc, 4096)); err == nil {
+				if err = checkLinkTarget(dst, path, string(target)); err == nil {
+					err = writeSymlink(dst, path, string(target))
+				}
+			}
+		case mode.IsRegular():
+			err = writeExtracted(dst, path, mode.Perm(), rc)
+		}
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExtracted writes a regular file from an archive, refusing to follow
+// a symlink already at path
+func writeExtracted(dst, path string, mode os.FileMode, r io.Reader) error {
+	if err := makeParent(dst, path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeSymlink creates a symlink from an archive
+func writeSymlink(dst, path, target string) error {
+	if err := makeParent(dst, path); err != nil {
+		return err
+	}
+	return os.Symlink(target, path)
+}
@@ -0,0 +1,166 @@
+package fsops
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// tarNames lists the entries of a tarball, gunzipping it if compressed
+func tarNames(t *testing.T, path string, compressed bool) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("Failed to gunzip archive: %v", err)
+		}
+		r = gz
+	}
+
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWriteArchive(t *testing.T) {
+	tempDir := setupTree(t)
+	sources := []string{filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "config")}
+	expected := "config/ config/config.ini config/settings.json file1.txt"
+
+	for _, tt := range []struct {
+		name       string
+		compressed bool
+	}{
+		{"out.tar", false},
+		{"out.tar.gz", true},
+	} {
+		dst := filepath.Join(tempDir, tt.name)
+		format, err := ArchiveFormatFor(dst)
+		if err != nil {
+			t.Fatalf("ArchiveFormatFor failed: %v", err)
+		}
+		if err := WriteArchive(dst, format, sources, Options{}); err != nil {
+			t.Fatalf("WriteArchive(%s) failed: %v", tt.name, err)
+		}
+		if got := strings.Join(tarNames(t, dst, tt.compressed), " "); got != expected {
+			t.Errorf("Expected %s to hold %q, got %q", tt.name, expected, got)
+		}
+	}
+
+	// Excluded paths are left out
+	dst := filepath.Join(tempDir, "out.zip")
+	exclude, _ := ParseExcludes([]string{"*.ini"})
+	if err := WriteArchive(dst, ArchiveZip, sources, Options{Exclude: exclude}); err != nil {
+		t.Fatalf("WriteArchive(zip) failed: %v", err)
+	}
+	zr, err := zip.OpenReader(dst)
+	if err != nil {
+		t.Fatalf("Failed to open zip: %v", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, " "); got != "config/ config/settings.json file1.txt" {
+		t.Errorf("Unexpected zip contents: %q", got)
+	}
+
+	if _, err := ArchiveFormatFor("out.rar"); err == nil {
+		t.Error("Expected error for an unknown archive type, got nil")
+	}
+	clash := []string{filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "nested", "file1.txt")}
+	if err := WriteArchive(filepath.Join(tempDir, "clash.tar"), ArchiveTar, clash, Options{}); err == nil {
+		t.Error("Expected error for two sources with the same name, got nil")
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	tempDir := setupTree(t)
+	sources := []string{filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "config")}
+
+	for _, name := range []string{"out.tar", "out.tar.gz", "out.zip"} {
+		src := filepath.Join(tempDir, name)
+		format, _ := ArchiveFormatFor(src)
+		if err := WriteArchive(src, format, sources, Options{}); err != nil {
+			t.Fatalf("WriteArchive(%s) failed: %v", name, err)
+		}
+
+		dst := filepath.Join(tempDir, "extracted-"+name)
+		if err := ExtractArchive(src, dst, format); err != nil {
+			t.Fatalf("ExtractArchive(%s) failed: %v", name, err)
+		}
+		for _, path := range []string{"file1.txt", "config/settings.json"} {
+			original, _ := os.ReadFile(filepath.Join(tempDir, path))
+			extracted, err := os.ReadFile(filepath.Join(dst, path))
+			if err != nil || string(extracted) != string(original) {
+				t.Errorf("Expected %s from %s to hold %q, got %q (%v)", path, name, original, extracted, err)
+			}
+		}
+	}
+}
+
+func TestExtractArchiveUnsafe(t *testing.T) {
+	tempDir := t.TempDir()
+
+	for _, tt := range []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{"dotdot", []*tar.Header{{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0o644}}},
+		{"absolute", []*tar.Header{{Name: "/tmp/evil.txt", Typeflag: tar.TypeReg, Mode: 0o644}}},
+		{"symlink", []*tar.Header{{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../.."}}},
+		{"through-symlink", []*tar.Header{
+			{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "up/../../evil.txt", Typeflag: tar.TypeReg, Mode: 0o644},
+		}},
+	} {
+		src := filepath.Join(tempDir, tt.name+".tar")
+		f, err := os.Create(src)
+		if err != nil {
+			t.Fatalf("Failed to create archive: %v", err)
+		}
+		tw := tar.NewWriter(f)
+		for _, header := range tt.headers {
+			if err := tw.WriteHeader(header); err != nil {
+				t.Fatalf("Failed to write header: %v", err)
+			}
+		}
+		tw.Close()
+		f.Close()
+
+		dst := filepath.Join(tempDir, "out", tt.name)
+		if err := ExtractArchive(src, dst, ArchiveTar); err == nil {
+			t.Errorf("Expected error extracting %s archive, got nil", tt.name)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, "evil.txt")); err == nil {
+			t.Errorf("Expected %s archive not to write outside the destination", tt.name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package fsops
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Attrs selects which file attributes a copy carries over from its source
+type Attrs struct {
+	Mode       bool
+	Timestamps bool
+	Ownership  bool
+}
+
+// AllAttrs is what a move across filesystems keeps, matching a rename
+var AllAttrs = Attrs{Mode: true, Timestamps: true, Ownership: true}
+
+// AttrNames lists the names accepted by ParseAttrs
+var AttrNames = []string{"mode", "timestamps", "ownership", "all"}
+
+// ParseAttrs parses a list of attribute names such as "mode" or "all"
+func ParseAttrs(names []string) (Attrs, error) {
+	var a Attrs
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "mode":
+			a.Mode = true
+		case "timestamps":
+			a.Timestamps = true
+		case "ownership":
+			a.Ownership = true
+		case "all":
+			a = AllAttrs
+		case "":
+		default:
+			return a, fmt.Errorf("invalid attribute %q (expected one of: %s)", name, strings.Join(AttrNames, ", "))
+		}
+	}
+	return a, nil
+}
+
+// ApplyAttrs copies the selected attributes of src onto dst. Ownership is
+// only carried over when running as root, since nobody else can give files
+// away; like cp, other users silently keep ownership of their copies.
+func ApplyAttrs(src, dst string, srcInfo os.FileInfo, a Attrs) error {
+	isLink := srcInfo.Mode()&os.ModeSymlink != 0
+
+	var st unix.Stat_t
+	if a.Timestamps || a.Ownership {
+		if err := unix.Lstat(src, &st); err != nil {
+			return err
+		}
+	}
+
+	if a.Ownership && os.Geteuid() == 0 {
+		if err := os.Lchown(dst, int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+	}
+
+	// symlink permissions are meaningless on most systems and can't be changed
+	if a.Mode && !isLink {
+		if err := os.Chmod(dst, srcInfo.Mode().Perm()|srcInfo.Mode()&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return err
+		}
+	}
+
+	if a.Timestamps {
+		atime := time.Unix(st.Atim.Unix())
+		times := []unix.Timespec{
+			unix.NsecToTimespec(atime.UnixNano()),
+			unix.NsecToTimespec(srcInfo.ModTime().UnixNano()),
+		}
+		if err := unix.UtimesNanoAt(unix.AT_FDCWD, dst, times, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
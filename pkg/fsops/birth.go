@@ -0,0 +1,10 @@
+package fsops
+
+import "time"
+
+// BirthTime returns when the file at path, not what a symlink there points
+// to, was created, if its filesystem records that. Unlike its inode, a file
+// made after another was deleted never gets the same birth time.
+func BirthTime(path string) (time.Time, bool) {
+	return birthTime(path)
+}
@@ -0,0 +1,16 @@
+package fsops
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// birthTime reads the birth time lstat gives on macOS
+func birthTime(path string) (time.Time, bool) {
+	var st unix.Stat_t
+	if unix.Lstat(path, &st) != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Btim.Unix()), true
+}
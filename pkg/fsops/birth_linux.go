@@ -0,0 +1,17 @@
+package fsops
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// birthTime reads the birth time with statx, which some filesystems, like
+// tmpfs on older kernels, leave out
+func birthTime(path string) (time.Time, bool) {
+	var st unix.Statx_t
+	if unix.Statx(unix.AT_FDCWD, path, unix.AT_SYMLINK_NOFOLLOW, unix.STATX_BTIME, &st) != nil || st.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Btime.Sec, int64(st.Btime.Nsec)), true
+}
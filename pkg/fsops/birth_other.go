@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package fsops
+
+import "time"
+
+// birthTime can't read birth times on this platform
+func birthTime(_ string) (time.Time, bool) {
+	return time.Time{}, false
+}
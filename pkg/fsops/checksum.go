@@ -0,0 +1,145 @@
+package fsops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Checksum returns a sha256 checksum of the file, symlink or directory tree
+// at path. A tree's checksum covers every relative path, its type, and the
+// contents of its files or the targets of its links.
+func Checksum(path string) (string, error) {
+	h := sha256.New()
+
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			fmt.Fprintf(h, "dir %s\n", rel)
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "link %s %s\n", rel, target)
+		case info.Mode()&specialTypes != 0:
+			// FIFOs and devices have no contents of their own to compare
+			fmt.Fprintf(h, "special %s %s\n", rel, info.Mode().Type())
+		default:
+			fileSum, err := checksumFile(p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "file %s %s\n", rel, fileSum)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fingerprintBlock is how much of each end of a file Fingerprint reads
+const fingerprintBlock = 64 << 10
+
+// Fingerprint returns a quick fingerprint of the regular file at path: an
+// xxHash64 of its size and its first and last blocks. Unlike Checksum it
+// takes the same time whatever the file's size, so it can be taken of every
+// file cut, but files differing only in the middle share it.
+func Fingerprint(path string) (string, error) {
+	return fingerprint(path, "xxh64", xxhash.New())
+}
+
+// MatchFingerprint reports whether the file at path has the given
+// fingerprint, taken the way its prefix names: by Fingerprint, or by the
+// FNV-1a hash older versions used
+func MatchFingerprint(path, fp string) (bool, error) {
+	algorithm, _, _ := strings.Cut(fp, ":")
+	var got string
+	var err error
+	switch algorithm {
+	case "xxh64":
+		got, err = Fingerprint(path)
+	case "fnv1a":
+		got, err = fingerprint(path, algorithm, fnv.New64a())
+	default:
+		return false, fmt.Errorf("unknown fingerprint %q", fp)
+	}
+	return err == nil && got == fp, err
+}
+
+// fingerprint hashes a file's size and its first and last blocks with h,
+// naming the algorithm in the result
+func fingerprint(path, algorithm string, h hash.Hash64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(h, "%d\n", info.Size())
+	if _, err := io.CopyN(h, file, fingerprintBlock); err != nil && err != io.EOF {
+		return "", err
+	}
+	// what's left is the last block, unless the middle is skipped first
+	if info.Size() > 2*fingerprintBlock {
+		if _, err := file.Seek(-fingerprintBlock, io.SeekEnd); err != nil {
+			return "", err
+		}
+	}
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%016x", algorithm, h.Sum64()), nil
+}
+
+// checksumFile returns the hex sha256 of a regular file's contents
+func checksumFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChecksum checks that dst has the checksum expected of its source src
+func VerifyChecksum(src, dst, expected string) error {
+	actual, err := Checksum(dst)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch: %s is %s but %s is %s", src, expected, dst, actual)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package fsops
+
+import (
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "config")
+	dst := filepath.Join(tempDir, "config-copy")
+	if err := CopyDir(src, dst, Options{}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	srcSum, err := Checksum(src)
+	if err != nil {
+		t.Fatalf("Checksum failed: %v", err)
+	}
+	dstSum, err := Checksum(dst)
+	if err != nil {
+		t.Fatalf("Checksum failed: %v", err)
+	}
+	if srcSum != dstSum {
+		t.Errorf("Expected identical trees to have the same checksum, got %s and %s", srcSum, dstSum)
+	}
+
+	// Same size, different contents
+	if err := os.WriteFile(filepath.Join(dst, "config.ini"), []byte("key=VALUE"), 0o644); err != nil {
+		t.Fatalf("Failed to modify copy: %v", err)
+	}
+	if err := VerifyChecksum(src, dst, srcSum); err == nil {
+		t.Error("Expected VerifyChecksum to detect changed contents, got nil")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tempDir := t.TempDir()
+
+	data := make([]byte, 3*fingerprintBlock)
+	write := func(name string, data []byte) string {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		return path
+	}
+	fingerprint := func(path string) string {
+		fp, err := Fingerprint(path)
+		if err != nil {
+			t.Fatalf("Fingerprint failed: %v", err)
+		}
+		return fp
+	}
+
+	original := fingerprint(write("a", data))
+	if fingerprint(write("b", data)) != original {
+		t.Error("Expected files with the same content to share a fingerprint")
+	}
+
+	// only the ends are read, so a change in the middle goes unnoticed
+	data[len(data)/2] = 1
+	if fingerprint(write("c", data)) != original {
+		t.Error("Expected a change in the middle not to affect the fingerprint")
+	}
+	data[len(data)-1] = 1
+	if fingerprint(write("d", data)) == original {
+		t.Error("Expected a change in the last block to change the fingerprint")
+	}
+	if fingerprint(write("e", data[:10])) == fingerprint(write("f", data[:11])) {
+		t.Error("Expected files of different sizes to differ")
+	}
+}
+
+func TestMatchFingerprint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a")
+	if err := os.WriteFile(path, []byte("some content"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	current, err := Fingerprint(path)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	// entries cut by older versions have FNV-1a fingerprints
+	legacy, err := fingerprint(path, "fnv1a", fnv.New64a())
+	if err != nil {
+		t.Fatalf("fingerprint failed: %v", err)
+	}
+	for _, fp := range []string{current, legacy} {
+		if ok, err := MatchFingerprint(path, fp); err != nil || !ok {
+			t.Errorf("Expected %s to match, got %t, %v", fp, ok, err)
+		}
+	}
+	if ok, _ := MatchFingerprint(path, "xxh64:0000000000000000"); ok {
+		t.Error("Expected a different fingerprint not to match")
+	}
+	if _, err := MatchFingerprint(path, "md5:abc"); err == nil {
+		t.Error("Expected an unknown algorithm to be an error")
+	}
+}
@@ -0,0 +1,13 @@
+package fsops
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile makes dst a copy-on-write clone of src with fclonefileat, which
+// APFS supports. It fails if dst already exists, or on other filesystems.
+func cloneFile(src *os.File, dst string, _ os.FileMode) error {
+	return unix.Fclonefileat(int(src.Fd()), unix.AT_FDCWD, dst, 0)
+}
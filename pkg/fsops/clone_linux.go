@@ -0,0 +1,24 @@
+package fsops
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile makes dst a copy-on-write clone of src with the FICLONE ioctl,
+// which Btrfs and XFS support. It fails on filesystems that don't, or when
+// src and dst are on different filesystems.
+func cloneFile(src *os.File, dst string, mode os.FileMode) error {
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if err := unix.IoctlFileClone(int(dstFile.Fd()), int(src.Fd())); err != nil {
+		dstFile.Close()
+		return err
+	}
+
+	return dstFile.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package fsops
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile is unsupported on this platform, so copies always fall back to
+// copying the data
+func cloneFile(_ *os.File, _ string, _ os.FileMode) error {
+	return errors.ErrUnsupported
+}
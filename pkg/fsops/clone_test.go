@@ -0,0 +1,30 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyFileReflink(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "file1.txt")
+
+	// Whether or not the filesystem supports clones, the result must match
+	for _, noReflink := range []bool{false, true} {
+		dst := filepath.Join(tempDir, "copy.txt")
+		if err := CopyFile(src, dst, Options{NoReflink: noReflink}); err != nil {
+			t.Fatalf("CopyFile(NoReflink=%v) failed: %v", noReflink, err)
+		}
+
+		content, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatalf("Failed to read copy: %v", err)
+		}
+		if string(content) != "This is file 1" {
+			t.Errorf("Expected copied content %q, got %q", "This is file 1", content)
+		}
+		os.Remove(dst)
+	}
+}
@@ -0,0 +1,127 @@
+package fsops
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Drift is one way a copy no longer matches its source
+type Drift struct {
+	// Path is where the difference is, relative to the trees compared, or
+	// "." for the copy itself
+	Path string
+	// Kind says what differs: DriftMissing, DriftExtra, DriftType,
+	// DriftSize, DriftContent or DriftTarget
+	Kind string
+	// SrcSize and DstSize are the sizes of the two files under DriftSize
+	SrcSize, DstSize int64
+}
+
+// the kinds of Drift
+const (
+	DriftMissing = "missing"
+	DriftExtra   = "extra"
+	DriftType    = "type"
+	DriftSize    = "size"
+	DriftContent = "content"
+	DriftTarget  = "target"
+)
+
+// CompareTrees reports how the copy dst has drifted from src, in two
+// phases: first both trees are walked and their shapes, file sizes and link
+// targets compared, which is quick; then only files whose sizes still match
+// are read and compared by checksum. Drift is sorted by path.
+func CompareTrees(src, dst string) ([]Drift, error) {
+	srcFiles, err := walkTree(src)
+	if err != nil {
+		return nil, err
+	}
+	dstFiles, err := walkTree(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []Drift
+	var sameSize []string
+	for rel, srcInfo := range srcFiles {
+		dstInfo, ok := dstFiles[rel]
+		switch {
+		case !ok:
+			if !inMissingDir(rel, dstFiles) {
+				drift = append(drift, Drift{Path: rel, Kind: DriftMissing})
+			}
+		case srcInfo.Mode().Type() != dstInfo.Mode().Type():
+			drift = append(drift, Drift{Path: rel, Kind: DriftType})
+		case srcInfo.Mode()&os.ModeSymlink != 0:
+			srcTarget, srcErr := os.Readlink(filepath.Join(src, rel))
+			dstTarget, dstErr := os.Readlink(filepath.Join(dst, rel))
+			if srcErr != nil || dstErr != nil || srcTarget != dstTarget {
+				drift = append(drift, Drift{Path: rel, Kind: DriftTarget})
+			}
+		case srcInfo.Mode().IsRegular() && srcInfo.Size() != dstInfo.Size():
+			drift = append(drift, Drift{Path: rel, Kind: DriftSize, SrcSize: srcInfo.Size(), DstSize: dstInfo.Size()})
+		case srcInfo.Mode().IsRegular():
+			sameSize = append(sameSize, rel)
+		}
+	}
+	for rel := range dstFiles {
+		if _, ok := srcFiles[rel]; !ok {
+			if !inMissingDir(rel, srcFiles) {
+				drift = append(drift, Drift{Path: rel, Kind: DriftExtra})
+			}
+		}
+	}
+
+	for _, rel := range sameSize {
+		srcSum, err := checksumFile(filepath.Join(src, rel))
+		if err != nil {
+			return nil, err
+		}
+		dstSum, err := checksumFile(filepath.Join(dst, rel))
+		if err != nil {
+			return nil, err
+		}
+		if srcSum != dstSum {
+			drift = append(drift, Drift{Path: rel, Kind: DriftContent})
+		}
+	}
+
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Path < drift[j].Path })
+	return drift, nil
+}
+
+// walkTree describes everything in the tree at root by its relative path,
+// with "." for root itself
+func walkTree(root string) (map[string]os.FileInfo, error) {
+	files := make(map[string]os.FileInfo)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[rel] = info
+		return nil
+	})
+	return files, err
+}
+
+// inMissingDir reports whether a directory above rel isn't a directory in
+// files, so drift inside a missing directory is reported once, for the
+// directory
+func inMissingDir(rel string, files map[string]os.FileInfo) bool {
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		if info, ok := files[dir]; !ok || !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompareTrees(t *testing.T) {
+	tempDir := setupTree(t)
+	src := filepath.Join(tempDir, "config")
+	dst := filepath.Join(tempDir, "copy")
+	if err := CopyDir(src, dst, Options{}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	if drift, err := CompareTrees(src, dst); err != nil || len(drift) != 0 {
+		t.Fatalf("Expected no drift in a fresh copy, got %+v (%v)", drift, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dst, "config.ini"), []byte("key=VALUE"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "settings.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dst, "extra", "deep"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "new.txt"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	drift, err := CompareTrees(src, dst)
+	if err != nil {
+		t.Fatalf("CompareTrees failed: %v", err)
+	}
+	expected := []Drift{
+		{Path: "config.ini", Kind: DriftContent},
+		{Path: "extra", Kind: DriftExtra},
+		{Path: "new.txt", Kind: DriftMissing},
+		{Path: "settings.json", Kind: DriftSize, SrcSize: 20, DstSize: 2},
+	}
+	if !reflect.DeepEqual(drift, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, drift)
+	}
+}
@@ -0,0 +1,581 @@
+// Package fsops implements the file operations behind cx: copying and moving
+// files, directories and symlinks, with attribute preservation, exclude
+// patterns, copy-on-write clones and in-kernel copies where available.
+package fsops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Progress is told about the data being copied, e.g. to draw a progress bar
+type Progress interface {
+	// StartFile is called before a file of the given size is copied
+	StartFile(name string, size int64)
+	// Add is called as each chunk of the current file is copied
+	Add(n int64)
+}
+
+// Options controls how files are copied
+type Options struct {
+	// Preserve selects the attributes copies keep from their source
+	Preserve Attrs
+	// Exclude leaves matching paths out of directory copies
+	Exclude Rules
+	// Gitignore also leaves out paths ignored by .gitignore files in the
+	// copied directories
+	Gitignore bool
+	// NoReflink always copies file data instead of cloning files on
+	// filesystems with copy-on-write support
+	NoReflink bool
+	// Progress, if set, is told about the data being copied
+	Progress Progress
+	// Symlinks decides what happens to symlinks
+	Symlinks SymlinkPolicy
+	// Hardlink makes copies of files hard links to the source instead,
+	// which only works within one filesystem
+	Hardlink bool
+	// Devices recreates device nodes, which only root can do; otherwise
+	// they're skipped
+	Devices bool
+	// Warn, if set, is told about files left out of a copy, like sockets
+	Warn func(message string)
+	// Manifest, if set, records the files a directory copy completes, and
+	// lets it skip those an interrupted copy already did
+	Manifest *Manifest
+	// Context, if set, stops a copy when it's canceled, between files and
+	// between chunks of a file; the file being copied is removed
+	Context context.Context
+	// Limit, if set, throttles copying file data to its rate
+	Limit *Limiter
+	// Update copies into an existing destination, leaving files that already
+	// have the source's size and modification time, and replacing the rest,
+	// so repeating a copy only copies what changed
+	Update bool
+	// Checksum, with Update, compares files by their contents instead of
+	// their size and modification time
+	Checksum bool
+
+	// links maps files with several hard links, by device and inode, to
+	// their first copy while a directory is copied, so the rest are linked
+	// to it like cp -a does
+	links map[fileID]string
+}
+
+// fileID identifies a file by device and inode
+type fileID struct {
+	dev, ino uint64
+}
+
+// SymlinkPolicy decides how symlinks are copied and moved
+type SymlinkPolicy string
+
+const (
+	// SymlinksKeep pastes a symlink as a symlink with the same target, while
+	// symlinks inside copied directories are copied as the files they point to
+	SymlinksKeep SymlinkPolicy = ""
+	// SymlinksFollow copies what symlinks point to instead of the links,
+	// including symlinks inside copied directories. A moved symlink is
+	// replaced by a copy of its target.
+	SymlinksFollow SymlinkPolicy = "follow"
+	// SymlinksRewriteRelative keeps symlinks as symlinks, adjusting relative
+	// targets that point outside the pasted tree so they still resolve from
+	// the new location
+	SymlinksRewriteRelative SymlinkPolicy = "rewrite-relative"
+)
+
+// noProgress is the Progress used when none is set
+type noProgress struct{}
+
+func (noProgress) StartFile(string, int64) {}
+func (noProgress) Add(int64)               {}
+
+// canceled returns the error of opts.Context once it's canceled
+func (opts Options) canceled() error {
+	if opts.Context == nil {
+		return nil
+	}
+	return opts.Context.Err()
+}
+
+// progress returns opts.Progress, or a Progress that ignores everything
+func (opts Options) progress() Progress {
+	if opts.Progress == nil {
+		return noProgress{}
+	}
+	return opts.Progress
+}
+
+// Copy copies the file, directory or symlink at src, described by srcInfo,
+// to dst
+func Copy(src, dst string, srcInfo os.FileInfo, opts Options) error {
+	if srcInfo.IsDir() {
+		return CopyDir(src, dst, opts)
+	} else if srcInfo.Mode()&os.ModeSymlink != 0 {
+		if opts.Symlinks == SymlinksFollow {
+			targetInfo, err := os.Stat(src)
+			if err != nil {
+				return fmt.Errorf("cannot follow symlink %s: %w", src, err)
+			}
+			return Copy(src, dst, targetInfo, opts)
+		}
+		return copySymlinkAt(src, dst, "", opts)
+	} else if srcInfo.Mode()&specialTypes != 0 {
+		return copySpecial(src, dst, srcInfo, opts)
+	}
+	return CopyFile(src, dst, opts)
+}
+
+// ErrCrossDevice is returned when a move to another filesystem fails partway,
+// or a hard link is asked for across filesystems
+var ErrCrossDevice = errors.New("destination is on another filesystem")
+
+// rename is os.Rename, replaceable in tests to simulate cross-device moves
+var rename = os.Rename
+
+// Move moves src to dst. Renaming across filesystems fails with EXDEV, in
+// which case src is copied, the copy verified, and only then src removed.
+// The copy is verified by its shape and sizes, or if either side is on a
+// network filesystem, by checksums as well.
+func Move(src, dst string, opts Options) error {
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if opts.Symlinks == SymlinksFollow && srcInfo.Mode()&os.ModeSymlink != 0 {
+		if err := Copy(src, dst, srcInfo, opts); err != nil {
+			os.RemoveAll(dst)
+			return err
+		}
+		return os.Remove(src)
+	}
+
+	err = rename(src, dst)
+	if err == nil && opts.Symlinks == SymlinksRewriteRelative {
+		return rewriteMovedLinks(src, dst)
+	}
+	if !errors.Is(err, unix.EXDEV) {
+		return err
+	}
+
+	// a move must carry everything, since the source is deleted afterwards
+	opts.Preserve = AllAttrs
+	opts.Devices = true
+	opts.Hardlink = false
+	opts.Exclude = nil
+	opts.Gitignore = false
+	if err := Copy(src, dst, srcInfo, opts); err != nil {
+		os.RemoveAll(dst)
+		return fmt.Errorf("%w, moving %s failed: %w", ErrCrossDevice, src, err)
+	}
+
+	// over the network, the copy is checked down to its contents
+	verify := VerifyTree
+	if _, ok := NetworkFS(src); ok {
+		verify = verifyContents
+	} else if _, ok := NetworkFS(dst); ok {
+		verify = verifyContents
+	}
+	if err := verify(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return fmt.Errorf("%w, moving %s failed: %w", ErrCrossDevice, src, err)
+	}
+
+	return os.RemoveAll(src)
+}
+
+// VerifyTree checks that dst has the same tree shape and file sizes as src
+func VerifyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, srcInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		dstPath := filepath.Join(dst, rel)
+		dstInfo, err := os.Lstat(dstPath)
+		if err != nil {
+			return fmt.Errorf("copy is missing %s", dstPath)
+		}
+
+		if srcInfo.Mode().Type() != dstInfo.Mode().Type() {
+			return fmt.Errorf("copy of %s has a different type", path)
+		}
+
+		if srcInfo.Mode().IsRegular() && srcInfo.Size() != dstInfo.Size() {
+			return fmt.Errorf("copy of %s has size %d, expected %d", path, dstInfo.Size(), srcInfo.Size())
+		}
+
+		return nil
+	})
+}
+
+// CopyDir recursively copies a directory, leaving out anything matched by
+// opts.Exclude or, with opts.Gitignore, by the .gitignore files it contains
+func CopyDir(src, dst string, opts Options) error {
+	opts.links = make(map[fileID]string)
+	if opts.Manifest != nil {
+		if err := opts.Manifest.begin(dst); err != nil {
+			return err
+		}
+	}
+	return copySubdir(src, dst, "", opts)
+}
+
+// copySubdir copies the directory at rel inside the tree being copied
+func copySubdir(src, dst, rel string, opts Options) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if opts.Update {
+		if err := clearForUpdate(dst, true); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+		return err
+	}
+
+	dirEntries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	if opts.Gitignore {
+		if opts.Exclude, err = opts.Exclude.WithGitignore(src, rel); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range dirEntries {
+		if err := opts.canceled(); err != nil {
+			return err
+		}
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+		entryRel := filepath.Join(rel, entry.Name())
+		if opts.Exclude.Excluded(entryRel, entry.IsDir()) {
+			continue
+		}
+		if opts.Manifest != nil && !entry.IsDir() {
+			done, err := opts.Manifest.resume(srcPath, dstPath, entryRel, opts.progress())
+			if err != nil {
+				return err
+			}
+			if done {
+				continue
+			}
+		}
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			switch opts.Symlinks {
+			case SymlinksFollow:
+				targetInfo, err := os.Stat(srcPath)
+				if err != nil {
+					return fmt.Errorf("cannot follow symlink %s: %w", srcPath, err)
+				}
+				isDir = targetInfo.IsDir()
+				if targetInfo.Mode()&specialTypes != 0 {
+					if err := copySpecial(srcPath, dstPath, targetInfo, opts); err != nil {
+						return err
+					}
+					continue
+				}
+			case SymlinksRewriteRelative:
+				if err := copySymlinkAt(srcPath, dstPath, entryRel, opts); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if isDir {
+			if err := copySubdir(srcPath, dstPath, entryRel, opts); err != nil {
+				return err
+			}
+		} else if entry.Type()&specialTypes != 0 {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if err := copySpecial(srcPath, dstPath, info, opts); err != nil {
+				return err
+			}
+		} else {
+			if err := copyLinkedFile(srcPath, dstPath, opts); err != nil {
+				return err
+			}
+			if opts.Manifest != nil {
+				if err := opts.Manifest.complete(entryRel, srcPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// applied last, since filling the directory updates its mtime
+	return ApplyAttrs(src, dst, srcInfo, opts.Preserve)
+}
+
+// copyLinkedFile copies a file inside a directory being copied. A file with
+// other hard links that has already been copied is linked to its copy
+// instead, keeping the links between files in the tree.
+func copyLinkedFile(src, dst string, opts Options) error {
+	var st unix.Stat_t
+	if opts.Hardlink || opts.links == nil || unix.Stat(src, &st) != nil || st.Nlink < 2 {
+		return CopyFile(src, dst, opts)
+	}
+
+	id := fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}
+	if first, ok := opts.links[id]; ok {
+		if err := os.Link(first, dst); err != nil {
+			return err
+		}
+		// counted like a copy, so progress still adds up to the tree's size
+		opts.progress().StartFile(src, st.Size)
+		opts.progress().Add(st.Size)
+		return nil
+	}
+	if err := CopyFile(src, dst, opts); err != nil {
+		return err
+	}
+	opts.links[id] = dst
+	return nil
+}
+
+// CopyFile copies a single file, as an instant copy-on-write clone where the
+// filesystem supports it unless opts.NoReflink is set
+func CopyFile(src, dst string, opts Options) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	progress := opts.progress()
+
+	if opts.Update {
+		if upToDate(src, dst, srcInfo, opts) {
+			// counted like a copy, so progress still adds up to the size
+			progress.StartFile(src, srcInfo.Size())
+			progress.Add(srcInfo.Size())
+			return nil
+		}
+		if err := clearForUpdate(dst, false); err != nil {
+			return err
+		}
+	}
+
+	if opts.Hardlink {
+		if err := os.Link(src, dst); err != nil {
+			if errors.Is(err, unix.EXDEV) {
+				return fmt.Errorf("cannot hard link %s: %w", src, ErrCrossDevice)
+			}
+			return err
+		}
+		progress.StartFile(src, srcInfo.Size())
+		progress.Add(srcInfo.Size())
+		return nil
+	}
+
+	if !opts.NoReflink && cloneFile(srcFile, dst, srcInfo.Mode()) == nil {
+		progress.StartFile(src, srcInfo.Size())
+		progress.Add(srcInfo.Size())
+		return ApplyAttrs(src, dst, srcInfo, opts.Preserve)
+	}
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
+	if err != nil {
+		return err
+	}
+
+	progress.StartFile(src, srcInfo.Size())
+	// a throttled copy goes through userspace, in chunks small enough to
+	// pace; copy_file_range copies too much at once
+	copied := false
+	if opts.Limit == nil {
+		copied, err = copyFileRange(dstFile, srcFile, progress, opts.canceled)
+	}
+	if err == nil && !copied {
+		_, err = io.Copy(progressWriter{w: dstFile, p: progress, ctx: opts.Context, limit: opts.Limit}, srcFile)
+	}
+	if err != nil {
+		// don't leave a partly written file behind
+		dstFile.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	if err := dstFile.Close(); err != nil {
+		return err
+	}
+
+	return ApplyAttrs(src, dst, srcInfo, opts.Preserve)
+}
+
+// CopySymlink recreates a symlink with the same target, or with
+// SymlinksRewriteRelative, a relative target adjusted for where dst is
+func CopySymlink(src, dst string, opts Options) error {
+	return copySymlinkAt(src, dst, "", opts)
+}
+
+// copySymlinkAt copies the symlink at rel inside the tree being copied, or
+// the symlink being pasted itself if rel is empty
+func copySymlinkAt(src, dst, rel string, opts Options) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if opts.Symlinks == SymlinksRewriteRelative {
+		target = rewriteTarget(src, dst, rel, target)
+	}
+
+	if opts.Update {
+		if err := clearForUpdate(dst, false); err != nil {
+			return err
+		}
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return err
+	}
+
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	return ApplyAttrs(src, dst, srcInfo, opts.Preserve)
+}
+
+// rewriteTarget returns the target a symlink copied or moved from src to dst
+// needs to keep pointing at the same place. Absolute targets, and relative
+// ones that stay inside the pasted tree when the link is at rel in it, need
+// no change.
+func rewriteTarget(src, dst, rel, target string) string {
+	if filepath.IsAbs(target) {
+		return target
+	}
+	if rel != "" {
+		within := filepath.Join(filepath.Dir(rel), target)
+		if within != ".." && !strings.HasPrefix(within, ".."+string(filepath.Separator)) {
+			return target
+		}
+	}
+
+	resolved := filepath.Join(filepath.Dir(src), target)
+	rewritten, err := filepath.Rel(filepath.Dir(dst), resolved)
+	if err != nil {
+		return resolved
+	}
+	return rewritten
+}
+
+// rewriteMovedLinks adjusts the relative symlinks renamed from src to dst,
+// the pasted path itself or those inside it, so they still resolve
+func rewriteMovedLinks(src, dst string) error {
+	return filepath.WalkDir(dst, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&os.ModeSymlink == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dst, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			rel = ""
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		rewritten := rewriteTarget(filepath.Join(src, rel), path, rel, target)
+		if rewritten == target {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		return os.Symlink(rewritten, path)
+	})
+}
+
+// progressWriter counts bytes written through it towards a Progress. It
+// stops once ctx is canceled, and paces writes to limit if set.
+type progressWriter struct {
+	w     io.Writer
+	p     Progress
+	ctx   context.Context
+	limit *Limiter
+}
+
+func (pw progressWriter) Write(b []byte) (int, error) {
+	if pw.ctx != nil && pw.ctx.Err() != nil {
+		return 0, pw.ctx.Err()
+	}
+	if pw.limit != nil {
+		if err := pw.limit.wait(pw.ctx, len(b)); err != nil {
+			return 0, err
+		}
+	}
+	n, err := pw.w.Write(b)
+	pw.p.Add(int64(n))
+	return n, err
+}
+
+// TreeSize returns the total size of the regular files under path
+func TreeSize(path string) (int64, error) {
+	var total int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// SameDevice reports whether two paths live on the same filesystem, so that
+// one can be renamed onto the other
+func SameDevice(a, b string) bool {
+	var stA, stB unix.Stat_t
+	if unix.Lstat(a, &stA) != nil || unix.Stat(b, &stB) != nil {
+		return true
+	}
+	return stA.Dev == stB.Dev
+}
+
+// FreeSpace returns how many bytes unprivileged users can still write to the
+// filesystem holding path
+func FreeSpace(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,273 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// setupTree creates a temporary directory with a few test files and
+// directories, returning its path
+func setupTree(t *testing.T) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"file1.txt":            "This is file 1",
+		"nested/file3.txt":     "This is a nested file",
+		"config/settings.json": `{"setting": "value"}`,
+		"config/config.ini":    "key=value",
+	}
+
+	for relativePath, content := range testFiles {
+		fullPath := filepath.Join(tempDir, relativePath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", fullPath, err)
+		}
+	}
+
+	return tempDir
+}
+
+func TestMoveAcrossDevices(t *testing.T) {
+	tempDir := setupTree(t)
+
+	// Simulate a move between filesystems
+	originalRename := rename
+	defer func() { rename = originalRename }()
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: unix.EXDEV}
+	}
+
+	sourceDir := filepath.Join(tempDir, "config")
+	destDir := filepath.Join(tempDir, "destination")
+
+	if err := Move(sourceDir, destDir, Options{}); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+
+	if _, err := os.Stat(sourceDir); !os.IsNotExist(err) {
+		t.Errorf("Source directory still exists after move: %s", sourceDir)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "settings.json"))
+	if err != nil {
+		t.Fatalf("Failed to read moved file: %v", err)
+	}
+	if string(content) != `{"setting": "value"}` {
+		t.Errorf("Unexpected content after move: %s", content)
+	}
+}
+
+func TestVerifyTree(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "config")
+	dst := filepath.Join(tempDir, "config_copy")
+
+	if err := CopyDir(src, dst, Options{}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+	if err := VerifyTree(src, dst); err != nil {
+		t.Fatalf("VerifyTree failed on an identical copy: %v", err)
+	}
+
+	// Truncate a copied file so the sizes differ
+	if err := os.WriteFile(filepath.Join(dst, "config.ini"), []byte("k"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := VerifyTree(src, dst); err == nil {
+		t.Error("Expected VerifyTree to detect a size mismatch, got nil")
+	}
+}
+
+func TestCopyPreserve(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "config")
+	srcFile := filepath.Join(src, "config.ini")
+
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chmod(srcFile, 0o600); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+	if err := os.Chtimes(srcFile, old, old); err != nil {
+		t.Fatalf("Failed to set times: %v", err)
+	}
+	if err := os.Chtimes(src, old, old); err != nil {
+		t.Fatalf("Failed to set times: %v", err)
+	}
+
+	// Without Preserve the copy gets fresh timestamps
+	plain := filepath.Join(tempDir, "plain")
+	if err := CopyDir(src, plain, Options{}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(plain, "config.ini"))
+	if err != nil {
+		t.Fatalf("Failed to stat copy: %v", err)
+	}
+	if info.ModTime().Equal(old) {
+		t.Errorf("Expected fresh mtime without preserving attributes")
+	}
+
+	preserved := filepath.Join(tempDir, "preserved")
+	if err := CopyDir(src, preserved, Options{Preserve: AllAttrs}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	for _, path := range []string{preserved, filepath.Join(preserved, "config.ini")} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat copy: %v", err)
+		}
+		if !info.ModTime().Equal(old) {
+			t.Errorf("Expected mtime %v for %s, got %v", old, path, info.ModTime())
+		}
+	}
+
+	info, err = os.Stat(filepath.Join(preserved, "config.ini"))
+	if err != nil {
+		t.Fatalf("Failed to stat copy: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestParseAttrs(t *testing.T) {
+	p, err := ParseAttrs([]string{"mode", "timestamps"})
+	if err != nil {
+		t.Fatalf("ParseAttrs failed: %v", err)
+	}
+	if !p.Mode || !p.Timestamps || p.Ownership {
+		t.Errorf("Unexpected attributes: %+v", p)
+	}
+
+	if p, _ := ParseAttrs([]string{"all"}); p != AllAttrs {
+		t.Errorf("Expected all attributes, got %+v", p)
+	}
+
+	if _, err := ParseAttrs([]string{"xattrs"}); err == nil {
+		t.Error("Expected error for unknown attribute, got nil")
+	}
+}
+
+func TestSymlinkPolicies(t *testing.T) {
+	tempDir := setupTree(t)
+
+	// a link entry pointing at a sibling, and a directory holding one link
+	// inside it and one pointing out of it
+	link := filepath.Join(tempDir, "link.txt")
+	if err := os.Symlink("file1.txt", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	dir := filepath.Join(tempDir, "nested")
+	os.Symlink("file3.txt", filepath.Join(dir, "inner"))
+	os.Symlink("../file1.txt", filepath.Join(dir, "outer"))
+	dest := filepath.Join(tempDir, "dest", "deeper")
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		t.Fatalf("Failed to create destination: %v", err)
+	}
+
+	readlink := func(path string) string {
+		target, _ := os.Readlink(path)
+		return target
+	}
+
+	// the default keeps the link's target as it is
+	linkInfo, _ := os.Lstat(link)
+	if err := Copy(link, filepath.Join(dest, "kept"), linkInfo, Options{}); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if got := readlink(filepath.Join(dest, "kept")); got != "file1.txt" {
+		t.Errorf("Expected the target to be kept, got %q", got)
+	}
+
+	if err := Copy(link, filepath.Join(dest, "followed"), linkInfo, Options{Symlinks: SymlinksFollow}); err != nil {
+		t.Fatalf("Copy with follow failed: %v", err)
+	}
+	if info, err := os.Lstat(filepath.Join(dest, "followed")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("Expected following to copy the target as a regular file, got %v, %v", info, err)
+	}
+
+	if err := Copy(link, filepath.Join(dest, "rewritten"), linkInfo, Options{Symlinks: SymlinksRewriteRelative}); err != nil {
+		t.Fatalf("Copy with rewrite failed: %v", err)
+	}
+	if got := readlink(filepath.Join(dest, "rewritten")); got != "../../file1.txt" {
+		t.Errorf("Expected the target to be rewritten to ../../file1.txt, got %q", got)
+	}
+
+	// moving a directory rewrites only the links that point out of it
+	moved := filepath.Join(dest, "nested")
+	if err := Move(dir, moved, Options{Symlinks: SymlinksRewriteRelative}); err != nil {
+		t.Fatalf("Move with rewrite failed: %v", err)
+	}
+	if got := readlink(filepath.Join(moved, "inner")); got != "file3.txt" {
+		t.Errorf("Expected the inner link to be kept, got %q", got)
+	}
+	if got := readlink(filepath.Join(moved, "outer")); got != "../../../file1.txt" {
+		t.Errorf("Expected the outer link to be rewritten, got %q", got)
+	}
+	if content, err := os.ReadFile(filepath.Join(moved, "outer")); err != nil || string(content) != "This is file 1" {
+		t.Errorf("Expected the rewritten link to resolve, got %q, %v", content, err)
+	}
+}
+
+func TestCopyHardlink(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "config")
+	dst := filepath.Join(tempDir, "linked")
+	if err := CopyDir(src, dst, Options{Hardlink: true}); err != nil {
+		t.Fatalf("CopyDir with hardlinks failed: %v", err)
+	}
+
+	srcInfo, _ := os.Stat(filepath.Join(src, "settings.json"))
+	dstInfo, err := os.Stat(filepath.Join(dst, "settings.json"))
+	if err != nil {
+		t.Fatalf("Expected the file to be linked: %v", err)
+	}
+	if !os.SameFile(srcInfo, dstInfo) {
+		t.Error("Expected the copy to be a hard link to the source")
+	}
+}
+
+func TestCopyDirKeepsHardlinks(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "config")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Link(filepath.Join(src, "settings.json"), filepath.Join(src, "sub", "settings-link.json")); err != nil {
+		t.Fatalf("Failed to create hard link: %v", err)
+	}
+
+	dst := filepath.Join(tempDir, "copy")
+	if err := CopyDir(src, dst, Options{NoReflink: true}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	first, _ := os.Stat(filepath.Join(dst, "settings.json"))
+	second, err := os.Stat(filepath.Join(dst, "sub", "settings-link.json"))
+	if err != nil {
+		t.Fatalf("Expected the linked file to be copied: %v", err)
+	}
+	if !os.SameFile(first, second) {
+		t.Error("Expected the copies to be hard links to each other")
+	}
+	srcInfo, _ := os.Stat(filepath.Join(src, "settings.json"))
+	if os.SameFile(srcInfo, first) {
+		t.Error("Expected the copy not to be linked to its source")
+	}
+	if other, _ := os.Stat(filepath.Join(dst, "config.ini")); os.SameFile(first, other) {
+		t.Error("Expected unrelated files to stay separate")
+	}
+}
@@ -0,0 +1,49 @@
+package fsops
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyRangeChunk is how much copy_file_range is asked to copy at a time, so
+// that progress keeps updating on large files
+const copyRangeChunk = 8 << 20
+
+// copyFileRange copies the rest of src into dst inside the kernel with
+// copy_file_range, so the data never passes through userspace. It returns
+// false without copying anything if the kernel or filesystem can't do this,
+// in which case the caller should copy the data itself. It stops between
+// chunks once canceled returns an error.
+func copyFileRange(dst, src *os.File, p Progress, canceled func() error) (bool, error) {
+	var written int64
+	for {
+		if err := canceled(); err != nil {
+			return true, err
+		}
+		n, err := unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, copyRangeChunk, 0)
+		if err != nil {
+			if written == 0 && isCopyRangeUnsupported(err) {
+				return false, nil
+			}
+			return true, err
+		}
+
+		if n == 0 {
+			// some special files report no data here but can still be read
+			return written > 0, nil
+		}
+
+		written += int64(n)
+		p.Add(int64(n))
+	}
+}
+
+// isCopyRangeUnsupported reports whether a copy_file_range error means the
+// copy should be done in userspace instead
+func isCopyRangeUnsupported(err error) bool {
+	return errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) ||
+		errors.Is(err, unix.EPERM) || errors.Is(err, unix.EIO)
+}
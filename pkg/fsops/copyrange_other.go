@@ -0,0 +1,12 @@
+//go:build !linux
+
+package fsops
+
+import "os"
+
+// copyFileRange has no in-kernel equivalent available here, so the caller
+// always copies the data itself. On macOS, APFS copies are usually already
+// handled by cloneFile.
+func copyFileRange(_, _ *os.File, _ Progress, _ func() error) (bool, error) {
+	return false, nil
+}
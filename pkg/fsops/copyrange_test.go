@@ -0,0 +1,119 @@
+package fsops
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// copyTestSize is the size of the file used by the copy test and benchmark,
+// several copy_file_range chunks long
+const copyTestSize = 32 << 20
+
+// countingProgress records how much it is told was copied
+type countingProgress struct {
+	files int
+	done  int64
+}
+
+func (p *countingProgress) StartFile(string, int64) { p.files++ }
+func (p *countingProgress) Add(n int64)             { p.done += n }
+
+func TestCopyFileRange(t *testing.T) {
+	tempDir := setupTree(t)
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), copyTestSize/16+3)
+	src := filepath.Join(tempDir, "big.bin")
+	if err := os.WriteFile(src, data, 0o644); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+
+	p := &countingProgress{}
+	dst := filepath.Join(tempDir, "big-copy.bin")
+	if err := CopyFile(src, dst, Options{NoReflink: true, Progress: p}); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+
+	copied, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("Failed to read copy: %v", err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Error("Copied data doesn't match the source")
+	}
+	if p.done != int64(len(data)) || p.files != 1 {
+		t.Errorf("Expected %d bytes of 1 file tracked, got %d bytes of %d", len(data), p.done, p.files)
+	}
+}
+
+// BenchmarkCopyFile compares CopyFile, which copies inside the kernel where
+// it can, against copying the data through userspace with io.Copy
+func BenchmarkCopyFile(b *testing.B) {
+	dir := b.TempDir()
+	src := filepath.Join(dir, "src.bin")
+	if err := os.WriteFile(src, bytes.Repeat([]byte{0xc5}, copyTestSize), 0o644); err != nil {
+		b.Fatalf("Failed to write source: %v", err)
+	}
+	dst := filepath.Join(dir, "dst.bin")
+
+	b.Run("CopyFile", func(b *testing.B) {
+		b.SetBytes(copyTestSize)
+		for i := 0; i < b.N; i++ {
+			if err := CopyFile(src, dst, Options{NoReflink: true}); err != nil {
+				b.Fatalf("CopyFile failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("userspace", func(b *testing.B) {
+		b.SetBytes(copyTestSize)
+		for i := 0; i < b.N; i++ {
+			srcFile, err := os.Open(src)
+			if err != nil {
+				b.Fatal(err)
+			}
+			dstFile, err := os.Create(dst)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// progressWriter hides ReadFrom, so io.Copy can't take a fast path
+			if _, err := io.Copy(progressWriter{w: dstFile, p: noProgress{}}, srcFile); err != nil {
+				b.Fatal(err)
+			}
+			srcFile.Close()
+			dstFile.Close()
+		}
+	})
+}
+
+// cancelingProgress cancels the copy once the first chunk has been copied
+type cancelingProgress struct {
+	cancel context.CancelFunc
+}
+
+func (p cancelingProgress) StartFile(string, int64) {}
+func (p cancelingProgress) Add(int64)               { p.cancel() }
+
+func TestCopyFileCanceled(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "big.bin")
+	if err := os.WriteFile(src, bytes.Repeat([]byte{0xc5}, copyTestSize), 0o644); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dst := filepath.Join(tempDir, "big-copy.bin")
+	err := CopyFile(src, dst, Options{NoReflink: true, Progress: cancelingProgress{cancel}, Context: ctx})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+		t.Errorf("Expected the partly written copy to be removed, got %v", err)
+	}
+}
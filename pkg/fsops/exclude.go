@@ -0,0 +1,148 @@
+package fsops
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// excludeRule is one compiled gitignore-style exclude pattern
+type excludeRule struct {
+	// base is the directory the pattern is relative to, as a slash-separated
+	// path inside the copied tree ("" for the tree itself)
+	base     string
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// Rules decides which paths inside a copied directory are left out.
+// Like .gitignore, the last matching rule wins and a leading "!" re-includes.
+type Rules []excludeRule
+
+// ParseExcludes compiles gitignore-style patterns: a pattern without a slash
+// matches a name at any depth, a pattern with one is anchored to the copied
+// directory, a trailing slash only matches directories and "**" matches any
+// number of directories
+func ParseExcludes(patterns []string) (Rules, error) {
+	return parseExcludesAt("", patterns)
+}
+
+// parseExcludesAt compiles patterns relative to the directory base
+func parseExcludesAt(base string, patterns []string) (Rules, error) {
+	var rules Rules
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		rule := excludeRule{base: base}
+		if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+			rule.negate = true
+			pattern = rest
+		}
+		// a leading backslash escapes a literal "#" or "!"
+		pattern = strings.TrimPrefix(pattern, "\\")
+		if rest, ok := strings.CutSuffix(pattern, "/"); ok {
+			rule.dirOnly = true
+			pattern = rest
+		}
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		pattern = strings.TrimPrefix(pattern, "/")
+
+		rule.segments = strings.Split(pattern, "/")
+		for _, segment := range rule.segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, err
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Excluded reports whether rel, a path relative to the copied directory, is
+// left out of the copy
+func (r Rules) Excluded(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+
+	excluded := false
+	for _, rule := range r {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		ruleRel := rel
+		if rule.base != "" {
+			var ok bool
+			if ruleRel, ok = strings.CutPrefix(rel, rule.base+"/"); !ok {
+				continue
+			}
+		}
+		if matchSegments(rule.segments, strings.Split(ruleRel, "/")) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
+
+// matchSegments matches path segments against pattern segments, where "**"
+// stands for zero or more segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// WithGitignore returns r extended with the rules from the .gitignore file in
+// dir, which is at rel inside the copied tree. Rules from deeper .gitignore
+// files come later, so they take precedence as they do in git.
+func (r Rules) WithGitignore(dir, rel string) (Rules, error) {
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	rules, err := parseExcludesAt(filepath.ToSlash(rel), patterns)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, ".gitignore"), err)
+	}
+
+	// copy so sibling directories don't share the appended rules
+	return append(append(Rules(nil), r...), rules...), nil
+}
@@ -0,0 +1,122 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExcluded(t *testing.T) {
+	rules, err := ParseExcludes([]string{"node_modules", "*.o", "/build", "docs/**/*.tmp", "cache/", "!keep.o"})
+	if err != nil {
+		t.Fatalf("ParseExcludes failed: %v", err)
+	}
+
+	tests := []struct {
+		rel      string
+		isDir    bool
+		excluded bool
+	}{
+		{"node_modules", true, true},
+		{"src/node_modules", true, true},
+		{"main.o", false, true},
+		{"src/lib/util.o", false, true},
+		{"keep.o", false, false},
+		{"build", true, true},
+		{"src/build", true, false},
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"a.tmp", false, false},
+		{"cache", true, true},
+		{"cache", false, false},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := rules.Excluded(tt.rel, tt.isDir); got != tt.excluded {
+			t.Errorf("Excluded(%q, %v) = %v, expected %v", tt.rel, tt.isDir, got, tt.excluded)
+		}
+	}
+
+	if _, err := ParseExcludes([]string{"[z-a"}); err == nil {
+		t.Error("Expected error for malformed pattern")
+	}
+}
+
+func TestCopyDirExclude(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "project")
+	for _, name := range []string{"main.go", "main.o", "node_modules/pkg/index.js", "src/util.go", "src/util.o"} {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	rules, err := ParseExcludes([]string{"node_modules", "*.o"})
+	if err != nil {
+		t.Fatalf("ParseExcludes failed: %v", err)
+	}
+
+	dst := filepath.Join(tempDir, "copy")
+	if err := CopyDir(src, dst, Options{Exclude: rules}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	for _, name := range []string{"main.go", "src/util.go"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("Expected %s to be copied: %v", name, err)
+		}
+	}
+	for _, name := range []string{"main.o", "node_modules", "src/util.o"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be excluded", name)
+		}
+	}
+}
+
+func TestCopyDirGitignore(t *testing.T) {
+	tempDir := setupTree(t)
+
+	src := filepath.Join(tempDir, "project")
+	files := map[string]string{
+		".gitignore":          "build/\n*.log\n!keep.log\n",
+		"main.go":             "package main",
+		"debug.log":           "log",
+		"keep.log":            "log",
+		"build/out":           "binary",
+		"web/.gitignore":      "/dist\n",
+		"web/dist/app.js":     "bundle",
+		"web/src/dist/app.js": "source",
+		"other/dist/app.js":   "kept",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	dst := filepath.Join(tempDir, "copy")
+	if err := CopyDir(src, dst, Options{Gitignore: true}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	for _, name := range []string{".gitignore", "main.go", "keep.log", "web/.gitignore", "web/src/dist/app.js", "other/dist/app.js"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("Expected %s to be copied: %v", name, err)
+		}
+	}
+	for _, name := range []string{"debug.log", "build", "web/dist"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be ignored", name)
+		}
+	}
+}
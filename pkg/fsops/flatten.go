@@ -0,0 +1,75 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ListFiles returns everything under the directory src that isn't itself a
+// directory, at any depth, leaving out paths matched by opts.Exclude or, with
+// opts.Gitignore, by .gitignore files along the way. Paths come in lexical
+// order within each directory.
+func ListFiles(src string, opts Options) ([]string, error) {
+	var files []string
+	err := listFiles(src, "", opts, &files)
+	return files, err
+}
+
+// listFiles adds the files under the directory at rel inside the tree
+func listFiles(dir, rel string, opts Options, files *[]string) error {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if opts.Gitignore {
+		if opts.Exclude, err = opts.Exclude.WithGitignore(dir, rel); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range dirEntries {
+		path := filepath.Join(dir, entry.Name())
+		entryRel := filepath.Join(rel, entry.Name())
+		if opts.Exclude.Excluded(entryRel, entry.IsDir()) {
+			continue
+		}
+		if entry.IsDir() {
+			if err := listFiles(path, entryRel, opts, files); err != nil {
+				return err
+			}
+		} else {
+			*files = append(*files, path)
+		}
+	}
+	return nil
+}
+
+// RemoveEmptyDirs removes the directory root and every directory under it
+// that is left empty once the empty directories below it are gone. Anything
+// still holding files stays.
+func RemoveEmptyDirs(root string) error {
+	dirEntries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+
+	empty := true
+	for _, entry := range dirEntries {
+		if !entry.IsDir() {
+			empty = false
+			continue
+		}
+		if err := RemoveEmptyDirs(filepath.Join(root, entry.Name())); err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(root, entry.Name())); err == nil {
+			empty = false
+		}
+	}
+
+	if !empty {
+		return nil
+	}
+	return os.Remove(root)
+}
@@ -0,0 +1,57 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListFiles(t *testing.T) {
+	tempDir := setupTree(t)
+
+	files, err := ListFiles(tempDir, Options{})
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	var rels []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(tempDir, file)
+		rels = append(rels, rel)
+	}
+	expected := "config/config.ini config/settings.json file1.txt nested/file3.txt"
+	if got := strings.Join(rels, " "); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	exclude, _ := ParseExcludes([]string{"config"})
+	files, err = ListFiles(tempDir, Options{Exclude: exclude})
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected 2 files outside config, got %v", files)
+	}
+}
+
+func TestRemoveEmptyDirs(t *testing.T) {
+	tempDir := setupTree(t)
+
+	// nested only holds empty directories once its file is gone
+	os.Remove(filepath.Join(tempDir, "nested", "file3.txt"))
+	os.MkdirAll(filepath.Join(tempDir, "nested", "a", "b"), 0o755)
+
+	if err := RemoveEmptyDirs(filepath.Join(tempDir, "nested")); err != nil {
+		t.Fatalf("RemoveEmptyDirs failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "nested")); !os.IsNotExist(err) {
+		t.Error("Expected the emptied directory to be removed")
+	}
+
+	if err := RemoveEmptyDirs(tempDir); err != nil {
+		t.Fatalf("RemoveEmptyDirs failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "config", "config.ini")); err != nil {
+		t.Errorf("Expected directories holding files to stay: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package fsops
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limitBurst is how much a Limiter lets through at once, as time at its
+// rate, so throughput stays smooth rather than arriving in bursts
+const limitBurst = 250 * time.Millisecond
+
+// minBurst is the least a Limiter lets through at once, so that slow rates
+// still allow whole writes
+const minBurst = 64 << 10
+
+// Limiter throttles copies to a number of bytes per second with a token
+// bucket. One Limiter is shared by every file of a paste, so the rate holds
+// across them.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter allowing rate bytes per second
+func NewLimiter(rate int64) *Limiter {
+	burst := max(float64(rate)*limitBurst.Seconds(), minBurst)
+	return &Limiter{rate: float64(rate), burst: burst, tokens: burst}
+}
+
+// wait takes n bytes' worth of tokens, sleeping until the bucket has
+// refilled enough to cover them, or until ctx is canceled
+func (l *Limiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.burst)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,41 @@
+package fsops
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyFileLimited(t *testing.T) {
+	tempDir := setupTree(t)
+
+	const rate = 512 << 10
+	data := bytes.Repeat([]byte{0xc5}, 384<<10)
+	src := filepath.Join(tempDir, "limited.bin")
+	if err := os.WriteFile(src, data, 0o644); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+
+	// the first quarter second's worth goes straight through, the rest at
+	// the rate
+	want := time.Duration(float64(len(data)-rate/4) / rate * float64(time.Second))
+
+	start := time.Now()
+	dst := filepath.Join(tempDir, "limited-copy.bin")
+	if err := CopyFile(src, dst, Options{NoReflink: true, Limit: NewLimiter(rate)}); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < want*9/10 {
+		t.Errorf("Expected the copy to take about %v, got %v", want, elapsed)
+	}
+
+	copied, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("Failed to read copy: %v", err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Error("Copied data doesn't match the source")
+	}
+}
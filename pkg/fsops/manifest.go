@@ -0,0 +1,161 @@
+package fsops
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Manifest records the files a directory copy has completed, one JSON line
+// each in a file, so that a copy that was interrupted can be resumed without
+// copying them again. Lines are appended as files complete, so the manifest
+// stays valid however the copy ends.
+type Manifest struct {
+	path     string
+	file     *os.File
+	dest     string
+	done     map[string]manifestLine
+	resuming bool
+}
+
+// manifestLine is one line of a manifest: the destination of the copy, which
+// comes first, or a file that was copied, with its source's size and mtime
+type manifestLine struct {
+	Dest    string `json:"dest,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	ModTime int64  `json:"mtime,omitempty"`
+}
+
+// OpenManifest opens the manifest at path. With resume, the files recorded
+// by an earlier copy count as done; otherwise the manifest starts afresh once
+// a copy begins, though Dest still reports what an earlier copy was doing.
+func OpenManifest(path string, resume bool) (*Manifest, error) {
+	m := &Manifest{path: path, done: make(map[string]manifestLine), resuming: resume}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	if !resume {
+		clear(m.done)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	m.file = file
+	return m, nil
+}
+
+// load reads the lines written by an earlier copy. A line cut short by the
+// copy being interrupted ends the manifest.
+func (m *Manifest) load() error {
+	file, err := os.Open(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line manifestLine
+		if json.Unmarshal(scanner.Bytes(), &line) != nil {
+			break
+		}
+		if line.Dest != "" {
+			m.dest = line.Dest
+		} else {
+			m.done[line.Path] = line
+		}
+	}
+	return scanner.Err()
+}
+
+// Dest returns the destination an earlier copy was writing to, or "" if
+// there was none
+func (m *Manifest) Dest() string {
+	return m.dest
+}
+
+// Resumed returns how many files the earlier copy completed
+func (m *Manifest) Resumed() int {
+	return len(m.done)
+}
+
+// begin records the destination of a directory copy, unless it's resuming
+// one to the same place
+func (m *Manifest) begin(dst string) error {
+	if m.resuming && m.dest == dst {
+		return nil
+	}
+	if err := m.file.Truncate(0); err != nil {
+		return err
+	}
+	m.dest, m.resuming = dst, true
+	clear(m.done)
+	return m.write(manifestLine{Dest: dst})
+}
+
+// resume reports whether the file src, at rel in the tree, was copied to dst
+// by an earlier copy: the source hasn't changed size or mtime since, and the
+// copy is complete. Otherwise anything the earlier copy left at dst, other
+// than a directory, is removed so it can be copied again.
+func (m *Manifest) resume(src, dst, rel string, progress Progress) (bool, error) {
+	dstInfo, err := os.Lstat(dst)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil || dstInfo.IsDir() {
+		return false, err
+	}
+
+	if line, ok := m.done[rel]; ok {
+		info, err := os.Stat(src)
+		if err == nil && line.Size == info.Size() && line.ModTime == info.ModTime().UnixNano() &&
+			dstInfo.Mode().IsRegular() && dstInfo.Size() == info.Size() {
+			progress.StartFile(src, info.Size())
+			progress.Add(info.Size())
+			return true, nil
+		}
+	}
+	return false, os.Remove(dst)
+}
+
+// complete records that the file src, at rel in the tree, has been copied
+func (m *Manifest) complete(rel, src string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return m.write(manifestLine{Path: rel, Size: info.Size(), ModTime: info.ModTime().UnixNano()})
+}
+
+// write appends a line to the manifest
+func (m *Manifest) write(line manifestLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	_, err = m.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the manifest, keeping it for a later resume
+func (m *Manifest) Close() error {
+	return m.file.Close()
+}
+
+// Remove closes and deletes the manifest once the copy has finished
+func (m *Manifest) Remove() error {
+	m.file.Close()
+	return os.Remove(m.path)
+}
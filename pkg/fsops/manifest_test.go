@@ -0,0 +1,58 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestResume(t *testing.T) {
+	tempDir := setupTree(t)
+	manifestPath := filepath.Join(tempDir, "state", "transfer.jsonl")
+	dst := filepath.Join(tempDir, "copy")
+
+	manifest, err := OpenManifest(manifestPath, false)
+	if err != nil {
+		t.Fatalf("OpenManifest failed: %v", err)
+	}
+	if err := CopyDir(filepath.Join(tempDir, "config"), dst, Options{Manifest: manifest}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+	manifest.Close()
+
+	// as if the copy stopped partway: one file missing, another cut short,
+	// and the manifest's last line half-written
+	os.Remove(filepath.Join(dst, "config.ini"))
+	marker := []byte(`{"setting":"marker"}`)
+	if err := os.WriteFile(filepath.Join(dst, "settings.json"), marker, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	file, _ := os.OpenFile(manifestPath, os.O_WRONLY|os.O_APPEND, 0)
+	file.WriteString(`{"path":"nested/fi`)
+	file.Close()
+
+	manifest, err = OpenManifest(manifestPath, true)
+	if err != nil {
+		t.Fatalf("OpenManifest failed: %v", err)
+	}
+	if manifest.Dest() != dst || manifest.Resumed() != 2 {
+		t.Fatalf("Expected 2 files copied to %s, got %d to %q", dst, manifest.Resumed(), manifest.Dest())
+	}
+	if err := CopyDir(filepath.Join(tempDir, "config"), dst, Options{Manifest: manifest}); err != nil {
+		t.Fatalf("resumed CopyDir failed: %v", err)
+	}
+	if err := manifest.Remove(); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+
+	// the completed file of the same size is skipped, the missing one copied
+	if data, _ := os.ReadFile(filepath.Join(dst, "settings.json")); string(data) != string(marker) {
+		t.Errorf("Expected the completed file to be skipped, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "config.ini")); string(data) != "key=value" {
+		t.Errorf("Expected the missing file to be copied, got %q", data)
+	}
+	if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+		t.Error("Expected the manifest to be removed")
+	}
+}
@@ -0,0 +1,67 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// MirrorExtras lists what's in the directory dst that isn't in src: the
+// paths deleted to make dst a mirror of src. A directory missing from src is
+// listed without its contents, and a path that's in src as another type
+// isn't listed, since copying replaces it. Like rsync --delete, paths left
+// out by opts.Exclude, or with opts.Gitignore by src's .gitignore files, are
+// kept.
+func MirrorExtras(src, dst string, opts Options) ([]string, error) {
+	var extras []string
+	if err := mirrorExtras(src, dst, "", opts, &extras); err != nil {
+		return nil, err
+	}
+	return extras, nil
+}
+
+// mirrorExtras adds what's in dst but not in src, the directory at rel in the
+// tree, to extras
+func mirrorExtras(src, dst, rel string, opts Options, extras *[]string) error {
+	dirEntries, err := os.ReadDir(dst)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Gitignore {
+		if opts.Exclude, err = opts.Exclude.WithGitignore(src, rel); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range dirEntries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+		entryRel := filepath.Join(rel, entry.Name())
+		if opts.Exclude.Excluded(entryRel, entry.IsDir()) {
+			continue
+		}
+
+		stat := os.Lstat
+		if opts.Symlinks == SymlinksFollow {
+			stat = os.Stat
+		}
+		srcInfo, err := stat(srcPath)
+		if os.IsNotExist(err) {
+			*extras = append(*extras, dstPath)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() && srcInfo.IsDir() {
+			if err := mirrorExtras(srcPath, dstPath, entryRel, opts, extras); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
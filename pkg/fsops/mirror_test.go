@@ -0,0 +1,43 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMirrorExtras(t *testing.T) {
+	tempDir := setupTree(t)
+	src := filepath.Join(tempDir, "config")
+	dst := filepath.Join(tempDir, "mirror")
+
+	for _, path := range []string{"settings.json", "stale.txt", "old/a.txt", "keep.log", "config.ini/x"} {
+		full := filepath.Join(dst, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	exclude, err := ParseExcludes([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("ParseExcludes failed: %v", err)
+	}
+	extras, err := MirrorExtras(src, dst, Options{Exclude: exclude})
+	if err != nil {
+		t.Fatalf("MirrorExtras failed: %v", err)
+	}
+
+	// config.ini is a file in the source, so copying replaces the directory
+	expected := []string{filepath.Join(dst, "old"), filepath.Join(dst, "stale.txt")}
+	if !reflect.DeepEqual(extras, expected) {
+		t.Errorf("Expected %q, got %q", expected, extras)
+	}
+
+	if extras, err := MirrorExtras(src, filepath.Join(tempDir, "nope"), Options{}); err != nil || len(extras) != 0 {
+		t.Errorf("Expected nothing to delete from a missing destination, got %q (%v)", extras, err)
+	}
+}
@@ -0,0 +1,83 @@
+package fsops
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"unicode/utf8"
+
+	"golang.org/x/sys/unix"
+)
+
+// MaxNameLen is the longest file name, in bytes, that most filesystems
+// allow
+const MaxNameLen = 255
+
+// MaxPathLen is the longest path, in bytes, the system takes: PATH_MAX less
+// its terminating NUL. Files can end up deeper, when a directory above them
+// is renamed, but then can't be reached by their full path, so copies of
+// them can't be made either.
+const MaxPathLen = unix.PathMax - 1
+
+// PathTooLongError is returned for a path longer than MaxPathLen
+type PathTooLongError struct {
+	Path string
+}
+
+// Error names the path by its end, since all of it would fill the screen
+func (e *PathTooLongError) Error() string {
+	tail := e.Path
+	if len(tail) > 64 {
+		tail = tail[len(tail)-64:]
+		for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+			tail = tail[1:]
+		}
+		tail = "…" + tail
+	}
+	return fmt.Sprintf("%s is %d bytes long, over the %d the system allows", tail, len(e.Path), MaxPathLen)
+}
+
+// CheckPathLen returns a PathTooLongError if copying src into dir would
+// make a path longer than MaxPathLen anywhere under the copy. Only copies
+// deeper than src need looking into, since src's own paths all fit. Parts
+// of src that can't be read are left for the copy to report.
+func CheckPathLen(src, dir string) error {
+	deeper := len(dir) - len(filepath.Dir(src))
+	if deeper <= 0 {
+		return nil
+	}
+
+	var longest string
+	filepath.WalkDir(src, func(path string, _ fs.DirEntry, err error) error {
+		if len(path) > len(longest) {
+			longest = path
+		}
+		return nil
+	})
+	if len(longest)+deeper <= MaxPathLen {
+		return nil
+	}
+	return &PathTooLongError{Path: filepath.Join(dir, longest[len(filepath.Dir(src)):])}
+}
+
+// FitName shortens stem, if it has to, so that stem followed by suffix still
+// fits in a file name, for names made by adding to an existing one like
+// "report (2).pdf". The stem is cut at a character boundary.
+func FitName(stem, suffix string) string {
+	room := MaxNameLen - len(suffix)
+	if len(stem) <= room {
+		return stem + suffix
+	}
+	if room < 0 {
+		room = 0
+	}
+	stem = stem[:room]
+	// drop what's left of a character the cut split in two
+	for len(stem) > 0 {
+		if r, size := utf8.DecodeLastRuneInString(stem); r != utf8.RuneError || size > 1 {
+			break
+		}
+		stem = stem[:len(stem)-1]
+	}
+	return stem + suffix
+}
@@ -0,0 +1,70 @@
+package fsops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFitName(t *testing.T) {
+	long := strings.Repeat("é", 200)
+	got := FitName(long, " (2).txt")
+	if len(got) > MaxNameLen || !utf8.ValidString(got) || !strings.HasSuffix(got, " (2).txt") {
+		t.Errorf("Expected a valid name of at most %d bytes ending in the suffix, got %d bytes: %q", MaxNameLen, len(got), got)
+	}
+	if got := FitName("report", " copy.pdf"); got != "report copy.pdf" {
+		t.Errorf("Expected a short name left alone, got %q", got)
+	}
+}
+
+func FuzzFitName(f *testing.F) {
+	f.Add("report", " (2).pdf")
+	f.Add(strings.Repeat("日本", 100), " copy 12")
+	f.Add(strings.Repeat("a", 300), "~")
+
+	f.Fuzz(func(t *testing.T, stem, suffix string) {
+		got := FitName(stem, suffix)
+		if !strings.HasSuffix(got, suffix) || !strings.HasPrefix(stem, strings.TrimSuffix(got, suffix)) {
+			t.Fatalf("FitName(%q, %q) = %q, not a prefix of the stem and the suffix", stem, suffix, got)
+		}
+		if len(stem)+len(suffix) > MaxNameLen && len(suffix) <= MaxNameLen && len(got) > MaxNameLen {
+			t.Errorf("FitName(%q, %q) is %d bytes, over %d", stem, suffix, len(got), MaxNameLen)
+		}
+		if utf8.ValidString(stem) && !utf8.ValidString(got) && utf8.ValidString(suffix) {
+			t.Errorf("FitName(%q, %q) = %q split a character", stem, suffix, got)
+		}
+	})
+}
+
+func TestCheckPathLen(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	deepest := filepath.Join(src, strings.Repeat("d", 100), strings.Repeat("f", 100))
+	if err := os.MkdirAll(deepest, 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	// copies only get deeper if they're pasted somewhere deeper
+	if err := CheckPathLen(src, "/"); err != nil {
+		t.Errorf("Expected a copy into / to fit, got %v", err)
+	}
+
+	// the deepest copy is dir/src/ddd…/fff…, 206 bytes longer than dir
+	dir := strings.Repeat("/x", (MaxPathLen-206)/2)
+	if err := CheckPathLen(src, dir); err != nil {
+		t.Errorf("Expected a copy into a %d byte directory to fit, got %v", len(dir), err)
+	}
+	dir += "xx"
+	var tooLong *PathTooLongError
+	if err := CheckPathLen(src, dir); !errors.As(err, &tooLong) {
+		t.Fatalf("Expected a copy into a %d byte directory not to fit, got %v", len(dir), err)
+	}
+	if want := filepath.Join(dir, "src", strings.Repeat("d", 100), strings.Repeat("f", 100)); tooLong.Path != want {
+		t.Errorf("Expected the deepest copy to be named, got %q", tooLong.Path)
+	}
+	if msg := tooLong.Error(); !strings.HasPrefix(msg, "…") || !strings.Contains(msg, "fff is") {
+		t.Errorf("Expected the message to name the path by its end, got %q", msg)
+	}
+}
@@ -0,0 +1,36 @@
+package fsops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// NetworkFS reports whether path is on a network or FUSE filesystem, such
+// as NFS or SMB, and if so which. A path that doesn't exist yet is looked
+// up by the nearest directory above it that does.
+func NetworkFS(path string) (fstype string, ok bool) {
+	for {
+		if _, err := os.Lstat(path); err == nil {
+			return networkFS(path)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", false
+		}
+		path = parent
+	}
+}
+
+// verifyContents checks that dst matches src file for file, down to their
+// contents, for moves where a copy is more likely to go wrong unnoticed
+func verifyContents(src, dst string) error {
+	drift, err := CompareTrees(src, dst)
+	if err != nil {
+		return err
+	}
+	if len(drift) > 0 {
+		return fmt.Errorf("%s doesn't match %s: %s is %s", dst, src, drift[0].Path, drift[0].Kind)
+	}
+	return nil
+}
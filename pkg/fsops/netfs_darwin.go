@@ -0,0 +1,29 @@
+package fsops
+
+import (
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// networkFSNames lists the network filesystems by the name statfs gives
+// them; FUSE filesystems are recognised by "fuse" in theirs
+var networkFSNames = []string{"nfs", "smbfs", "afpfs", "webdav", "cifs"}
+
+// networkFS looks the filesystem holding path up by its statfs type name
+func networkFS(path string) (string, bool) {
+	var st unix.Statfs_t
+	if unix.Statfs(path, &st) != nil {
+		return "", false
+	}
+	fstype := unix.ByteSliceToString(st.Fstypename[:])
+	for _, name := range networkFSNames {
+		if fstype == name {
+			return fstype, true
+		}
+	}
+	if strings.Contains(fstype, "fuse") {
+		return fstype, true
+	}
+	return "", false
+}
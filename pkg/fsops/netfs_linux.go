@@ -0,0 +1,28 @@
+package fsops
+
+import "golang.org/x/sys/unix"
+
+// networkFSTypes names the network and FUSE filesystems by their statfs
+// magic numbers
+var networkFSTypes = map[int64]string{
+	unix.NFS_SUPER_MAGIC:  "nfs",
+	unix.SMB_SUPER_MAGIC:  "smb",
+	unix.SMB2_SUPER_MAGIC: "smb2",
+	unix.CIFS_SUPER_MAGIC: "cifs",
+	unix.FUSE_SUPER_MAGIC: "fuse",
+	unix.V9FS_MAGIC:       "9p",
+	unix.AFS_SUPER_MAGIC:  "afs",
+	unix.AFS_FS_MAGIC:     "afs",
+	unix.CODA_SUPER_MAGIC: "coda",
+	unix.CEPH_SUPER_MAGIC: "ceph",
+}
+
+// networkFS looks the filesystem holding path up by its statfs type
+func networkFS(path string) (string, bool) {
+	var st unix.Statfs_t
+	if unix.Statfs(path, &st) != nil {
+		return "", false
+	}
+	fstype, ok := networkFSTypes[int64(st.Type)]
+	return fstype, ok
+}
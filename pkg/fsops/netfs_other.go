@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package fsops
+
+// networkFS can't tell filesystems apart on this platform, so treats every
+// one as local
+func networkFS(_ string) (string, bool) {
+	return "", false
+}
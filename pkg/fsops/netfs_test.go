@@ -0,0 +1,44 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNetworkFSMissingPath(t *testing.T) {
+	dir := t.TempDir()
+	fstype, ok := NetworkFS(dir)
+	// a path that doesn't exist yet is on its nearest existing directory's
+	// filesystem
+	gotType, gotOK := NetworkFS(filepath.Join(dir, "not", "yet"))
+	if gotType != fstype || gotOK != ok {
+		t.Errorf("Expected %q, %t for a missing path, got %q, %t", fstype, ok, gotType, gotOK)
+	}
+}
+
+func TestVerifyContents(t *testing.T) {
+	src := setupTree(t)
+	dst := filepath.Join(t.TempDir(), "copy")
+	info, err := os.Lstat(src)
+	if err != nil {
+		t.Fatalf("Lstat failed: %v", err)
+	}
+	if err := Copy(src, dst, info, Options{}); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if err := verifyContents(src, dst); err != nil {
+		t.Fatalf("Expected the copy to match, got: %v", err)
+	}
+
+	// same size, different bytes, which VerifyTree can't tell apart
+	if err := os.WriteFile(filepath.Join(dst, "file1.txt"), []byte("This is file X"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := VerifyTree(src, dst); err != nil {
+		t.Fatalf("Expected VerifyTree to miss the change, got: %v", err)
+	}
+	if err := verifyContents(src, dst); err == nil {
+		t.Error("Expected the changed contents to be found")
+	}
+}
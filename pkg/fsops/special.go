@@ -0,0 +1,54 @@
+package fsops
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// specialTypes are the file types that are neither regular files,
+// directories nor symlinks
+const specialTypes = os.ModeNamedPipe | os.ModeSocket | os.ModeDevice | os.ModeCharDevice
+
+// warn reports a file left out of a copy to opts.Warn, if set
+func (opts Options) warn(format string, args ...any) {
+	if opts.Warn != nil {
+		opts.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// copySpecial recreates the FIFO or, with opts.Devices, the device node at
+// src, described by srcInfo, at dst. Sockets belong to the process that
+// listens on them and can't be copied, so they're skipped with a warning, as
+// are device nodes without opts.Devices.
+func copySpecial(src, dst string, srcInfo os.FileInfo, opts Options) error {
+	mode := srcInfo.Mode()
+	if opts.Update && (mode&os.ModeNamedPipe != 0 || mode&os.ModeDevice != 0 && opts.Devices) {
+		if err := clearForUpdate(dst, false); err != nil {
+			return err
+		}
+	}
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		if err := unix.Mkfifo(dst, uint32(mode.Perm())); err != nil {
+			return err
+		}
+	case mode&os.ModeDevice != 0 && opts.Devices:
+		var st unix.Stat_t
+		if err := unix.Stat(src, &st); err != nil {
+			return err
+		}
+		if err := unix.Mknod(dst, uint32(st.Mode), int(st.Rdev)); err != nil {
+			return fmt.Errorf("cannot recreate device %s: %w", src, err)
+		}
+	case mode&os.ModeDevice != 0:
+		opts.warn("skipped device %s (recreating devices needs --devices, as root)", src)
+		return nil
+	default:
+		opts.warn("skipped socket %s, which can't be copied", src)
+		return nil
+	}
+
+	return ApplyAttrs(src, dst, srcInfo, opts.Preserve)
+}
@@ -0,0 +1,77 @@
+package fsops
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopyDirSpecialFiles(t *testing.T) {
+	tempDir := setupTree(t)
+	src := filepath.Join(tempDir, "nested")
+
+	if err := unix.Mkfifo(filepath.Join(src, "pipe"), 0o600); err != nil {
+		t.Fatalf("Failed to create FIFO: %v", err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(src, "sock"))
+	if err != nil {
+		t.Fatalf("Failed to create socket: %v", err)
+	}
+	defer listener.Close()
+
+	var warnings []string
+	opts := Options{Warn: func(message string) { warnings = append(warnings, message) }}
+	dst := filepath.Join(tempDir, "copy")
+	if err := CopyDir(src, dst, opts); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	info, err := os.Lstat(filepath.Join(dst, "pipe"))
+	if err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		t.Errorf("Expected the FIFO to be recreated, got %v (%v)", info, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "sock")); err == nil {
+		t.Error("Expected the socket to be skipped")
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "skipped socket") {
+		t.Errorf("Expected a warning about the socket, got %q", warnings)
+	}
+	if _, err := Checksum(dst); err != nil {
+		t.Errorf("Expected the copy to be checksummed without reading the FIFO: %v", err)
+	}
+}
+
+func TestCopyDevices(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating device nodes needs root")
+	}
+	tempDir := setupTree(t)
+	src := filepath.Join(tempDir, "nested")
+	if err := unix.Mknod(filepath.Join(src, "null"), unix.S_IFCHR|0o666, int(unix.Mkdev(1, 3))); err != nil {
+		t.Skipf("cannot create device nodes here: %v", err)
+	}
+
+	var warnings []string
+	warn := func(message string) { warnings = append(warnings, message) }
+	if err := CopyDir(src, filepath.Join(tempDir, "skipped"), Options{Warn: warn}); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(tempDir, "skipped", "null")); err == nil || len(warnings) != 1 {
+		t.Errorf("Expected the device to be skipped with a warning, got %q", warnings)
+	}
+
+	if err := CopyDir(src, filepath.Join(tempDir, "devices"), Options{Devices: true}); err != nil {
+		t.Fatalf("CopyDir with Devices failed: %v", err)
+	}
+	var st unix.Stat_t
+	if err := unix.Lstat(filepath.Join(tempDir, "devices", "null"), &st); err != nil {
+		t.Fatalf("Expected the device to be recreated: %v", err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFCHR || uint64(st.Rdev) != unix.Mkdev(1, 3) {
+		t.Errorf("Expected a character device 1:3, got mode %o rdev %d", st.Mode, st.Rdev)
+	}
+}
@@ -0,0 +1,88 @@
+package fsops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Swap exchanges the files, directories or symlinks at a and b, so each ends
+// up under the other's name. Where the kernel can exchange them in one step,
+// nothing ever sees them half swapped; elsewhere they're swapped with three
+// renames through a temporary name, and the renames done are undone if one
+// fails. Both must be on the same filesystem.
+func Swap(a, b string) error {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return err
+	}
+	if absA == absB {
+		return fmt.Errorf("cannot swap %s with itself", a)
+	}
+	if inside(absA, absB) || inside(absB, absA) {
+		return fmt.Errorf("cannot swap %s and %s, one is inside the other", a, b)
+	}
+	for _, path := range []string{a, b} {
+		if _, err := os.Lstat(path); err != nil {
+			return err
+		}
+	}
+
+	err = exchange(a, b)
+	if errors.Is(err, errors.ErrUnsupported) {
+		err = swapByRenaming(a, b)
+	}
+	if errors.Is(err, unix.EXDEV) {
+		return fmt.Errorf("%w, cannot swap %s and %s", ErrCrossDevice, a, b)
+	}
+	return err
+}
+
+// inside reports whether path lies inside the directory dir
+func inside(dir, path string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// swapByRenaming swaps a and b by renaming a out of the way, b to a, and a
+// to b, putting things back if a rename fails
+func swapByRenaming(a, b string) error {
+	tmp, err := swapTempName(a)
+	if err != nil {
+		return err
+	}
+
+	if err := rename(a, tmp); err != nil {
+		return err
+	}
+	if err := rename(b, a); err != nil {
+		rename(tmp, a)
+		return err
+	}
+	if err := rename(tmp, b); err != nil {
+		rename(a, b)
+		rename(tmp, a)
+		return err
+	}
+	return nil
+}
+
+// swapTempName finds a free hidden name next to path to park it under while
+// it's swapped
+func swapTempName(path string) (string, error) {
+	dir, base := filepath.Split(path)
+	for n := 0; n < 10000; n++ {
+		candidate := filepath.Join(dir, FitName("."+base, fmt.Sprintf(".cx-swap-%d", n)))
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free temporary name found to swap %s", path)
+}
@@ -0,0 +1,21 @@
+package fsops
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// exchange swaps a and b in one step with renamex_np RENAME_SWAP, which APFS
+// supports. Other filesystems report errors.ErrUnsupported.
+func exchange(a, b string) error {
+	err := unix.RenamexNp(a, b, unix.RENAME_SWAP)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EINVAL) {
+		return errors.ErrUnsupported
+	}
+	if err != nil {
+		return &os.LinkError{Op: "swap", Old: a, New: b, Err: err}
+	}
+	return nil
+}
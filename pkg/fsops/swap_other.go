@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package fsops
+
+import "errors"
+
+// exchange is unsupported on this platform, so swaps always fall back to
+// renaming through a temporary name
+func exchange(_, _ string) error {
+	return errors.ErrUnsupported
+}
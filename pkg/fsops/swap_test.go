@@ -0,0 +1,53 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	tempDir := setupTree(t)
+	file := filepath.Join(tempDir, "file1.txt")
+	dir := filepath.Join(tempDir, "nested")
+
+	fileData, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	// the kernel's exchange where it's supported, then the fallback
+	for _, swap := range []func(a, b string) error{Swap, swapByRenaming} {
+		if err := swap(file, dir); err != nil {
+			t.Fatalf("swap failed: %v", err)
+		}
+		if info, err := os.Stat(file); err != nil || !info.IsDir() {
+			t.Errorf("Expected %s to be the directory now", file)
+		}
+		if data, err := os.ReadFile(dir); err != nil || string(data) != string(fileData) {
+			t.Errorf("Expected %s to hold the file now, got %q (%v)", dir, data, err)
+		}
+
+		// and back again
+		if err := swap(file, dir); err != nil {
+			t.Fatalf("swap back failed: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name()[0] == '.' {
+			t.Errorf("Unexpected leftover %s", entry.Name())
+		}
+	}
+
+	if err := Swap(file, file); err == nil {
+		t.Error("Expected error swapping a path with itself")
+	}
+	if err := Swap(file, filepath.Join(tempDir, "nope")); err == nil {
+		t.Error("Expected error swapping with a missing path")
+	}
+}
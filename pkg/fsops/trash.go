@@ -0,0 +1,65 @@
+package fsops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Trashed describes a path moved to the trash by Trash
+type Trashed struct {
+	// OriginalPath is where the path was deleted from
+	OriginalPath string `json:"original_path"`
+	// TrashPath is where it now lives in the trash
+	TrashPath string `json:"trash_path"`
+	// InfoPath is the trash's record of the deletion, if it keeps one
+	InfoPath  string    `json:"info_path,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Restore moves a trashed path back to where it was deleted from, failing if
+// something else has since been put there
+func (t Trashed) Restore() error {
+	if _, err := os.Lstat(t.OriginalPath); err == nil {
+		return fmt.Errorf("cannot restore, %s already exists", t.OriginalPath)
+	}
+	if _, err := os.Lstat(t.TrashPath); err != nil {
+		return fmt.Errorf("no longer in the trash: %s", t.TrashPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.OriginalPath), 0o755); err != nil {
+		return err
+	}
+	if err := Move(t.TrashPath, t.OriginalPath, Options{}); err != nil {
+		return err
+	}
+
+	if t.InfoPath != "" {
+		if err := os.Remove(t.InfoPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashName returns a name based on name for which free reports true,
+// adding " 2", " 3", ... before the extension as needed
+func trashName(name string, free func(string) bool) (string, error) {
+	if free(name) {
+		return name, nil
+	}
+
+	ext := filepath.Ext(name)
+	stem := name[:len(name)-len(ext)]
+	if stem == "" {
+		stem, ext = name, ""
+	}
+	for n := 2; n < 10000; n++ {
+		candidate := fmt.Sprintf("%s %d%s", stem, n, ext)
+		if free(candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a free name in the trash for %s", name)
+}
@@ -0,0 +1,44 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Trash moves path into ~/.Trash, where Finder can see and empty it. The
+// original location is returned for Restore, since ~/.Trash doesn't keep
+// one that other programs can read.
+func Trash(path string) (Trashed, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Trashed{}, err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return Trashed{}, err
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return Trashed{}, err
+	}
+	trashDir := filepath.Join(homeDir, ".Trash")
+	if err := os.MkdirAll(trashDir, 0o700); err != nil {
+		return Trashed{}, err
+	}
+
+	name, err := trashName(filepath.Base(path), func(name string) bool {
+		_, err := os.Lstat(filepath.Join(trashDir, name))
+		return os.IsNotExist(err)
+	})
+	if err != nil {
+		return Trashed{}, err
+	}
+
+	trashPath := filepath.Join(trashDir, name)
+	if err := Move(path, trashPath, Options{}); err != nil {
+		return Trashed{}, err
+	}
+
+	return Trashed{OriginalPath: path, TrashPath: trashPath, DeletedAt: time.Now()}, nil
+}
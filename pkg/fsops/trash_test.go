@@ -0,0 +1,87 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	tempDir := setupTree(t)
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+
+	path := filepath.Join(tempDir, "file1.txt")
+	trashed, err := Trash(path)
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone after Trash, got: %v", path, err)
+	}
+	if content, err := os.ReadFile(trashed.TrashPath); err != nil || string(content) != "This is file 1" {
+		t.Errorf("Expected the file in the trash at %s, got %q (%v)", trashed.TrashPath, content, err)
+	}
+
+	if runtime.GOOS != "darwin" {
+		info, err := os.ReadFile(trashed.InfoPath)
+		if err != nil {
+			t.Fatalf("Failed to read trash info: %v", err)
+		}
+		if !strings.Contains(string(info), "Path="+path+"\n") {
+			t.Errorf("Expected trash info to record %s, got %q", path, info)
+		}
+	}
+
+	// A second file with the same name gets a new name in the trash
+	if err := os.WriteFile(path, []byte("again"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	second, err := Trash(path)
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	if filepath.Base(second.TrashPath) != "file1 2.txt" {
+		t.Errorf("Expected the second file to be trashed as \"file1 2.txt\", got %s", second.TrashPath)
+	}
+
+	if err := second.Restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != "again" {
+		t.Errorf("Expected the restored file at %s, got %q (%v)", path, content, err)
+	}
+	if second.InfoPath != "" {
+		if _, err := os.Lstat(second.InfoPath); !os.IsNotExist(err) {
+			t.Errorf("Expected trash info to be removed on restore, got: %v", err)
+		}
+	}
+
+	// Restoring over an existing path fails
+	if err := trashed.Restore(); err == nil {
+		t.Error("Expected error restoring over an existing file, got nil")
+	}
+}
+
+func TestTrashName(t *testing.T) {
+	taken := map[string]bool{"notes.txt": true, "notes 2.txt": true, ".bashrc": true}
+	free := func(name string) bool { return !taken[name] }
+
+	tests := map[string]string{
+		"other.txt": "other.txt",
+		"notes.txt": "notes 3.txt",
+		".bashrc":   ".bashrc 2",
+	}
+	for name, expected := range tests {
+		got, err := trashName(name, free)
+		if err != nil {
+			t.Fatalf("trashName(%q) failed: %v", name, err)
+		}
+		if got != expected {
+			t.Errorf("Expected trashName(%q) = %q, got %q", name, expected, got)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+//go:build !darwin
+
+package fsops
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Trash moves path into the trash following the freedesktop.org trash
+// specification, so that desktop file managers can list and restore it.
+// Paths on the same filesystem as the home directory go to the home trash
+// ($XDG_DATA_HOME/Trash); others go to a .Trash-$UID directory at the top
+// of their own filesystem, so the data doesn't have to be copied.
+func Trash(path string) (Trashed, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Trashed{}, err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return Trashed{}, err
+	}
+
+	trashDir, err := homeTrash()
+	if err != nil {
+		return Trashed{}, err
+	}
+	if err := makeTrashDirs(trashDir); err != nil {
+		return Trashed{}, err
+	}
+	if !SameDevice(path, trashDir) {
+		// fall back to the home trash, copying the data, if the top of the
+		// filesystem can't take a trash directory
+		topTrash := filepath.Join(mountTop(path), ".Trash-"+strconv.Itoa(os.Getuid()))
+		if makeTrashDirs(topTrash) == nil {
+			trashDir = topTrash
+		}
+	}
+
+	deletedAt := time.Now()
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), deletedAt.Format("2006-01-02T15:04:05"))
+
+	// the spec has the info file created exclusively first, to claim the name
+	var infoPath string
+	var infoErr error
+	name, err := trashName(filepath.Base(path), func(name string) bool {
+		infoPath = filepath.Join(trashDir, "info", name+".trashinfo")
+		err := writeTrashInfo(infoPath, info)
+		if err != nil && !os.IsExist(err) {
+			infoErr = err
+			return true
+		}
+		return err == nil
+	})
+	if infoErr != nil {
+		return Trashed{}, infoErr
+	}
+	if err != nil {
+		return Trashed{}, err
+	}
+
+	trashPath := filepath.Join(trashDir, "files", name)
+	if err := Move(path, trashPath, Options{}); err != nil {
+		os.Remove(infoPath)
+		return Trashed{}, err
+	}
+
+	return Trashed{OriginalPath: path, TrashPath: trashPath, InfoPath: infoPath, DeletedAt: deletedAt}, nil
+}
+
+// homeTrash returns the home trash directory, honouring $XDG_DATA_HOME
+func homeTrash() (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataDir = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataDir, "Trash"), nil
+}
+
+// makeTrashDirs creates the files and info directories of a trash
+func makeTrashDirs(trashDir string) error {
+	for _, dir := range []string{filepath.Join(trashDir, "files"), filepath.Join(trashDir, "info")} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mountTop returns the top directory of the filesystem path is on
+func mountTop(path string) string {
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || !SameDevice(dir, parent) {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// writeTrashInfo creates the info file at path, failing if it already exists
+func writeTrashInfo(path, info string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(info)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package fsops
+
+import (
+	"os"
+)
+
+// upToDate reports whether dst already matches the file src, described by
+// srcInfo, so an update can leave it alone: the same size and modification
+// time, or with opts.Checksum, the same contents
+func upToDate(src, dst string, srcInfo os.FileInfo, opts Options) bool {
+	dstInfo, err := os.Lstat(dst)
+	if err != nil || !dstInfo.Mode().IsRegular() || dstInfo.Size() != srcInfo.Size() {
+		return false
+	}
+	if !opts.Checksum {
+		return dstInfo.ModTime().Equal(srcInfo.ModTime())
+	}
+
+	srcSum, err := checksumFile(src)
+	if err != nil {
+		return false
+	}
+	dstSum, err := checksumFile(dst)
+	return err == nil && srcSum == dstSum
+}
+
+// clearForUpdate removes what's at dst during an update so it can be
+// replaced, unless it's a directory and keepDir is set, so a directory is
+// updated in place
+func clearForUpdate(dst string, keepDir bool) error {
+	info, err := os.Lstat(dst)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if keepDir && info.IsDir() {
+		return nil
+	}
+	return os.RemoveAll(dst)
+}
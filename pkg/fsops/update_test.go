@@ -0,0 +1,69 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyDirUpdate(t *testing.T) {
+	tempDir := setupTree(t)
+	src := filepath.Join(tempDir, "config")
+	dst := filepath.Join(tempDir, "mirror")
+
+	opts := Options{Update: true, Preserve: Attrs{Timestamps: true}}
+	if err := CopyDir(src, dst, opts); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	// a destination file with the source's size and mtime counts as up to
+	// date, whatever it holds
+	settings := filepath.Join(dst, "settings.json")
+	info, err := os.Stat(settings)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	tampered := []byte(`{"setting": "VALUE"}`)
+	if err := os.WriteFile(settings, tampered, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(settings, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	// a changed source file and a new one are copied
+	later := time.Now().Add(time.Hour)
+	if err := os.WriteFile(filepath.Join(src, "config.ini"), []byte("key=changed"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(src, "config.ini"), later, later); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "new.txt"), []byte("new"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := CopyDir(src, dst, opts); err != nil {
+		t.Fatalf("CopyDir update failed: %v", err)
+	}
+	expected := map[string]string{
+		"settings.json": string(tampered),
+		"config.ini":    "key=changed",
+		"new.txt":       "new",
+	}
+	for name, content := range expected {
+		if data, err := os.ReadFile(filepath.Join(dst, name)); err != nil || string(data) != content {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, data, err)
+		}
+	}
+
+	// comparing contents catches the tampered file
+	opts.Checksum = true
+	if err := CopyDir(src, dst, opts); err != nil {
+		t.Fatalf("CopyDir checksum update failed: %v", err)
+	}
+	if data, err := os.ReadFile(settings); err != nil || string(data) != `{"setting": "value"}` {
+		t.Errorf("Expected --checksum to replace the changed file, got %q (%v)", data, err)
+	}
+}